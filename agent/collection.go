@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// defaultCollectWorkers — сколько контейнеров опрашивается одновременно
+const defaultCollectWorkers = 8
+
+// containerCallTimeout — сколько ждать ответа Docker на один запрос по контейнеру.
+// Зависший контейнер не должен задерживать весь сбор.
+const containerCallTimeout = 10 * time.Second
+
+// CollectionInfo представляет сведения о самом сборе данных: если сбор дольше
+// интервала, пинги уходят реже, и по этим сведениям видно почему
+type CollectionInfo struct {
+	DurationMs       float64             `json:"duration_ms"`
+	DockerDurationMs float64             `json:"docker_duration_ms"`
+	Containers       int                 `json:"containers"`
+	Workers          int                 `json:"workers"`
+	Failures         []CollectionFailure `json:"failures"`
+}
+
+// CollectionFailure представляет неудачный запрос к Docker по контейнеру
+type CollectionFailure struct {
+	ContainerID string `json:"container_id"`
+	Name        string `json:"name"`
+	Call        string `json:"call"` // inspect, logs, stats
+	Error       string `json:"error"`
+}
+
+// collectWorkers возвращает число одновременно опрашиваемых контейнеров из COLLECT_WORKERS
+func collectWorkers() (int, error) {
+	value := os.Getenv("COLLECT_WORKERS")
+	if value == "" {
+		return defaultCollectWorkers, nil
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers <= 0 {
+		return 0, fmt.Errorf("%q is not a positive number", value)
+	}
+	return workers, nil
+}
+
+// containerResult — результат опроса одного контейнера; info nil, если контейнер не удалось осмотреть
+type containerResult struct {
+	info     *ContainerInfo
+	failures []CollectionFailure
+}
+
+// collectContainers опрашивает контейнеры пулом из workers горутин. Порядок
+// контейнеров в ответе совпадает со списком Docker.
+func collectContainers(ctx context.Context, dockerClient *client.Client, sampler *containerSampler, cursors *logCursors, containers []container.Summary, workers int) ([]ContainerInfo, []CollectionFailure) {
+	results := make([]containerResult, len(containers))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(containers); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = collectContainer(ctx, dockerClient, sampler, cursors, containers[index])
+			}
+		}()
+	}
+	for index := range containers {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	containerInfos := []ContainerInfo{}
+	failures := []CollectionFailure{}
+	for _, result := range results {
+		if result.info != nil {
+			containerInfos = append(containerInfos, *result.info)
+		}
+		failures = append(failures, result.failures...)
+	}
+	return containerInfos, failures
+}
+
+// collectContainer опрашивает один контейнер. Без inspect контейнер пропускается,
+// без логов или статистики отправляется с тем, что удалось собрать.
+func collectContainer(ctx context.Context, dockerClient *client.Client, sampler *containerSampler, cursors *logCursors, summary container.Summary) containerResult {
+	var result containerResult
+	fail := func(call string, err error) {
+		result.failures = append(result.failures, CollectionFailure{
+			ContainerID: summary.ID,
+			Name:        containerName(summary),
+			Call:        call,
+			Error:       err.Error(),
+		})
+	}
+
+	// Базовая информация о контейнере
+	inspectCtx, cancel := context.WithTimeout(ctx, containerCallTimeout)
+	inspect, err := dockerClient.ContainerInspect(inspectCtx, summary.ID)
+	cancel()
+	if err != nil {
+		fail("inspect", err)
+		return result
+	}
+
+	// Получаем логи; при ошибке чтения — то, что успели прочитать
+	tty := inspect.Config != nil && inspect.Config.Tty
+	logsCtx, cancel := context.WithTimeout(ctx, containerCallTimeout)
+	logs, err := getContainerLogs(logsCtx, dockerClient, cursors, summary.ID, tty)
+	cancel()
+	if err != nil {
+		fail("logs", err)
+	}
+
+	// Получаем статистику контейнера
+	statsCtx, cancel := context.WithTimeout(ctx, containerCallTimeout)
+	stats, err := getContainerStats(statsCtx, dockerClient, sampler, summary.ID)
+	cancel()
+	if err != nil {
+		fail("stats", err)
+		stats = &ContainerStats{}
+	}
+
+	networks := []string{}
+	var ip, mac *string
+	if inspect.NetworkSettings != nil {
+		for netName := range inspect.NetworkSettings.Networks {
+			networks = append(networks, netName)
+		}
+		if len(inspect.NetworkSettings.Networks) > 0 {
+			for _, net := range inspect.NetworkSettings.Networks {
+				if ip == nil {
+					if net.IPAddress != "" {
+						ip = &net.IPAddress
+					}
+					if net.MacAddress != "" {
+						mac = &net.MacAddress
+					}
+				}
+				break
+			}
+		}
+	}
+
+	info := ContainerInfo{
+		ID:            summary.ID,
+		Created:       time.Unix(summary.Created, 0).Format(time.RFC3339Nano),
+		Status:        summary.Status,
+		State:         string(summary.State),
+		RestartCount:  inspect.RestartCount,
+		Image:         strings.TrimPrefix(summary.ImageID, "sha256:"),
+		Name:          containerName(summary),
+		IP:            ip,
+		MAC:           mac,
+		CPU:           stats.CPU,
+		Memory:        stats.Memory,
+		MemoryLimit:   stats.MemoryLimit,
+		MemoryPercent: stats.MemoryPercent,
+		BlockRead:     stats.BlockRead,
+		BlockWrite:    stats.BlockWrite,
+		PIDs:          stats.PIDs,
+		Network: ContainerNetworkInfo{
+			Sent:     stats.NetworkSent,
+			Received: stats.NetworkReceived,
+			Networks: networks,
+		},
+		Logs:          logs,
+		Ports:         containerPorts(summary),
+		Mounts:        containerMounts(inspect),
+		Labels:        summary.Labels,
+		RestartPolicy: containerRestartPolicy(inspect),
+	}
+
+	// Состояние процесса: код выхода и OOM отличают штатную остановку от падения
+	if inspect.ContainerJSONBase != nil && inspect.State != nil {
+		info.Health = containerHealth(inspect.State)
+		info.ExitCode = inspect.State.ExitCode
+		info.OOMKilled = inspect.State.OOMKilled
+		info.StartedAt = containerTime(inspect.State.StartedAt)
+		info.FinishedAt = containerTime(inspect.State.FinishedAt)
+	}
+
+	result.info = &info
+	return result
+}
+
+// containerName возвращает имя контейнера без ведущего "/"
+func containerName(summary container.Summary) string {
+	if len(summary.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(summary.Names[0], "/")
+}
+
+// milliseconds переводит длительность в миллисекунды
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// ContainerStats представляет потребление ресурсов контейнером
+type ContainerStats struct {
+	CPU             *float64 // доля одного ядра: 1.5 — полтора ядра
+	Memory          *uint64  // MB, без неактивного файлового кеша
+	MemoryLimit     *uint64  // MB
+	MemoryPercent   *float64 // процент от лимита
+	BlockRead       *uint64  // накопительный счетчик, байты
+	BlockWrite      *uint64  // накопительный счетчик, байты
+	PIDs            *uint64
+	NetworkSent     *uint64
+	NetworkReceived *uint64
+}
+
+// cpuSample — накопительные счетчики CPU контейнера и хоста в наносекундах
+type cpuSample struct {
+	container uint64
+	system    uint64
+}
+
+// containerSampler хранит счетчики CPU контейнеров из прошлого сбора. Статистика
+// без потока (one-shot) не содержит прошлого замера: precpu_stats в ней пуст, поэтому
+// загрузку CPU считаем по разнице с прошлым пингом.
+type containerSampler struct {
+	mu       sync.Mutex
+	previous map[string]cpuSample
+}
+
+func newContainerSampler() *containerSampler {
+	return &containerSampler{previous: make(map[string]cpuSample)}
+}
+
+// cpuUsage возвращает загрузку CPU контейнера с прошлого сбора так же, как docker stats.
+// nil — прошлого замера нет или счетчики сброшены перезапуском контейнера.
+func (s *containerSampler) cpuUsage(containerID string, stats container.CPUStats) *float64 {
+	current := cpuSample{container: stats.CPUUsage.TotalUsage, system: stats.SystemUsage}
+	s.mu.Lock()
+	previous, ok := s.previous[containerID]
+	s.previous[containerID] = current
+	s.mu.Unlock()
+
+	if !ok || current.container < previous.container || current.system <= previous.system {
+		return nil
+	}
+
+	cpus := float64(stats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUUsage.PercpuUsage))
+	}
+	if cpus == 0 {
+		return nil
+	}
+
+	usage := float64(current.container-previous.container) / float64(current.system-previous.system) * cpus
+	return &usage
+}
+
+// retain забывает счетчики удаленных контейнеров
+func (s *containerSampler) retain(containerIDs map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.previous {
+		if !containerIDs[id] {
+			delete(s.previous, id)
+		}
+	}
+}
+
+func getContainerStats(ctx context.Context, dockerClient *client.Client, sampler *containerSampler, containerID string) (*ContainerStats, error) {
+	result := &ContainerStats{}
+
+	stats, err := dockerClient.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		return result, err
+	}
+	defer stats.Body.Close()
+
+	var v container.StatsResponse
+	if err := json.NewDecoder(stats.Body).Decode(&v); err != nil {
+		return result, fmt.Errorf("failed to decode stats: %v", err)
+	}
+
+	// У остановленного контейнера статистика пустая
+	if v.CPUStats.CPUUsage.TotalUsage == 0 {
+		return result, nil
+	}
+
+	// CPU
+	result.CPU = sampler.cpuUsage(containerID, v.CPUStats)
+
+	// Память
+	if used := memoryUsed(v.MemoryStats); used > 0 {
+		memUsage := used / 1024 / 1024 // MB
+		result.Memory = &memUsage
+		if v.MemoryStats.Limit > 0 {
+			limit := v.MemoryStats.Limit / 1024 / 1024 // MB
+			percent := float64(used) / float64(v.MemoryStats.Limit) * 100
+			result.MemoryLimit = &limit
+			result.MemoryPercent = &percent
+		}
+	}
+
+	// Блочный ввод-вывод
+	var blockRead, blockWrite uint64
+	for _, entry := range v.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			blockRead += entry.Value
+		case "write":
+			blockWrite += entry.Value
+		}
+	}
+	result.BlockRead = &blockRead
+	result.BlockWrite = &blockWrite
+
+	// Процессы
+	if v.PidsStats.Current > 0 {
+		pids := v.PidsStats.Current
+		result.PIDs = &pids
+	}
+
+	// Сеть
+	if len(v.Networks) > 0 {
+		var totalRx, totalTx uint64
+		for _, network := range v.Networks {
+			totalRx += network.RxBytes
+			totalTx += network.TxBytes
+		}
+		if totalRx > 0 {
+			result.NetworkReceived = &totalRx
+		}
+		if totalTx > 0 {
+			result.NetworkSent = &totalTx
+		}
+	}
+
+	return result, nil
+}
+
+// memoryUsed возвращает занятую контейнером память без неактивного файлового кеша,
+// как docker stats: кеш ядро освободит при нехватке памяти
+func memoryUsed(stats container.MemoryStats) uint64 {
+	// cgroup v1 — total_inactive_file, cgroup v2 — inactive_file
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if inactive, ok := stats.Stats[key]; ok {
+			if inactive < stats.Usage {
+				return stats.Usage - inactive
+			}
+			return stats.Usage
+		}
+	}
+	return stats.Usage
+}
+
+// maxHealthOutput — сколько байт вывода последней проверки здоровья отправлять на сервер
+const maxHealthOutput = 1024
+
+// ContainerHealth представляет состояние проверки здоровья (HEALTHCHECK) контейнера
+type ContainerHealth struct {
+	Status        string `json:"status"` // starting, healthy, unhealthy
+	FailingStreak int    `json:"failing_streak"`
+	LastExitCode  int    `json:"last_exit_code"`
+	LastOutput    string `json:"last_output"`
+	LastCheck     string `json:"last_check"`
+}
+
+// ContainerPort представляет порт контейнера и его публикацию на хосте
+type ContainerPort struct {
+	IP          string `json:"ip"`
+	PrivatePort uint16 `json:"private_port"`
+	PublicPort  uint16 `json:"public_port"` // 0 — порт не опубликован
+	Type        string `json:"type"`
+}
+
+// ContainerMount представляет том или каталог хоста, смонтированный в контейнер
+type ContainerMount struct {
+	Type        string `json:"type"` // bind, volume, tmpfs
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	RW          bool   `json:"rw"`
+}
+
+// ContainerRestartPolicy представляет политику перезапуска контейнера
+type ContainerRestartPolicy struct {
+	Name              string `json:"name"` // no, always, unless-stopped, on-failure
+	MaximumRetryCount int    `json:"maximum_retry_count"`
+}
+
+// containerHealth возвращает состояние проверки здоровья; nil — у контейнера нет HEALTHCHECK
+func containerHealth(state *container.State) *ContainerHealth {
+	if state == nil || state.Health == nil {
+		return nil
+	}
+
+	health := &ContainerHealth{
+		Status:        string(state.Health.Status),
+		FailingStreak: state.Health.FailingStreak,
+	}
+	// Журнал хранит несколько последних проверок, последняя — в конце
+	if count := len(state.Health.Log); count > 0 {
+		last := state.Health.Log[count-1]
+		health.LastExitCode = last.ExitCode
+		health.LastOutput = strings.TrimSpace(truncate(last.Output, maxHealthOutput))
+		health.LastCheck = last.End.Format(time.RFC3339Nano)
+	}
+	return health
+}
+
+// containerTime возвращает время из состояния контейнера; пустая строка — события не было
+func containerTime(value string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || parsed.IsZero() {
+		return ""
+	}
+	return value
+}
+
+// containerPorts возвращает порты контейнера из списка контейнеров
+func containerPorts(summary container.Summary) []ContainerPort {
+	ports := []ContainerPort{}
+	for _, port := range summary.Ports {
+		ports = append(ports, ContainerPort{
+			IP:          port.IP,
+			PrivatePort: port.PrivatePort,
+			PublicPort:  port.PublicPort,
+			Type:        port.Type,
+		})
+	}
+	return ports
+}
+
+// containerMounts возвращает смонтированные в контейнер тома и каталоги
+func containerMounts(inspect container.InspectResponse) []ContainerMount {
+	mounts := []ContainerMount{}
+	for _, mount := range inspect.Mounts {
+		source := mount.Source
+		// Для именованного тома имя понятнее пути в /var/lib/docker
+		if mount.Name != "" {
+			source = mount.Name
+		}
+		mounts = append(mounts, ContainerMount{
+			Type:        string(mount.Type),
+			Source:      source,
+			Destination: mount.Destination,
+			RW:          mount.RW,
+		})
+	}
+	return mounts
+}
+
+// containerRestartPolicy возвращает политику перезапуска контейнера
+func containerRestartPolicy(inspect container.InspectResponse) ContainerRestartPolicy {
+	if inspect.ContainerJSONBase == nil || inspect.HostConfig == nil {
+		return ContainerRestartPolicy{}
+	}
+	return ContainerRestartPolicy{
+		Name:              string(inspect.HostConfig.RestartPolicy.Name),
+		MaximumRetryCount: inspect.HostConfig.RestartPolicy.MaximumRetryCount,
+	}
+}
+
+// truncate обрезает строку до limit байт, не разрывая символ UTF-8
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	for limit > 0 && !utf8.RuneStart(value[limit]) {
+		limit--
+	}
+	return value[:limit]
+}
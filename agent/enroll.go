@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// version — версия агента, задается при сборке: -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
+// defaultCredentialsFile — куда сохраняются учетные данные, полученные при регистрации
+const defaultCredentialsFile = "data/credentials.json"
+
+// Credentials представляет постоянные учетные данные агента, выданные при регистрации
+type Credentials struct {
+	AgentID    string `json:"agent_id"`
+	Name       string `json:"name"`
+	Token      string `json:"token"`
+	SigningKey string `json:"signing_key,omitempty"`
+}
+
+// HostFacts представляет сведения о хосте для инвентаризации
+type HostFacts struct {
+	Hostname        string `json:"hostname"`
+	OS              string `json:"os"`
+	Platform        string `json:"platform"`
+	PlatformVersion string `json:"platform_version"`
+	KernelVersion   string `json:"kernel_version"`
+	Architecture    string `json:"architecture"`
+	CPUModel        string `json:"cpu_model"`
+	CPUCores        int    `json:"cpu_cores"`
+	DockerVersion   string `json:"docker_version"`
+	AgentVersion    string `json:"agent_version"`
+}
+
+// RegisterRequest представляет запрос на регистрацию агента
+type RegisterRequest struct {
+	Name  string     `json:"name"`
+	Facts *HostFacts `json:"facts"`
+}
+
+// RegisterResponse представляет ответ сервера на регистрацию
+type RegisterResponse struct {
+	AgentID    string `json:"agent_id"`
+	Name       string `json:"name"`
+	Token      string `json:"token"`
+	SigningKey string `json:"signing_key"`
+	Approved   bool   `json:"approved"`
+}
+
+// credentialsPath возвращает путь к файлу учетных данных агента
+func credentialsPath() string {
+	if path := os.Getenv("CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	return defaultCredentialsFile
+}
+
+// resolveCredentials возвращает учетные данные агента: сохраненные, из TOKEN
+// или, если их нет, регистрирует агента по одноразовому ENROLLMENT_TOKEN.
+// Сохраненные учетные данные важнее TOKEN: после ротации в них лежит новый токен.
+func resolveCredentials(url string, dockerClient *client.Client) (*Credentials, error) {
+	path := credentialsPath()
+
+	credentials, err := loadCredentials(path)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != nil {
+		log.Printf("Using saved credentials of agent %s from %s", credentials.AgentID, path)
+		return credentials, nil
+	}
+
+	// ID агента станет известен из первого ответа сервера
+	if token := os.Getenv("TOKEN"); token != "" {
+		return &Credentials{Token: token}, nil
+	}
+
+	enrollmentToken := os.Getenv("ENROLLMENT_TOKEN")
+	if enrollmentToken == "" {
+		return nil, fmt.Errorf("TOKEN or ENROLLMENT_TOKEN environment variable is required")
+	}
+
+	response, err := registerAgent(url, enrollmentToken, dockerClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register agent: %v", err)
+	}
+
+	credentials = &Credentials{AgentID: response.AgentID, Name: response.Name, Token: response.Token, SigningKey: response.SigningKey}
+	if err := saveCredentials(path, credentials); err != nil {
+		// Токен регистрации уже погашен: без сохраненных данных агент не сможет переподключиться
+		return nil, fmt.Errorf("registered as agent %s but failed to save credentials: %v", response.AgentID, err)
+	}
+
+	if response.Approved {
+		log.Printf("Registered as agent %s (%s)", response.Name, response.AgentID)
+	} else {
+		log.Printf("Registered as agent %s (%s), waiting for approval by an administrator", response.Name, response.AgentID)
+	}
+
+	return credentials, nil
+}
+
+// loadCredentials читает сохраненные учетные данные; nil — файла еще нет
+func loadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %v", err)
+	}
+
+	var credentials Credentials
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials %s: %v", path, err)
+	}
+	if credentials.Token == "" {
+		return nil, fmt.Errorf("credentials %s contain no token", path)
+	}
+	return &credentials, nil
+}
+
+// saveCredentials сохраняет учетные данные с правами 0600 через временный файл,
+// чтобы прерванная запись не оставила поврежденный файл
+func saveCredentials(path string, credentials *Credentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// registerAgent обменивает токен регистрации на постоянные учетные данные
+func registerAgent(url, enrollmentToken string, dockerClient *client.Client) (*RegisterResponse, error) {
+	facts := collectHostFacts(dockerClient)
+	jsonData, err := json.Marshal(RegisterRequest{
+		Name:  os.Getenv("AGENT_NAME"),
+		Facts: facts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	registerURL := strings.TrimSuffix(url, "/agent/ping") + "/agent/register"
+	req, err := http.NewRequest("POST", registerURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+enrollmentToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	var response RegisterResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode registration response: %v", err)
+	}
+	if response.Token == "" {
+		return nil, fmt.Errorf("server returned no agent token")
+	}
+
+	return &response, nil
+}
+
+// collectHostFacts собирает сведения о хосте. Недоступные сведения остаются пустыми.
+func collectHostFacts(dockerClient *client.Client) *HostFacts {
+	facts := &HostFacts{
+		OS:           runtime.GOOS,
+		Architecture: runtime.GOARCH,
+		AgentVersion: version,
+	}
+
+	if info, err := host.Info(); err != nil {
+		log.Printf("Failed to get host info: %v", err)
+	} else {
+		facts.Hostname = info.Hostname
+		facts.OS = info.OS
+		facts.Platform = info.Platform
+		facts.PlatformVersion = info.PlatformVersion
+		facts.KernelVersion = info.KernelVersion
+		facts.Architecture = info.KernelArch
+	}
+
+	if cpus, err := cpu.Info(); err != nil {
+		log.Printf("Failed to get CPU info: %v", err)
+	} else if len(cpus) > 0 {
+		facts.CPUModel = cpus[0].ModelName
+	}
+	if cores, err := cpu.Counts(true); err == nil {
+		facts.CPUCores = cores
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Агент работает в контейнере, поэтому имя хоста берем у Docker: os.Hostname вернул бы ID контейнера
+	if info, err := dockerClient.Info(ctx); err != nil {
+		log.Printf("Failed to get docker info: %v", err)
+	} else {
+		if info.Name != "" {
+			facts.Hostname = info.Name
+		}
+		facts.DockerVersion = info.ServerVersion
+	}
+
+	return facts
+}
+
+// handleRotateToken сохраняет новый токен и ключ подписи, выданные сервером при ротации,
+// и переключает агента на них. Если сохранить токен не удалось, агент продолжает работать со старым.
+func handleRotateToken(credentials *Credentials, payload map[string]interface{}) (ActionResult, *string, string) {
+	newToken, ok := payload["token"].(string)
+	if !ok || newToken == "" {
+		errMsg := "token is required"
+		return nil, &errMsg, ActionStatusFailed
+	}
+	// Без ключа агент отправит следующий запрос с Bearer токеном и получит ключ в ответе
+	signingKey, _ := payload["signing_key"].(string)
+
+	// Агент, настроенный через TOKEN, сохраняет учетные данные впервые
+	path := credentialsPath()
+	rotated := *credentials
+	rotated.Token = newToken
+	rotated.SigningKey = signingKey
+	if err := saveCredentials(path, &rotated); err != nil {
+		errMsg := fmt.Sprintf("Failed to save new token: %v", err)
+		return nil, &errMsg, ActionStatusFailed
+	}
+
+	credentials.Token = newToken
+	credentials.SigningKey = signingKey
+	log.Printf("Agent token rotated, new token saved to %s", path)
+
+	return ActionResult{"message": "Token rotated"}, nil, ActionStatusCompleted
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// maxBufferedEvents — сколько событий агент хранит до отправки. Если сервер долго
+// недоступен, самые старые события отбрасываются.
+const maxBufferedEvents = 1000
+
+// eventsReconnectDelay — пауза перед переподключением к потоку событий Docker
+const eventsReconnectDelay = 5 * time.Second
+
+// watchedEvents — события Docker, которые агент пересылает серверу. Между пингами
+// контейнер может упасть и перезапуститься: по пингам это видно только как рост restart_count.
+var watchedEvents = map[string]bool{
+	"die":           true,
+	"oom":           true,
+	"kill":          true,
+	"restart":       true,
+	"health_status": true,
+	"pull":          true,
+	"destroy":       true,
+}
+
+// DockerEvent представляет событие Docker, произошедшее между пингами
+type DockerEvent struct {
+	Time     string `json:"time"`   // RFC3339 с наносекундами
+	Type     string `json:"type"`   // container, image
+	Action   string `json:"action"` // die, oom, kill, restart, health_status, pull, destroy
+	ID       string `json:"id"`     // ID контейнера или ссылка на образ
+	Name     string `json:"name"`
+	Image    string `json:"image,omitempty"`
+	Status   string `json:"status,omitempty"` // результат health_status: healthy, unhealthy
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Signal   string `json:"signal,omitempty"`
+}
+
+// eventCollector слушает поток событий Docker и копит события до следующего пинга
+type eventCollector struct {
+	mu      sync.Mutex
+	events  []DockerEvent
+	dropped int
+}
+
+// run слушает события до отмены контекста, переподключаясь после обрыва потока.
+// После переподключения события запрашиваются с момента последнего полученного,
+// поэтому обрыв ничего не теряет; повтор последнего события сервер отбрасывает.
+func (c *eventCollector) run(ctx context.Context, dockerClient *client.Client) {
+	since := time.Now()
+	for {
+		err := c.watch(ctx, dockerClient, &since)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Docker event stream interrupted, reconnecting in %s: %v", eventsReconnectDelay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsReconnectDelay):
+		}
+	}
+}
+
+// watch читает поток событий до его обрыва
+func (c *eventCollector) watch(ctx context.Context, dockerClient *client.Client, since *time.Time) error {
+	messages, errs := dockerClient.Events(ctx, events.ListOptions{
+		Since: fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()),
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("type", string(events.ImageEventType)),
+		),
+	})
+
+	for {
+		select {
+		case message := <-messages:
+			*since = time.Unix(0, message.TimeNano)
+			if event, ok := newDockerEvent(message); ok {
+				c.add(event)
+			}
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+// newDockerEvent преобразует сообщение Docker; false — событие не отслеживается
+func newDockerEvent(message events.Message) (DockerEvent, bool) {
+	// Результат проверки здоровья Docker передает в самом действии: "health_status: healthy"
+	action, status, _ := strings.Cut(string(message.Action), ":")
+	if !watchedEvents[action] {
+		return DockerEvent{}, false
+	}
+
+	attributes := message.Actor.Attributes
+	event := DockerEvent{
+		Time:   time.Unix(0, message.TimeNano).UTC().Format(time.RFC3339Nano),
+		Type:   string(message.Type),
+		Action: action,
+		ID:     message.Actor.ID,
+		Name:   attributes["name"],
+		Status: strings.TrimSpace(status),
+		Signal: attributes["signal"],
+	}
+	if message.Type == events.ContainerEventType {
+		event.Image = attributes["image"]
+	}
+	if exitCode, ok := attributes["exitCode"]; ok {
+		if code, err := strconv.Atoi(exitCode); err == nil {
+			event.ExitCode = &code
+		}
+	}
+	return event, true
+}
+
+// add добавляет событие в буфер, отбрасывая самые старые при переполнении
+func (c *eventCollector) add(event DockerEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(c.events, event)
+	c.trim()
+}
+
+// trim оставляет в буфере не больше maxBufferedEvents последних событий
+func (c *eventCollector) trim() {
+	if overflow := len(c.events) - maxBufferedEvents; overflow > 0 {
+		c.events = c.events[overflow:]
+		c.dropped += overflow
+	}
+}
+
+// drain забирает накопленные события для отправки
+func (c *eventCollector) drain() []DockerEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dropped > 0 {
+		log.Printf("Dropped %d docker events: buffer is full", c.dropped)
+		c.dropped = 0
+	}
+
+	events := c.events
+	c.events = nil
+	return events
+}
+
+// requeue возвращает в буфер события, которые не удалось отправить
+func (c *eventCollector) requeue(events []DockerEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(append([]DockerEvent{}, events...), c.events...)
+	c.trim()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// FilesystemInfo представляет заполненность файловой системы, смонтированной на хосте
+type FilesystemInfo struct {
+	Mountpoint  string `json:"mountpoint"`
+	Device      string `json:"device"`
+	Fstype      string `json:"fstype"`
+	Total       uint64 `json:"total"`
+	Used        uint64 `json:"used"`
+	Free        uint64 `json:"free"`
+	InodesTotal uint64 `json:"inodes_total"`
+	InodesUsed  uint64 `json:"inodes_used"`
+	InodesFree  uint64 `json:"inodes_free"`
+}
+
+// ignoredFilesystemTypes — файловые системы только для чтения, которые всегда заполнены на 100%
+var ignoredFilesystemTypes = map[string]bool{
+	"squashfs": true,
+	"iso9660":  true,
+	"udf":      true,
+}
+
+// hostRoot возвращает путь, по которому в контейнер агента смонтирован корень хоста
+func hostRoot() string {
+	if root := os.Getenv("HOST_ROOT"); root != "" {
+		return root
+	}
+	return "/"
+}
+
+// collectFilesystems собирает заполненность реальных файловых систем хоста.
+// Список точек монтирования gopsutil читает из HOST_PROC/1/mountinfo, то есть видит
+// монтирования хоста; размеры берутся по тому же пути внутри HOST_ROOT.
+// Виртуальные файловые системы (tmpfs, overlay, proc и т.п.) gopsutil пропускает сам,
+// bind-монтирования и повторные монтирования одного устройства пропускаем здесь.
+func collectFilesystems() []FilesystemInfo {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		log.Printf("Failed to get partitions: %v", err)
+		return nil
+	}
+
+	root := hostRoot()
+	seen := make(map[string]bool)
+	var filesystems []FilesystemInfo
+	for _, partition := range partitions {
+		if ignoredFilesystemTypes[partition.Fstype] || seen[partition.Device] {
+			continue
+		}
+		if isBindMount(partition.Opts) {
+			continue
+		}
+
+		usage, err := disk.Usage(filepath.Join(root, partition.Mountpoint))
+		if err != nil {
+			log.Printf("Failed to get usage of %s: %v", partition.Mountpoint, err)
+			continue
+		}
+		if usage.Total == 0 {
+			continue
+		}
+		seen[partition.Device] = true
+
+		filesystems = append(filesystems, FilesystemInfo{
+			Mountpoint:  partition.Mountpoint,
+			Device:      partition.Device,
+			Fstype:      partition.Fstype,
+			Total:       usage.Total,
+			Used:        usage.Used,
+			Free:        usage.Free,
+			InodesTotal: usage.InodesTotal,
+			InodesUsed:  usage.InodesUsed,
+			InodesFree:  usage.InodesFree,
+		})
+	}
+
+	return filesystems
+}
+
+// isBindMount сообщает, смонтирован ли не корень файловой системы, а ее каталог
+func isBindMount(opts []string) bool {
+	for _, opt := range opts {
+		if opt == "bind" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+)
+
+// factsRefreshInterval — как часто агент заново собирает сведения о хосте.
+// Сведения меняются редко (обновление ядра или Docker), а собирать их дорого.
+const factsRefreshInterval = 10 * time.Minute
+
+// HostMetrics представляет нагрузку и состояние хоста
+type HostMetrics struct {
+	Load1           float64  `json:"load1"`
+	Load5           float64  `json:"load5"`
+	Load15          float64  `json:"load15"`
+	Uptime          uint64   `json:"uptime"`    // секунды
+	BootTime        uint64   `json:"boot_time"` // unix-время
+	Processes       int      `json:"processes"`
+	Threads         int      `json:"threads"`
+	ContextSwitches uint64   `json:"context_switches"` // накопительный счетчик
+	CPUTimes        CPUTimes `json:"cpu_times"`
+}
+
+// CPUTimes представляет накопительное время всех CPU хоста по режимам в секундах
+type CPUTimes struct {
+	User    float64 `json:"user"`
+	Nice    float64 `json:"nice"`
+	System  float64 `json:"system"`
+	Idle    float64 `json:"idle"`
+	Iowait  float64 `json:"iowait"`
+	Irq     float64 `json:"irq"`
+	Softirq float64 `json:"softirq"`
+	Steal   float64 `json:"steal"`
+}
+
+// collectHostMetrics собирает нагрузку хоста. Счетчики читаются из HOST_PROC, поэтому
+// агент в контейнере видит весь хост.
+func collectHostMetrics() (*HostMetrics, error) {
+	avg, err := load.Avg()
+	if err != nil {
+		return nil, err
+	}
+
+	// Misc: переключения контекста и число потоков (поле total в loadavg)
+	misc, err := load.Misc()
+	if err != nil {
+		return nil, err
+	}
+
+	bootTime, err := host.BootTime()
+	if err != nil {
+		return nil, err
+	}
+
+	times, err := cpu.Times(false)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &HostMetrics{
+		Load1:           avg.Load1,
+		Load5:           avg.Load5,
+		Load15:          avg.Load15,
+		BootTime:        bootTime,
+		Processes:       countProcesses(),
+		Threads:         misc.ProcsTotal,
+		ContextSwitches: uint64(misc.Ctxt),
+	}
+	if now := uint64(time.Now().Unix()); now > bootTime {
+		metrics.Uptime = now - bootTime
+	}
+	if len(times) > 0 {
+		metrics.CPUTimes = CPUTimes{
+			User:    times[0].User,
+			Nice:    times[0].Nice,
+			System:  times[0].System,
+			Idle:    times[0].Idle,
+			Iowait:  times[0].Iowait,
+			Irq:     times[0].Irq,
+			Softirq: times[0].Softirq,
+			Steal:   times[0].Steal,
+		}
+	}
+
+	return metrics, nil
+}
+
+// hostProc возвращает путь, по которому в контейнер агента смонтирован /proc хоста
+func hostProc() string {
+	if proc := os.Getenv("HOST_PROC"); proc != "" {
+		return proc
+	}
+	return "/proc"
+}
+
+// countProcesses считает процессы хоста по каталогам HOST_PROC; 0 — каталог недоступен
+func countProcesses() int {
+	entries, err := os.ReadDir(hostProc())
+	if err != nil {
+		log.Printf("Failed to count processes: %v", err)
+		return 0
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := strconv.Atoi(entry.Name()); err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// factsReporter отправляет сведения о хосте только при изменении: первый пинг после
+// запуска агента содержит их всегда, дальше — если они отличаются от принятых сервером
+type factsReporter struct {
+	current   *HostFacts
+	collected time.Time
+	sent      string
+}
+
+// pending возвращает сведения о хосте, если сервер их еще не получил, иначе nil
+func (r *factsReporter) pending(dockerClient *client.Client) *HostFacts {
+	if r.current == nil || time.Since(r.collected) >= factsRefreshInterval {
+		r.current = collectHostFacts(dockerClient)
+		r.collected = time.Now()
+	}
+
+	data, err := json.Marshal(r.current)
+	if err != nil || string(data) == r.sent {
+		return nil
+	}
+	return r.current
+}
+
+// acknowledge запоминает сведения, принятые сервером
+func (r *factsReporter) acknowledge(facts *HostFacts) {
+	if facts == nil {
+		return
+	}
+	if data, err := json.Marshal(facts); err == nil {
+		r.sent = string(data)
+	}
+}
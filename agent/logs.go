@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// defaultLogCursorsFile — где агент хранит позиции чтения логов контейнеров
+const defaultLogCursorsFile = "data/log_cursors.json"
+
+// maxLogLinesPerContainer — сколько строк лога контейнера отправлять за пинг.
+// Остальные строки уйдут со следующими пингами: позиция чтения остановится на последней отправленной.
+const maxLogLinesPerContainer = 1000
+
+// maxLogLineLength — сколько байт одной строки лога отправлять на сервер
+const maxLogLineLength = 16 * 1024
+
+// ContainerLogLine представляет строку лога контейнера
+type ContainerLogLine struct {
+	Time   string `json:"time"`   // RFC3339 с наносекундами, время Docker
+	Stream string `json:"stream"` // stdout, stderr
+	Line   string `json:"line"`
+}
+
+// logCursors хранит время последней прочитанной строки лога каждого контейнера.
+// Позиции, прочитанные при сборе, сохраняются только после доставки пинга:
+// если пинг не дошел, следующий сбор прочитает те же строки заново.
+type logCursors struct {
+	mu      sync.Mutex
+	path    string
+	start   time.Time            // с этого момента читаются логи контейнеров без позиции
+	cursors map[string]time.Time // подтвержденные позиции
+	pending map[string]time.Time // позиции последнего сбора
+	listed  map[string]bool      // контейнеры из списка Docker последнего сбора; nil — список не получен
+}
+
+// logCursorsFile хранит позиции чтения логов на диске
+type logCursorsFile struct {
+	Start   time.Time            `json:"start"`
+	Cursors map[string]time.Time `json:"cursors"`
+}
+
+// logCursorsPath возвращает путь к файлу позиций чтения логов
+func logCursorsPath() string {
+	if path := os.Getenv("LOG_CURSORS_FILE"); path != "" {
+		return path
+	}
+	return defaultLogCursorsFile
+}
+
+// loadLogCursors читает сохраненные позиции. Без файла логи читаются с момента запуска
+// агента: старую историю контейнеров при первом запуске отправлять незачем.
+func loadLogCursors(path string) *logCursors {
+	c := &logCursors{
+		path:    path,
+		start:   time.Now(),
+		cursors: make(map[string]time.Time),
+		pending: make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read log cursors, collecting logs from now: %v", err)
+		}
+		return c
+	}
+
+	var saved logCursorsFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Printf("Failed to parse log cursors %s, collecting logs from now: %v", path, err)
+		return c
+	}
+	if !saved.Start.IsZero() {
+		c.start = saved.Start
+	}
+	for id, cursor := range saved.Cursors {
+		c.cursors[id] = cursor
+	}
+	return c
+}
+
+// since возвращает момент, с которого читать логи контейнера
+func (c *logCursors) since(containerID string) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cursor, ok := c.cursors[containerID]; ok {
+		return cursor
+	}
+	return c.start
+}
+
+// advance запоминает позицию после сбора логов контейнера
+func (c *logCursors) advance(containerID string, cursor time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending[containerID] = cursor
+}
+
+// retain запоминает контейнеры из списка Docker. По нему, а не по собранным
+// контейнерам, commit забывает позиции: контейнер, который не удалось осмотреть,
+// не должен терять позицию и заново отправлять свои логи.
+func (c *logCursors) retain(containerIDs map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.listed = containerIDs
+}
+
+// commit подтверждает позиции последнего сбора после доставки пинга.
+// Позиции удаленных контейнеров забываются.
+func (c *logCursors) commit() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, cursor := range c.pending {
+		c.cursors[id] = cursor
+	}
+	c.pending = make(map[string]time.Time)
+
+	if c.listed != nil {
+		for id := range c.cursors {
+			if !c.listed[id] {
+				delete(c.cursors, id)
+			}
+		}
+		c.listed = nil
+	}
+
+	if err := c.save(); err != nil {
+		log.Printf("Failed to save log cursors: %v", err)
+	}
+}
+
+// discard отменяет позиции последнего сбора: пинг не собран или не дошел
+func (c *logCursors) discard() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = make(map[string]time.Time)
+	c.listed = nil
+}
+
+// save сохраняет позиции через временный файл, как saveCredentials
+func (c *logCursors) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(logCursorsFile{Start: c.start, Cursors: c.cursors})
+	if err != nil {
+		return err
+	}
+
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
+
+// getContainerLogs читает строки лога контейнера после сохраненной позиции.
+// У контейнера с TTY поток не мультиплексирован: заголовков нет, все строки — stdout.
+// Вместе с ошибкой чтения возвращает строки, прочитанные до нее.
+func getContainerLogs(ctx context.Context, dockerClient *client.Client, cursors *logCursors, containerID string, tty bool) ([]ContainerLogLine, error) {
+	since := cursors.since(containerID)
+
+	// Docker включает строки с временем, равным since: начинаем со следующей наносекунды
+	next := since.Add(time.Nanosecond)
+	logs, err := dockerClient.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      fmt.Sprintf("%d.%09d", next.Unix(), next.Nanosecond()),
+		Timestamps: true,
+	})
+	if err != nil {
+		return []ContainerLogLine{}, err
+	}
+	defer logs.Close()
+
+	// При ошибке чтения прочитанные строки отправляем, остальные дочитаем со следующим пингом
+	reader := newLogReader(logs, tty)
+	lines := []ContainerLogLine{}
+	cursor := since
+	var readErr error
+	for len(lines) < maxLogLinesPerContainer {
+		stream, payload, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = fmt.Errorf("failed to read logs: %v", err)
+			break
+		}
+
+		timestamp, line, ok := parseLogLine(payload)
+		if !ok {
+			continue
+		}
+		cursor = timestamp
+		lines = append(lines, ContainerLogLine{
+			Time:   timestamp.UTC().Format(time.RFC3339Nano),
+			Stream: stream,
+			Line:   strings.ToValidUTF8(truncate(line, maxLogLineLength), ""),
+		})
+	}
+
+	cursors.advance(containerID, cursor)
+	return lines, readErr
+}
+
+// parseLogLine отделяет метку времени Docker от текста строки
+func parseLogLine(payload string) (time.Time, string, bool) {
+	payload = strings.TrimRight(payload, "\r\n")
+	value, line, _ := strings.Cut(payload, " ")
+	timestamp, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return timestamp, line, true
+}
+
+// logReader читает сообщения из потока логов Docker
+type logReader struct {
+	reader *bufio.Reader
+	tty    bool
+	header [8]byte
+}
+
+func newLogReader(r io.Reader, tty bool) *logReader {
+	return &logReader{reader: bufio.NewReaderSize(r, 64*1024), tty: tty}
+}
+
+// next возвращает поток и текст следующего сообщения. Без TTY каждое сообщение
+// предваряется заголовком: байт потока (1 — stdout, 2 — stderr), три нулевых байта
+// и длина сообщения (uint32, big endian).
+func (r *logReader) next() (string, string, error) {
+	if r.tty {
+		line, err := r.reader.ReadString('\n')
+		if err == io.EOF && line != "" {
+			return "stdout", line, nil
+		}
+		return "stdout", line, err
+	}
+
+	if _, err := io.ReadFull(r.reader, r.header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", "", fmt.Errorf("truncated log header")
+		}
+		return "", "", err
+	}
+
+	stream := "stdout"
+	switch r.header[0] {
+	case 1:
+	case 2:
+		stream = "stderr"
+	default:
+		return "", "", fmt.Errorf("unexpected log stream %d", r.header[0])
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(r.header[4:]))
+	if _, err := io.ReadFull(r.reader, payload); err != nil {
+		return "", "", fmt.Errorf("truncated log message: %v", err)
+	}
+	return stream, string(payload), nil
+}
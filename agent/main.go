@@ -17,6 +17,7 @@ import (
 	"os/exec"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
@@ -24,7 +25,6 @@ import (
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/mem"
-	gopsutilnet "github.com/shirou/gopsutil/v3/net"
 )
 
 // Action представляет действие от сервера
@@ -36,16 +36,20 @@ type Action struct {
 	Status    string                 `json:"status"`
 	Created   string                 `json:"created"`
 	Completed *string                `json:"completed"`
-	Response  *string                `json:"response"`
+	Response  ActionResult           `json:"response"`
 	Error     *string                `json:"error"`
 }
 
+// ActionResult — структурированный результат выполнения действия.
+// Поле message содержит человекочитаемое описание, остальные поля зависят от типа действия.
+type ActionResult map[string]interface{}
+
 // ActionResponse представляет ответ агента на действие
 type ActionResponse struct {
-	ID       string  `json:"id"`
-	Status   string  `json:"status"`
-	Response *string `json:"response"`
-	Error    *string `json:"error"`
+	ID       string       `json:"id"`
+	Status   string       `json:"status"`
+	Response ActionResult `json:"response"`
+	Error    *string      `json:"error"`
 }
 
 // Константы для типов действий
@@ -55,11 +59,13 @@ const (
 	ActionTypeRemoveContainer   = "remove_container"
 	ActionTypeRemoveImage       = "remove_image"
 	ActionTypePullImage         = "pull_image"
+	ActionTypePruneImages       = "prune_images"
 	ActionTypeRestartContainer  = "restart_container"
 	ActionTypeCreateNginxConfig = "create_nginx_config"
 	ActionTypeDeleteNginxConfig = "delete_nginx_config"
 	ActionTypeUpdateNginxConfig = "update_nginx_config"
 	ActionTypeGetNginxConfig    = "get_nginx_config"
+	ActionTypeRotateToken       = "rotate_token"
 )
 
 // Константы для статусов действий
@@ -70,15 +76,25 @@ const (
 )
 
 type AgentData struct {
-	Metrics Metrics    `json:"metrics"`
-	Docker  DockerInfo `json:"docker"`
+	// Timestamp — время сбора: пинг из очереди доходит до сервера позже
+	Timestamp string     `json:"timestamp"`
+	Metrics   Metrics    `json:"metrics"`
+	Docker    DockerInfo `json:"docker"`
+	// Facts отправляются только при изменении, см. factsReporter
+	Facts *HostFacts `json:"facts,omitempty"`
+	// Events — события Docker с прошлого пинга, см. eventCollector
+	Events []DockerEvent `json:"events,omitempty"`
+	// Collection — длительность сбора и неудачные запросы к Docker
+	Collection *CollectionInfo `json:"collection,omitempty"`
 }
 
 type Metrics struct {
-	CPU     []CPUInfo   `json:"cpu"`
-	Memory  MemoryInfo  `json:"memory"`
-	Disk    []DiskInfo  `json:"disk"`
-	Network NetworkInfo `json:"network"`
+	CPU         []CPUInfo        `json:"cpu"`
+	Memory      MemoryInfo       `json:"memory"`
+	Disk        []DiskInfo       `json:"disk"`
+	Filesystems []FilesystemInfo `json:"filesystems"`
+	Network     NetworkInfo      `json:"network"`
+	Host        *HostMetrics     `json:"host"`
 }
 
 type CPUInfo struct {
@@ -110,9 +126,12 @@ type DiskInfo struct {
 }
 
 type NetworkInfo struct {
-	PublicIP string `json:"public_ip"`
-	Sent     uint64 `json:"sent"`
-	Received uint64 `json:"received"`
+	PublicIP        string          `json:"public_ip"`
+	Sent            uint64          `json:"sent"`
+	Received        uint64          `json:"received"`
+	PacketsSent     uint64          `json:"packets_sent"`
+	PacketsReceived uint64          `json:"packets_received"`
+	Interfaces      []InterfaceInfo `json:"interfaces"`
 }
 
 type DockerInfo struct {
@@ -121,18 +140,33 @@ type DockerInfo struct {
 }
 
 type ContainerInfo struct {
-	ID           string               `json:"id"`
-	Created      string               `json:"created"`
-	Status       string               `json:"status"`
-	RestartCount int                  `json:"restart_count"`
-	Image        string               `json:"image"`
-	Name         string               `json:"name"`
-	IP           *string              `json:"ip"`
-	MAC          *string              `json:"mac"`
-	CPU          *float64             `json:"cpu"`
-	Memory       *uint64              `json:"memory"`
-	Network      ContainerNetworkInfo `json:"network"`
-	Logs         []string             `json:"logs"`
+	ID            string                 `json:"id"`
+	Created       string                 `json:"created"`
+	Status        string                 `json:"status"`
+	State         string                 `json:"state"` // created, running, paused, restarting, exited, dead
+	RestartCount  int                    `json:"restart_count"`
+	Image         string                 `json:"image"`
+	Name          string                 `json:"name"`
+	IP            *string                `json:"ip"`
+	MAC           *string                `json:"mac"`
+	CPU           *float64               `json:"cpu"`
+	Memory        *uint64                `json:"memory"`
+	MemoryLimit   *uint64                `json:"memory_limit"`
+	MemoryPercent *float64               `json:"memory_percent"`
+	BlockRead     *uint64                `json:"block_read"`
+	BlockWrite    *uint64                `json:"block_write"`
+	PIDs          *uint64                `json:"pids"`
+	Network       ContainerNetworkInfo   `json:"network"`
+	Logs          []ContainerLogLine     `json:"logs"`
+	Health        *ContainerHealth       `json:"health"`
+	ExitCode      int                    `json:"exit_code"`
+	OOMKilled     bool                   `json:"oom_killed"`
+	StartedAt     string                 `json:"started_at"`
+	FinishedAt    string                 `json:"finished_at"`
+	Ports         []ContainerPort        `json:"ports"`
+	Mounts        []ContainerMount       `json:"mounts"`
+	Labels        map[string]string      `json:"labels"`
+	RestartPolicy ContainerRestartPolicy `json:"restart_policy"`
 }
 
 type ContainerNetworkInfo struct {
@@ -155,11 +189,6 @@ func main() {
 		log.Fatal("URL environment variable is required")
 	}
 
-	token := os.Getenv("TOKEN")
-	if token == "" {
-		log.Fatal("TOKEN environment variable is required")
-	}
-
 	intervalStr := os.Getenv("INTERVAL")
 	if intervalStr == "" {
 		intervalStr = "5"
@@ -170,6 +199,11 @@ func main() {
 		log.Fatal("Invalid INTERVAL value:", err)
 	}
 
+	workers, err := collectWorkers()
+	if err != nil {
+		log.Fatal("Invalid COLLECT_WORKERS value:", err)
+	}
+
 	// Создаем Docker клиент
 	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
@@ -177,28 +211,69 @@ func main() {
 	}
 	defer dockerClient.Close()
 
+	// Учетные данные агента: сохраненные, из TOKEN или полученные при регистрации по ENROLLMENT_TOKEN
+	credentials, err := resolveCredentials(url, dockerClient)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	log.Printf("Agent started. Sending data to %s every %d seconds", url, interval)
 
 	// Основной цикл
 	ticker := time.NewTicker(time.Duration(interval) * time.Second)
 	defer ticker.Stop()
 
+	facts := &factsReporter{}
+	sampler := newContainerSampler()
+	cursors := loadLogCursors(logCursorsPath())
+	queue, err := newPingQueue()
+	if err != nil {
+		log.Fatal(err)
+	}
+	collector := &eventCollector{}
+	go collector.run(context.Background(), dockerClient)
 	for {
-		data, err := collectData(dockerClient)
+		data, err := collectData(dockerClient, sampler, cursors, workers)
 		if err != nil {
 			log.Printf("Error collecting data: %v", err)
+			cursors.discard()
 		} else {
-			actions, err := sendData(url, token, data)
+			// Тикер пропускает такты, пока идет сбор: пинги уходят реже интервала
+			if duration := data.Collection.DurationMs; duration > float64(interval)*1000 {
+				log.Printf("Data collection took %.0f ms, longer than the %d second interval", duration, interval)
+			}
+			if failed := len(data.Collection.Failures); failed > 0 {
+				log.Printf("Data collection had %d failed docker calls", failed)
+			}
+			data.Facts = facts.pending(dockerClient)
+			data.Events = collector.drain()
+			actions, err := deliver(url, credentials, queue, data, time.Duration(interval)*time.Second)
 			if err != nil {
-				log.Printf("Error sending data: %v", err)
+				if err != errQueueNotEmpty {
+					log.Printf("Error sending data: %v", err)
+				}
+
+				// Пинг отверг сам сервер — в очереди он не нужен
+				queued := !rejected(err) && queue.push(data) == nil
+				if queued {
+					// События и строки логов уже в очереди; сведения о хосте повторим в следующем пинге
+					log.Println("Data queued until the server is reachable")
+					cursors.commit()
+				} else {
+					// События и строки логов отправим со следующим пингом
+					collector.requeue(data.Events)
+					cursors.discard()
+				}
 			} else {
 				log.Println("Data sent successfully")
+				facts.acknowledge(data.Facts)
+				cursors.commit()
 
 				// Обрабатываем полученные действия
 				if len(actions) > 0 {
 					log.Printf("Received %d actions to process", len(actions))
 					for _, action := range actions {
-						if err := processAction(dockerClient, action); err != nil {
+						if err := processAction(dockerClient, url, credentials, action); err != nil {
 							log.Printf("Error processing action %s: %v", action.ID, err)
 						}
 					}
@@ -210,8 +285,9 @@ func main() {
 	}
 }
 
-func collectData(dockerClient *client.Client) (*AgentData, error) {
+func collectData(dockerClient *client.Client, sampler *containerSampler, cursors *logCursors, workers int) (*AgentData, error) {
 	ctx := context.Background()
+	started := time.Now()
 
 	// Собираем системные метрики
 	metrics, err := collectSystemMetrics()
@@ -220,14 +296,23 @@ func collectData(dockerClient *client.Client) (*AgentData, error) {
 	}
 
 	// Собираем Docker метрики
-	dockerInfo, err := collectDockerMetrics(ctx, dockerClient)
+	dockerStarted := time.Now()
+	dockerInfo, failures, err := collectDockerMetrics(ctx, dockerClient, sampler, cursors, workers)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect docker metrics: %v", err)
 	}
 
 	return &AgentData{
-		Metrics: *metrics,
-		Docker:  *dockerInfo,
+		Timestamp: started.UTC().Format(time.RFC3339Nano),
+		Metrics:   *metrics,
+		Docker:    *dockerInfo,
+		Collection: &CollectionInfo{
+			DurationMs:       milliseconds(time.Since(started)),
+			DockerDurationMs: milliseconds(time.Since(dockerStarted)),
+			Containers:       len(dockerInfo.Containers),
+			Workers:          workers,
+			Failures:         failures,
+		},
 	}, nil
 }
 
@@ -276,21 +361,26 @@ func collectSystemMetrics() (*Metrics, error) {
 		})
 	}
 
-	// Сеть
-	netStats, err := gopsutilnet.IOCounters(true)
+	// Нагрузка хоста: без нее остальные метрики все равно отправляются
+	hostMetrics, err := collectHostMetrics()
+	if err != nil {
+		log.Printf("Failed to collect host metrics: %v", err)
+	}
+
+	// Сеть: итоговые счетчики — сумма по учитываемым интерфейсам
+	interfaces, err := collectInterfaces(newInterfaceFilter())
 	if err != nil {
 		return nil, err
 	}
 
-	var sent, received uint64
+	var sent, received, packetsSent, packetsReceived uint64
 	publicIP := getPublicIP()
 
-	for _, stat := range netStats {
-		if stat.Name == "eth0" {
-			sent = stat.BytesSent
-			received = stat.BytesRecv
-			break
-		}
+	for _, iface := range interfaces {
+		sent += iface.Sent
+		received += iface.Received
+		packetsSent += iface.PacketsSent
+		packetsReceived += iface.PacketsReceived
 	}
 
 	return &Metrics{
@@ -305,11 +395,16 @@ func collectSystemMetrics() (*Metrics, error) {
 				Usage: swapStat.Used / 1024 / 1024,  // MB
 			},
 		},
-		Disk: diskInfo,
+		Disk:        diskInfo,
+		Filesystems: collectFilesystems(),
+		Host:        hostMetrics,
 		Network: NetworkInfo{
-			PublicIP: publicIP,
-			Sent:     sent,
-			Received: received,
+			PublicIP:        publicIP,
+			Sent:            sent,
+			Received:        received,
+			PacketsSent:     packetsSent,
+			PacketsReceived: packetsReceived,
+			Interfaces:      interfaces,
 		},
 	}, nil
 }
@@ -353,77 +448,25 @@ func getPublicIP() string {
 	return xorAddr.IP.String()
 }
 
-func collectDockerMetrics(ctx context.Context, dockerClient *client.Client) (*DockerInfo, error) {
+func collectDockerMetrics(ctx context.Context, dockerClient *client.Client, sampler *containerSampler, cursors *logCursors, workers int) (*DockerInfo, []CollectionFailure, error) {
 	containers, err := dockerClient.ContainerList(ctx, container.ListOptions{All: true})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	containerInfos := []ContainerInfo{}
-	for _, container := range containers {
-		// Базовая информация о контейнере
-		inspect, err := dockerClient.ContainerInspect(ctx, container.ID)
-		if err != nil {
-			continue
-		}
-
-		// Получаем логи
-		logs, err := getContainerLogs(ctx, dockerClient, container.ID)
-		if err != nil {
-			logs = []string{}
-		}
+	containerInfos, failures := collectContainers(ctx, dockerClient, sampler, cursors, containers, workers)
 
-		// Получаем статистику контейнера
-		stats, err := getContainerStats(ctx, dockerClient, container.ID)
-		if err != nil {
-			stats = &ContainerStats{}
-		}
-
-		networks := []string{}
-		var ip, mac *string
-		if inspect.NetworkSettings != nil {
-			for netName := range inspect.NetworkSettings.Networks {
-				networks = append(networks, netName)
-			}
-			if len(inspect.NetworkSettings.Networks) > 0 {
-				for _, net := range inspect.NetworkSettings.Networks {
-					if ip == nil {
-						if net.IPAddress != "" {
-							ip = &net.IPAddress
-						}
-						if net.MacAddress != "" {
-							mac = &net.MacAddress
-						}
-					}
-					break
-				}
-			}
-		}
-
-		containerInfos = append(containerInfos, ContainerInfo{
-			ID:           container.ID,
-			Created:      time.Unix(container.Created, 0).Format(time.RFC3339Nano),
-			Status:       container.Status,
-			RestartCount: inspect.RestartCount,
-			Image:        strings.TrimPrefix(container.ImageID, "sha256:"),
-			Name:         strings.TrimPrefix(container.Names[0], "/"),
-			IP:           ip,
-			MAC:          mac,
-			CPU:          stats.CPU,
-			Memory:       stats.Memory,
-			Network: ContainerNetworkInfo{
-				Sent:     stats.NetworkSent,
-				Received: stats.NetworkReceived,
-				Networks: networks,
-			},
-			Logs: logs,
-		})
+	containerIDs := make(map[string]bool)
+	for _, container := range containers {
+		containerIDs[container.ID] = true
 	}
+	sampler.retain(containerIDs)
+	cursors.retain(containerIDs)
 
 	// Образы
 	images, err := dockerClient.ImageList(ctx, image.ListOptions{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var imageInfos []ImageInfo
@@ -446,160 +489,20 @@ func collectDockerMetrics(ctx context.Context, dockerClient *client.Client) (*Do
 	return &DockerInfo{
 		Containers: containerInfos,
 		Images:     imageInfos,
-	}, nil
-}
-
-type ContainerStats struct {
-	CPU             *float64 `json:"cpu"`
-	Memory          *uint64  `json:"memory"`
-	NetworkSent     *uint64  `json:"network_sent"`
-	NetworkReceived *uint64  `json:"network_received"`
-}
-
-func getContainerStats(ctx context.Context, dockerClient *client.Client, containerID string) (*ContainerStats, error) {
-	result := &ContainerStats{
-		CPU:             nil,
-		Memory:          nil,
-		NetworkSent:     nil,
-		NetworkReceived: nil,
-	}
-
-	stats, err := dockerClient.ContainerStatsOneShot(ctx, containerID)
-	if err != nil {
-		return result, nil
-	}
-
-	var v map[string]interface{}
-	if err := json.NewDecoder(stats.Body).Decode(&v); err != nil {
-		return result, nil
-	}
-
-	// CPU
-	cpuUsage := calculateCPUUsage(v)
-	if cpuUsage > 0 {
-		result.CPU = &cpuUsage
-	}
-
-	// Память
-	if memStats, ok := v["memory_stats"].(map[string]interface{}); ok {
-		if usage, ok := memStats["usage"].(float64); ok && usage > 0 {
-			memUsage := uint64(usage) / 1024 / 1024 // MB
-			result.Memory = &memUsage
-		}
-	}
-
-	// Сеть
-	if networks, ok := v["networks"].(map[string]interface{}); ok {
-		var totalRx, totalTx uint64
-		for _, netData := range networks {
-			if network, ok := netData.(map[string]interface{}); ok {
-				if rxBytes, ok := network["rx_bytes"].(float64); ok {
-					totalRx += uint64(rxBytes)
-				}
-				if txBytes, ok := network["tx_bytes"].(float64); ok {
-					totalTx += uint64(txBytes)
-				}
-			}
-		}
-		if totalRx > 0 {
-			result.NetworkReceived = &totalRx
-		}
-		if totalTx > 0 {
-			result.NetworkSent = &totalTx
-		}
-	}
-
-	return result, nil
-}
-
-func calculateCPUUsage(stats map[string]interface{}) float64 {
-	cpuStats, ok := stats["cpu_stats"].(map[string]interface{})
-	if !ok {
-		return 0
-	}
-
-	cpuUsage, ok := cpuStats["cpu_usage"].(map[string]interface{})
-	if !ok {
-		return 0
-	}
-
-	totalUsage, ok := cpuUsage["total_usage"].(float64)
-	if !ok {
-		return 0
-	}
-
-	systemUsage, ok := cpuStats["system_cpu_usage"].(float64)
-	if !ok {
-		return 0
-	}
-
-	var numberCpus float64
-	if onlineCpus, ok := cpuStats["online_cpus"].(float64); ok && onlineCpus > 0 {
-		numberCpus = onlineCpus
-	} else if percpuUsage, ok := cpuUsage["percpu_usage"].([]interface{}); ok && len(percpuUsage) > 0 {
-		numberCpus = float64(len(percpuUsage))
-	}
-
-	if numberCpus <= 0 {
-		return 0
-	}
-
-	return (totalUsage / systemUsage) * numberCpus
+	}, failures, nil
 }
 
-func getContainerLogs(ctx context.Context, dockerClient *client.Client, containerID string) ([]string, error) {
-	intervalStr := os.Getenv("INTERVAL")
-	if intervalStr == "" {
-		intervalStr = "5"
-	}
-
-	options := container.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Since:      intervalStr + "s",
-		Timestamps: true,
-	}
-
-	logs, err := dockerClient.ContainerLogs(ctx, containerID, options)
-	if err != nil {
-		return []string{}, err
-	}
-	defer logs.Close()
-
-	logLines := []string{}
-	scanner := bufio.NewScanner(logs)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) > 8 {
-			line = line[8:]
-		}
-		logLines = append(logLines, line)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return []string{}, err
-	}
-
-	if len(logLines) == 0 {
-		return []string{}, nil
-	}
-
-	return logLines, nil
-}
-
-func sendData(url, token string, data *AgentData) ([]Action, error) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
+// sendData отправляет пинг. jsonData — готовое тело: пинг из очереди отправляется как сохранен.
+func sendData(url string, credentials *Credentials, jsonData []byte) ([]Action, error) {
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+	if err := credentials.authorize(req, jsonData); err != nil {
+		return nil, err
+	}
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
@@ -608,8 +511,10 @@ func sendData(url, token string, data *AgentData) ([]Action, error) {
 	}
 	defer resp.Body.Close()
 
+	credentials.learnCredentials(resp)
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+		return nil, &statusError{code: resp.StatusCode}
 	}
 
 	// Парсим ответ с действиями
@@ -621,11 +526,11 @@ func sendData(url, token string, data *AgentData) ([]Action, error) {
 	return actions, nil
 }
 
-// processAction обрабатывает действие от сервера
-func processAction(dockerClient *client.Client, action Action) error {
+// processAction обрабатывает действие от сервера. Действие rotate_token заменяет токен в credentials.
+func processAction(dockerClient *client.Client, url string, credentials *Credentials, action Action) error {
 	log.Printf("Processing action %s of type %s", action.ID, action.Type)
 
-	var response *string
+	var response ActionResult
 	var errMsg *string
 	var status string
 
@@ -640,6 +545,8 @@ func processAction(dockerClient *client.Client, action Action) error {
 		response, errMsg, status = handleRemoveImage(dockerClient, action.Payload)
 	case ActionTypePullImage:
 		response, errMsg, status = handlePullImage(dockerClient, action.Payload)
+	case ActionTypePruneImages:
+		response, errMsg, status = handlePruneImages(dockerClient, action.Payload)
 	case ActionTypeRestartContainer:
 		response, errMsg, status = handleRestartContainer(dockerClient, action.Payload)
 	case ActionTypeCreateNginxConfig:
@@ -650,18 +557,21 @@ func processAction(dockerClient *client.Client, action Action) error {
 		response, errMsg, status = handleUpdateNginxConfig(action.Payload)
 	case ActionTypeGetNginxConfig:
 		response, errMsg, status = handleGetNginxConfig(action.Payload)
+	case ActionTypeRotateToken:
+		response, errMsg, status = handleRotateToken(credentials, action.Payload)
 	default:
 		err := fmt.Sprintf("Unknown action type: %s", action.Type)
 		errMsg = &err
 		status = ActionStatusFailed
 	}
 
-	// Отправляем результат обратно на сервер
-	return sendActionResult(action.ID, status, response, errMsg)
+	// Отправляем результат обратно на сервер. После ротации результат отправляется уже
+	// с новым токеном — это завершает переходный период старого токена на сервере.
+	return sendActionResult(url, credentials, action.ID, status, response, errMsg)
 }
 
 // handleStartContainer обрабатывает запуск контейнера
-func handleStartContainer(dockerClient *client.Client, payload map[string]interface{}) (*string, *string, string) {
+func handleStartContainer(dockerClient *client.Client, payload map[string]interface{}) (ActionResult, *string, string) {
 	ctx := context.Background()
 
 	// Проверяем, есть ли container_id для запуска существующего контейнера
@@ -673,8 +583,10 @@ func handleStartContainer(dockerClient *client.Client, payload map[string]interf
 			return nil, &errMsg, ActionStatusFailed
 		}
 
-		successMsg := fmt.Sprintf("Existing container %s started successfully", containerID)
-		return &successMsg, nil, ActionStatusCompleted
+		return ActionResult{
+			"message":      fmt.Sprintf("Existing container %s started successfully", containerID),
+			"container_id": containerID,
+		}, nil, ActionStatusCompleted
 	}
 
 	// Создаем новый контейнер
@@ -771,8 +683,13 @@ func handleStartContainer(dockerClient *client.Client, payload map[string]interf
 			}
 		}
 
-		successMsg := fmt.Sprintf("Container %s started successfully with ID: %s", name, resp.ID)
-		return &successMsg, nil, ActionStatusCompleted
+		return ActionResult{
+			"message":      fmt.Sprintf("Container %s started successfully", name),
+			"container_id": resp.ID,
+			"name":         name,
+			"image":        image,
+			"warnings":     resp.Warnings,
+		}, nil, ActionStatusCompleted
 	} else {
 		// Создаем конфигурацию хоста без портов
 		hostConfig := &container.HostConfig{}
@@ -809,13 +726,18 @@ func handleStartContainer(dockerClient *client.Client, payload map[string]interf
 			}
 		}
 
-		successMsg := fmt.Sprintf("Container %s started successfully with ID: %s", name, resp.ID)
-		return &successMsg, nil, ActionStatusCompleted
+		return ActionResult{
+			"message":      fmt.Sprintf("Container %s started successfully", name),
+			"container_id": resp.ID,
+			"name":         name,
+			"image":        image,
+			"warnings":     resp.Warnings,
+		}, nil, ActionStatusCompleted
 	}
 }
 
 // handleStopContainer обрабатывает остановку контейнера
-func handleStopContainer(dockerClient *client.Client, payload map[string]interface{}) (*string, *string, string) {
+func handleStopContainer(dockerClient *client.Client, payload map[string]interface{}) (ActionResult, *string, string) {
 	ctx := context.Background()
 
 	containerID, ok := payload["container_id"].(string)
@@ -838,12 +760,14 @@ func handleStopContainer(dockerClient *client.Client, payload map[string]interfa
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := fmt.Sprintf("Container %s stopped successfully", containerID)
-	return &successMsg, nil, ActionStatusCompleted
+	return ActionResult{
+		"message":      fmt.Sprintf("Container %s stopped successfully", containerID),
+		"container_id": containerID,
+	}, nil, ActionStatusCompleted
 }
 
 // handleRemoveContainer обрабатывает удаление контейнера
-func handleRemoveContainer(dockerClient *client.Client, payload map[string]interface{}) (*string, *string, string) {
+func handleRemoveContainer(dockerClient *client.Client, payload map[string]interface{}) (ActionResult, *string, string) {
 	ctx := context.Background()
 
 	containerID, ok := payload["container_id"].(string)
@@ -866,12 +790,14 @@ func handleRemoveContainer(dockerClient *client.Client, payload map[string]inter
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := fmt.Sprintf("Container %s removed successfully", containerID)
-	return &successMsg, nil, ActionStatusCompleted
+	return ActionResult{
+		"message":      fmt.Sprintf("Container %s removed successfully", containerID),
+		"container_id": containerID,
+	}, nil, ActionStatusCompleted
 }
 
 // handleRemoveImage обрабатывает удаление образа
-func handleRemoveImage(dockerClient *client.Client, payload map[string]interface{}) (*string, *string, string) {
+func handleRemoveImage(dockerClient *client.Client, payload map[string]interface{}) (ActionResult, *string, string) {
 	ctx := context.Background()
 
 	imageID, ok := payload["image_id"].(string)
@@ -886,7 +812,7 @@ func handleRemoveImage(dockerClient *client.Client, payload map[string]interface
 	}
 
 	// Удаляем образ
-	_, err := dockerClient.ImageRemove(ctx, imageID, image.RemoveOptions{
+	deleteResponses, err := dockerClient.ImageRemove(ctx, imageID, image.RemoveOptions{
 		Force: force,
 	})
 	if err != nil {
@@ -894,12 +820,27 @@ func handleRemoveImage(dockerClient *client.Client, payload map[string]interface
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := fmt.Sprintf("Image %s removed successfully", imageID)
-	return &successMsg, nil, ActionStatusCompleted
+	untagged := []string{}
+	deleted := []string{}
+	for _, item := range deleteResponses {
+		if item.Untagged != "" {
+			untagged = append(untagged, item.Untagged)
+		}
+		if item.Deleted != "" {
+			deleted = append(deleted, item.Deleted)
+		}
+	}
+
+	return ActionResult{
+		"message":  fmt.Sprintf("Image %s removed successfully", imageID),
+		"image_id": imageID,
+		"untagged": untagged,
+		"deleted":  deleted,
+	}, nil, ActionStatusCompleted
 }
 
 // handlePullImage обрабатывает загрузку образа
-func handlePullImage(dockerClient *client.Client, payload map[string]interface{}) (*string, *string, string) {
+func handlePullImage(dockerClient *client.Client, payload map[string]interface{}) (ActionResult, *string, string) {
 	ctx := context.Background()
 
 	imageName, ok := payload["image"].(string)
@@ -939,12 +880,61 @@ func handlePullImage(dockerClient *client.Client, payload map[string]interface{}
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := fmt.Sprintf("Image %s pulled successfully", fullImageName)
-	return &successMsg, nil, ActionStatusCompleted
+	result := ActionResult{
+		"message": fmt.Sprintf("Image %s pulled successfully", fullImageName),
+		"image":   fullImageName,
+	}
+
+	// Дополняем результат ID и дайджестом загруженного образа
+	inspect, err := dockerClient.ImageInspect(ctx, fullImageName)
+	if err != nil {
+		log.Printf("Warning: failed to inspect pulled image %s: %v", fullImageName, err)
+	} else {
+		result["image_id"] = inspect.ID
+		result["repo_digests"] = inspect.RepoDigests
+		if len(inspect.RepoDigests) > 0 {
+			if parts := strings.SplitN(inspect.RepoDigests[0], "@", 2); len(parts) == 2 {
+				result["digest"] = parts[1]
+			}
+		}
+		result["size"] = inspect.Size
+	}
+
+	return result, nil, ActionStatusCompleted
+}
+
+// handlePruneImages обрабатывает удаление неиспользуемых образов
+func handlePruneImages(dockerClient *client.Client, payload map[string]interface{}) (ActionResult, *string, string) {
+	ctx := context.Background()
+
+	// По умолчанию удаляем только dangling образы, как `docker image prune`
+	pruneFilters := filters.NewArgs()
+	if all, ok := payload["all"].(bool); ok && all {
+		pruneFilters.Add("dangling", "false")
+	}
+
+	report, err := dockerClient.ImagesPrune(ctx, pruneFilters)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to prune images: %v", err)
+		return nil, &errMsg, ActionStatusFailed
+	}
+
+	deleted := []string{}
+	for _, item := range report.ImagesDeleted {
+		if item.Deleted != "" {
+			deleted = append(deleted, item.Deleted)
+		}
+	}
+
+	return ActionResult{
+		"message":         fmt.Sprintf("Pruned %d images, reclaimed %d bytes", len(report.ImagesDeleted), report.SpaceReclaimed),
+		"images_deleted":  deleted,
+		"space_reclaimed": report.SpaceReclaimed,
+	}, nil, ActionStatusCompleted
 }
 
 // handleRestartContainer обрабатывает перезапуск контейнера
-func handleRestartContainer(dockerClient *client.Client, payload map[string]interface{}) (*string, *string, string) {
+func handleRestartContainer(dockerClient *client.Client, payload map[string]interface{}) (ActionResult, *string, string) {
 	ctx := context.Background()
 
 	containerID, ok := payload["container_id"].(string)
@@ -967,12 +957,14 @@ func handleRestartContainer(dockerClient *client.Client, payload map[string]inte
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := fmt.Sprintf("Container %s restarted successfully", containerID)
-	return &successMsg, nil, ActionStatusCompleted
+	return ActionResult{
+		"message":      fmt.Sprintf("Container %s restarted successfully", containerID),
+		"container_id": containerID,
+	}, nil, ActionStatusCompleted
 }
 
 // handleCreateNginxConfig создает конфигурацию NGINX
-func handleCreateNginxConfig(payload map[string]interface{}) (*string, *string, string) {
+func handleCreateNginxConfig(payload map[string]interface{}) (ActionResult, *string, string) {
 	domain, ok := payload["domain"].(string)
 	if !ok {
 		err := "Domain is required"
@@ -1027,12 +1019,17 @@ func handleCreateNginxConfig(payload map[string]interface{}) (*string, *string,
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := fmt.Sprintf("Nginx config for %s created successfully", domain)
-	return &successMsg, nil, ActionStatusCompleted
+	return ActionResult{
+		"message":     fmt.Sprintf("Nginx config for %s created successfully", domain),
+		"domain":      domain,
+		"config_path": configPath,
+		"config":      config,
+		"ssl":         ssl,
+	}, nil, ActionStatusCompleted
 }
 
 // handleDeleteNginxConfig удаляет конфигурацию NGINX
-func handleDeleteNginxConfig(payload map[string]interface{}) (*string, *string, string) {
+func handleDeleteNginxConfig(payload map[string]interface{}) (ActionResult, *string, string) {
 	domain, ok := payload["domain"].(string)
 	if !ok {
 		err := "Domain is required"
@@ -1059,8 +1056,11 @@ func handleDeleteNginxConfig(payload map[string]interface{}) (*string, *string,
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := fmt.Sprintf("Nginx config for %s deleted successfully", domain)
-	return &successMsg, nil, ActionStatusCompleted
+	return ActionResult{
+		"message":     fmt.Sprintf("Nginx config for %s deleted successfully", domain),
+		"domain":      domain,
+		"config_path": configPath,
+	}, nil, ActionStatusCompleted
 }
 
 // generateNginxConfig генерирует конфигурацию NGINX
@@ -1198,7 +1198,7 @@ server {
 }
 
 // handleUpdateNginxConfig обрабатывает обновление конфигурации nginx
-func handleUpdateNginxConfig(payload map[string]interface{}) (*string, *string, string) {
+func handleUpdateNginxConfig(payload map[string]interface{}) (ActionResult, *string, string) {
 	// Создаем директорию для конфигураций если не существует
 	if err := os.MkdirAll("conf.d", 0755); err != nil {
 		errMsg := fmt.Sprintf("Failed to create conf.d directory: %v", err)
@@ -1298,25 +1298,30 @@ location %s {
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	successMsg := "Nginx configuration updated successfully"
-	return &successMsg, nil, ActionStatusCompleted
+	return ActionResult{
+		"message":     fmt.Sprintf("Nginx config for %s updated successfully", domain),
+		"domain":      domain,
+		"config_path": configPath,
+		"config":      config,
+		"ssl_enabled": sslEnabled,
+		"routes":      len(routes),
+	}, nil, ActionStatusCompleted
 }
 
 // handleGetNginxConfig получает текущую конфигурацию nginx
-func handleGetNginxConfig(payload map[string]interface{}) (*string, *string, string) {
+func handleGetNginxConfig(payload map[string]interface{}) (ActionResult, *string, string) {
 	// Читаем все файлы конфигурации из директории conf.d
 	entries, err := os.ReadDir("conf.d")
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Если директория не существует, возвращаем пустую конфигурацию
-			emptyConfig := `{"domains": []}`
-			return &emptyConfig, nil, ActionStatusCompleted
+			return ActionResult{"domains": []map[string]interface{}{}}, nil, ActionStatusCompleted
 		}
 		errMsg := fmt.Sprintf("Failed to read conf.d directory: %v", err)
 		return nil, &errMsg, ActionStatusFailed
 	}
 
-	var domains []map[string]interface{}
+	domains := []map[string]interface{}{}
 
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
@@ -1344,19 +1349,10 @@ func handleGetNginxConfig(payload map[string]interface{}) (*string, *string, str
 		domains = append(domains, domainConfig)
 	}
 
-	// Формируем ответ
-	response := map[string]interface{}{
+	return ActionResult{
+		"message": fmt.Sprintf("Found %d nginx configs", len(domains)),
 		"domains": domains,
-	}
-
-	responseJSON, err := json.Marshal(response)
-	if err != nil {
-		errMsg := fmt.Sprintf("Failed to marshal response: %v", err)
-		return nil, &errMsg, ActionStatusFailed
-	}
-
-	responseStr := string(responseJSON)
-	return &responseStr, nil, ActionStatusCompleted
+	}, nil, ActionStatusCompleted
 }
 
 // parseNginxConfig парсит конфигурацию nginx для извлечения маршрутов
@@ -1409,10 +1405,7 @@ func parseNginxConfig(config string) []map[string]interface{} {
 }
 
 // sendActionResult отправляет результат выполнения действия на сервер
-func sendActionResult(actionID, status string, response, error *string) error {
-	url := os.Getenv("URL")
-	token := os.Getenv("TOKEN")
-
+func sendActionResult(url string, credentials *Credentials, actionID, status string, response ActionResult, error *string) error {
 	// Формируем URL для обновления статуса действия
 	updateURL := strings.TrimSuffix(url, "/agent/ping") + "/actions/" + actionID + "/status"
 
@@ -1437,7 +1430,9 @@ func sendActionResult(actionID, status string, response, error *string) error {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+	if err := credentials.authorize(req, jsonData); err != nil {
+		return err
+	}
 
 	// Отправляем запрос
 	client := &http.Client{Timeout: 30 * time.Second}
@@ -1447,6 +1442,8 @@ func sendActionResult(actionID, status string, response, error *string) error {
 	}
 	defer resp.Body.Close()
 
+	credentials.learnCredentials(resp)
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("server returned status %d", resp.StatusCode)
 	}
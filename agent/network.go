@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gopsutilnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// defaultInterfaceExclude — интерфейсы, которые по умолчанию не учитываются: loopback,
+// концы veth контейнеров и мосты Docker. Их трафик уже посчитан на физических интерфейсах.
+const defaultInterfaceExclude = "lo,veth*,docker*,br-*"
+
+// InterfaceInfo представляет накопительные счетчики сетевого интерфейса хоста
+type InterfaceInfo struct {
+	Name            string `json:"name"`
+	Sent            uint64 `json:"sent"`
+	Received        uint64 `json:"received"`
+	PacketsSent     uint64 `json:"packets_sent"`
+	PacketsReceived uint64 `json:"packets_received"`
+	ErrorsIn        uint64 `json:"errors_in"`
+	ErrorsOut       uint64 `json:"errors_out"`
+	DropsIn         uint64 `json:"drops_in"`
+	DropsOut        uint64 `json:"drops_out"`
+}
+
+// interfaceFilter отбирает интерфейсы по шаблонам filepath.Match
+type interfaceFilter struct {
+	include []string
+	exclude []string
+}
+
+// newInterfaceFilter читает шаблоны из NETWORK_INTERFACES_INCLUDE и NETWORK_INTERFACES_EXCLUDE
+// (через запятую). Пустой include — все интерфейсы; exclude по умолчанию — defaultInterfaceExclude.
+func newInterfaceFilter() *interfaceFilter {
+	exclude, ok := os.LookupEnv("NETWORK_INTERFACES_EXCLUDE")
+	if !ok {
+		exclude = defaultInterfaceExclude
+	}
+	return &interfaceFilter{
+		include: splitPatterns(os.Getenv("NETWORK_INTERFACES_INCLUDE")),
+		exclude: splitPatterns(exclude),
+	}
+}
+
+// splitPatterns разбирает список шаблонов через запятую
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// matchAny сообщает, подходит ли имя хотя бы под один шаблон
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed сообщает, учитывать ли интерфейс. Исключение важнее включения.
+func (f *interfaceFilter) allowed(name string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, name) {
+		return false
+	}
+	return !matchAny(f.exclude, name)
+}
+
+// hostNetDevPath возвращает путь к счетчикам интерфейсов хоста. /proc/net указывает на сетевое
+// пространство читающего процесса, то есть контейнера агента; счетчики хоста видны через
+// процесс 1 хоста (агент запущен с pid: host).
+func hostNetDevPath() string {
+	return filepath.Join(hostProc(), "1", "net", "dev")
+}
+
+// collectInterfaces собирает счетчики интерфейсов хоста, прошедших фильтр
+func collectInterfaces(filter *interfaceFilter) ([]InterfaceInfo, error) {
+	stats, err := gopsutilnet.IOCountersByFile(true, hostNetDevPath())
+	if err != nil {
+		// Без доступа к процессу 1 хоста остаются счетчики собственного пространства
+		log.Printf("Failed to read host network counters, falling back to own namespace: %v", err)
+		if stats, err = gopsutilnet.IOCounters(true); err != nil {
+			return nil, err
+		}
+	}
+
+	var interfaces []InterfaceInfo
+	for _, stat := range stats {
+		if !filter.allowed(stat.Name) {
+			continue
+		}
+		interfaces = append(interfaces, InterfaceInfo{
+			Name:            stat.Name,
+			Sent:            stat.BytesSent,
+			Received:        stat.BytesRecv,
+			PacketsSent:     stat.PacketsSent,
+			PacketsReceived: stat.PacketsRecv,
+			ErrorsIn:        stat.Errin,
+			ErrorsOut:       stat.Errout,
+			DropsIn:         stat.Dropin,
+			DropsOut:        stat.Dropout,
+		})
+	}
+
+	return interfaces, nil
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultQueueDir — где агент хранит пинги, не доставленные на сервер
+const defaultQueueDir = "data/queue"
+
+// Ограничения очереди по умолчанию: при превышении отбрасываются самые старые пинги
+const (
+	defaultQueueMaxSizeMB   = 100
+	defaultQueueMaxAgeHours = 24
+	defaultQueueMaxAttempts = 5
+)
+
+// failedQueueDir — подкаталог очереди для пингов, которые сервер раз за разом не смог обработать
+const failedQueueDir = "failed"
+
+// errQueueNotEmpty — в очереди остались пинги: новый пинг ставится за ними, чтобы сервер получил их по порядку
+var errQueueNotEmpty = errors.New("queued pings are not delivered yet")
+
+// statusError — сервер ответил ошибкой
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned status %d", e.code)
+}
+
+// rejected сообщает, что сервер отверг сам пинг: повторять его бессмысленно
+func rejected(err error) bool {
+	var status *statusError
+	return errors.As(err, &status) && status.code == http.StatusBadRequest
+}
+
+// serverFailed сообщает, что сервер доступен и принял агента, но не обработал пинг.
+// Недоступность сервера, отказ в доступе и ограничение частоты не зависят от пинга.
+func serverFailed(err error) bool {
+	var status *statusError
+	if !errors.As(err, &status) {
+		return false
+	}
+	switch status.code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return true
+}
+
+// pingQueue хранит недоставленные пинги на диске, по файлу на пинг. Имя файла —
+// время постановки в очередь в наносекундах, поэтому порядок имен совпадает с порядком сбора.
+// После неудачных попыток в имя добавляется их число: "<время>.<попытки>.json".
+// Время сбора сервер берет из самого пинга.
+type pingQueue struct {
+	dir         string
+	maxSize     int64
+	maxAge      time.Duration
+	maxAttempts int
+}
+
+// newPingQueue создает очередь по QUEUE_DIR, QUEUE_MAX_SIZE_MB, QUEUE_MAX_AGE_HOURS и QUEUE_MAX_ATTEMPTS
+func newPingQueue() (*pingQueue, error) {
+	queue := &pingQueue{dir: defaultQueueDir}
+	if dir := os.Getenv("QUEUE_DIR"); dir != "" {
+		queue.dir = dir
+	}
+
+	sizeMB, err := positiveEnv("QUEUE_MAX_SIZE_MB", defaultQueueMaxSizeMB)
+	if err != nil {
+		return nil, err
+	}
+	ageHours, err := positiveEnv("QUEUE_MAX_AGE_HOURS", defaultQueueMaxAgeHours)
+	if err != nil {
+		return nil, err
+	}
+	queue.maxAttempts, err = positiveEnv("QUEUE_MAX_ATTEMPTS", defaultQueueMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	queue.maxSize = int64(sizeMB) * 1024 * 1024
+	queue.maxAge = time.Duration(ageHours) * time.Hour
+
+	if err := os.MkdirAll(filepath.Join(queue.dir, failedQueueDir), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %v", err)
+	}
+	return queue, nil
+}
+
+// positiveEnv читает положительное целое из переменной окружения
+func positiveEnv(name string, defaultValue int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("invalid %s value %q: must be a positive number", name, value)
+	}
+	return parsed, nil
+}
+
+// queuedPing — пинг в очереди
+type queuedPing struct {
+	path     string
+	queued   time.Time
+	attempts int // неудачных попыток, в которых сервер не обработал пинг
+	size     int64
+}
+
+// entries возвращает пинги очереди от старых к новым
+func (q *pingQueue) entries() ([]queuedPing, error) {
+	files, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []queuedPing{}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		queued, attempts, _ := strings.Cut(strings.TrimSuffix(name, ".json"), ".")
+		nanos, err := strconv.ParseInt(queued, 10, 64)
+		if err != nil {
+			continue
+		}
+		entry := queuedPing{
+			path:   filepath.Join(q.dir, name),
+			queued: time.Unix(0, nanos),
+		}
+		if attempts != "" {
+			if entry.attempts, err = strconv.Atoi(attempts); err != nil {
+				continue
+			}
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		entry.size = info.Size()
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].queued.Before(entries[j].queued)
+	})
+	return entries, nil
+}
+
+// push ставит пинг в конец очереди через временный файл, как saveCredentials
+func (q *pingQueue) push(data *AgentData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(q.dir, fmt.Sprintf("%020d.json", time.Now().UnixNano()))
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, payload, 0600); err != nil {
+		log.Printf("Failed to queue ping: %v", err)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		log.Printf("Failed to queue ping: %v", err)
+		return err
+	}
+
+	q.trim()
+	return nil
+}
+
+// trim отбрасывает пинги старше maxAge и самые старые пинги сверх maxSize
+func (q *pingQueue) trim() {
+	entries, err := q.entries()
+	if err != nil {
+		log.Printf("Failed to read ping queue: %v", err)
+		return
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.size
+	}
+
+	dropped := 0
+	for _, entry := range entries {
+		if time.Since(entry.queued) <= q.maxAge && total <= q.maxSize {
+			break
+		}
+		if err := os.Remove(entry.path); err != nil {
+			log.Printf("Failed to drop queued ping: %v", err)
+			break
+		}
+		total -= entry.size
+		dropped++
+	}
+	if dropped > 0 {
+		log.Printf("Dropped %d oldest queued pings: queue limits exceeded", dropped)
+	}
+
+	q.trimFailed()
+}
+
+// trimFailed удаляет отложенные пинги старше maxAge: их хранят только для разбора
+func (q *pingQueue) trimFailed() {
+	dir := filepath.Join(q.dir, failedQueueDir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("Failed to read failed pings: %v", err)
+		return
+	}
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || time.Since(info.ModTime()) <= q.maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.Name())); err != nil {
+			log.Printf("Failed to drop failed ping: %v", err)
+		}
+	}
+}
+
+// fail учитывает неудачную попытку отправки пинга. После maxAttempts попыток пинг
+// переносится в подкаталог failed, чтобы не задерживать остальную очередь; true — пинг перенесен.
+func (q *pingQueue) fail(entry queuedPing) (bool, error) {
+	attempts := entry.attempts + 1
+	name := fmt.Sprintf("%020d.%d.json", entry.queued.UnixNano(), attempts)
+
+	path := filepath.Join(q.dir, name)
+	setAside := attempts >= q.maxAttempts
+	if setAside {
+		path = filepath.Join(q.dir, failedQueueDir, name)
+	}
+	if err := os.Rename(entry.path, path); err != nil {
+		return false, fmt.Errorf("failed to update queued ping: %v", err)
+	}
+	return setAside, nil
+}
+
+// replay отправляет пинги очереди по порядку, пока они доставляются и не истекло
+// время budget, чтобы сбор данных не останавливался на время разбора большой очереди.
+// Пинг, который сервер не обработал maxAttempts раз, откладывается, и разбор продолжается.
+// Действия из ответов не возвращаются: сервер присылает все невыполненные действия
+// в каждом ответе, и они придут в ответе на новый пинг. errQueueNotEmpty — в очереди остались пинги.
+func (q *pingQueue) replay(budget time.Duration, send func(payload []byte) ([]Action, error)) error {
+	q.trim()
+
+	entries, err := q.entries()
+	if err != nil {
+		return fmt.Errorf("failed to read ping queue: %v", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	started := time.Now()
+	replayed := 0
+	defer func() {
+		if replayed > 0 {
+			log.Printf("Replayed %d queued pings, %d left", replayed, len(entries)-replayed)
+		}
+	}()
+
+	for _, entry := range entries {
+		if time.Since(started) > budget {
+			return errQueueNotEmpty
+		}
+
+		payload, err := os.ReadFile(entry.path)
+		if err != nil {
+			return fmt.Errorf("failed to read queued ping: %v", err)
+		}
+
+		_, err = send(payload)
+		switch {
+		case err == nil:
+		case rejected(err):
+			log.Printf("Server rejected queued ping from %s, dropping it: %v", entry.queued.Format(time.RFC3339), err)
+		case serverFailed(err):
+			setAside, failErr := q.fail(entry)
+			if failErr != nil {
+				return failErr
+			}
+			if !setAside {
+				return err
+			}
+			log.Printf("Server failed to process queued ping from %s %d times, moved it to %s: %v",
+				entry.queued.Format(time.RFC3339), q.maxAttempts, failedQueueDir, err)
+			replayed++
+			continue
+		default:
+			return err
+		}
+
+		if err := os.Remove(entry.path); err != nil {
+			return fmt.Errorf("failed to remove delivered ping: %v", err)
+		}
+		replayed++
+	}
+	return nil
+}
+
+// deliver отправляет пинг на сервер. Сначала отправляются пинги из очереди: сервер
+// должен получить их раньше нового, иначе скорости посчитаются не по порядку сбора.
+// Пока очередь не разобрана, новый пинг не отправляется и возвращается errQueueNotEmpty.
+func deliver(url string, credentials *Credentials, queue *pingQueue, data *AgentData, budget time.Duration) ([]Action, error) {
+	send := func(payload []byte) ([]Action, error) {
+		return sendData(url, credentials, payload)
+	}
+
+	if err := queue.replay(budget, send); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return send(payload)
+}
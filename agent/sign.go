@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки подписанного запроса; сервер проверяет подпись вместо Bearer токена
+const (
+	headerAgentID        = "X-Agent-ID"
+	headerAgentTimestamp = "X-Agent-Timestamp"
+	headerAgentNonce     = "X-Agent-Nonce"
+	headerAgentSignature = "X-Agent-Signature"
+	// headerAgentSigningKey — ключ подписи в ответе сервера на запрос с Bearer токеном
+	headerAgentSigningKey = "X-Agent-Signing-Key"
+)
+
+// signingEnabled сообщает, подписывает ли агент запросы. SIGN_REQUESTS=false
+// возвращает отправку Bearer токена, если сервер еще не поддерживает подпись.
+func signingEnabled() bool {
+	value := os.Getenv("SIGN_REQUESTS")
+	return value == "" || value == "true" || value == "1"
+}
+
+// authorize подписывает запрос, если ID агента и ключ подписи известны, иначе добавляет
+// Bearer токен. Подпись — HMAC-SHA256 с ключом, выданным сервером, над методом, путем,
+// временем, одноразовым значением и хешем тела; сам токен при этом не передается.
+func (c *Credentials) authorize(req *http.Request, body []byte) error {
+	if c.AgentID == "" || c.SigningKey == "" || !signingEnabled() {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		return nil
+	}
+
+	key, err := hex.DecodeString(c.SigningKey)
+	if err != nil {
+		return fmt.Errorf("invalid signing key: %v", err)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	nonceHex := hex.EncodeToString(nonce)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{req.Method, req.URL.Path, timestamp, nonceHex, hex.EncodeToString(bodyHash[:])}, "\n")))
+
+	req.Header.Set(headerAgentID, c.AgentID)
+	req.Header.Set(headerAgentTimestamp, timestamp)
+	req.Header.Set(headerAgentNonce, nonceHex)
+	req.Header.Set(headerAgentSignature, "v2="+hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// learnCredentials запоминает ID агента и ключ подписи из ответа на запрос с Bearer
+// токеном, чтобы следующие запросы отправлялись подписанными. Если сервер отклонил
+// подписанный запрос (например, после смены его секрета), ключ забывается и следующий
+// запрос уходит с Bearer токеном, в ответ на который сервер выдаст ключ заново.
+func (c *Credentials) learnCredentials(resp *http.Response) {
+	if resp.StatusCode == http.StatusUnauthorized && resp.Request.Header.Get(headerAgentSignature) != "" {
+		log.Printf("Signed request rejected, requesting a new signing key")
+		c.SigningKey = ""
+		return
+	}
+
+	if agentID := resp.Header.Get(headerAgentID); agentID != "" {
+		c.AgentID = agentID
+	}
+	signingKey := resp.Header.Get(headerAgentSigningKey)
+	if signingKey == "" || signingKey == c.SigningKey {
+		return
+	}
+	c.SigningKey = signingKey
+	if signingEnabled() {
+		log.Printf("Agent ID is %s, signing further requests", c.AgentID)
+	}
+}
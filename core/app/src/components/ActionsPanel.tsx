@@ -275,7 +275,10 @@ export default function ActionsPanel({ agentId, onActionCreated }: ActionsPanelP
             </div>
             {action.response && (
               <div className={styles.actionResponse}>
-                <strong>Ответ:</strong> {action.response}
+                <strong>Ответ:</strong> {action.response.message || JSON.stringify(action.response)}
+                {action.response.container_id && (
+                  <div>ID контейнера: <code>{action.response.container_id}</code></div>
+                )}
               </div>
             )}
             {action.error && (
@@ -10,10 +10,25 @@ interface User {
   last_login?: string
 }
 
+// Второй шаг входа: totp — ввести код, enroll — подключить 2FA, обязательную для роли
+export interface LoginChallenge {
+  challengeToken: string
+  challengeType: 'totp' | 'enroll'
+}
+
+export interface SecondFactorResult {
+  // Коды восстановления, выданные при подключении 2FA во время входа
+  recoveryCodes: string[]
+  // Завершает вход; вызывается после того, как пользователь сохранил коды
+  finish: () => void
+}
+
 interface AuthContextType {
   user: User | null
   token: string | null
-  login: (username: string, password: string) => Promise<void>
+  login: (username: string, password: string) => Promise<LoginChallenge | null>
+  verifySecondFactor: (challenge: LoginChallenge, code: string) => Promise<SecondFactorResult>
+  completeLogin: (token: string, refreshToken: string) => Promise<void>
   logout: () => void
   loading: boolean
 }
@@ -39,27 +54,65 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     setLoading(false)
   }, [])
 
+  const storeSession = (data: { token: string; refresh_token: string; user: User }) => {
+    setToken(data.token)
+    setUser(data.user)
+
+    localStorage.setItem('token', data.token)
+    localStorage.setItem('refresh_token', data.refresh_token)
+    localStorage.setItem('user', JSON.stringify(data.user))
+    api.defaults.headers.common['Authorization'] = `Bearer ${data.token}`
+  }
+
   const login = async (username: string, password: string) => {
     try {
       const response = await api.post('/api/login', { username, password })
-      const { token: newToken, user: newUser } = response.data
-      
-      setToken(newToken)
-      setUser(newUser)
-      
-      localStorage.setItem('token', newToken)
-      localStorage.setItem('user', JSON.stringify(newUser))
-      api.defaults.headers.common['Authorization'] = `Bearer ${newToken}`
+      if (response.data.mfa_required) {
+        return {
+          challengeToken: response.data.challenge_token,
+          challengeType: response.data.challenge_type,
+        } as LoginChallenge
+      }
+      storeSession(response.data)
+      return null
     } catch (error) {
       console.error('Login failed:', error)
       throw error
     }
   }
 
+  const verifySecondFactor = async (challenge: LoginChallenge, code: string) => {
+    const url = challenge.challengeType === 'enroll' ? '/api/login/2fa/activate' : '/api/login/2fa'
+    const response = await api.post(url, { challenge_token: challenge.challengeToken, code })
+    return {
+      recoveryCodes: response.data.recovery_codes ?? [],
+      finish: () => storeSession(response.data),
+    }
+  }
+
+  // Завершает вход, выполненный вне формы (например, через OIDC), по готовым токенам
+  const completeLogin = async (newToken: string, refreshToken: string) => {
+    localStorage.setItem('token', newToken)
+    localStorage.setItem('refresh_token', refreshToken)
+    api.defaults.headers.common['Authorization'] = `Bearer ${newToken}`
+
+    const response = await api.get('/api/profile')
+    setToken(newToken)
+    setUser(response.data)
+    localStorage.setItem('user', JSON.stringify(response.data))
+  }
+
   const logout = () => {
+    // Отзываем сессию на сервере; ошибка не мешает локальному выходу
+    const currentToken = localStorage.getItem('token')
+    if (currentToken) {
+      api.post('/api/logout', null, { headers: { Authorization: `Bearer ${currentToken}` } }).catch(() => {})
+    }
+
     setToken(null)
     setUser(null)
     localStorage.removeItem('token')
+    localStorage.removeItem('refresh_token')
     localStorage.removeItem('user')
     delete api.defaults.headers.common['Authorization']
   }
@@ -68,6 +121,8 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     user,
     token,
     login,
+    verifySecondFactor,
+    completeLogin,
     logout,
     loading,
   }
@@ -8,13 +8,17 @@ import {
   CheckCircle,
   XCircle,
   HardDrive,
+  Database,
   Network,
+  Activity,
+  Timer,
 } from 'lucide-react'
 import {
   agentsApi,
   type AgentDetail as AgentDetailType,
   formatCPUUsage,
   formatBytes,
+  formatUptime,
   getAgentStatusColor
 } from '../services/api'
 import {
@@ -116,8 +120,8 @@ export default function AgentDetail() {
       hour: '2-digit', 
       minute: '2-digit' 
     }),
-    read: (metric.disk_read || 0) / 1024 / 1024, // MB/s
-    write: (metric.disk_write || 0) / 1024 / 1024, // MB/s
+    read: (metric.disk_read_speed || 0) / 1024 / 1024, // MB/s
+    write: (metric.disk_write_speed || 0) / 1024 / 1024, // MB/s
   })) || []
 
   const networkChartData = data.system_metrics?.slice(-20).map(metric => ({
@@ -125,8 +129,8 @@ export default function AgentDetail() {
       hour: '2-digit', 
       minute: '2-digit' 
     }),
-    sent: (metric.network_sent || 0) / 1024 / 1024, // MB/s
-    received: (metric.network_received || 0) / 1024 / 1024, // MB/s
+    sent: (metric.network_sent_speed || 0) / 1024 / 1024, // MB/s
+    received: (metric.network_received_speed || 0) / 1024 / 1024, // MB/s
   })) || []
 
   const ramData = [
@@ -270,6 +274,83 @@ export default function AgentDetail() {
           )}
         </div>
 
+        {/* Host load */}
+        {data.metrics.host && (
+          <div className={styles.metricCard}>
+            <h3 className={styles.metricTitle}>
+              <Activity className={styles.metricIcon} />
+              Нагрузка хоста
+            </h3>
+            <div className={styles.networkInfo}>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>Load average:</span>
+                <span className={styles.networkValue}>
+                  {data.metrics.host.load1.toFixed(2)} / {data.metrics.host.load5.toFixed(2)} / {data.metrics.host.load15.toFixed(2)}
+                </span>
+              </div>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>Время работы:</span>
+                <span className={styles.networkValue} title={new Date(data.metrics.host.boot_time).toLocaleString()}>
+                  {formatUptime(data.metrics.host.uptime)}
+                </span>
+              </div>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>Процессы / потоки:</span>
+                <span className={styles.networkValue}>{data.metrics.host.processes} / {data.metrics.host.threads}</span>
+              </div>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>Переключения контекста:</span>
+                <span className={styles.networkValue}>
+                  {data.metrics.host.context_switches_speed !== null ? `${Math.round(data.metrics.host.context_switches_speed)}/s` : '—'}
+                </span>
+              </div>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>CPU:</span>
+                <span className={styles.networkValue}>
+                  user {data.metrics.host.cpu_user_percent?.toFixed(1) ?? '—'}%,
+                  system {data.metrics.host.cpu_system_percent?.toFixed(1) ?? '—'}%,
+                  iowait {data.metrics.host.cpu_iowait_percent?.toFixed(1) ?? '—'}%,
+                  steal {data.metrics.host.cpu_steal_percent?.toFixed(1) ?? '—'}%
+                </span>
+              </div>
+            </div>
+          </div>
+        )}
+
+        {/* Data collection */}
+        {data.metrics.collection && (
+          <div className={styles.metricCard}>
+            <h3 className={styles.metricTitle}>
+              <Timer className={styles.metricIcon} />
+              Сбор данных
+            </h3>
+            <div className={styles.networkInfo}>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>Длительность:</span>
+                <span className={styles.networkValue}>
+                  {Math.round(data.metrics.collection.duration_ms)} мс (Docker {Math.round(data.metrics.collection.docker_duration_ms)} мс)
+                </span>
+              </div>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>Параллельных запросов:</span>
+                <span className={styles.networkValue}>{data.metrics.collection.workers}</span>
+              </div>
+              <div className={styles.networkItem}>
+                <span className={styles.networkLabel}>Ошибок Docker:</span>
+                <span className={`${styles.networkValue} ${data.metrics.collection.failures.length > 0 ? 'text-red-600' : ''}`}>
+                  {data.metrics.collection.failures.length}
+                </span>
+              </div>
+              {data.metrics.collection.failures.map((failure) => (
+                <div key={`${failure.container_id}-${failure.call}`} className={styles.networkItem}>
+                  <span className={styles.networkLabel}>{failure.name || failure.container_id.slice(0, 12)} ({failure.call}):</span>
+                  <span className={styles.networkValue} title={failure.error}>{failure.error}</span>
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
+
         {/* Disk I/O */}
         <div className={styles.metricCard}>
           <h3 className={styles.metricTitle}>
@@ -281,8 +362,9 @@ export default function AgentDetail() {
               <div key={disk.name} className={styles.diskItem}>
                 <span className={styles.diskName}>{disk.name}</span>
                 <div className={styles.diskMetrics}>
-                  <span>R: {formatBytes(disk.read_bytes)}</span>
-                  <span>W: {formatBytes(disk.write_bytes)}</span>
+                  <span>R: {formatBytes(disk.read_speed)}/s</span>
+                  <span>W: {formatBytes(disk.write_speed)}/s</span>
+                  <span>IOPS: {Math.round(disk.read_iops + disk.write_iops)}</span>
                 </div>
               </div>
             ))}
@@ -303,6 +385,25 @@ export default function AgentDetail() {
           )}
         </div>
 
+        {/* Filesystems */}
+        <div className={styles.metricCard}>
+          <h3 className={styles.metricTitle}>
+            <Database className={styles.metricIcon} />
+            Файловые системы
+          </h3>
+          <div className={styles.diskList}>
+            {(data.metrics.filesystems ?? []).map((fs) => (
+              <div key={fs.mountpoint} className={styles.diskItem}>
+                <span className={styles.diskName} title={`${fs.device} (${fs.fstype})`}>{fs.mountpoint}</span>
+                <div className={styles.diskMetrics}>
+                  <span>{formatBytes(fs.used_bytes)} / {formatBytes(fs.total_bytes)} ({fs.used_percent.toFixed(1)}%)</span>
+                  <span>inode: {fs.inodes_used_percent.toFixed(1)}%</span>
+                </div>
+              </div>
+            ))}
+          </div>
+        </div>
+
         {/* Network I/O */}
         <div className={styles.metricCard}>
           <h3 className={styles.metricTitle}>
@@ -322,6 +423,32 @@ export default function AgentDetail() {
               <span className={styles.networkLabel}>Получено:</span>
               <span className={styles.networkValue}>{formatBytes(data.metrics.network.received_bytes)}</span>
             </div>
+            <div className={styles.networkItem}>
+              <span className={styles.networkLabel}>Скорость:</span>
+              <span className={styles.networkValue}>
+                ↑ {formatBytes(data.metrics.network.sent_speed)}/s ↓ {formatBytes(data.metrics.network.received_speed)}/s
+              </span>
+            </div>
+            <div className={styles.networkItem}>
+              <span className={styles.networkLabel}>Пакеты:</span>
+              <span className={styles.networkValue}>
+                ↑ {Math.round(data.metrics.network.packets_sent_speed)}/s ↓ {Math.round(data.metrics.network.packets_received_speed)}/s
+              </span>
+            </div>
+          </div>
+          <div className={styles.diskList}>
+            {(data.metrics.network.interfaces ?? []).map((iface) => (
+              <div key={iface.name} className={styles.diskItem}>
+                <span className={styles.diskName}>{iface.name}</span>
+                <div className={styles.diskMetrics}>
+                  <span>↑ {formatBytes(iface.sent_speed)}/s</span>
+                  <span>↓ {formatBytes(iface.received_speed)}/s</span>
+                  <span title="Ошибки / отброшенные пакеты (вход + выход)">
+                    err: {iface.errors_in + iface.errors_out}, drop: {iface.drops_in + iface.drops_out}
+                  </span>
+                </div>
+              </div>
+            ))}
           </div>
           {networkChartData.length > 0 && (
             <div className={styles.chartContainer}>
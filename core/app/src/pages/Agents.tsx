@@ -3,7 +3,7 @@ import { useNavigate } from 'react-router-dom'
 import { 
   Plus, 
   Trash2, 
-  Eye,
+  KeyRound,
   CheckCircle, 
   XCircle, 
   Copy,
@@ -23,6 +23,9 @@ export default function Agents() {
   const [showCreateModal, setShowCreateModal] = useState(false)
   const [showTokenModal, setShowTokenModal] = useState(false)
   const [selectedAgent, setSelectedAgent] = useState<Agent | null>(null)
+  // Токен показывается только сразу после создания агента или ротации
+  const [issuedToken, setIssuedToken] = useState('')
+  const [rotatedUntil, setRotatedUntil] = useState<string | null>(null)
   const [newAgentName, setNewAgentName] = useState('')
   const [copiedToken, setCopiedToken] = useState(false)
 
@@ -59,6 +62,8 @@ export default function Agents() {
       setNewAgentName('')
       setShowCreateModal(false)
       setSelectedAgent(response.data)
+      setIssuedToken(response.data.token)
+      setRotatedUntil(null)
       setShowTokenModal(true)
     } catch (error: any) {
       setError('Ошибка создания агента')
@@ -88,9 +93,19 @@ export default function Agents() {
     }
   }
 
-  const handleViewToken = (agent: Agent) => {
-    setSelectedAgent(agent)
-    setShowTokenModal(true)
+  const handleRotateToken = async (agent: Agent) => {
+    if (!confirm(`Выпустить новый токен для агента "${agent.name}"? Агент получит его автоматически, старый токен будет действовать еще 24 часа.`)) return
+
+    try {
+      const response = await agentsApi.rotateToken(agent.id)
+      setSelectedAgent(agent)
+      setIssuedToken(response.data.token)
+      setRotatedUntil(response.data.previous_token_expires)
+      setShowTokenModal(true)
+      fetchAgents()
+    } catch (error: any) {
+      setError('Ошибка ротации токена')
+    }
   }
 
   const handleAgentClick = (agentId: string) => {
@@ -155,12 +170,12 @@ export default function Agents() {
                   <button
                     onClick={(e) => {
                       e.stopPropagation()
-                      handleViewToken(agent)
+                      handleRotateToken(agent)
                     }}
                     className={`${styles.actionButton} ${styles.view}`}
-                    title="Показать токен"
+                    title="Выпустить новый токен"
                   >
-                    <Eye className={styles.actionIcon} />
+                    <KeyRound className={styles.actionIcon} />
                   </button>
                   <button
                     onClick={(e) => {
@@ -274,7 +289,10 @@ export default function Agents() {
             
             <div>
               <p style={{ marginBottom: '1rem', color: '#64748b' }}>
-                Используйте этот токен для настройки агента "{selectedAgent.name}"
+                {rotatedUntil
+                  ? `Новый токен будет доставлен агенту "${selectedAgent.name}" при следующем пинге. Старый токен действует до ${new Date(rotatedUntil).toLocaleString()}.`
+                  : `Используйте этот токен для настройки агента "${selectedAgent.name}".`}
+                {' '}Токен показывается только один раз.
               </p>
               
               <div className={styles.agentToken}>
@@ -282,12 +300,12 @@ export default function Agents() {
                 <div className={styles.tokenContainer}>
                   <input
                     type="text"
-                    value={selectedAgent.token}
+                    value={issuedToken}
                     readOnly
                     className={styles.tokenValue}
                   />
                   <button
-                    onClick={() => copyToClipboard(selectedAgent.token)}
+                    onClick={() => copyToClipboard(issuedToken)}
                     className={`${styles.copyButton} ${copiedToken ? styles.copied : ''}`}
                     title="Копировать токен"
                   >
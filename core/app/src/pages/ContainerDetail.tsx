@@ -14,12 +14,16 @@ import {
   CheckCircle,
   XCircle,
   Pause,
-  RotateCcw
+  RotateCcw,
+  History
 } from 'lucide-react'
 import {
   containersApi,
   type ContainerDetail as ContainerDetailType,
+  type ContainerEvent,
+  formatContainerEvent,
   formatCPUUsage,
+  formatMemoryUsage,
   formatMemoryUsageMB,
   formatBytes,
   getContainerStatusColor
@@ -35,7 +39,7 @@ import {
 } from 'recharts'
 import styles from './ContainerDetail.module.css'
 
-type TabType = 'details' | 'resources' | 'logs'
+type TabType = 'details' | 'resources' | 'logs' | 'events'
 
 export default function ContainerDetail() {
   const { id } = useParams<{ id: string }>()
@@ -47,6 +51,8 @@ export default function ContainerDetail() {
   const [activeTab, setActiveTab] = useState<TabType>('details')
   const [logSearch, setLogSearch] = useState('')
   const [autoScroll, setAutoScroll] = useState(false)
+  const [events, setEvents] = useState<ContainerEvent[]>([])
+  const [eventsTotal, setEventsTotal] = useState(0)
 
   const fetchData = async (isRefresh = false) => {
     if (!id) return
@@ -75,6 +81,25 @@ export default function ContainerDetail() {
     return () => clearInterval(interval)
   }, [id])
 
+  const fetchEvents = async () => {
+    if (!id) return
+
+    try {
+      const response = await containersApi.getEvents(id, { limit: 200 })
+      setEvents(response.data.events)
+      setEventsTotal(response.data.total)
+    } catch (error) {
+      console.error('Container events fetch error:', error)
+    }
+  }
+
+  useEffect(() => {
+    if (activeTab !== 'events') return
+    fetchEvents()
+    const interval = setInterval(fetchEvents, 30000)
+    return () => clearInterval(interval)
+  }, [id, activeTab])
+
   if (loading && !data) {
     return (
       <div className={styles.loading}>
@@ -120,6 +145,7 @@ export default function ContainerDetail() {
     { id: 'details', label: 'Детали', icon: Info },
     { id: 'resources', label: 'Ресурсы', icon: BarChart3 },
     { id: 'logs', label: 'Логи', icon: FileText },
+    { id: 'events', label: 'События', icon: History },
   ]
 
   const renderDetailsTab = () => (
@@ -150,9 +176,71 @@ export default function ContainerDetail() {
               <span className={styles.infoLabel}>Перезапуски:</span>
               <span className={styles.infoValue}>{data.restart_count}</span>
             </div>
+            {data.restart_policy && (
+              <div className={styles.infoRow}>
+                <span className={styles.infoLabel}>Политика перезапуска:</span>
+                <span className={styles.infoValue}>
+                  {data.restart_policy}
+                  {data.restart_policy === 'on-failure' && data.restart_max_retries ? ` (до ${data.restart_max_retries} раз)` : ''}
+                </span>
+              </div>
+            )}
           </div>
         </div>
 
+        {data.state && (
+          <div className={styles.infoCard}>
+            <div className={styles.infoHeader}>
+              <Activity className={styles.infoIcon} />
+              <span className={styles.infoTitle}>Состояние</span>
+            </div>
+            <div className={styles.infoBody}>
+              <div className={styles.infoRow}>
+                <span className={styles.infoLabel}>Состояние:</span>
+                <span className={styles.infoValue}>{data.state}</span>
+              </div>
+              {data.started_at && (
+                <div className={styles.infoRow}>
+                  <span className={styles.infoLabel}>Запущен:</span>
+                  <span className={styles.infoValue}>{new Date(data.started_at).toLocaleString('ru-RU')}</span>
+                </div>
+              )}
+              {(data.state === 'exited' || data.state === 'dead') && (
+                <>
+                  {data.finished_at && (
+                    <div className={styles.infoRow}>
+                      <span className={styles.infoLabel}>Остановлен:</span>
+                      <span className={styles.infoValue}>{new Date(data.finished_at).toLocaleString('ru-RU')}</span>
+                    </div>
+                  )}
+                  <div className={styles.infoRow}>
+                    <span className={styles.infoLabel}>Причина:</span>
+                    <span className={`${styles.infoValue} ${data.oom_killed || data.exit_code ? 'text-red-600' : ''}`}>
+                      {data.oom_killed
+                        ? `Нехватка памяти (OOM), код ${data.exit_code}`
+                        : data.exit_code
+                          ? `Аварийное завершение, код ${data.exit_code}`
+                          : 'Штатная остановка, код 0'}
+                    </span>
+                  </div>
+                </>
+              )}
+              {data.health_status && (
+                <div className={styles.infoRow}>
+                  <span className={styles.infoLabel}>Healthcheck:</span>
+                  <span
+                    className={`${styles.infoValue} ${data.health_status === 'unhealthy' ? 'text-red-600' : data.health_status === 'healthy' ? 'text-green-600' : 'text-yellow-600'}`}
+                    title={data.health_output || undefined}
+                  >
+                    {data.health_status}
+                    {data.health_failing_streak ? ` (ошибок подряд: ${data.health_failing_streak})` : ''}
+                  </span>
+                </div>
+              )}
+            </div>
+          </div>
+        )}
+
         <div className={styles.infoCard}>
           <div className={styles.infoHeader}>
             <Network className={styles.infoIcon} />
@@ -179,9 +267,51 @@ export default function ContainerDetail() {
                 {formatBytes(data.network_received_bytes || 0)}
               </span>
             </div>
+            {(data.ports ?? []).map((port) => (
+              <div key={`${port.ip}:${port.public_port}:${port.private_port}/${port.type}`} className={styles.infoRow}>
+                <span className={styles.infoLabel}>Порт:</span>
+                <span className={styles.infoValue}>
+                  {port.public_port ? `${port.ip || '0.0.0.0'}:${port.public_port} → ` : ''}{port.private_port}/{port.type}
+                </span>
+              </div>
+            ))}
           </div>
         </div>
 
+        {(data.mounts ?? []).length > 0 && (
+          <div className={styles.infoCard}>
+            <div className={styles.infoHeader}>
+              <Server className={styles.infoIcon} />
+              <span className={styles.infoTitle}>Тома</span>
+            </div>
+            <div className={styles.infoBody}>
+              {(data.mounts ?? []).map((mount) => (
+                <div key={mount.destination} className={styles.infoRow}>
+                  <span className={styles.infoLabel} title={mount.type}>{mount.destination}</span>
+                  <span className={styles.infoValue}>{mount.source}{mount.rw ? '' : ' (ro)'}</span>
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
+
+        {Object.keys(data.labels ?? {}).length > 0 && (
+          <div className={styles.infoCard}>
+            <div className={styles.infoHeader}>
+              <Info className={styles.infoIcon} />
+              <span className={styles.infoTitle}>Метки</span>
+            </div>
+            <div className={styles.infoBody}>
+              {Object.entries(data.labels ?? {}).map(([key, value]) => (
+                <div key={key} className={styles.infoRow}>
+                  <span className={styles.infoLabel}>{key}</span>
+                  <span className={styles.infoValue}>{value}</span>
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
+
         <div className={styles.infoCard}>
           <div className={styles.infoHeader}>
             <Server className={styles.infoIcon} />
@@ -228,8 +358,20 @@ export default function ContainerDetail() {
               <span className={styles.metricLabel}>Память:</span>
               <span className={styles.metricValue}>
                 {formatMemoryUsageMB(data.memory_usage_mb)}
+                {data.memory_limit_mb != null &&
+                  ` / ${formatMemoryUsageMB(data.memory_limit_mb)} (${formatMemoryUsage(data.memory_percent)})`}
+              </span>
+            </div>
+            <div className={styles.metricItem}>
+              <span className={styles.metricLabel}>Диск (чтение / запись):</span>
+              <span className={styles.metricValue}>
+                {formatBytes(data.block_read_bytes ?? 0)} / {formatBytes(data.block_write_bytes ?? 0)}
               </span>
             </div>
+            <div className={styles.metricItem}>
+              <span className={styles.metricLabel}>Процессы:</span>
+              <span className={styles.metricValue}>{data.pids ?? 'N/A'}</span>
+            </div>
           </div>
         </div>
       </div>
@@ -317,7 +459,9 @@ export default function ContainerDetail() {
                 <span className={styles.logTimestamp}>
                   {new Date(log.timestamp).toLocaleString('ru-RU')}
                 </span>
-                <span className={styles.logContent}>{log.log_line}</span>
+                <span className={`${styles.logContent} ${log.stream === 'stderr' ? 'text-red-600' : ''}`}>
+                  {log.log_line}
+                </span>
               </div>
             ))
         ) : (
@@ -327,6 +471,36 @@ export default function ContainerDetail() {
     </div>
   )
 
+  const renderEventsTab = () => (
+    <div className={styles.logsContent}>
+      <div className={styles.logsContainer}>
+        {events.length > 0 ? (
+          events.map((event) => (
+            <div key={event.id} className={styles.logLine}>
+              <span className={styles.logTimestamp}>
+                {new Date(event.time).toLocaleString('ru-RU')}
+              </span>
+              <span
+                className={`${styles.logContent} ${
+                  event.action === 'oom' || (event.action === 'die' && event.exit_code) || event.status === 'unhealthy'
+                    ? 'text-red-600'
+                    : ''
+                }`}
+              >
+                {formatContainerEvent(event)}
+              </span>
+            </div>
+          ))
+        ) : (
+          <div className={styles.noLogs}>Нет событий для отображения</div>
+        )}
+        {eventsTotal > events.length && (
+          <div className={styles.noLogs}>Показаны последние {events.length} из {eventsTotal}</div>
+        )}
+      </div>
+    </div>
+  )
+
   return (
     <div className={styles.container}>
       {/* Header */}
@@ -375,6 +549,7 @@ export default function ContainerDetail() {
         {activeTab === 'details' && renderDetailsTab()}
         {activeTab === 'resources' && renderResourcesTab()}
         {activeTab === 'logs' && renderLogsTab()}
+        {activeTab === 'events' && renderEventsTab()}
       </div>
     </div>
   )
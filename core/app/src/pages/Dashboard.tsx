@@ -122,13 +122,23 @@ export default function Dashboard() {
     memory: point.memory
   }))
 
+  const diskData = (data.resource_usage || []).map(point => ({
+    time: new Date(point.timestamp).toLocaleTimeString('ru-RU', { 
+      hour: '2-digit', 
+      minute: '2-digit' 
+    }),
+    read: point.disk_read_speed / (1024 * 1024), // Конвертируем в MB/s
+    write: point.disk_write_speed / (1024 * 1024),
+    iops: Math.round(point.disk_iops)
+  }))
+
   const networkData = (data.network_activity || []).map(point => ({
     time: new Date(point.timestamp).toLocaleTimeString('ru-RU', { 
       hour: '2-digit', 
       minute: '2-digit' 
     }),
-    sent: point.sent / (1024 * 1024), // Конвертируем в MB
-    received: point.received / (1024 * 1024)
+    sent: point.sent_speed / (1024 * 1024), // Конвертируем в MB/s
+    received: point.received_speed / (1024 * 1024)
   }))
 
   // Данные для круговой диаграммы контейнеров
@@ -215,20 +225,62 @@ export default function Dashboard() {
                 <CartesianGrid strokeDasharray="3 3" />
                 <XAxis dataKey="time" />
                 <YAxis />
-                <Tooltip formatter={(value: number) => [`${value.toFixed(2)} MB`, '']} />
+                <Tooltip formatter={(value: number) => [`${value.toFixed(2)} MB/s`, '']} />
                 <Line 
                   type="monotone" 
                   dataKey="sent" 
                   stroke="#f59e0b" 
                   strokeWidth={2}
-                  name="Отправлено (MB)"
+                  name="Отправлено (MB/s)"
                 />
                 <Line 
                   type="monotone" 
                   dataKey="received" 
                   stroke="#8b5cf6" 
                   strokeWidth={2}
-                  name="Получено (MB)"
+                  name="Получено (MB/s)"
+                />
+              </LineChart>
+            </ResponsiveContainer>
+          ) : (
+            <div className={styles.noData}>Нет данных для отображения</div>
+          )}
+        </div>
+
+        {/* Дисковая активность */}
+        <div className={styles.chartCard}>
+          <h3>Дисковая активность</h3>
+          {diskData.length > 0 ? (
+            <ResponsiveContainer width="100%" height={300}>
+              <LineChart data={diskData}>
+                <CartesianGrid strokeDasharray="3 3" />
+                <XAxis dataKey="time" />
+                <YAxis yAxisId="bytes" />
+                <YAxis yAxisId="iops" orientation="right" />
+                <Tooltip />
+                <Line 
+                  yAxisId="bytes"
+                  type="monotone" 
+                  dataKey="read" 
+                  stroke="#3b82f6" 
+                  strokeWidth={2}
+                  name="Чтение (MB/s)"
+                />
+                <Line 
+                  yAxisId="bytes"
+                  type="monotone" 
+                  dataKey="write" 
+                  stroke="#ef4444" 
+                  strokeWidth={2}
+                  name="Запись (MB/s)"
+                />
+                <Line 
+                  yAxisId="iops"
+                  type="monotone" 
+                  dataKey="iops" 
+                  stroke="#6b7280" 
+                  strokeWidth={2}
+                  name="IOPS"
                 />
               </LineChart>
             </ResponsiveContainer>
@@ -1,5 +1,6 @@
-import { useState } from 'react'
-import { useAuth } from '../contexts/AuthContext'
+import { useEffect, useState } from 'react'
+import { useAuth, LoginChallenge, SecondFactorResult } from '../contexts/AuthContext'
+import { oidcApi, mfaApi, TOTPEnrollment } from '../services/api'
 import { Activity, AlertCircle } from 'lucide-react'
 import styles from './Login.module.css'
 
@@ -8,7 +9,43 @@ export default function Login() {
   const [password, setPassword] = useState('')
   const [error, setError] = useState('')
   const [loading, setLoading] = useState(false)
-  const { login } = useAuth()
+  const [oidcEnabled, setOidcEnabled] = useState(false)
+  // Второй шаг входа: код 2FA или ее обязательное подключение
+  const [challenge, setChallenge] = useState<LoginChallenge | null>(null)
+  const [enrollment, setEnrollment] = useState<TOTPEnrollment | null>(null)
+  const [code, setCode] = useState('')
+  const [secondFactor, setSecondFactor] = useState<SecondFactorResult | null>(null)
+  const { login, verifySecondFactor, completeLogin } = useAuth()
+
+  useEffect(() => {
+    oidcApi.status()
+      .then((response) => setOidcEnabled(response.data.enabled))
+      .catch(() => setOidcEnabled(false))
+
+    // Результат входа через OIDC приходит во фрагменте URL
+    const params = new URLSearchParams(window.location.hash.slice(1))
+    window.history.replaceState(null, '', window.location.pathname)
+    if (params.get('error')) {
+      setError(params.get('error') as string)
+    } else if (params.get('challenge_token') && params.get('challenge_type')) {
+      // Провайдер подтвердил вход, но нужен второй фактор
+      const nextChallenge = {
+        challengeToken: params.get('challenge_token') as string,
+        challengeType: params.get('challenge_type'),
+      } as LoginChallenge
+      setChallenge(nextChallenge)
+      if (nextChallenge.challengeType === 'enroll') {
+        mfaApi.loginEnroll(nextChallenge.challengeToken)
+          .then((response) => setEnrollment(response.data))
+          .catch(() => setError('Ошибка авторизации'))
+      }
+    } else if (params.get('token') && params.get('refresh_token')) {
+      setLoading(true)
+      completeLogin(params.get('token') as string, params.get('refresh_token') as string)
+        .catch(() => setError('Ошибка авторизации'))
+        .finally(() => setLoading(false))
+    }
+  }, [])
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault()
@@ -16,8 +53,31 @@ export default function Login() {
     setLoading(true)
 
     try {
-      await login(username, password)
+      if (challenge) {
+        const result = await verifySecondFactor(challenge, code)
+        if (result.recoveryCodes.length > 0) {
+          // Показываем коды восстановления до входа в систему
+          setSecondFactor(result)
+        } else {
+          result.finish()
+        }
+        return
+      }
+
+      const nextChallenge = await login(username, password)
+      if (nextChallenge) {
+        setChallenge(nextChallenge)
+        if (nextChallenge.challengeType === 'enroll') {
+          const response = await mfaApi.loginEnroll(nextChallenge.challengeToken)
+          setEnrollment(response.data)
+        }
+      }
     } catch (error: any) {
+      if (error.response?.status === 429) {
+        const retryAfter = error.response.headers?.['retry-after']
+        setError(`Слишком много неудачных попыток. Повторите через ${retryAfter || 'несколько'} с`)
+        return
+      }
       setError(
         error.response?.data?.message || 
         error.message || 
@@ -49,45 +109,91 @@ export default function Login() {
             </div>
           )}
 
-          <div className={styles.field}>
-            <label htmlFor="username" className={styles.label}>
-              Имя пользователя
-            </label>
-            <input
-              id="username"
-              name="username"
-              type="text"
-              required
-              value={username}
-              onChange={(e) => setUsername(e.target.value)}
-              className={styles.input}
-              placeholder="admin"
-            />
-          </div>
+          {secondFactor ? (
+            <div className={styles.field}>
+              <p className={styles.label}>
+                Сохраните коды восстановления: они понадобятся при утере телефона и больше не будут показаны
+              </p>
+              <pre>{secondFactor.recoveryCodes.join('\n')}</pre>
+            </div>
+          ) : challenge ? (
+            <div className={styles.field}>
+              {enrollment && (
+                <p className={styles.label}>
+                  Для вашей роли обязательна двухфакторная аутентификация. Добавьте ключ в приложение-аутентификатор:{' '}
+                  <code>{enrollment.secret}</code>
+                </p>
+              )}
+              <label htmlFor="code" className={styles.label}>
+                Код из приложения{enrollment ? '' : ' или код восстановления'}
+              </label>
+              <input
+                id="code"
+                name="code"
+                type="text"
+                autoComplete="one-time-code"
+                required
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+                className={styles.input}
+                placeholder="123456"
+              />
+            </div>
+          ) : (
+            <>
+              <div className={styles.field}>
+                <label htmlFor="username" className={styles.label}>
+                  Имя пользователя
+                </label>
+                <input
+                  id="username"
+                  name="username"
+                  type="text"
+                  required
+                  value={username}
+                  onChange={(e) => setUsername(e.target.value)}
+                  className={styles.input}
+                  placeholder="admin"
+                />
+              </div>
+
+              <div className={styles.field}>
+                <label htmlFor="password" className={styles.label}>
+                  Пароль
+                </label>
+                <input
+                  id="password"
+                  name="password"
+                  type="password"
+                  required
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  className={styles.input}
+                  placeholder="admin"
+                />
+              </div>
+            </>
+          )}
 
-          <div className={styles.field}>
-            <label htmlFor="password" className={styles.label}>
-              Пароль
-            </label>
-            <input
-              id="password"
-              name="password"
-              type="password"
-              required
-              value={password}
-              onChange={(e) => setPassword(e.target.value)}
-              className={styles.input}
-              placeholder="admin"
-            />
-          </div>
+          {secondFactor ? (
+            <button type="button" onClick={secondFactor.finish} className={styles.button}>
+              Продолжить
+            </button>
+          ) : (
+            <button
+              type="submit"
+              disabled={loading}
+              className={styles.button}
+            >
+              {loading ? 'Вход...' : challenge ? 'Подтвердить' : 'Войти'}
+            </button>
+          )}
 
-          <button
-            type="submit"
-            disabled={loading}
-            className={styles.button}
-          >
-            {loading ? 'Вход...' : 'Войти'}
-          </button>
+          {oidcEnabled && (
+            <a href={oidcApi.loginUrl} className={styles.button}>
+              Войти через SSO
+            </a>
+          )}
 
           <p className={styles.hint}>
             По умолчанию: admin / admin
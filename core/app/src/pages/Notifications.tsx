@@ -1,5 +1,5 @@
 import { useState, useEffect } from 'react'
-import { Bell, Bot, AlertTriangle, Cpu, HardDrive, Server, Container, Mail } from 'lucide-react'
+import { Bell, Bot, AlertTriangle, Cpu, HardDrive, Database, Server, Container, Mail } from 'lucide-react'
 import { notificationsApi, type NotificationSettings } from '../services/api'
 
 export default function Notifications() {
@@ -25,7 +25,7 @@ export default function Notifications() {
       },
       container_stopped: {
         enabled: false,
-        message: '⚠️ Контейнер {CONTAINER_NAME} остановился на агенте {AGENT_NAME}'
+        message: '⚠️ Контейнер {CONTAINER_NAME} остановился на агенте {AGENT_NAME}: {REASON}'
       },
       cpu_threshold: {
         enabled: false,
@@ -36,6 +36,11 @@ export default function Notifications() {
         enabled: false,
         threshold: 80,
         message: '💾 Высокое использование RAM: {AGENT_NAME} - {RAM_USAGE}%'
+      },
+      disk_threshold: {
+        enabled: false,
+        threshold: 90,
+        message: '🗄 Заканчивается место: {AGENT_NAME} {MOUNTPOINT} - {DISK_USAGE}%, inode {INODE_USAGE}%'
       }
     }
   })
@@ -493,7 +498,7 @@ export default function Notifications() {
               placeholder="Введите текст уведомления"
             />
             <p style={{ fontSize: '0.75rem', color: '#6b7280', marginTop: '0.5rem' }}>
-              Доступные переменные: {'{CONTAINER_NAME}'}, {'{AGENT_NAME}'}
+              Доступные переменные: {'{CONTAINER_NAME}'}, {'{AGENT_NAME}'}, {'{EXIT_CODE}'}, {'{REASON}'} (штатная остановка, аварийное завершение или OOM)
             </p>
           </div>
         </div>
@@ -615,6 +620,65 @@ export default function Notifications() {
             </p>
           </div>
         </div>
+
+        {/* Disk Threshold */}
+        <div style={{ marginBottom: '2rem', padding: '1rem', border: '1px solid #e5e7eb', borderRadius: '0.375rem' }}>
+          <div style={{ display: 'flex', alignItems: 'center', gap: '0.5rem', marginBottom: '1rem' }}>
+            <Database size={16} />
+            <h3 style={{ margin: 0, fontWeight: '600' }}>Диск &gt; K %</h3>
+            <label style={{ marginLeft: 'auto', display: 'flex', alignItems: 'center', gap: '0.5rem' }}>
+              <input
+                type="checkbox"
+                checked={settings.notifications?.disk_threshold?.enabled ?? false}
+                onChange={(e) => updateNotification('disk_threshold', 'enabled', e.target.checked)}
+              />
+              Включено
+            </label>
+          </div>
+          <div style={{ display: 'grid', gridTemplateColumns: '1fr 2fr', gap: '1rem', marginBottom: '1rem' }}>
+            <div>
+              <label style={{ display: 'block', marginBottom: '0.5rem', fontWeight: '500' }}>
+                Порог заполнения места или inode (%):
+              </label>
+              <input
+                type="number"
+                value={settings.notifications?.disk_threshold?.threshold ?? 90}
+                onChange={(e) => updateNotification('disk_threshold', 'threshold', parseInt(e.target.value) || 0)}
+                min="1"
+                max="100"
+                style={{
+                  width: '100%',
+                  padding: '0.75rem',
+                  border: '1px solid #d1d5db',
+                  borderRadius: '0.375rem',
+                  fontSize: '0.875rem'
+                }}
+              />
+            </div>
+          </div>
+          <div>
+            <label style={{ display: 'block', marginBottom: '0.5rem', fontWeight: '500' }}>
+              Текст уведомления:
+            </label>
+            <textarea
+              value={settings.notifications?.disk_threshold?.message || ''}
+              onChange={(e) => updateNotification('disk_threshold', 'message', e.target.value)}
+              style={{
+                width: '100%',
+                padding: '0.75rem',
+                border: '1px solid #d1d5db',
+                borderRadius: '0.375rem',
+                fontSize: '0.875rem',
+                minHeight: '80px',
+                resize: 'vertical'
+              }}
+              placeholder="Введите текст уведомления"
+            />
+            <p style={{ fontSize: '0.75rem', color: '#6b7280', marginTop: '0.5rem' }}>
+              Доступные переменные: {'{AGENT_NAME}'}, {'{MOUNTPOINT}'}, {'{DISK_USAGE}'}, {'{INODE_USAGE}'}
+            </p>
+          </div>
+        </div>
       </div>
 
       {/* Save Button */}
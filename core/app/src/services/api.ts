@@ -14,13 +14,47 @@ api.interceptors.request.use((config) => {
   return config
 })
 
+// Один запрос обновления токенов на все одновременно получившие 401 запросы
+let refreshPromise: Promise<string> | null = null
+
+const refreshAccessToken = async (): Promise<string> => {
+  const refreshToken = localStorage.getItem('refresh_token')
+  if (!refreshToken) {
+    throw new Error('No refresh token')
+  }
+  const response = await axios.post('/api/refresh', { refresh_token: refreshToken })
+  const { token, refresh_token: newRefreshToken } = response.data
+  localStorage.setItem('token', token)
+  localStorage.setItem('refresh_token', newRefreshToken)
+  api.defaults.headers.common['Authorization'] = `Bearer ${token}`
+  return token
+}
+
 // Обрабатываем ответы и ошибки
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
+  async (error) => {
+    const original = error.config
+    // Ошибки входа (неверный пароль или код) обрабатывает страница входа
+    const isLoginRequest = original?.url?.startsWith('/api/login')
+    if (error.response?.status === 401 && original && !original._retry && !isLoginRequest) {
+      // Пробуем обновить истекший JWT по refresh-токену и повторить запрос
+      original._retry = true
+      try {
+        refreshPromise = refreshPromise ?? refreshAccessToken().finally(() => {
+          refreshPromise = null
+        })
+        const token = await refreshPromise
+        original.headers.Authorization = `Bearer ${token}`
+        return api(original)
+      } catch {
+        // Сессия отозвана или истекла — выходим из системы
+      }
+    }
+    if (error.response?.status === 401 && !isLoginRequest) {
       // Автоматически выходим из системы при ошибке аутентификации
       localStorage.removeItem('token')
+      localStorage.removeItem('refresh_token')
       localStorage.removeItem('user')
       window.location.href = '/login'
     }
@@ -34,12 +68,80 @@ api.interceptors.response.use(
 export interface Agent {
   id: string
   name: string
-  token: string
+  // Начало токена; сам токен показывается только при создании и ротации
+  token_prefix: string
   is_active: boolean
+  // false — агент зарегистрирован по токену и ждет подтверждения
+  approved: boolean
+  hostname?: string
+  facts?: AgentFacts
+  // Когда агент последний раз прислал изменившиеся сведения о хосте
+  facts_updated?: string
+  // Задано, пока после ротации принимается и старый токен
+  previous_token_expires?: string
+  // Принимать только подписанные запросы агента
+  require_signature: boolean
+  last_signed_request?: string
   created: string
   last_ping?: string
   public_ip?: string
-  status: 'online' | 'offline' | 'unknown'
+  status: 'online' | 'offline' | 'unknown' | 'pending'
+}
+
+export interface CreateAgentResponse extends Agent {
+  // Показывается один раз
+  token: string
+}
+
+export interface RotateAgentTokenResponse {
+  action_id: string
+  token_prefix: string
+  token: string
+  previous_token_expires: string
+}
+
+export interface AgentFacts {
+  hostname: string
+  os: string
+  platform: string
+  platform_version: string
+  kernel_version: string
+  architecture: string
+  cpu_model: string
+  cpu_cores: number
+  docker_version: string
+  agent_version: string
+}
+
+// Agent enrollment types
+export interface AgentEnrollmentToken {
+  id: string
+  name: string
+  prefix: string
+  auto_approve: boolean
+  expires: string
+  used?: string
+  agent_id?: string
+  revoked?: string
+  created_by?: string
+  created: string
+}
+
+export interface CreateEnrollmentTokenRequest {
+  name: string
+  // 0 — 24 часа, максимум 720
+  expires_in_hours?: number
+  auto_approve?: boolean
+}
+
+export interface CreateEnrollmentTokenResponse extends AgentEnrollmentToken {
+  // Показывается один раз
+  token: string
+}
+
+export interface EnrollmentTokenListResponse {
+  tokens: AgentEnrollmentToken[]
+  total: number
 }
 
 export interface Container {
@@ -57,10 +159,46 @@ export interface Container {
   memory_usage_mb?: number
   network_sent_bytes?: number
   network_received_bytes?: number
+  memory_limit_mb?: number
+  // Процент от лимита памяти
+  memory_percent?: number
+  block_read_bytes?: number
+  block_write_bytes?: number
+  pids?: number
+  // Состояние процесса; null у контейнеров, присланных старыми агентами
+  state?: 'created' | 'running' | 'paused' | 'restarting' | 'exited' | 'dead' | null
+  // null — у контейнера нет HEALTHCHECK
+  health_status?: 'starting' | 'healthy' | 'unhealthy' | null
+  health_output?: string | null
+  health_failing_streak?: number | null
+  exit_code?: number | null
+  oom_killed?: boolean | null
+  started_at?: string | null
+  finished_at?: string | null
+  restart_policy?: string | null
+  restart_max_retries?: number | null
+  ports?: ContainerPort[] | null
+  mounts?: ContainerMount[] | null
+  labels?: Record<string, string> | null
   agent_id?: string
   agent_name?: string
 }
 
+export interface ContainerPort {
+  ip: string
+  private_port: number
+  // 0 — порт не опубликован
+  public_port: number
+  type: string
+}
+
+export interface ContainerMount {
+  type: string
+  source: string
+  destination: string
+  rw: boolean
+}
+
 export interface ContainerDetail extends Container {
   agent: Agent
   logs: ContainerLog[]
@@ -71,7 +209,37 @@ export interface ContainerLog {
   id: string
   container_id: string
   log_line: string
-  timestamp: string
+  stream: 'stdout' | 'stderr' | null // null у строк от старых агентов
+  timestamp: string // время строки в Docker
+}
+
+// События Docker между пингами
+export interface ContainerEvent {
+  id: string
+  agent_id: string
+  type: 'container' | 'image'
+  action: string // die, oom, kill, restart, health_status, pull, destroy
+  actor_id: string // ID контейнера в Docker или ссылка на образ
+  actor_name: string | null
+  image: string | null
+  status: string | null // результат health_status
+  exit_code: number | null
+  signal: string | null
+  time: string
+  created: string
+}
+
+export interface ContainerEventListResponse {
+  events: ContainerEvent[]
+  total: number
+}
+
+export interface ContainerEventParams {
+  action?: string
+  from?: string
+  to?: string
+  limit?: number
+  offset?: number
 }
 
 export interface ContainerMetric {
@@ -135,12 +303,19 @@ export interface ResourceUsagePoint {
   timestamp: string
   cpu: number
   memory: number
+  disk_read_speed: number
+  disk_write_speed: number
+  disk_iops: number
 }
 
 export interface NetworkActivityPoint {
   timestamp: string
   sent: number
   received: number
+  sent_speed: number
+  received_speed: number
+  packets_sent_speed: number
+  packets_received_speed: number
 }
 
 export interface TopContainer {
@@ -206,7 +381,45 @@ export interface AgentMetrics {
   cpu: CPUMetricCurrent[]
   memory: MemoryMetricCurrent
   disk: DiskMetricCurrent[]
+  filesystems: FilesystemMetricCurrent[] | null
   network: NetworkMetricCurrent
+  // null, если агент не присылает нагрузку хоста
+  host: HostMetricCurrent | null
+  // null, если агент не присылает сведения о сборе данных
+  collection: CollectionMetricCurrent | null
+}
+
+// Последний сбор данных агентом
+export interface CollectionMetricCurrent {
+  duration_ms: number
+  docker_duration_ms: number
+  workers: number
+  failures: CollectionFailure[]
+}
+
+export interface CollectionFailure {
+  container_id: string
+  name: string
+  call: 'inspect' | 'logs' | 'stats'
+  error: string
+}
+
+export interface HostMetricCurrent {
+  load1: number
+  load5: number
+  load15: number
+  // Секунды
+  uptime: number
+  boot_time: string
+  processes: number
+  threads: number
+  // Скорость и доли CPU с прошлого пинга; null для первого пинга и после перезагрузки
+  context_switches_speed: number | null
+  cpu_user_percent: number | null
+  cpu_system_percent: number | null
+  cpu_iowait_percent: number | null
+  cpu_steal_percent: number | null
+  cpu_idle_percent: number | null
 }
 
 export interface CPUMetricCurrent {
@@ -229,6 +442,22 @@ export interface DiskMetricCurrent {
   write_bytes: number
   read_speed: number
   write_speed: number
+  read_iops: number
+  write_iops: number
+}
+
+export interface FilesystemMetricCurrent {
+  mountpoint: string
+  device: string
+  fstype: string
+  total_bytes: number
+  used_bytes: number
+  free_bytes: number
+  used_percent: number
+  inodes_total: number
+  inodes_used: number
+  inodes_free: number
+  inodes_used_percent: number
 }
 
 export interface NetworkMetricCurrent {
@@ -237,6 +466,25 @@ export interface NetworkMetricCurrent {
   received_bytes: number
   sent_speed: number
   received_speed: number
+  packets_sent_speed: number
+  packets_received_speed: number
+  interfaces: NetworkInterfaceMetricCurrent[] | null
+}
+
+export interface NetworkInterfaceMetricCurrent {
+  name: string
+  sent_bytes: number
+  received_bytes: number
+  packets_sent: number
+  packets_received: number
+  errors_in: number
+  errors_out: number
+  drops_in: number
+  drops_out: number
+  sent_speed: number
+  received_speed: number
+  packets_sent_speed: number
+  packets_received_speed: number
 }
 
 export interface SystemMetric {
@@ -247,13 +495,37 @@ export interface SystemMetric {
   disk_write: number
   network_sent: number
   network_received: number
+  // Скорости с прошлого пинга; null для первого пинга и после сброса счетчиков
+  disk_read_speed: number | null
+  disk_write_speed: number | null
+  disk_iops: number | null
+  network_sent_speed: number | null
+  network_received_speed: number | null
+  network_packets_sent_speed: number | null
+  network_packets_received_speed: number | null
+  // Нагрузка хоста; null, если агент ее не присылает
+  load1: number | null
+  cpu_iowait_percent: number | null
+  cpu_steal_percent: number | null
+}
+
+// Отбор агентов по сведениям о хосте (точное совпадение)
+export interface AgentFactsFilter {
+  os?: string
+  platform?: string
+  platform_version?: string
+  kernel_version?: string
+  architecture?: string
+  cpu_model?: string
+  docker_version?: string
+  agent_version?: string
 }
 
 // API методы
 export const agentsApi = {
-  getAll: () => api.get<Agent[]>('/api/agents'),
-  create: (name: string) => api.post<Agent>('/api/agents', { name }),
-  update: (id: string, data: { name?: string; is_active?: boolean }) => 
+  getAll: (filter?: AgentFactsFilter) => api.get<Agent[]>('/api/agents', { params: filter }),
+  create: (name: string) => api.post<CreateAgentResponse>('/api/agents', { name }),
+  update: (id: string, data: { name?: string; is_active?: boolean; require_signature?: boolean }) => 
     api.put(`/api/agents/${id}`, data),
   delete: (id: string) => api.delete(`/api/agents/${id}`),
   getDetail: (id: string) => api.get<AgentDetail>(`/api/agents/${id}`),
@@ -265,6 +537,19 @@ export const agentsApi = {
     api.get<Container[]>(`/api/agents/${id}/containers`),
   getImages: (id: string) => 
     api.get<Image[]>(`/api/agents/${id}/images`),
+  getEvents: (id: string, params?: ContainerEventParams & { container_id?: string; type?: string }) =>
+    api.get<ContainerEventListResponse>(`/api/agents/${id}/events`, { params }),
+  approve: (id: string) => api.post(`/api/agents/${id}/approve`),
+  // 0 — переходный период 24 часа, максимум 168
+  rotateToken: (id: string, gracePeriodHours?: number) =>
+    api.post<RotateAgentTokenResponse>(`/api/agents/${id}/rotate-token`, { grace_period_hours: gracePeriodHours ?? 0 }),
+}
+
+export const enrollmentApi = {
+  listTokens: () => api.get<EnrollmentTokenListResponse>('/api/agents/enrollment-tokens'),
+  createToken: (data: CreateEnrollmentTokenRequest) =>
+    api.post<CreateEnrollmentTokenResponse>('/api/agents/enrollment-tokens', data),
+  revokeToken: (id: string) => api.delete(`/api/agents/enrollment-tokens/${id}`),
 }
 
 export const dashboardApi = {
@@ -281,6 +566,30 @@ export const containersApi = {
   },
   getDetail: (id: string) => api.get<ContainerDetail>(`/api/containers/${id}`),
   getLogs: (id: string) => api.get<ContainerLog[]>(`/api/containers/${id}/logs`),
+  getEvents: (id: string, params?: ContainerEventParams) =>
+    api.get<ContainerEventListResponse>(`/api/containers/${id}/events`, { params }),
+}
+
+// Описание события Docker для хронологии
+export const formatContainerEvent = (event: ContainerEvent): string => {
+  switch (event.action) {
+    case 'die':
+      return `Процесс завершился${event.exit_code !== null ? ` с кодом ${event.exit_code}` : ''}`
+    case 'oom':
+      return 'Нехватка памяти (OOM)'
+    case 'kill':
+      return `Отправлен сигнал${event.signal ? ` ${event.signal}` : ''}`
+    case 'restart':
+      return 'Перезапуск'
+    case 'health_status':
+      return `Проверка здоровья: ${event.status || 'неизвестно'}`
+    case 'pull':
+      return `Загружен образ ${event.actor_name || event.actor_id}`
+    case 'destroy':
+      return 'Контейнер удален'
+    default:
+      return event.action
+  }
 }
 
 export const imagesApi = {
@@ -320,6 +629,15 @@ export const formatMemoryUsageMB = (usage?: number): string => {
   return `${Math.round(usage)} MB`
 }
 
+export const formatUptime = (seconds: number): string => {
+  const days = Math.floor(seconds / 86400)
+  const hours = Math.floor((seconds % 86400) / 3600)
+  const minutes = Math.floor((seconds % 3600) / 60)
+  if (days > 0) return `${days} д ${hours} ч`
+  if (hours > 0) return `${hours} ч ${minutes} мин`
+  return `${minutes} мин`
+}
+
 export const getContainerStatusColor = (status: string): string => {
   switch (status.toLowerCase()) {
     case 'running':
@@ -352,11 +670,19 @@ export interface Action {
   agent_id: string
   type: string
   payload: Record<string, any>
-  status: 'pending' | 'completed' | 'failed'
+  status: 'pending' | 'awaiting_approval' | 'rejected' | 'completed' | 'failed'
   created: string
   completed?: string
-  response?: string
+  response?: ActionResult
   error?: string
+  created_by?: string
+}
+
+// Структурированный результат действия; набор полей зависит от типа действия
+export interface ActionResult {
+  message?: string
+  container_id?: string
+  [key: string]: any
 }
 
 export interface CreateActionRequest {
@@ -373,10 +699,422 @@ export interface ActionListResponse {
 // API functions for actions
 export const actionsApi = {
   create: (data: CreateActionRequest) => api.post<Action>('/api/actions', data),
-  list: (params?: { agent_id?: string; status?: string }) => 
+  list: (params?: { agent_id?: string; status?: string; created_by?: string }) => 
     api.get<ActionListResponse>('/api/actions', { params }),
 }
 
+// Approval types
+export interface ApprovalPolicy {
+  id: string
+  action_type: string
+  agent_id?: string
+  agent_name?: string
+  force_only: boolean
+  description?: string
+  created: string
+}
+
+export interface ActionApproval {
+  id: string
+  action_id: string
+  policy_id?: string
+  requested_by?: string
+  requested_by_username?: string
+  decision?: 'approved' | 'rejected'
+  decided_by?: string
+  decided_by_username?: string
+  reason?: string
+  created: string
+  decided?: string
+  action: Action
+  agent_name?: string
+}
+
+// API functions for approvals
+export const approvalsApi = {
+  list: (params?: { status?: 'pending' | 'all' }) =>
+    api.get<{ approvals: ActionApproval[]; total: number }>('/api/approvals', { params }),
+  approve: (actionId: string, reason: string) =>
+    api.post(`/api/actions/${actionId}/approve`, { reason }),
+  reject: (actionId: string, reason: string) =>
+    api.post(`/api/actions/${actionId}/reject`, { reason }),
+  listPolicies: () =>
+    api.get<{ policies: ApprovalPolicy[]; total: number }>('/api/approval-policies'),
+  createPolicy: (data: { action_type: string; agent_id?: string; force_only: boolean; description?: string }) =>
+    api.post<ApprovalPolicy>('/api/approval-policies', data),
+  deletePolicy: (id: string) => api.delete(`/api/approval-policies/${id}`),
+}
+
+// Schedule types
+export interface ActionSchedule {
+  id: string
+  agent_id: string
+  agent_name?: string
+  name: string
+  type: string
+  payload: Record<string, any>
+  cron_expr?: string
+  run_at?: string
+  is_paused: boolean
+  next_run?: string
+  last_run?: string
+  last_action_id?: string
+  created: string
+  updated: string
+}
+
+export interface CreateScheduleRequest {
+  agent_id: string
+  name: string
+  type: string
+  payload: Record<string, any>
+  cron_expr?: string
+  run_at?: string
+}
+
+export interface ScheduleListResponse {
+  schedules: ActionSchedule[]
+  total: number
+}
+
+// API functions for schedules
+export const schedulesApi = {
+  list: (params?: { agent_id?: string }) =>
+    api.get<ScheduleListResponse>('/api/schedules', { params }),
+  create: (data: CreateScheduleRequest) => api.post<ActionSchedule>('/api/schedules', data),
+  update: (id: string, data: Partial<CreateScheduleRequest> & { is_paused?: boolean }) =>
+    api.put<ActionSchedule>(`/api/schedules/${id}`, data),
+  delete: (id: string) => api.delete(`/api/schedules/${id}`),
+  pause: (id: string) => api.post<ActionSchedule>(`/api/schedules/${id}/pause`),
+  resume: (id: string) => api.post<ActionSchedule>(`/api/schedules/${id}/resume`),
+}
+
+// Workflow types
+export interface WorkflowCompensation {
+  type: string
+  payload: Record<string, any>
+}
+
+export interface WorkflowStep {
+  id?: string
+  name: string
+  type: string
+  payload: Record<string, any>
+  depends_on?: string[]
+  compensation?: WorkflowCompensation
+  position?: number
+}
+
+export interface Workflow {
+  id: string
+  name: string
+  description?: string
+  variables: Record<string, string>
+  created: string
+  updated: string
+  steps: WorkflowStep[]
+}
+
+export interface CreateWorkflowRequest {
+  name: string
+  description?: string
+  variables?: Record<string, string>
+  steps: WorkflowStep[]
+}
+
+export interface WorkflowRunStep {
+  id: string
+  run_id: string
+  name: string
+  type: string
+  payload: Record<string, any>
+  depends_on: string[]
+  is_compensation: boolean
+  status: 'waiting' | 'running' | 'completed' | 'failed' | 'skipped'
+  action_id?: string
+  response?: ActionResult
+  error?: string
+  started?: string
+  completed?: string
+}
+
+export interface WorkflowRun {
+  id: string
+  workflow_id: string
+  workflow_name: string
+  agent_id: string
+  status: 'running' | 'compensating' | 'completed' | 'failed'
+  variables: Record<string, string>
+  error?: string
+  started_by?: string
+  created: string
+  completed?: string
+  steps?: WorkflowRunStep[]
+}
+
+export interface WorkflowListResponse {
+  workflows: Workflow[]
+  total: number
+}
+
+export interface WorkflowRunListResponse {
+  runs: WorkflowRun[]
+  total: number
+}
+
+// API functions for workflows
+export const workflowsApi = {
+  list: () => api.get<WorkflowListResponse>('/api/workflows'),
+  get: (id: string) => api.get<Workflow>(`/api/workflows/${id}`),
+  create: (data: CreateWorkflowRequest) => api.post<Workflow>('/api/workflows', data),
+  update: (id: string, data: Partial<CreateWorkflowRequest>) =>
+    api.put<Workflow>(`/api/workflows/${id}`, data),
+  delete: (id: string) => api.delete(`/api/workflows/${id}`),
+  start: (id: string, data: { agent_id: string; variables?: Record<string, string> }) =>
+    api.post<WorkflowRun>(`/api/workflows/${id}/runs`, data),
+  listRuns: (id: string) => api.get<WorkflowRunListResponse>(`/api/workflows/${id}/runs`),
+  getRun: (runId: string) => api.get<WorkflowRun>(`/api/workflows/runs/${runId}`),
+}
+
+// Audit types
+export interface AuditChange {
+  before: any
+  after: any
+}
+
+export interface AuditEntry {
+  id: string
+  user_id?: string
+  username?: string
+  ip?: string
+  method: string
+  path: string
+  entity_type: string
+  entity_id?: string
+  summary: string
+  before?: Record<string, any>
+  after?: Record<string, any>
+  changes: Record<string, AuditChange>
+  created: string
+}
+
+export interface AuditListResponse {
+  entries: AuditEntry[]
+  total: number
+}
+
+// API functions for audit log
+export const auditApi = {
+  list: (params?: {
+    user_id?: string
+    entity_type?: string
+    entity_id?: string
+    from?: string
+    to?: string
+    limit?: number
+    offset?: number
+  }) => api.get<AuditListResponse>('/api/audit', { params }),
+}
+
+// Auth event types
+export type AuthEventType =
+  | 'login_success'
+  | 'login_failure'
+  | 'mfa_failure'
+  | 'lockout'
+  | 'login_blocked'
+  | 'unlock'
+  | 'logout'
+
+export interface AuthEvent {
+  id: string
+  user_id?: string
+  username?: string
+  ip?: string
+  user_agent?: string
+  event: AuthEventType
+  detail?: string
+  created: string
+}
+
+export interface AuthEventListResponse {
+  events: AuthEvent[]
+  total: number
+}
+
+export interface AuthEventParams {
+  user_id?: string
+  username?: string
+  ip?: string
+  event?: AuthEventType
+  from?: string
+  to?: string
+  limit?: number
+  offset?: number
+}
+
+// API functions for login events
+export const authEventsApi = {
+  list: (params?: AuthEventParams) => api.get<AuthEventListResponse>('/api/auth-events', { params }),
+  listMine: (params?: Pick<AuthEventParams, 'from' | 'to' | 'limit' | 'offset'>) =>
+    api.get<AuthEventListResponse>('/api/profile/auth-events', { params }),
+}
+
+// User management types
+export interface UserAccount {
+  id: string
+  username: string
+  email?: string
+  is_active: boolean
+  role: 'admin' | 'approver' | 'operator' | 'viewer'
+  created: string
+  last_login?: string
+  // local — вход по паролю, oidc — пользователь создан при входе через OIDC
+  auth_provider: 'local' | 'oidc'
+  totp_enabled: boolean
+  // Агенты, доступные пользователю; пустой список — все агенты
+  agent_ids: string[]
+}
+
+export interface CreateUserRequest {
+  username: string
+  password: string
+  email?: string
+  role?: UserAccount['role']
+  agent_ids?: string[]
+}
+
+export interface UpdateUserRequest {
+  email?: string
+  role?: UserAccount['role']
+  is_active?: boolean
+  agent_ids?: string[]
+}
+
+export interface UserListResponse {
+  users: UserAccount[]
+  total: number
+}
+
+// Personal API token types
+export type Permission = 'view' | 'operate' | 'approve' | 'admin'
+
+export interface APIToken {
+  id: string
+  user_id: string
+  name: string
+  prefix: string
+  // Права токена; пустой список — все права роли владельца
+  scopes: Permission[]
+  expires?: string
+  last_used?: string
+  revoked?: string
+  created: string
+}
+
+export interface CreateAPITokenRequest {
+  name: string
+  scopes?: Permission[]
+  // 0 или не указано — бессрочный токен
+  expires_in_days?: number
+}
+
+export interface CreateAPITokenResponse extends APIToken {
+  // Значение токена показывается только один раз
+  token: string
+}
+
+export interface APITokenListResponse {
+  tokens: APIToken[]
+  total: number
+}
+
+// Session types
+export interface Session {
+  id: string
+  user_id: string
+  ip?: string
+  user_agent?: string
+  created: string
+  last_used: string
+  expires: string
+  revoked?: string
+  // Сессия, от имени которой выполнен запрос
+  current: boolean
+}
+
+export interface SessionListResponse {
+  sessions: Session[]
+  total: number
+}
+
+// API functions for users (admin only)
+export const usersApi = {
+  list: () => api.get<UserListResponse>('/api/users'),
+  get: (id: string) => api.get<UserAccount>(`/api/users/${id}`),
+  create: (data: CreateUserRequest) => api.post<UserAccount>('/api/users', data),
+  update: (id: string, data: UpdateUserRequest) => api.put<UserAccount>(`/api/users/${id}`, data),
+  delete: (id: string) => api.delete(`/api/users/${id}`),
+  setPassword: (id: string, password: string) => api.put(`/api/users/${id}/password`, { password }),
+  // Сброс 2FA, например при утере телефона
+  resetTwoFactor: (id: string) => api.delete(`/api/users/${id}/2fa`),
+  // Снятие временной блокировки входа после неудачных попыток
+  unlock: (id: string) => api.post(`/api/users/${id}/unlock`),
+  listTokens: (id: string) => api.get<APITokenListResponse>(`/api/users/${id}/tokens`),
+  revokeToken: (id: string, tokenId: string) => api.delete(`/api/users/${id}/tokens/${tokenId}`),
+  listSessions: (id: string) => api.get<SessionListResponse>(`/api/users/${id}/sessions`),
+  revokeSessions: (id: string) => api.delete(`/api/users/${id}/sessions`),
+}
+
+// API functions for OpenID Connect login
+export const oidcApi = {
+  status: () => api.get<{ enabled: boolean }>('/api/oidc'),
+  // Вход начинается переходом браузера, а не XHR-запросом
+  loginUrl: '/api/oidc/login',
+}
+
+// Two-factor authentication types
+export interface TOTPEnrollment {
+  secret: string
+  // Для QR-кода в приложении-аутентификаторе
+  otpauth_url: string
+}
+
+export interface MFAStatus {
+  enabled: boolean
+  // 2FA обязательна для роли пользователя
+  required: boolean
+  recovery_codes_left: number
+}
+
+// API functions for two-factor authentication
+export const mfaApi = {
+  // Обязательное подключение 2FA на втором шаге входа
+  loginEnroll: (challengeToken: string) =>
+    api.post<TOTPEnrollment>('/api/login/2fa/enroll', { challenge_token: challengeToken }),
+  status: () => api.get<MFAStatus>('/api/profile/2fa'),
+  enroll: () => api.post<TOTPEnrollment>('/api/profile/2fa/enroll'),
+  activate: (code: string) => api.post<{ recovery_codes: string[] }>('/api/profile/2fa/activate', { code }),
+  disable: (code: string) => api.delete('/api/profile/2fa', { data: { code } }),
+  regenerateRecoveryCodes: (code: string) =>
+    api.post<{ recovery_codes: string[] }>('/api/profile/2fa/recovery-codes', { code }),
+}
+
+// API functions for the current user's profile
+export const profileApi = {
+  get: () => api.get<UserAccount>('/api/profile'),
+  update: (data: { email?: string }) => api.put<UserAccount>('/api/profile', data),
+  changePassword: (currentPassword: string, newPassword: string) =>
+    api.put('/api/profile/password', { current_password: currentPassword, new_password: newPassword }),
+  listTokens: () => api.get<APITokenListResponse>('/api/profile/tokens'),
+  createToken: (data: CreateAPITokenRequest) => api.post<CreateAPITokenResponse>('/api/profile/tokens', data),
+  revokeToken: (id: string) => api.delete(`/api/profile/tokens/${id}`),
+  listSessions: () => api.get<SessionListResponse>('/api/profile/sessions'),
+  revokeSession: (id: string) => api.delete(`/api/profile/sessions/${id}`),
+  // Выход из всех сессий, включая текущую
+  revokeAllSessions: () => api.delete('/api/profile/sessions'),
+}
+
 // Notification types
 export interface EmailSettings {
   enabled: boolean
@@ -414,6 +1152,11 @@ export interface NotificationSettings {
       threshold: number
       message: string
     }
+    disk_threshold: {
+      enabled: boolean
+      threshold: number
+      message: string
+    }
   }
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -118,11 +119,12 @@ func (rp *ReverseProxy) proxyToAgent(w http.ResponseWriter, r *http.Request, age
 		// Устанавливаем дополнительные заголовки
 		req.Header.Set("X-Forwarded-Host", originalHost)
 		req.Header.Set("X-Forwarded-Proto", "http")
-		if r.Header.Get("X-Real-IP") != "" {
-			req.Header.Set("X-Real-IP", r.Header.Get("X-Real-IP"))
-		} else {
-			req.Header.Set("X-Real-IP", r.RemoteAddr)
+		// IP клиента берется из соединения: заголовок от клиента перезаписывается
+		clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIP = r.RemoteAddr
 		}
+		req.Header.Set("X-Real-IP", clientIP)
 	}
 
 	// Настраиваем модификатор ответов
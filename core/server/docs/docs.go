@@ -52,6 +52,12 @@ const docTemplate = `{
                         "description": "Тип действия",
                         "name": "type",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя, создавшего действие",
+                        "name": "created_by",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -70,7 +76,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Создает новое действие для выполнения агентом",
+                "description": "Создает новое действие для выполнения агентом. Если под действие подпадает политика подтверждения, оно создается в статусе awaiting_approval",
                 "consumes": [
                     "application/json"
                 ],
@@ -114,9 +120,137 @@ const docTemplate = `{
                 }
             }
         },
+        "/actions/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переводит действие в статус pending, после чего оно будет выдано агенту. Требуется роль approver или admin; подтвердить собственное действие нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "approvals"
+                ],
+                "summary": "Подтверждение действия",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID действия",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Причина решения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ApprovalDecisionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Действие подтверждено"
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Действие не ожидает подтверждения",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/actions/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переводит действие в статус rejected. Требуется роль approver или admin; отклонить собственное действие нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "approvals"
+                ],
+                "summary": "Отклонение действия",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID действия",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Причина решения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ApprovalDecisionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Действие отклонено"
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Действие не ожидает подтверждения",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/actions/{id}/status": {
             "put": {
-                "description": "Обновляет статус действия и сохраняет ответ от агента",
+                "description": "Обновляет статус действия и сохраняет структурированный ответ агента (JSON-объект)",
                 "consumes": [
                     "application/json"
                 ],
@@ -137,10 +271,33 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Bearer токен агента",
+                        "description": "Bearer токен агента (для неподписанных запросов)",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID агента (для подписанных запросов)",
+                        "name": "X-Agent-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Время запроса, Unix-секунды",
+                        "name": "X-Agent-Timestamp",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Одноразовое значение",
+                        "name": "X-Agent-Nonce",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "v2=\u003chex HMAC-SHA256\u003e",
+                        "name": "X-Agent-Signature",
+                        "in": "header"
                     },
                     {
                         "description": "Ответ агента",
@@ -166,7 +323,7 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Неверный токен агента",
+                        "description": "Неверный токен агента, подпись, устаревший или повторный запрос",
                         "schema": {
                             "type": "string"
                         }
@@ -177,6 +334,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "Действие не ожидает выполнения",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -202,10 +365,33 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer токен агента",
+                        "description": "Bearer токен агента (для неподписанных запросов)",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID агента (для подписанных запросов)",
+                        "name": "X-Agent-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Время запроса, Unix-секунды",
+                        "name": "X-Agent-Timestamp",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Одноразовое значение",
+                        "name": "X-Agent-Nonce",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "v2=\u003chex HMAC-SHA256\u003e",
+                        "name": "X-Agent-Signature",
+                        "in": "header"
                     },
                     {
                         "description": "Данные мониторинга от агента",
@@ -234,7 +420,72 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Неверный токен агента",
+                        "description": "Неверный токен агента, подпись, устаревший или повторный запрос",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Агент ждет подтверждения администратором",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/agent/register": {
+            "post": {
+                "description": "Агент, запущенный с токеном регистрации, сообщает имя хоста и сведения о нем и получает постоянный токен. Токен регистрации действует один раз. Если токен выдан без auto_approve, пинги агента отклоняются (403) до подтверждения администратором.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "agent-data"
+                ],
+                "summary": "Регистрация агента",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer токен регистрации (mse_...)",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Имя и сведения о хосте",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AgentRegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Постоянные учетные данные агента",
+                        "schema": {
+                            "$ref": "#/definitions/models.AgentRegisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Токен регистрации недействителен",
                         "schema": {
                             "type": "string"
                         }
@@ -255,7 +506,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список всех активных агентов",
+                "description": "Возвращает список всех активных агентов. Параметры отбирают агентов по сведениям о хосте (точное совпадение).",
                 "produces": [
                     "application/json"
                 ],
@@ -263,6 +514,56 @@ const docTemplate = `{
                     "agents"
                 ],
                 "summary": "Получить список агентов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ОС (linux)",
+                        "name": "os",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дистрибутив (ubuntu)",
+                        "name": "platform",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия дистрибутива",
+                        "name": "platform_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия ядра",
+                        "name": "kernel_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Архитектура (x86_64)",
+                        "name": "architecture",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Модель CPU",
+                        "name": "cpu_model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия Docker",
+                        "name": "docker_version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия агента",
+                        "name": "agent_version",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Список агентов",
@@ -293,7 +594,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создает нового агента мониторинга и возвращает токен для подключения",
+                "description": "Создает нового агента мониторинга и возвращает токен для подключения. Токен показывается только в ответе на этот запрос.",
                 "consumes": [
                     "application/json"
                 ],
@@ -317,9 +618,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "201": {
-                        "description": "Созданный агент",
+                        "description": "Созданный агент и его токен",
                         "schema": {
-                            "$ref": "#/definitions/models.Agent"
+                            "$ref": "#/definitions/models.CreateAgentResponse"
                         }
                     },
                     "400": {
@@ -343,41 +644,167 @@ const docTemplate = `{
                 }
             }
         },
-        "/agents/{id}": {
+        "/agents/enrollment-tokens": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает полную информацию об агенте включая метрики, контейнеры, образы и т.д.",
+                "description": "Возвращает токены регистрации агентов, включая использованные и отозванные. Сами токены не возвращаются. Доступно только администраторам.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "agents"
                 ],
-                "summary": "Получить детальную информацию об агенте",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID агента",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "Получение токенов регистрации",
                 "responses": {
                     "200": {
-                        "description": "Детальная информация об агенте",
-                        "schema": {
-                            "$ref": "#/definitions/models.AgentDetail"
-                        }
-                    },
-                    "400": {
-                        "description": "Неверный ID",
+                        "description": "Список токенов",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.EnrollmentTokenListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает одноразовый токен, с которым агент регистрируется сам (переменная ENROLLMENT_TOKEN). Токен показывается только в ответе на этот запрос. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "agents"
+                ],
+                "summary": "Создание токена регистрации",
+                "parameters": [
+                    {
+                        "description": "Параметры токена",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateEnrollmentTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Токен создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateEnrollmentTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/agents/enrollment-tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только администраторам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "agents"
+                ],
+                "summary": "Отзыв токена регистрации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID токена",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Токен отозван",
+                        "schema": {
+                            "$ref": "#/definitions/models.AgentEnrollmentToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/agents/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает полную информацию об агенте включая метрики, контейнеры, образы и т.д.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "agents"
+                ],
+                "summary": "Получить детальную информацию об агенте",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID агента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Детальная информация об агенте",
+                        "schema": {
+                            "$ref": "#/definitions/models.AgentDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "401": {
@@ -386,12 +813,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Агент не найден",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
+                    "404": {
+                        "description": "Агент не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -406,7 +833,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет имя и статус активности агента",
+                "description": "Обновляет имя, статус активности агента и обязательность подписи его запросов (require_signature). Перед включением подписи убедитесь, что агент уже подписывает запросы (last_signed_request).",
                 "consumes": [
                     "application/json"
                 ],
@@ -510,6 +937,46 @@ const docTemplate = `{
                 }
             }
         },
+        "/agents/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Разрешает агенту, зарегистрированному по токену без auto_approve, отправлять данные. Чтобы отклонить регистрацию, удалите агента. Доступно только администраторам.",
+                "tags": [
+                    "agents"
+                ],
+                "summary": "Подтверждение агента",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID агента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Агент подтвержден"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Агент не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/agents/{id}/containers": {
             "get": {
                 "security": [
@@ -565,21 +1032,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/agents/{id}/metrics": {
+        "/agents/{id}/events": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает историю метрик агента (CPU, память, сеть)",
+                "description": "Возвращает события Docker между пингами (падения, OOM, перезапуски, смена здоровья, загрузка образов), новые первыми",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "agents"
                 ],
-                "summary": "Получить метрики агента",
+                "summary": "Получить события Docker агента",
                 "parameters": [
                     {
                         "type": "string",
@@ -588,31 +1055,64 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ID контейнера в Docker",
+                        "name": "container_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип события (container, image)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Действие (die, oom, kill, restart, health_status, pull, destroy)",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Лимит записей (по умолчанию 50)",
+                        "description": "Лимит записей (по умолчанию 100, максимум 1000)",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "История метрик агента",
+                        "description": "События Docker",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "$ref": "#/definitions/models.ContainerEventListResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверный ID",
+                        "description": "Неверные параметры",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Не авторизован",
+                    "403": {
+                        "description": "Нет доступа к агенту",
                         "schema": {
                             "type": "string"
                         }
@@ -626,48 +1126,172 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/agents/{agent_id}/nginx-config": {
+        "/agents/{id}/metrics": {
             "get": {
-                "description": "Возвращает конфигурацию nginx для указанного агента",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает историю метрик агента (память, сеть, скорости дисков и сети, нагрузка хоста)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "nginx"
+                    "agents"
                 ],
-                "summary": "Получить конфигурацию nginx агента",
+                "summary": "Получить метрики агента",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "ID агента",
-                        "name": "agent_id",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лимит записей (по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "История метрик агента",
                         "schema": {
-                            "$ref": "#/definitions/models.AgentNginxConfig"
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad Request"
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/api/domains": {
-            "get": {
-                "description": "Возвращает список всех доменов с их маршрутами",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
+        "/agents/{id}/rotate-token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выпускает новый токен и создает действие rotate_token, которое доставит его агенту при следующем пинге. До конца переходного периода или до первого запроса с новым токеном принимается и старый токен. Незавершенная предыдущая ротация отменяется. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "agents"
+                ],
+                "summary": "Ротация токена агента",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID агента",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Переходный период",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.RotateAgentTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новый токен выпущен",
+                        "schema": {
+                            "$ref": "#/definitions/models.RotateAgentTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Агент не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/agents/{agent_id}/nginx-config": {
+            "get": {
+                "description": "Возвращает конфигурацию nginx для указанного агента",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "nginx"
+                ],
+                "summary": "Получить конфигурацию nginx агента",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID агента",
+                        "name": "agent_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AgentNginxConfig"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/domains": {
+            "get": {
+                "description": "Возвращает список всех доменов с их маршрутами",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
                     "domains"
                 ],
                 "summary": "Получить список доменов",
@@ -1016,52 +1640,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/containers": {
+        "/approval-policies": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список всех контейнеров с фильтрацией",
+                "description": "Возвращает политики, по которым действия удерживаются до подтверждения",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "containers"
-                ],
-                "summary": "Получить список контейнеров",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID агента для фильтрации",
-                        "name": "agent_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Статус для фильтрации",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Поиск по имени или образу",
-                        "name": "search",
-                        "in": "query"
-                    }
+                    "approvals"
                 ],
+                "summary": "Получение политик подтверждения",
                 "responses": {
                     "200": {
-                        "description": "Список контейнеров",
-                        "schema": {
-                            "$ref": "#/definitions/models.ContainerListResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Не авторизован",
+                        "description": "Список политик",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ApprovalPolicyListResponse"
                         }
                     },
                     "500": {
@@ -1071,53 +1669,50 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/containers/{id}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает полную информацию о контейнере включая метрики и историю",
+                "description": "Действия указанного типа (на указанном агенте или на всех агентах) будут создаваться в статусе awaiting_approval. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "containers"
+                    "approvals"
                 ],
-                "summary": "Получить детальную информацию о контейнере",
+                "summary": "Создание политики подтверждения",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID контейнера",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Данные политики",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateApprovalPolicyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Детальная информация о контейнере",
+                    "201": {
+                        "description": "Политика создана",
                         "schema": {
-                            "$ref": "#/definitions/models.ContainerDetail"
+                            "$ref": "#/definitions/models.ApprovalPolicy"
                         }
                     },
                     "400": {
-                        "description": "Неверный ID",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверные данные",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Контейнер не найден",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "type": "string"
                         }
@@ -1131,39 +1726,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/containers/{id}/logs": {
-            "get": {
+        "/approval-policies/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает последние логи контейнера (до 100 записей)",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Доступно только администраторам",
                 "tags": [
-                    "containers"
+                    "approvals"
                 ],
-                "summary": "Получить логи контейнера",
+                "summary": "Удаление политики подтверждения",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID контейнера",
+                        "description": "ID политики",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Логи контейнера",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.ContainerLog"
-                            }
-                        }
+                    "204": {
+                        "description": "Политика удалена"
                     },
                     "400": {
                         "description": "Неверный ID",
@@ -1171,8 +1757,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Не авторизован",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "type": "string"
                         }
@@ -1186,32 +1772,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard": {
+        "/approvals": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает KPI метрики, графики использования ресурсов и топ контейнеры",
+                "description": "По умолчанию возвращает только действия, ожидающие решения; status=all возвращает также историю решений",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboard"
+                    "approvals"
+                ],
+                "summary": "Получение запросов на подтверждение",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending (по умолчанию) или all",
+                        "name": "status",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Получить данные дашборда",
                 "responses": {
                     "200": {
-                        "description": "Данные дашборда",
-                        "schema": {
-                            "$ref": "#/definitions/models.DashboardData"
-                        }
-                    },
-                    "401": {
-                        "description": "Не авторизован",
+                        "description": "Список запросов",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ActionApprovalListResponse"
                         }
                     },
                     "500": {
@@ -1223,44 +1811,74 @@ const docTemplate = `{
                 }
             }
         },
-        "/images": {
+        "/audit": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список всех Docker образов с фильтрацией",
+                "description": "Возвращает записи об изменяющих API-вызовах: пользователь, IP, описание и изменения полей",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "images"
+                    "audit"
                 ],
-                "summary": "Получить список Docker образов",
+                "summary": "Получение журнала аудита",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID агента для фильтрации",
-                        "name": "agent_id",
+                        "description": "ID пользователя",
+                        "name": "user_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Поиск по ID образа или тегу",
-                        "name": "search",
+                        "description": "Тип сущности (agent, domain, action, notification_settings, ...)",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сущности",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лимит записей (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список образов",
+                        "description": "Записи журнала аудита",
                         "schema": {
-                            "$ref": "#/definitions/models.ImageListResponse"
+                            "$ref": "#/definitions/models.AuditListResponse"
                         }
                     },
-                    "401": {
-                        "description": "Не авторизован",
+                    "400": {
+                        "description": "Неверные параметры",
                         "schema": {
                             "type": "string"
                         }
@@ -1274,45 +1892,137 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Выполняет вход в систему и возвращает JWT токен",
-                "consumes": [
-                    "application/json"
+        "/auth-events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает успешные и неудачные входы, задержки и блокировки, выходы из системы",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "audit"
                 ],
-                "summary": "Аутентификация пользователя",
+                "summary": "Получение событий входа",
                 "parameters": [
                     {
-                        "description": "Данные для входа",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя, как введено при входе",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IP клиента",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип события (login_success, login_failure, mfa_failure, lockout, login_blocked, unlock, logout)",
+                        "name": "event",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лимит записей (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешная аутентификация",
+                        "description": "События входа",
                         "schema": {
-                            "$ref": "#/definitions/models.LoginResponse"
+                            "$ref": "#/definitions/models.AuthEventListResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверный JSON",
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/containers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список всех контейнеров с фильтрацией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "containers"
+                ],
+                "summary": "Получить список контейнеров",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID агента для фильтрации",
+                        "name": "agent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус для фильтрации",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поиск по имени или образу",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список контейнеров",
+                        "schema": {
+                            "$ref": "#/definitions/models.ContainerListResponse"
+                        }
                     },
                     "401": {
-                        "description": "Неверные учетные данные",
+                        "description": "Не авторизован",
                         "schema": {
                             "type": "string"
                         }
@@ -1326,21 +2036,53 @@ const docTemplate = `{
                 }
             }
         },
-        "/notifications/settings": {
+        "/containers/{id}": {
             "get": {
-                "description": "Получает текущие настройки уведомлений",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает полную информацию о контейнере включая метрики и историю",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "containers"
+                ],
+                "summary": "Получить детальную информацию о контейнере",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID контейнера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Получение настроек уведомлений",
                 "responses": {
                     "200": {
-                        "description": "Настройки уведомлений",
+                        "description": "Детальная информация о контейнере",
                         "schema": {
-                            "$ref": "#/definitions/models.NotificationSettings"
+                            "$ref": "#/definitions/models.ContainerDetail"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Контейнер не найден",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
@@ -1350,39 +2092,83 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Обновляет настройки уведомлений",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/containers/{id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает хронологию событий контейнера: падения с кодом выхода, OOM, kill с сигналом, перезапуски и смену здоровья, новые первыми",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "containers"
                 ],
-                "summary": "Обновление настроек уведомлений",
+                "summary": "Получить события контейнера",
                 "parameters": [
                     {
-                        "description": "Настройки уведомлений",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.NotificationSettings"
-                        }
+                        "type": "string",
+                        "description": "ID контейнера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Действие (die, oom, kill, restart, health_status, destroy)",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лимит записей (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Настройки обновлены",
+                        "description": "События контейнера",
                         "schema": {
-                            "$ref": "#/definitions/models.NotificationSettings"
+                            "$ref": "#/definitions/models.ContainerEventListResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверные данные",
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к агенту",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Контейнер не найден",
                         "schema": {
                             "type": "string"
                         }
@@ -1396,83 +2182,4631 @@ const docTemplate = `{
                 }
             }
         },
-        "/notifications/test": {
-            "post": {
-                "description": "Отправляет тестовое уведомление в Telegram и/или email",
+        "/containers/{id}/logs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает последние логи контейнера (до 100 записей)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "containers"
+                ],
+                "summary": "Получить логи контейнера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID контейнера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Отправка тестового уведомления",
                 "responses": {
                     "200": {
-                        "description": "Уведомление отправлено",
+                        "description": "Логи контейнера",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ContainerLog"
                             }
                         }
                     },
                     "400": {
-                        "description": "Не настроены уведомления",
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Ошибка отправки",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Action": {
-            "type": "object",
-            "properties": {
-                "agent_id": {
-                    "type": "string"
-                },
-                "completed": {
-                    "type": "string"
-                },
-                "created": {
-                    "type": "string"
-                },
-                "error": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
+        },
+        "/dashboard": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает KPI метрики, графики использования ресурсов и топ контейнеры",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboard"
+                ],
+                "summary": "Получить данные дашборда",
+                "responses": {
+                    "200": {
+                        "description": "Данные дашборда",
+                        "schema": {
+                            "$ref": "#/definitions/models.DashboardData"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/images": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список всех Docker образов с фильтрацией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "Получить список Docker образов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID агента для фильтрации",
+                        "name": "agent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поиск по ID образа или тегу",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список образов",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImageListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Выполняет вход в систему, открывает сессию и возвращает короткоживущий JWT токен и refresh-токен.\nЕсли у пользователя включена 2FA или она обязательна для его роли, вместо токенов возвращается challenge_token для POST /login/2fa (challenge_type=totp) или POST /login/2fa/enroll и /login/2fa/activate (challenge_type=enroll).\nПосле 3 неудачных попыток для имени пользователя или IP следующие попытки откладываются на 1, 2, 4, ... секунд (до 30), после 10 ошибок для пользователя или 30 для IP вход блокируется на 15 минут.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Аутентификация пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для входа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много неудачных попыток, см. заголовок Retry-After",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/2fa": {
+            "post": {
+                "description": "Принимает challenge_token из ответа /login и код из приложения-аутентификатора или код восстановления. На один вход дается 5 попыток.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Второй шаг входа",
+                "parameters": [
+                    {
+                        "description": "Токен второго шага и код",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginChallengeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный код или токен второго шага",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много неудачных попыток, см. заголовок Retry-After",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/2fa/activate": {
+            "post": {
+                "description": "Для challenge_type=enroll: включает 2FA по первому коду из приложения и выдает токены вместе с кодами восстановления",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Подтверждение 2FA при входе",
+                "parameters": [
+                    {
+                        "description": "Токен второго шага и код",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginChallengeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная аутентификация и коды восстановления",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON или подключение не начато",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный код или токен второго шага",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много неудачных попыток, см. заголовок Retry-After",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/2fa/enroll": {
+            "post": {
+                "description": "Для challenge_type=enroll: возвращает секрет TOTP, который нужно подтвердить через /login/2fa/activate",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Подключение 2FA при входе",
+                "parameters": [
+                    {
+                        "description": "Токен второго шага",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginChallengeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Секрет TOTP",
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPEnrollment"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Токен второго шага недействителен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много неудачных попыток, см. заголовок Retry-After",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает текущую сессию: ее JWT и refresh-токен перестают действовать сразу",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Выход из системы",
+                "responses": {
+                    "204": {
+                        "description": "Сессия завершена"
+                    },
+                    "400": {
+                        "description": "Запрос выполнен не от имени сессии",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/settings": {
+            "get": {
+                "description": "Получает текущие настройки уведомлений",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Получение настроек уведомлений",
+                "responses": {
+                    "200": {
+                        "description": "Настройки уведомлений",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationSettings"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Обновляет настройки уведомлений",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Обновление настроек уведомлений",
+                "parameters": [
+                    {
+                        "description": "Настройки уведомлений",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Настройки обновлены",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/test": {
+            "post": {
+                "description": "Отправляет тестовое уведомление в Telegram и/или email",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Отправка тестового уведомления",
+                "responses": {
+                    "200": {
+                        "description": "Уведомление отправлено",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Не настроены уведомления",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка отправки",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc": {
+            "get": {
+                "description": "Позволяет странице входа показать кнопку единого входа",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Доступность входа через OIDC",
+                "responses": {
+                    "200": {
+                        "description": "Статус",
+                        "schema": {
+                            "$ref": "#/definitions/models.OIDCStatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/callback": {
+            "get": {
+                "description": "Принимает код авторизации, создает пользователя при первом входе, открывает сессию и перенаправляет на страницу входа приложения с токенами во фрагменте URL (#token=...\u0026refresh_token=...), с токеном второго шага, если нужна 2FA (#challenge_token=...\u0026challenge_type=...), или с ошибкой (#error=...)",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Обратный вызов OIDC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Код авторизации",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Перенаправление в приложение"
+                    }
+                }
+            }
+        },
+        "/oidc/login": {
+            "get": {
+                "description": "Перенаправляет на провайдера (authorization code flow с PKCE); state сохраняется в HttpOnly cookie oidc_state, без которой обратный вызов отклоняется",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Вход через OIDC",
+                "responses": {
+                    "302": {
+                        "description": "Перенаправление на провайдера"
+                    },
+                    "404": {
+                        "description": "Вход через OIDC не настроен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Провайдер недоступен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Получение своего профиля",
+                "responses": {
+                    "200": {
+                        "description": "Профиль",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Пользователь может изменить свой email; роль и активность меняет только администратор",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Изменение своего профиля",
+                "parameters": [
+                    {
+                        "description": "Поля профиля",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Профиль обновлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недоступно по API-токену",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/2fa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Состояние 2FA",
+                "responses": {
+                    "200": {
+                        "description": "Состояние 2FA",
+                        "schema": {
+                            "$ref": "#/definitions/models.MFAStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Требует действующий код из приложения или код восстановления. Недоступно, если 2FA обязательна для роли.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Отключение 2FA",
+                "parameters": [
+                    {
+                        "description": "Код подтверждения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "2FA отключена"
+                    },
+                    "400": {
+                        "description": "2FA не включена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный код",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA обязательна для роли",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/2fa/activate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Включает 2FA по первому коду из приложения и возвращает коды восстановления (показываются один раз)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Подтверждение 2FA",
+                "parameters": [
+                    {
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Коды восстановления",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Подключение не начато",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный код",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает новый секрет TOTP; 2FA включается после подтверждения кодом через /profile/2fa/activate",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Подключение 2FA",
+                "responses": {
+                    "200": {
+                        "description": "Секрет TOTP",
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPEnrollment"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Прежние коды перестают действовать. Требует действующий код из приложения или код восстановления.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Новые коды восстановления",
+                "parameters": [
+                    {
+                        "description": "Код подтверждения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TOTPCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Коды восстановления",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "2FA не включена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный код",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/auth-events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Позволяет заметить чужие попытки входа в учетную запись",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Получение своих событий входа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лимит записей (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "События входа",
+                        "schema": {
+                            "$ref": "#/definitions/models.AuthEventListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет пароль и завершает остальные сессии пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Смена своего пароля",
+                "parameters": [
+                    {
+                        "description": "Текущий и новый пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Пароль изменен"
+                    },
+                    "400": {
+                        "description": "Неверные данные или неверный текущий пароль",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недоступно по API-токену",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает активные сессии с IP и User-Agent; текущая сессия отмечена полем current",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Получение своих сессий",
+                "responses": {
+                    "200": {
+                        "description": "Список сессий",
+                        "schema": {
+                            "$ref": "#/definitions/models.SessionListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недоступно по API-токену",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает все сессии текущего пользователя, включая текущую. API-токены не затрагиваются.",
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Выход из всех сессий",
+                "responses": {
+                    "204": {
+                        "description": "Сессии завершены"
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недоступно по API-токену",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Завершение своей сессии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сессия завершена"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недоступно по API-токену",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Сессия не найдена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает персональные API-токены, включая отозванные. Значения токенов не возвращаются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Получение своих API-токенов",
+                "responses": {
+                    "200": {
+                        "description": "Список токенов",
+                        "schema": {
+                            "$ref": "#/definitions/models.APITokenListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Управление токенами по API-токену запрещено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает долгоживущий токен для скриптов и CI. Токен передается в заголовке Authorization: Bearer и показывается только в этом ответе. Права токена (scopes) не могут превышать права роли; пустой список — все права роли.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Создание API-токена",
+                "parameters": [
+                    {
+                        "description": "Параметры токена",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPITokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Токен создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPITokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Управление токенами по API-токену запрещено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "Отзыв своего API-токена",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID токена",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Токен отозван"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Управление токенами по API-токену запрещено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Выдает новый JWT и новый refresh-токен той же сессии; предъявленный refresh-токен становится недействительным. Повторное использование старого refresh-токена отзывает сессию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Обновление токенов",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новые токены",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh-токен недействителен",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает расписания действий с временем следующего и последнего запуска",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Получение списка расписаний",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID агента",
+                        "name": "agent_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список расписаний",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduleListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID агента",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает периодическое (cron_expr) или однократное (run_at) расписание действия",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Создание расписания",
+                "parameters": [
+                    {
+                        "description": "Данные расписания",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Расписание создано",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActionSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Агент не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Получение расписания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Расписание",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActionSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Расписание не найдено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет параметры расписания и пересчитывает время следующего запуска",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Обновление расписания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Поля для обновления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Расписание обновлено",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActionSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Удаление расписания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Расписание удалено"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Приостановка расписания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Расписание приостановлено",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActionSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Возобновление расписания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Расписание возобновлено",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActionSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только администраторам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение списка пользователей",
+                "responses": {
+                    "200": {
+                        "description": "Список пользователей",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserListResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает активного пользователя с указанной ролью (admin, approver, operator, viewer) и, при необходимости, ограничивает его списком агентов. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Создание пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные пользователя",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Имя пользователя занято",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только администраторам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Изменяет email, роль, активность и доступные агенты пользователя. Токены деактивированного пользователя перестают действовать сразу. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Изменение пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Поля для обновления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь обновлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Нельзя лишить систему последнего администратора",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет пользователя; созданные им действия и записи аудита сохраняются. Удалить самого себя нельзя. Доступно только администраторам.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Удаление пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Пользователь удален"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Нельзя удалить последнего администратора",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/2fa": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Например, при утере телефона. Если 2FA обязательна для роли, пользователь подключит ее заново при следующем входе. Доступно только администраторам.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Сброс 2FA пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "2FA сброшена"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Устанавливает новый пароль без проверки текущего и завершает все сессии пользователя. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Установка пароля пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Пароль изменен"
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только администраторам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение сессий пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список сессий",
+                        "schema": {
+                            "$ref": "#/definitions/models.SessionListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Например, при утере ноутбука. Доступно только администраторам.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Завершение всех сессий пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сессии завершены"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только администраторам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение API-токенов пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список токенов",
+                        "schema": {
+                            "$ref": "#/definitions/models.APITokenListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/tokens/{token_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Доступно только администраторам",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Отзыв API-токена пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID токена",
+                        "name": "token_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Токен отозван"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сбрасывает счетчик неудачных попыток входа пользователя и снимает временную блокировку. Блокировка по IP не снимается. Доступно только администраторам.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Снятие блокировки входа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Блокировка снята"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/workflows": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает сценарии развертывания вместе с шагами",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Получение списка сценариев",
+                "responses": {
+                    "200": {
+                        "description": "Список сценариев",
+                        "schema": {
+                            "$ref": "#/definitions/models.WorkflowListResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает сценарий из шагов-действий с зависимостями (DAG), переменными {{name}} и необязательными компенсациями",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Создание сценария",
+                "parameters": [
+                    {
+                        "description": "Данные сценария",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateWorkflowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Сценарий создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.Workflow"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/workflows/runs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Получение запуска сценария",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID запуска",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запуск с результатами шагов",
+                        "schema": {
+                            "$ref": "#/definitions/models.WorkflowRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Запуск не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/workflows/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Получение сценария",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сценария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сценарий",
+                        "schema": {
+                            "$ref": "#/definitions/models.Workflow"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Сценарий не найден",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет сценарий; переданный список шагов полностью заменяет текущий",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Обновление сценария",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сценария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Поля для обновления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateWorkflowRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сценарий обновлен",
+                        "schema": {
+                            "$ref": "#/definitions/models.Workflow"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет сценарий вместе с историей запусков",
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Удаление сценария",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сценария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сценарий удален"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/workflows/{id}/runs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "История запусков сценария",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сценария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список запусков",
+                        "schema": {
+                            "$ref": "#/definitions/models.WorkflowRunListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает запуск сценария на агенте и отправляет шаги без зависимостей",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflows"
+                ],
+                "summary": "Запуск сценария",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сценария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Агент и переменные запуска",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StartWorkflowRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Запуск создан",
+                        "schema": {
+                            "$ref": "#/definitions/models.WorkflowRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIToken": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "начало токена для узнавания в списке",
+                    "type": "string",
+                    "example": "msk_1a2b3c4d"
+                },
+                "revoked": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "права токена; пустой список — все права роли владельца",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APITokenListResponse": {
+            "type": "object",
+            "properties": {
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIToken"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Action": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string"
+                },
+                "completed": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "nil — создано системой",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "response": {
+                    "description": "структурированный результат от агента",
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ActionApproval": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Дополнительные поля для совместимости с frontend",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Action"
+                        }
+                    ]
+                },
+                "action_id": {
+                    "type": "string"
+                },
+                "agent_name": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "decided": {
+                    "type": "string"
+                },
+                "decided_by": {
+                    "type": "string"
+                },
+                "decided_by_username": {
+                    "type": "string"
+                },
+                "decision": {
+                    "description": "approved, rejected; nil — ожидает решения",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "policy_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "requested_by_username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ActionApprovalListResponse": {
+            "type": "object",
+            "properties": {
+                "approvals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ActionApproval"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ActionListResponse": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Action"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ActionResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "response": {
+                    "description": "JSON-объект; строка от старых агентов сохраняется как {\"message\": ...}",
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ActionSchedule": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string"
+                },
+                "agent_name": {
+                    "description": "Дополнительные поля для совместимости с frontend",
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "автор создаваемых действий",
+                    "type": "string"
+                },
+                "cron_expr": {
+                    "description": "например: \"0 3 * * *\" или \"@weekly\"",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_paused": {
+                    "type": "boolean"
+                },
+                "last_action_id": {
+                    "type": "string"
+                },
+                "last_run": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "run_at": {
+                    "description": "время однократного запуска",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Agent": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "description": "Approved = false — агент зарегистрировался сам и ждет подтверждения администратором",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "facts": {
+                    "$ref": "#/definitions/models.AgentFacts"
+                },
+                "facts_updated": {
+                    "description": "FactsUpdated — когда агент последний раз прислал изменившиеся сведения о хосте",
+                    "type": "string"
+                },
+                "hostname": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_ping": {
+                    "type": "string"
+                },
+                "last_signed_request": {
+                    "description": "с точностью до минуты",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "previous_token_expires": {
+                    "description": "PreviousTokenExpires задан, пока после ротации принимается и старый токен",
+                    "type": "string"
+                },
+                "public_ip": {
+                    "type": "string"
+                },
+                "require_signature": {
+                    "description": "RequireSignature — запросы агента принимаются только подписанными",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "online, offline, unknown, pending",
+                    "type": "string"
+                },
+                "token_prefix": {
+                    "description": "TokenPrefix — начало токена для узнавания; сам токен показывается только при создании",
+                    "type": "string",
+                    "example": "msa_1a2b3c4d"
+                }
+            }
+        },
+        "models.AgentData": {
+            "type": "object",
+            "properties": {
+                "collection": {
+                    "description": "Collection — длительность сбора данных агентом; старые агенты не присылают",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CollectionInfo"
+                        }
+                    ]
+                },
+                "docker": {
+                    "$ref": "#/definitions/models.DockerInfo"
+                },
+                "events": {
+                    "description": "Events — события Docker, накопленные агентом с прошлого пинга",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DockerEventInfo"
+                    }
+                },
+                "facts": {
+                    "description": "Facts агент присылает только при изменении сведений о хосте",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AgentFacts"
+                        }
+                    ]
+                },
+                "metrics": {
+                    "$ref": "#/definitions/models.Metrics"
+                },
+                "timestamp": {
+                    "description": "Timestamp — время сбора данных (RFC3339); пинги из очереди агента приходят позже.\nСтарые агенты не присылают: время сбора — время приема",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AgentDetail": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "description": "Approved = false — агент зарегистрировался сам и ждет подтверждения администратором",
+                    "type": "boolean"
+                },
+                "containers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerDetail"
+                    }
+                },
+                "created": {
+                    "type": "string"
+                },
+                "facts": {
+                    "$ref": "#/definitions/models.AgentFacts"
+                },
+                "facts_updated": {
+                    "description": "FactsUpdated — когда агент последний раз прислал изменившиеся сведения о хосте",
+                    "type": "string"
+                },
+                "hostname": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageDetail"
+                    }
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_ping": {
+                    "type": "string"
+                },
+                "last_signed_request": {
+                    "description": "с точностью до минуты",
+                    "type": "string"
+                },
+                "metrics": {
+                    "$ref": "#/definitions/models.AgentMetrics"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "previous_token_expires": {
+                    "description": "PreviousTokenExpires задан, пока после ротации принимается и старый токен",
+                    "type": "string"
+                },
+                "public_ip": {
+                    "type": "string"
+                },
+                "require_signature": {
+                    "description": "RequireSignature — запросы агента принимаются только подписанными",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "online, offline, unknown, pending",
+                    "type": "string"
+                },
+                "system_metrics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SystemMetric"
+                    }
+                },
+                "token_prefix": {
+                    "description": "TokenPrefix — начало токена для узнавания; сам токен показывается только при создании",
+                    "type": "string",
+                    "example": "msa_1a2b3c4d"
+                }
+            }
+        },
+        "models.AgentEnrollmentToken": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "description": "агент, зарегистрированный по токену",
+                    "type": "string"
+                },
+                "auto_approve": {
+                    "description": "агент начинает работу без подтверждения администратором",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "начало токена для узнавания в списке",
+                    "type": "string",
+                    "example": "mse_1a2b3c4d"
+                },
+                "revoked": {
+                    "type": "string"
+                },
+                "used": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AgentFacts": {
+            "type": "object",
+            "properties": {
+                "agent_version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                },
+                "architecture": {
+                    "type": "string",
+                    "example": "x86_64"
+                },
+                "cpu_cores": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "cpu_model": {
+                    "type": "string",
+                    "example": "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz"
+                },
+                "docker_version": {
+                    "type": "string",
+                    "example": "28.3.2"
+                },
+                "hostname": {
+                    "type": "string",
+                    "example": "web-01"
+                },
+                "kernel_version": {
+                    "type": "string",
+                    "example": "5.15.0-91-generic"
+                },
+                "os": {
+                    "type": "string",
+                    "example": "linux"
+                },
+                "platform": {
+                    "type": "string",
+                    "example": "ubuntu"
+                },
+                "platform_version": {
+                    "type": "string",
+                    "example": "22.04"
+                }
+            }
+        },
+        "models.AgentMetrics": {
+            "type": "object",
+            "properties": {
+                "collection": {
+                    "$ref": "#/definitions/models.CollectionMetricCurrent"
+                },
+                "cpu": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CPUMetricCurrent"
+                    }
+                },
+                "disk": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DiskMetricCurrent"
+                    }
+                },
+                "filesystems": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FilesystemMetricCurrent"
+                    }
+                },
+                "host": {
+                    "$ref": "#/definitions/models.HostMetricCurrent"
+                },
+                "memory": {
+                    "$ref": "#/definitions/models.MemoryMetricCurrent"
+                },
+                "network": {
+                    "$ref": "#/definitions/models.NetworkMetricCurrent"
+                }
+            }
+        },
+        "models.AgentNginxConfig": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string"
+                },
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NginxConfig"
+                    }
+                }
+            }
+        },
+        "models.AgentRegisterRequest": {
+            "type": "object",
+            "properties": {
+                "facts": {
+                    "$ref": "#/definitions/models.AgentFacts"
+                },
+                "name": {
+                    "description": "пусто — используется имя хоста",
+                    "type": "string",
+                    "example": "web-01"
+                }
+            }
+        },
+        "models.AgentRegisterResponse": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string"
+                },
+                "approved": {
+                    "description": "false — пинги отклоняются до подтверждения администратором",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "signing_key": {
+                    "description": "ключ подписи запросов агента, показывается один раз",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "постоянный токен агента, показывается один раз",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ApprovalDecisionRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Planned maintenance"
+                }
+            }
+        },
+        "models.ApprovalPolicy": {
+            "type": "object",
+            "properties": {
+                "action_type": {
+                    "type": "string"
+                },
+                "agent_id": {
+                    "description": "nil — политика действует для всех агентов",
+                    "type": "string"
+                },
+                "agent_name": {
+                    "description": "Дополнительные поля для совместимости с frontend",
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "description": {
+                    "description": "например: \"production\"",
+                    "type": "string"
+                },
+                "force_only": {
+                    "description": "только для действий с payload.force = true",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ApprovalPolicyListResponse": {
+            "type": "object",
+            "properties": {
+                "policies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ApprovalPolicy"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AuditChange": {
+            "type": "object",
+            "properties": {
+                "after": {},
+                "before": {}
+            }
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "before": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "changes": {
+                    "description": "ключ — путь к полю, например \"email_settings.smtp_host\"",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.AuditChange"
+                    }
+                },
+                "created": {
+                    "type": "string"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "entity_type": {
+                    "description": "agent, domain, action, ...",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditListResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEntry"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AuthEvent": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "description": "как введено при входе",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuthEventListResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuthEvent"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CPUInfo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "usage": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CPUMetricCurrent": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "usage": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CPUThresholdConfig": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CPUTimesInfo": {
+            "type": "object",
+            "properties": {
+                "idle": {
+                    "type": "number"
+                },
+                "iowait": {
+                    "type": "number"
+                },
+                "irq": {
+                    "type": "number"
+                },
+                "nice": {
+                    "type": "number"
+                },
+                "softirq": {
+                    "type": "number"
+                },
+                "steal": {
+                    "type": "number"
+                },
+                "system": {
+                    "type": "number"
+                },
+                "user": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ChangePasswordRequest": {
+            "type": "object",
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CollectionFailure": {
+            "type": "object",
+            "properties": {
+                "call": {
+                    "description": "inspect, logs, stats",
+                    "type": "string"
+                },
+                "container_id": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CollectionInfo": {
+            "type": "object",
+            "properties": {
+                "containers": {
+                    "type": "integer"
+                },
+                "docker_duration_ms": {
+                    "type": "number"
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CollectionFailure"
+                    }
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CollectionMetricCurrent": {
+            "type": "object",
+            "properties": {
+                "docker_duration_ms": {
+                    "type": "number"
+                },
+                "duration_ms": {
+                    "type": "number"
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CollectionFailure"
+                    }
+                },
+                "workers": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Container": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "description": "Дополнительные поля для совместимости с frontend",
+                    "type": "string"
+                },
+                "agent_name": {
+                    "type": "string"
+                },
+                "block_read_bytes": {
+                    "type": "integer"
+                },
+                "block_write_bytes": {
+                    "type": "integer"
+                },
+                "container_id": {
+                    "type": "string"
+                },
+                "cpu_usage_percent": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "health_failing_streak": {
+                    "type": "integer"
+                },
+                "health_output": {
+                    "type": "string"
+                },
+                "health_status": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "mac_address": {
+                    "type": "string"
+                },
+                "memory_limit_mb": {
+                    "type": "integer"
+                },
+                "memory_percent": {
+                    "description": "процент от лимита памяти",
+                    "type": "number"
+                },
+                "memory_usage_mb": {
+                    "type": "integer"
+                },
+                "mounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerMount"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "network_received_bytes": {
+                    "type": "integer"
+                },
+                "network_sent_bytes": {
+                    "type": "integer"
+                },
+                "oom_killed": {
+                    "type": "boolean"
+                },
+                "pids": {
+                    "type": "integer"
+                },
+                "ping_id": {
+                    "type": "string"
+                },
+                "ports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerPort"
+                    }
+                },
+                "restart_count": {
+                    "type": "integer"
+                },
+                "restart_max_retries": {
+                    "type": "integer"
+                },
+                "restart_policy": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Состояние процесса; nil у контейнеров, присланных старыми агентами",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerDetail": {
+            "type": "object",
+            "properties": {
+                "agent": {
+                    "$ref": "#/definitions/models.Agent"
+                },
+                "agent_id": {
+                    "description": "Дополнительные поля для совместимости с frontend",
+                    "type": "string"
+                },
+                "agent_name": {
+                    "type": "string"
+                },
+                "block_read_bytes": {
+                    "type": "integer"
+                },
+                "block_write_bytes": {
+                    "type": "integer"
+                },
+                "container_id": {
+                    "type": "string"
+                },
+                "cpu_usage_percent": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "health_failing_streak": {
+                    "type": "integer"
+                },
+                "health_output": {
+                    "type": "string"
+                },
+                "health_status": {
+                    "type": "string"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerMetric"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerLog"
+                    }
+                },
+                "mac_address": {
+                    "type": "string"
+                },
+                "memory_limit_mb": {
+                    "type": "integer"
+                },
+                "memory_percent": {
+                    "description": "процент от лимита памяти",
+                    "type": "number"
+                },
+                "memory_usage_mb": {
+                    "type": "integer"
+                },
+                "mounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerMount"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "network_received_bytes": {
+                    "type": "integer"
+                },
+                "network_sent_bytes": {
+                    "type": "integer"
+                },
+                "oom_killed": {
+                    "type": "boolean"
+                },
+                "pids": {
+                    "type": "integer"
+                },
+                "ping_id": {
+                    "type": "string"
+                },
+                "ports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerPort"
+                    }
+                },
+                "restart_count": {
+                    "type": "integer"
+                },
+                "restart_max_retries": {
+                    "type": "integer"
+                },
+                "restart_policy": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Состояние процесса; nil у контейнеров, присланных старыми агентами",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "actor_name": {
+                    "type": "string"
+                },
+                "agent_id": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "signal": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerEventListResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerEvent"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ContainerHealthInfo": {
+            "type": "object",
+            "properties": {
+                "failing_streak": {
+                    "type": "integer"
+                },
+                "last_check": {
+                    "type": "string"
+                },
+                "last_exit_code": {
+                    "type": "integer"
+                },
+                "last_output": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "starting, healthy, unhealthy",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerInfo": {
+            "type": "object",
+            "properties": {
+                "block_read": {
+                    "type": "integer"
+                },
+                "block_write": {
+                    "type": "integer"
+                },
+                "cpu": {
+                    "description": "доля одного ядра с прошлого пинга",
+                    "type": "number"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "health": {
+                    "$ref": "#/definitions/models.ContainerHealthInfo"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerLogInfo"
+                    }
+                },
+                "mac": {
+                    "type": "string"
+                },
+                "memory": {
+                    "description": "MB, без неактивного файлового кеша",
+                    "type": "integer"
+                },
+                "memory_limit": {
+                    "type": "integer"
+                },
+                "memory_percent": {
+                    "type": "number"
+                },
+                "mounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerMount"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "network": {
+                    "$ref": "#/definitions/models.ContainerNetworkInfo"
+                },
+                "oom_killed": {
+                    "type": "boolean"
+                },
+                "pids": {
+                    "type": "integer"
+                },
+                "ports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerPort"
+                    }
+                },
+                "restart_count": {
+                    "type": "integer"
+                },
+                "restart_policy": {
+                    "$ref": "#/definitions/models.ContainerRestartPolicy"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Состояние процесса; старые агенты присылают только status",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerListResponse": {
+            "type": "object",
+            "properties": {
+                "containers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerDetail"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ContainerLog": {
+            "type": "object",
+            "properties": {
+                "container_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "log_line": {
+                    "type": "string"
+                },
+                "stream": {
+                    "description": "stdout, stderr; NULL у строк от старых агентов",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerLogInfo": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "type": "string"
+                },
+                "stream": {
+                    "description": "stdout, stderr",
+                    "type": "string"
+                },
+                "time": {
+                    "description": "RFC3339 с наносекундами, время Docker",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerMetric": {
+            "type": "object",
+            "properties": {
+                "cpu_usage": {
+                    "type": "number"
+                },
+                "memory_usage": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerMount": {
+            "type": "object",
+            "properties": {
+                "destination": {
+                    "type": "string"
+                },
+                "rw": {
+                    "type": "boolean"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "bind, volume, tmpfs",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerNetworkInfo": {
+            "type": "object",
+            "properties": {
+                "received": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ContainerPort": {
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string"
+                },
+                "private_port": {
+                    "type": "integer"
+                },
+                "public_port": {
+                    "description": "0 — порт не опубликован",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ContainerRestartPolicy": {
+            "type": "object",
+            "properties": {
+                "maximum_retry_count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "no, always, unless-stopped, on-failure",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateAPITokenRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in_days": {
+                    "description": "ExpiresInDays задает срок действия токена; 0 — бессрочный",
+                    "type": "integer",
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "example": "ci-deploy"
+                },
+                "scopes": {
+                    "description": "Scopes ограничивает токен правами view, operate, approve, admin; пустой список — все права роли",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "view",
+                        "operate"
+                    ]
+                }
+            }
+        },
+        "models.CreateAPITokenResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "начало токена для узнавания в списке",
+                    "type": "string",
+                    "example": "msk_1a2b3c4d"
+                },
+                "revoked": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "права токена; пустой список — все права роли владельца",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "description": "показывается один раз",
+                    "type": "string",
+                    "example": "msk_1a2b3c4d..."
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateActionRequest": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateAgentRequest": {
+            "description": "Запрос на создание нового агента мониторинга",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Production Server 1"
+                }
+            }
+        },
+        "models.CreateAgentResponse": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "description": "Approved = false — агент зарегистрировался сам и ждет подтверждения администратором",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "facts": {
+                    "$ref": "#/definitions/models.AgentFacts"
+                },
+                "facts_updated": {
+                    "description": "FactsUpdated — когда агент последний раз прислал изменившиеся сведения о хосте",
+                    "type": "string"
+                },
+                "hostname": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_ping": {
+                    "type": "string"
+                },
+                "last_signed_request": {
+                    "description": "с точностью до минуты",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "previous_token_expires": {
+                    "description": "PreviousTokenExpires задан, пока после ротации принимается и старый токен",
+                    "type": "string"
+                },
+                "public_ip": {
+                    "type": "string"
+                },
+                "require_signature": {
+                    "description": "RequireSignature — запросы агента принимаются только подписанными",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "online, offline, unknown, pending",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "показывается один раз",
+                    "type": "string",
+                    "example": "msa_1a2b3c4d..."
+                },
+                "token_prefix": {
+                    "description": "TokenPrefix — начало токена для узнавания; сам токен показывается только при создании",
+                    "type": "string",
+                    "example": "msa_1a2b3c4d"
+                }
+            }
+        },
+        "models.CreateApprovalPolicyRequest": {
+            "description": "Запрос на создание политики подтверждения действий",
+            "type": "object",
+            "properties": {
+                "action_type": {
+                    "type": "string",
+                    "example": "remove_container"
+                },
+                "agent_id": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "production"
+                },
+                "force_only": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.CreateDomainRequest": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "dashboard.domain.net"
+                },
+                "ssl_enabled": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.CreateDomainRouteRequest": {
+            "type": "object",
+            "properties": {
+                "container_name": {
+                    "type": "string",
+                    "example": "my-container"
+                },
+                "domain_id": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/api"
+                },
+                "port": {
+                    "type": "string",
+                    "example": "3000"
+                }
+            }
+        },
+        "models.CreateEnrollmentTokenRequest": {
+            "type": "object",
+            "properties": {
+                "auto_approve": {
+                    "type": "boolean"
+                },
+                "expires_in_hours": {
+                    "description": "ExpiresInHours задает срок действия токена; 0 — 24 часа, максимум 30 дней",
+                    "type": "integer",
+                    "example": 24
+                },
+                "name": {
+                    "type": "string",
+                    "example": "web-01"
+                }
+            }
+        },
+        "models.CreateEnrollmentTokenResponse": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "description": "агент, зарегистрированный по токену",
+                    "type": "string"
+                },
+                "auto_approve": {
+                    "description": "агент начинает работу без подтверждения администратором",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "начало токена для узнавания в списке",
+                    "type": "string",
+                    "example": "mse_1a2b3c4d"
+                },
+                "revoked": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "показывается один раз",
+                    "type": "string",
+                    "example": "mse_1a2b3c4d..."
+                },
+                "used": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateScheduleRequest": {
+            "description": "Запрос на создание расписания действия (cron_expr или run_at)",
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string"
+                },
+                "cron_expr": {
+                    "type": "string",
+                    "example": "0 3 * * *"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Nightly restart"
                 },
                 "payload": {
                     "type": "object",
                     "additionalProperties": true
                 },
-                "response": {
+                "run_at": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "restart_container"
+                }
+            }
+        },
+        "models.CreateUserRequest": {
+            "type": "object",
+            "properties": {
+                "agent_ids": {
+                    "description": "AgentIDs ограничивает пользователя указанными агентами; пустой список — все агенты",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "email": {
+                    "type": "string",
+                    "example": "operator@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "s3cret-passw0rd"
+                },
+                "role": {
+                    "description": "по умолчанию viewer",
+                    "type": "string",
+                    "example": "operator"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "operator"
+                }
+            }
+        },
+        "models.CreateWorkflowRequest": {
+            "description": "Запрос на создание сценария развертывания",
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Deploy web"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WorkflowStepRequest"
+                    }
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.DashboardData": {
+            "type": "object",
+            "properties": {
+                "agents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Agent"
+                    }
+                },
+                "offline_agents": {
+                    "type": "integer"
+                },
+                "online_agents": {
+                    "type": "integer"
+                },
+                "recent_metrics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RecentMetric"
+                    }
+                },
+                "system_overview": {
+                    "$ref": "#/definitions/models.SystemOverview"
+                },
+                "total_agents": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DiskInfo": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "read_bytes": {
+                    "type": "integer"
+                },
+                "reads": {
+                    "type": "integer"
+                },
+                "write_bytes": {
+                    "type": "integer"
+                },
+                "writes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DiskMetricCurrent": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "read_bytes": {
+                    "type": "integer"
+                },
+                "read_iops": {
+                    "type": "number"
+                },
+                "read_speed": {
+                    "type": "integer"
+                },
+                "write_bytes": {
+                    "type": "integer"
+                },
+                "write_iops": {
+                    "type": "number"
+                },
+                "write_speed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DiskThresholdConfig": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DockerEventInfo": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "die, oom, kill, restart, health_status, pull, destroy",
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "ID контейнера в Docker или ссылка на образ",
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "signal": {
                     "type": "string"
                 },
                 "status": {
+                    "description": "результат health_status: healthy, unhealthy",
+                    "type": "string"
+                },
+                "time": {
+                    "description": "RFC3339 с наносекундами, время на хосте агента",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "container, image",
+                    "type": "string"
+                }
+            }
+        },
+        "models.DockerInfo": {
+            "type": "object",
+            "properties": {
+                "containers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ContainerInfo"
+                    }
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageInfo"
+                    }
+                }
+            }
+        },
+        "models.Domain": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "description": "ID агента, на котором размещен домен",
+                    "type": "string"
+                },
+                "agent_ip": {
+                    "description": "IP адрес агента",
                     "type": "string"
                 },
-                "type": {
+                "agent_name": {
+                    "description": "Дополнительные поля для совместимости с frontend",
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "description": "активен ли домен",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "например: \"dashboard.domain.net\"",
+                    "type": "string"
+                },
+                "ssl_enabled": {
+                    "description": "включен ли SSL",
+                    "type": "boolean"
+                },
+                "updated": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DomainDetail": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "description": "ID агента, на котором размещен домен",
+                    "type": "string"
+                },
+                "agent_ip": {
+                    "description": "IP адрес агента",
+                    "type": "string"
+                },
+                "agent_name": {
+                    "description": "Дополнительные поля для совместимости с frontend",
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "description": "активен ли домен",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "например: \"dashboard.domain.net\"",
+                    "type": "string"
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DomainRoute"
+                    }
+                },
+                "ssl_enabled": {
+                    "description": "включен ли SSL",
+                    "type": "boolean"
+                },
+                "updated": {
                     "type": "string"
                 }
             }
         },
-        "models.ActionListResponse": {
+        "models.DomainListResponse": {
             "type": "object",
             "properties": {
-                "actions": {
+                "domains": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Action"
+                        "$ref": "#/definitions/models.DomainDetail"
                     }
                 },
                 "total": {
@@ -1480,27 +6814,17 @@ const docTemplate = `{
                 }
             }
         },
-        "models.ActionResponse": {
+        "models.DomainRoute": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "id": {
+                "container_name": {
+                    "description": "имя контейнера",
                     "type": "string"
                 },
-                "response": {
+                "created": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.Agent": {
-            "type": "object",
-            "properties": {
-                "created": {
+                "domain_id": {
                     "type": "string"
                 },
                 "id": {
@@ -1509,287 +6833,273 @@ const docTemplate = `{
                 "is_active": {
                     "type": "boolean"
                 },
-                "last_ping": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "public_ip": {
+                "path": {
+                    "description": "путь (опционально, для /api/*)",
                     "type": "string"
                 },
-                "status": {
-                    "description": "online, offline, unknown",
+                "port": {
+                    "description": "порт контейнера",
                     "type": "string"
                 },
-                "token": {
+                "updated": {
                     "type": "string"
                 }
             }
         },
-        "models.AgentData": {
+        "models.DomainRouteListResponse": {
             "type": "object",
             "properties": {
-                "docker": {
-                    "$ref": "#/definitions/models.DockerInfo"
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DomainRoute"
+                    }
                 },
-                "metrics": {
-                    "$ref": "#/definitions/models.Metrics"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.AgentDetail": {
+        "models.DomainStatus": {
             "type": "object",
             "properties": {
-                "containers": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ContainerDetail"
-                    }
-                },
-                "created": {
+                "agent_id": {
                     "type": "string"
                 },
-                "id": {
+                "agent_ip": {
                     "type": "string"
                 },
-                "images": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ImageDetail"
-                    }
-                },
-                "is_active": {
-                    "type": "boolean"
-                },
-                "last_ping": {
+                "agent_name": {
                     "type": "string"
                 },
-                "metrics": {
-                    "$ref": "#/definitions/models.AgentMetrics"
-                },
-                "name": {
+                "domain_id": {
                     "type": "string"
                 },
-                "public_ip": {
+                "domain_name": {
                     "type": "string"
                 },
-                "status": {
-                    "description": "online, offline, unknown",
-                    "type": "string"
+                "is_active": {
+                    "type": "boolean"
                 },
-                "system_metrics": {
+                "routes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.SystemMetric"
+                        "$ref": "#/definitions/models.RouteStatus"
                     }
                 },
-                "token": {
-                    "type": "string"
+                "ssl_enabled": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.AgentMetrics": {
+        "models.EmailSettings": {
             "type": "object",
             "properties": {
-                "cpu": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.CPUMetricCurrent"
-                    }
+                "enabled": {
+                    "type": "boolean"
                 },
-                "disk": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.DiskMetricCurrent"
-                    }
+                "from_email": {
+                    "type": "string"
                 },
-                "memory": {
-                    "$ref": "#/definitions/models.MemoryMetricCurrent"
+                "from_name": {
+                    "type": "string"
                 },
-                "network": {
-                    "$ref": "#/definitions/models.NetworkMetricCurrent"
-                }
-            }
-        },
-        "models.AgentNginxConfig": {
-            "type": "object",
-            "properties": {
-                "agent_id": {
+                "password": {
                     "type": "string"
                 },
-                "domains": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.NginxConfig"
-                    }
-                }
-            }
-        },
-        "models.CPUInfo": {
-            "type": "object",
-            "properties": {
-                "name": {
+                "smtp_host": {
                     "type": "string"
                 },
-                "usage": {
-                    "type": "number"
-                }
-            }
-        },
-        "models.CPUMetricCurrent": {
-            "type": "object",
-            "properties": {
-                "name": {
+                "smtp_port": {
+                    "type": "integer"
+                },
+                "to_emails": {
+                    "description": "Список email через запятую",
                     "type": "string"
                 },
-                "usage": {
-                    "type": "number"
+                "use_start_tls": {
+                    "type": "boolean"
+                },
+                "use_tls": {
+                    "type": "boolean"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "models.CPUThresholdConfig": {
+        "models.EnrollmentTokenListResponse": {
             "type": "object",
             "properties": {
-                "enabled": {
-                    "type": "boolean"
-                },
-                "message": {
-                    "type": "string"
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AgentEnrollmentToken"
+                    }
                 },
-                "threshold": {
+                "total": {
                     "type": "integer"
                 }
             }
         },
-        "models.Container": {
+        "models.FilesystemInfo": {
             "type": "object",
             "properties": {
-                "agent_id": {
-                    "description": "Дополнительные поля для совместимости с frontend",
+                "device": {
                     "type": "string"
                 },
-                "agent_name": {
-                    "type": "string"
+                "free": {
+                    "type": "integer"
                 },
-                "container_id": {
+                "fstype": {
                     "type": "string"
                 },
-                "cpu_usage_percent": {
-                    "type": "number"
+                "inodes_free": {
+                    "type": "integer"
                 },
-                "created_at": {
-                    "type": "string"
+                "inodes_total": {
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string"
+                "inodes_used": {
+                    "type": "integer"
                 },
-                "image_id": {
+                "mountpoint": {
                     "type": "string"
                 },
-                "ip_address": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 },
-                "mac_address": {
+                "used": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.FilesystemMetricCurrent": {
+            "type": "object",
+            "properties": {
+                "device": {
                     "type": "string"
                 },
-                "memory_usage_mb": {
+                "free_bytes": {
                     "type": "integer"
                 },
-                "name": {
+                "fstype": {
                     "type": "string"
                 },
-                "network_received_bytes": {
+                "inodes_free": {
                     "type": "integer"
                 },
-                "network_sent_bytes": {
+                "inodes_total": {
                     "type": "integer"
                 },
-                "ping_id": {
+                "inodes_used": {
+                    "type": "integer"
+                },
+                "inodes_used_percent": {
+                    "type": "number"
+                },
+                "mountpoint": {
                     "type": "string"
                 },
-                "restart_count": {
+                "total_bytes": {
                     "type": "integer"
                 },
-                "status": {
-                    "type": "string"
+                "used_bytes": {
+                    "type": "integer"
+                },
+                "used_percent": {
+                    "type": "number"
                 }
             }
         },
-        "models.ContainerDetail": {
+        "models.HostMetricCurrent": {
             "type": "object",
             "properties": {
-                "agent": {
-                    "$ref": "#/definitions/models.Agent"
-                },
-                "agent_id": {
-                    "description": "Дополнительные поля для совместимости с frontend",
-                    "type": "string"
-                },
-                "agent_name": {
-                    "type": "string"
-                },
-                "container_id": {
+                "boot_time": {
                     "type": "string"
                 },
-                "cpu_usage_percent": {
+                "context_switches_speed": {
                     "type": "number"
                 },
-                "created_at": {
-                    "type": "string"
-                },
-                "history": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ContainerMetric"
-                    }
+                "cpu_idle_percent": {
+                    "type": "number"
                 },
-                "id": {
-                    "type": "string"
+                "cpu_iowait_percent": {
+                    "type": "number"
                 },
-                "image_id": {
-                    "type": "string"
+                "cpu_steal_percent": {
+                    "type": "number"
                 },
-                "ip_address": {
-                    "type": "string"
+                "cpu_system_percent": {
+                    "type": "number"
                 },
-                "logs": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ContainerLog"
-                    }
+                "cpu_user_percent": {
+                    "description": "user включает nice, system — обработку прерываний (irq, softirq)",
+                    "type": "number"
                 },
-                "mac_address": {
-                    "type": "string"
+                "load1": {
+                    "type": "number"
                 },
-                "memory_usage_mb": {
+                "load15": {
+                    "type": "number"
+                },
+                "load5": {
+                    "type": "number"
+                },
+                "processes": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "threads": {
+                    "type": "integer"
                 },
-                "network_received_bytes": {
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.HostMetricsInfo": {
+            "type": "object",
+            "properties": {
+                "boot_time": {
+                    "description": "unix-время",
                     "type": "integer"
                 },
-                "network_sent_bytes": {
+                "context_switches": {
+                    "description": "накопительный счетчик",
                     "type": "integer"
                 },
-                "ping_id": {
-                    "type": "string"
+                "cpu_times": {
+                    "$ref": "#/definitions/models.CPUTimesInfo"
                 },
-                "restart_count": {
+                "load1": {
+                    "type": "number"
+                },
+                "load15": {
+                    "type": "number"
+                },
+                "load5": {
+                    "type": "number"
+                },
+                "processes": {
                     "type": "integer"
                 },
-                "status": {
-                    "type": "string"
+                "threads": {
+                    "type": "integer"
+                },
+                "uptime": {
+                    "description": "секунды",
+                    "type": "integer"
                 }
             }
         },
-        "models.ContainerInfo": {
+        "models.ImageDetail": {
             "type": "object",
             "properties": {
-                "cpu": {
-                    "type": "number"
+                "agent": {
+                    "$ref": "#/definitions/models.Agent"
+                },
+                "architecture": {
+                    "type": "string"
                 },
                 "created": {
                     "type": "string"
@@ -1797,45 +7107,53 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "image": {
+                "image_id": {
                     "type": "string"
                 },
-                "ip": {
+                "ping_id": {
                     "type": "string"
                 },
-                "logs": {
+                "size": {
+                    "type": "integer"
+                },
+                "tags": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                },
-                "mac": {
+                }
+            }
+        },
+        "models.ImageInfo": {
+            "type": "object",
+            "properties": {
+                "architecture": {
                     "type": "string"
                 },
-                "memory": {
-                    "type": "integer"
-                },
-                "name": {
+                "created": {
                     "type": "string"
                 },
-                "network": {
-                    "$ref": "#/definitions/models.ContainerNetworkInfo"
+                "id": {
+                    "type": "string"
                 },
-                "restart_count": {
+                "size": {
                     "type": "integer"
                 },
-                "status": {
-                    "type": "string"
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.ContainerListResponse": {
+        "models.ImageListResponse": {
             "type": "object",
             "properties": {
-                "containers": {
+                "images": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.ContainerDetail"
+                        "$ref": "#/definitions/models.ImageDetail"
                     }
                 },
                 "total": {
@@ -1843,467 +7161,588 @@ const docTemplate = `{
                 }
             }
         },
-        "models.ContainerLog": {
+        "models.InterfaceInfo": {
             "type": "object",
             "properties": {
-                "container_id": {
-                    "type": "string"
+                "drops_in": {
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string"
+                "drops_out": {
+                    "type": "integer"
                 },
-                "log_line": {
-                    "type": "string"
+                "errors_in": {
+                    "type": "integer"
                 },
-                "timestamp": {
+                "errors_out": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
+                },
+                "packets_received": {
+                    "type": "integer"
+                },
+                "packets_sent": {
+                    "type": "integer"
+                },
+                "received": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
                 }
             }
         },
-        "models.ContainerMetric": {
+        "models.LoginChallengeRequest": {
             "type": "object",
             "properties": {
-                "cpu_usage": {
-                    "type": "number"
+                "challenge_token": {
+                    "type": "string",
+                    "example": "msc_9a1e..."
                 },
-                "memory_usage": {
-                    "type": "integer"
+                "code": {
+                    "description": "не нужен для начала обязательного подключения",
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "models.LoginRequest": {
+            "description": "Запрос на аутентификацию пользователя",
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "admin123"
                 },
-                "timestamp": {
-                    "type": "string"
+                "username": {
+                    "type": "string",
+                    "example": "admin"
                 }
             }
         },
-        "models.ContainerNetworkInfo": {
+        "models.LoginResponse": {
+            "description": "Ответ с JWT токеном, refresh-токеном и информацией о пользователе",
             "type": "object",
             "properties": {
-                "received": {
-                    "type": "integer"
+                "challenge_token": {
+                    "type": "string",
+                    "example": "msc_9a1e..."
                 },
-                "sent": {
-                    "type": "integer"
+                "challenge_type": {
+                    "description": "ChallengeType: totp — ввести код, enroll — сначала подключить 2FA (обязательно для роли)",
+                    "type": "string",
+                    "example": "totp"
+                },
+                "expires_in": {
+                    "description": "время жизни JWT в секундах",
+                    "type": "integer",
+                    "example": 900
+                },
+                "mfa_required": {
+                    "description": "MFARequired сообщает, что вход нужно завершить кодом второго фактора по challenge_token",
+                    "type": "boolean"
+                },
+                "recovery_codes": {
+                    "description": "RecoveryCodes возвращаются один раз при подключении 2FA во время входа",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "refresh_token": {
+                    "description": "одноразовый, заменяется при каждом обновлении",
+                    "type": "string",
+                    "example": "msr_5f2c..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
                 }
             }
         },
-        "models.CreateActionRequest": {
+        "models.MFAStatusResponse": {
             "type": "object",
             "properties": {
-                "agent_id": {
-                    "type": "string"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "payload": {
-                    "type": "object",
-                    "additionalProperties": true
+                "recovery_codes_left": {
+                    "type": "integer"
                 },
-                "type": {
-                    "type": "string"
+                "required": {
+                    "description": "2FA обязательна для роли пользователя",
+                    "type": "boolean"
                 }
             }
         },
-        "models.CreateAgentRequest": {
-            "description": "Запрос на создание нового агента мониторинга",
+        "models.MemoryInfo": {
             "type": "object",
             "properties": {
-                "name": {
-                    "type": "string",
-                    "example": "Production Server 1"
+                "ram": {
+                    "$ref": "#/definitions/models.RAMInfo"
+                },
+                "swap": {
+                    "$ref": "#/definitions/models.SwapInfo"
                 }
             }
         },
-        "models.CreateDomainRequest": {
+        "models.MemoryMetricCurrent": {
             "type": "object",
             "properties": {
-                "agent_id": {
-                    "type": "string"
+                "ram_percent": {
+                    "type": "number"
                 },
-                "name": {
-                    "type": "string",
-                    "example": "dashboard.domain.net"
+                "ram_total": {
+                    "type": "integer"
                 },
-                "ssl_enabled": {
-                    "type": "boolean",
-                    "example": false
+                "ram_usage": {
+                    "type": "integer"
+                },
+                "swap_percent": {
+                    "type": "number"
+                },
+                "swap_total": {
+                    "type": "integer"
+                },
+                "swap_usage": {
+                    "type": "integer"
                 }
             }
         },
-        "models.CreateDomainRouteRequest": {
+        "models.Metrics": {
             "type": "object",
             "properties": {
-                "container_name": {
-                    "type": "string",
-                    "example": "my-container"
+                "cpu": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CPUInfo"
+                    }
                 },
-                "domain_id": {
-                    "type": "string"
+                "disk": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DiskInfo"
+                    }
                 },
-                "path": {
-                    "type": "string",
-                    "example": "/api"
+                "filesystems": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FilesystemInfo"
+                    }
                 },
-                "port": {
-                    "type": "string",
-                    "example": "3000"
+                "host": {
+                    "description": "nil — агент старой версии или нагрузку хоста собрать не удалось",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.HostMetricsInfo"
+                        }
+                    ]
+                },
+                "memory": {
+                    "$ref": "#/definitions/models.MemoryInfo"
+                },
+                "network": {
+                    "$ref": "#/definitions/models.NetworkInfo"
                 }
             }
         },
-        "models.DashboardData": {
+        "models.NetworkInfo": {
             "type": "object",
             "properties": {
-                "agents": {
+                "interfaces": {
+                    "description": "Счетчики по интерфейсам хоста; итоговые счетчики — их сумма",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Agent"
+                        "$ref": "#/definitions/models.InterfaceInfo"
                     }
                 },
-                "offline_agents": {
+                "packets_received": {
                     "type": "integer"
                 },
-                "online_agents": {
+                "packets_sent": {
+                    "description": "nil — агент старой версии не присылает счетчики пакетов",
                     "type": "integer"
                 },
-                "recent_metrics": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.RecentMetric"
-                    }
+                "public_ip": {
+                    "type": "string"
                 },
-                "system_overview": {
-                    "$ref": "#/definitions/models.SystemOverview"
+                "received": {
+                    "type": "integer"
                 },
-                "total_agents": {
+                "sent": {
                     "type": "integer"
                 }
             }
         },
-        "models.DiskInfo": {
+        "models.NetworkInterfaceMetricCurrent": {
             "type": "object",
             "properties": {
+                "drops_in": {
+                    "type": "integer"
+                },
+                "drops_out": {
+                    "type": "integer"
+                },
+                "errors_in": {
+                    "type": "integer"
+                },
+                "errors_out": {
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
-                "read_bytes": {
+                "packets_received": {
                     "type": "integer"
                 },
-                "reads": {
+                "packets_received_speed": {
+                    "type": "number"
+                },
+                "packets_sent": {
                     "type": "integer"
                 },
-                "write_bytes": {
+                "packets_sent_speed": {
+                    "type": "number"
+                },
+                "received_bytes": {
                     "type": "integer"
                 },
-                "writes": {
+                "received_speed": {
+                    "type": "integer"
+                },
+                "sent_bytes": {
+                    "type": "integer"
+                },
+                "sent_speed": {
                     "type": "integer"
                 }
             }
         },
-        "models.DiskMetricCurrent": {
+        "models.NetworkMetricCurrent": {
             "type": "object",
             "properties": {
-                "name": {
+                "interfaces": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NetworkInterfaceMetricCurrent"
+                    }
+                },
+                "packets_received_speed": {
+                    "type": "number"
+                },
+                "packets_sent_speed": {
+                    "type": "number"
+                },
+                "public_ip": {
                     "type": "string"
                 },
-                "read_bytes": {
+                "received_bytes": {
                     "type": "integer"
                 },
-                "read_speed": {
+                "received_speed": {
                     "type": "integer"
                 },
-                "write_bytes": {
+                "sent_bytes": {
                     "type": "integer"
                 },
-                "write_speed": {
+                "sent_speed": {
                     "type": "integer"
                 }
             }
         },
-        "models.DockerInfo": {
+        "models.NginxConfig": {
             "type": "object",
             "properties": {
-                "containers": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ContainerInfo"
-                    }
+                "agent_ip": {
+                    "type": "string"
                 },
-                "images": {
+                "domain": {
+                    "type": "string"
+                },
+                "routes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.ImageInfo"
+                        "$ref": "#/definitions/models.NginxRoute"
                     }
+                },
+                "ssl_cert": {
+                    "$ref": "#/definitions/models.SSLCertificate"
+                },
+                "ssl_enabled": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.Domain": {
+        "models.NginxRoute": {
             "type": "object",
             "properties": {
-                "agent_id": {
-                    "description": "ID агента, на котором размещен домен",
-                    "type": "string"
-                },
-                "agent_ip": {
-                    "description": "IP адрес агента",
-                    "type": "string"
-                },
-                "agent_name": {
-                    "description": "Дополнительные поля для совместимости с frontend",
-                    "type": "string"
-                },
-                "created": {
+                "container_name": {
+                    "description": "имя контейнера",
                     "type": "string"
                 },
-                "id": {
+                "path": {
+                    "description": "например: \"/\" или \"/api\"",
                     "type": "string"
                 },
-                "is_active": {
-                    "description": "активен ли домен",
-                    "type": "boolean"
-                },
-                "name": {
-                    "description": "например: \"dashboard.domain.net\"",
+                "port": {
+                    "description": "порт контейнера",
                     "type": "string"
-                },
-                "ssl_enabled": {
-                    "description": "включен ли SSL",
+                }
+            }
+        },
+        "models.NotificationConfig": {
+            "type": "object",
+            "properties": {
+                "enabled": {
                     "type": "boolean"
                 },
-                "updated": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "models.DomainDetail": {
+        "models.NotificationConfigurations": {
             "type": "object",
             "properties": {
-                "agent_id": {
-                    "description": "ID агента, на котором размещен домен",
-                    "type": "string"
+                "agent_offline": {
+                    "$ref": "#/definitions/models.NotificationConfig"
                 },
-                "agent_ip": {
-                    "description": "IP адрес агента",
-                    "type": "string"
+                "container_stopped": {
+                    "$ref": "#/definitions/models.NotificationConfig"
                 },
-                "agent_name": {
-                    "description": "Дополнительные поля для совместимости с frontend",
-                    "type": "string"
+                "cpu_threshold": {
+                    "$ref": "#/definitions/models.CPUThresholdConfig"
                 },
-                "created": {
-                    "type": "string"
+                "disk_threshold": {
+                    "$ref": "#/definitions/models.DiskThresholdConfig"
                 },
-                "id": {
-                    "type": "string"
+                "ram_threshold": {
+                    "$ref": "#/definitions/models.RAMThresholdConfig"
+                }
+            }
+        },
+        "models.NotificationSettings": {
+            "type": "object",
+            "properties": {
+                "email_settings": {
+                    "$ref": "#/definitions/models.EmailSettings"
                 },
-                "is_active": {
-                    "description": "активен ли домен",
-                    "type": "boolean"
+                "notifications": {
+                    "$ref": "#/definitions/models.NotificationConfigurations"
                 },
-                "name": {
-                    "description": "например: \"dashboard.domain.net\"",
+                "telegram_bot_token": {
                     "type": "string"
                 },
-                "routes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.DomainRoute"
-                    }
-                },
-                "ssl_enabled": {
-                    "description": "включен ли SSL",
-                    "type": "boolean"
-                },
-                "updated": {
+                "telegram_chat_id": {
                     "type": "string"
                 }
             }
         },
-        "models.DomainListResponse": {
+        "models.OIDCStatusResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.RAMInfo": {
             "type": "object",
             "properties": {
-                "domains": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.DomainDetail"
-                    }
-                },
                 "total": {
                     "type": "integer"
+                },
+                "usage": {
+                    "type": "integer"
                 }
             }
         },
-        "models.DomainRoute": {
+        "models.RAMThresholdConfig": {
             "type": "object",
             "properties": {
-                "container_name": {
-                    "description": "имя контейнера",
-                    "type": "string"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "created": {
+                "message": {
                     "type": "string"
                 },
-                "domain_id": {
+                "threshold": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RecentMetric": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
                     "type": "string"
                 },
-                "id": {
+                "agent_name": {
                     "type": "string"
                 },
-                "is_active": {
-                    "type": "boolean"
+                "cpu_usage": {
+                    "type": "number"
                 },
-                "path": {
-                    "description": "путь (опционально, для /api/*)",
+                "public_ip": {
                     "type": "string"
                 },
-                "port": {
-                    "description": "порт контейнера",
-                    "type": "string"
+                "ram_usage": {
+                    "type": "number"
                 },
-                "updated": {
+                "timestamp": {
                     "type": "string"
                 }
             }
         },
-        "models.DomainRouteListResponse": {
+        "models.RecoveryCodesResponse": {
             "type": "object",
             "properties": {
-                "routes": {
+                "recovery_codes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.DomainRoute"
-                    }
-                },
-                "total": {
-                    "type": "integer"
+                        "type": "string"
+                    },
+                    "example": [
+                        "3f9a1-0c2b7"
+                    ]
                 }
             }
         },
-        "models.DomainStatus": {
+        "models.RefreshRequest": {
             "type": "object",
             "properties": {
-                "agent_id": {
-                    "type": "string"
-                },
-                "agent_ip": {
-                    "type": "string"
-                },
-                "agent_name": {
-                    "type": "string"
-                },
-                "domain_id": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "msr_5f2c..."
+                }
+            }
+        },
+        "models.RotateAgentTokenRequest": {
+            "type": "object",
+            "properties": {
+                "grace_period_hours": {
+                    "description": "GracePeriodHours — сколько часов принимается старый токен; 0 — 24 часа, максимум 168",
+                    "type": "integer",
+                    "example": 24
+                }
+            }
+        },
+        "models.RotateAgentTokenResponse": {
+            "type": "object",
+            "properties": {
+                "action_id": {
+                    "description": "действие rotate_token, которое доставит токен агенту",
                     "type": "string"
                 },
-                "domain_name": {
+                "previous_token_expires": {
+                    "description": "до этого времени принимается и старый токен",
                     "type": "string"
                 },
-                "is_active": {
-                    "type": "boolean"
-                },
-                "routes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.RouteStatus"
-                    }
+                "token": {
+                    "description": "Token показывается один раз — для ручной настройки агентов, не поддерживающих rotate_token",
+                    "type": "string",
+                    "example": "msa_1a2b3c4d..."
                 },
-                "ssl_enabled": {
-                    "type": "boolean"
+                "token_prefix": {
+                    "type": "string",
+                    "example": "msa_1a2b3c4d"
                 }
             }
         },
-        "models.EmailSettings": {
+        "models.RouteStatus": {
             "type": "object",
             "properties": {
-                "enabled": {
-                    "type": "boolean"
-                },
-                "from_email": {
+                "container_name": {
                     "type": "string"
                 },
-                "from_name": {
+                "container_status": {
+                    "description": "running, stopped, etc.",
                     "type": "string"
                 },
-                "password": {
-                    "type": "string"
+                "is_active": {
+                    "type": "boolean"
                 },
-                "smtp_host": {
+                "path": {
                     "type": "string"
                 },
-                "smtp_port": {
-                    "type": "integer"
-                },
-                "to_emails": {
-                    "description": "Список email через запятую",
+                "port": {
                     "type": "string"
                 },
-                "use_start_tls": {
-                    "type": "boolean"
-                },
-                "use_tls": {
-                    "type": "boolean"
-                },
-                "username": {
+                "route_id": {
                     "type": "string"
                 }
             }
         },
-        "models.ImageDetail": {
+        "models.SSLCertificate": {
             "type": "object",
             "properties": {
-                "agent": {
-                    "$ref": "#/definitions/models.Agent"
-                },
-                "architecture": {
-                    "type": "string"
-                },
-                "created": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "image_id": {
+                "private_key": {
                     "type": "string"
                 },
-                "ping_id": {
+                "public_key": {
                     "type": "string"
-                },
-                "size": {
-                    "type": "integer"
-                },
-                "tags": {
+                }
+            }
+        },
+        "models.ScheduleListResponse": {
+            "type": "object",
+            "properties": {
+                "schedules": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.ActionSchedule"
                     }
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.ImageInfo": {
+        "models.Session": {
             "type": "object",
             "properties": {
-                "architecture": {
+                "created": {
                     "type": "string"
                 },
-                "created": {
+                "current": {
+                    "description": "сессия, от имени которой выполнен запрос",
+                    "type": "boolean"
+                },
+                "expires": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "size": {
-                    "type": "integer"
+                "ip": {
+                    "type": "string"
                 },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "last_used": {
+                    "description": "время последнего обновления токенов",
+                    "type": "string"
+                },
+                "revoked": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "models.ImageListResponse": {
+        "models.SessionListResponse": {
             "type": "object",
             "properties": {
-                "images": {
+                "sessions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.ImageDetail"
+                        "$ref": "#/definitions/models.Session"
                     }
                 },
                 "total": {
@@ -2311,408 +7750,530 @@ const docTemplate = `{
                 }
             }
         },
-        "models.LoginRequest": {
-            "description": "Запрос на аутентификацию пользователя",
+        "models.SetPasswordRequest": {
             "type": "object",
             "properties": {
                 "password": {
-                    "type": "string",
-                    "example": "admin123"
-                },
-                "username": {
-                    "type": "string",
-                    "example": "admin"
+                    "type": "string"
                 }
             }
         },
-        "models.LoginResponse": {
-            "description": "Ответ с JWT токеном и информацией о пользователе",
+        "models.StartWorkflowRunRequest": {
             "type": "object",
             "properties": {
-                "token": {
-                    "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                "agent_id": {
+                    "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.MemoryInfo": {
+        "models.SwapInfo": {
             "type": "object",
             "properties": {
-                "ram": {
-                    "$ref": "#/definitions/models.RAMInfo"
+                "total": {
+                    "type": "integer"
                 },
-                "swap": {
-                    "$ref": "#/definitions/models.SwapInfo"
+                "usage": {
+                    "type": "integer"
                 }
             }
         },
-        "models.MemoryMetricCurrent": {
+        "models.SystemMetric": {
             "type": "object",
             "properties": {
-                "ram_percent": {
+                "cpu_iowait_percent": {
                     "type": "number"
                 },
-                "ram_total": {
-                    "type": "integer"
+                "cpu_steal_percent": {
+                    "type": "number"
                 },
-                "ram_usage": {
+                "cpu_usage": {
+                    "type": "number"
+                },
+                "disk_iops": {
+                    "type": "number"
+                },
+                "disk_read": {
                     "type": "integer"
                 },
-                "swap_percent": {
+                "disk_read_speed": {
+                    "description": "Скорости в байтах, операциях и пакетах в секунду с прошлого пинга;\nnil для первого пинга и после сброса счетчиков",
                     "type": "number"
                 },
-                "swap_total": {
+                "disk_write": {
                     "type": "integer"
                 },
-                "swap_usage": {
+                "disk_write_speed": {
+                    "type": "number"
+                },
+                "load1": {
+                    "description": "Нагрузка хоста; nil для агентов старых версий",
+                    "type": "number"
+                },
+                "network_packets_received_speed": {
+                    "type": "number"
+                },
+                "network_packets_sent_speed": {
+                    "type": "number"
+                },
+                "network_received": {
                     "type": "integer"
-                }
-            }
-        },
-        "models.Metrics": {
-            "type": "object",
-            "properties": {
-                "cpu": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.CPUInfo"
-                    }
                 },
-                "disk": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.DiskInfo"
-                    }
+                "network_received_speed": {
+                    "type": "number"
                 },
-                "memory": {
-                    "$ref": "#/definitions/models.MemoryInfo"
+                "network_sent": {
+                    "type": "integer"
+                },
+                "network_sent_speed": {
+                    "type": "number"
                 },
-                "network": {
-                    "$ref": "#/definitions/models.NetworkInfo"
-                }
-            }
-        },
-        "models.NetworkInfo": {
-            "type": "object",
-            "properties": {
                 "public_ip": {
                     "type": "string"
                 },
-                "received": {
-                    "type": "integer"
+                "ram_usage": {
+                    "type": "number"
                 },
-                "sent": {
-                    "type": "integer"
+                "timestamp": {
+                    "type": "string"
                 }
             }
         },
-        "models.NetworkMetricCurrent": {
+        "models.SystemOverview": {
             "type": "object",
             "properties": {
-                "public_ip": {
-                    "type": "string"
-                },
-                "received_bytes": {
+                "running_containers": {
                     "type": "integer"
                 },
-                "received_speed": {
+                "total_containers": {
                     "type": "integer"
                 },
-                "sent_bytes": {
+                "total_cpu_cores": {
                     "type": "integer"
                 },
-                "sent_speed": {
+                "total_ram_mb": {
                     "type": "integer"
                 }
             }
         },
-        "models.NginxConfig": {
+        "models.TOTPCodeRequest": {
             "type": "object",
             "properties": {
-                "agent_ip": {
-                    "type": "string"
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "models.TOTPEnrollment": {
+            "type": "object",
+            "properties": {
+                "otpauth_url": {
+                    "description": "для QR-кода",
+                    "type": "string",
+                    "example": "otpauth://totp/Monitoring%20System:admin?secret=JBSWY3DPEHPK3PXP\u0026issuer=Monitoring%20System"
                 },
-                "domain": {
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "models.UpdateDomainRequest": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
                     "type": "string"
                 },
-                "routes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.NginxRoute"
-                    }
+                "is_active": {
+                    "type": "boolean"
                 },
-                "ssl_cert": {
-                    "$ref": "#/definitions/models.SSLCertificate"
+                "name": {
+                    "type": "string"
                 },
                 "ssl_enabled": {
                     "type": "boolean"
                 }
             }
         },
-        "models.NginxRoute": {
+        "models.UpdateDomainRouteRequest": {
             "type": "object",
             "properties": {
                 "container_name": {
-                    "description": "имя контейнера",
                     "type": "string"
                 },
+                "is_active": {
+                    "type": "boolean"
+                },
                 "path": {
-                    "description": "например: \"/\" или \"/api\"",
                     "type": "string"
                 },
                 "port": {
-                    "description": "порт контейнера",
                     "type": "string"
                 }
             }
         },
-        "models.NotificationConfig": {
+        "models.UpdateProfileRequest": {
             "type": "object",
             "properties": {
-                "enabled": {
-                    "type": "boolean"
-                },
-                "message": {
+                "email": {
                     "type": "string"
                 }
             }
         },
-        "models.NotificationConfigurations": {
-            "type": "object",
-            "properties": {
-                "agent_offline": {
-                    "$ref": "#/definitions/models.NotificationConfig"
-                },
-                "container_stopped": {
-                    "$ref": "#/definitions/models.NotificationConfig"
-                },
-                "cpu_threshold": {
-                    "$ref": "#/definitions/models.CPUThresholdConfig"
-                },
-                "ram_threshold": {
-                    "$ref": "#/definitions/models.RAMThresholdConfig"
-                }
-            }
-        },
-        "models.NotificationSettings": {
+        "models.UpdateScheduleRequest": {
             "type": "object",
             "properties": {
-                "email_settings": {
-                    "$ref": "#/definitions/models.EmailSettings"
+                "cron_expr": {
+                    "type": "string"
                 },
-                "notifications": {
-                    "$ref": "#/definitions/models.NotificationConfigurations"
+                "is_paused": {
+                    "type": "boolean"
                 },
-                "telegram_bot_token": {
+                "name": {
                     "type": "string"
                 },
-                "telegram_chat_id": {
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "run_at": {
                     "type": "string"
                 }
             }
         },
-        "models.RAMInfo": {
+        "models.UpdateUserRequest": {
             "type": "object",
             "properties": {
-                "total": {
-                    "type": "integer"
+                "agent_ids": {
+                    "description": "AgentIDs заменяет список доступных агентов; пустой список снимает ограничение",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "usage": {
-                    "type": "integer"
+                "email": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "description": "false — деактивация, токены пользователя перестают действовать сразу",
+                    "type": "boolean"
+                },
+                "role": {
+                    "type": "string"
                 }
             }
         },
-        "models.RAMThresholdConfig": {
+        "models.UpdateWorkflowRequest": {
             "type": "object",
             "properties": {
-                "enabled": {
-                    "type": "boolean"
+                "description": {
+                    "type": "string"
                 },
-                "message": {
+                "name": {
                     "type": "string"
                 },
-                "threshold": {
-                    "type": "integer"
+                "steps": {
+                    "description": "если указаны, заменяют все шаги",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WorkflowStepRequest"
+                    }
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.RecentMetric": {
+        "models.User": {
             "type": "object",
             "properties": {
-                "agent_id": {
+                "agent_ids": {
+                    "description": "агенты, доступные пользователю; пустой список — все агенты",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "auth_provider": {
+                    "description": "local или oidc",
                     "type": "string"
                 },
-                "agent_name": {
+                "created": {
                     "type": "string"
                 },
-                "cpu_usage": {
-                    "type": "number"
+                "email": {
+                    "type": "string"
                 },
-                "public_ip": {
+                "id": {
                     "type": "string"
                 },
-                "ram_usage": {
-                    "type": "number"
+                "is_active": {
+                    "type": "boolean"
                 },
-                "timestamp": {
+                "last_login": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "totp_enabled": {
+                    "description": "включена двухфакторная аутентификация",
+                    "type": "boolean"
+                },
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "models.RouteStatus": {
+        "models.UserListResponse": {
             "type": "object",
             "properties": {
-                "container_name": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 },
-                "container_status": {
-                    "description": "running, stopped, etc.",
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                }
+            }
+        },
+        "models.Workflow": {
+            "type": "object",
+            "properties": {
+                "created": {
                     "type": "string"
                 },
-                "is_active": {
-                    "type": "boolean"
+                "description": {
+                    "type": "string"
                 },
-                "path": {
+                "id": {
                     "type": "string"
                 },
-                "port": {
+                "name": {
                     "type": "string"
                 },
-                "route_id": {
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WorkflowStep"
+                    }
+                },
+                "updated": {
                     "type": "string"
+                },
+                "variables": {
+                    "description": "значения переменных по умолчанию",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.SSLCertificate": {
+        "models.WorkflowCompensation": {
             "type": "object",
             "properties": {
-                "private_key": {
-                    "type": "string"
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
                 },
-                "public_key": {
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "models.SwapInfo": {
+        "models.WorkflowListResponse": {
             "type": "object",
             "properties": {
                 "total": {
                     "type": "integer"
                 },
-                "usage": {
-                    "type": "integer"
+                "workflows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Workflow"
+                    }
                 }
             }
         },
-        "models.SystemMetric": {
+        "models.WorkflowRun": {
             "type": "object",
             "properties": {
-                "cpu_usage": {
-                    "type": "number"
+                "agent_id": {
+                    "type": "string"
                 },
-                "disk_read": {
-                    "type": "integer"
+                "completed": {
+                    "type": "string"
                 },
-                "disk_write": {
-                    "type": "integer"
+                "created": {
+                    "type": "string"
                 },
-                "network_received": {
-                    "type": "integer"
+                "error": {
+                    "type": "string"
                 },
-                "network_sent": {
-                    "type": "integer"
+                "id": {
+                    "type": "string"
                 },
-                "public_ip": {
+                "started_by": {
                     "type": "string"
                 },
-                "ram_usage": {
-                    "type": "number"
+                "status": {
+                    "type": "string"
                 },
-                "timestamp": {
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WorkflowRunStep"
+                    }
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "workflow_id": {
+                    "type": "string"
+                },
+                "workflow_name": {
                     "type": "string"
                 }
             }
         },
-        "models.SystemOverview": {
+        "models.WorkflowRunListResponse": {
             "type": "object",
             "properties": {
-                "running_containers": {
-                    "type": "integer"
-                },
-                "total_containers": {
-                    "type": "integer"
-                },
-                "total_cpu_cores": {
-                    "type": "integer"
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WorkflowRun"
+                    }
                 },
-                "total_ram_mb": {
+                "total": {
                     "type": "integer"
                 }
             }
         },
-        "models.UpdateDomainRequest": {
+        "models.WorkflowRunStep": {
             "type": "object",
             "properties": {
-                "agent_id": {
+                "action_id": {
                     "type": "string"
                 },
-                "is_active": {
+                "completed": {
+                    "type": "string"
+                },
+                "depends_on": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_compensation": {
                     "type": "boolean"
                 },
                 "name": {
                     "type": "string"
                 },
-                "ssl_enabled": {
-                    "type": "boolean"
-                }
-            }
-        },
-        "models.UpdateDomainRouteRequest": {
-            "type": "object",
-            "properties": {
-                "container_name": {
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "response": {
+                    "type": "object"
+                },
+                "run_id": {
                     "type": "string"
                 },
-                "is_active": {
-                    "type": "boolean"
+                "started": {
+                    "type": "string"
                 },
-                "path": {
+                "status": {
                     "type": "string"
                 },
-                "port": {
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "models.User": {
+        "models.WorkflowStep": {
             "type": "object",
             "properties": {
-                "created": {
-                    "type": "string"
+                "compensation": {
+                    "$ref": "#/definitions/models.WorkflowCompensation"
                 },
-                "email": {
-                    "type": "string"
+                "depends_on": {
+                    "description": "имена шагов-предшественников",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "id": {
                     "type": "string"
                 },
-                "is_active": {
-                    "type": "boolean"
-                },
-                "last_login": {
+                "name": {
+                    "description": "уникальное имя шага в сценарии",
                     "type": "string"
                 },
-                "role": {
+                "payload": {
+                    "description": "может содержать {{переменные}}",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "type": {
                     "type": "string"
                 },
-                "username": {
+                "workflow_id": {
                     "type": "string"
                 }
             }
+        },
+        "models.WorkflowStepRequest": {
+            "type": "object",
+            "properties": {
+                "compensation": {
+                    "$ref": "#/definitions/models.WorkflowCompensation"
+                },
+                "depends_on": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "pull"
+                },
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string",
+                    "example": "pull_image"
+                }
+            }
         }
     },
     "securityDefinitions": {
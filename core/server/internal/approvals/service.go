@@ -0,0 +1,268 @@
+package approvals
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"monitoring-system/core/server/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidPolicy возвращается при некорректных параметрах политики
+	ErrInvalidPolicy = errors.New("invalid approval policy")
+	// ErrNotAwaiting возвращается, если действие не ожидает подтверждения
+	ErrNotAwaiting = errors.New("action is not awaiting approval")
+	// ErrSelfApproval возвращается при попытке подтвердить собственное действие
+	ErrSelfApproval = errors.New("action must be approved by a different user")
+	// ErrReasonRequired возвращается, если решение принято без указания причины
+	ErrReasonRequired = errors.New("reason is required")
+)
+
+// Service управляет политиками подтверждения и решениями по удержанным действиям
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Querier — общий интерфейс для *sql.DB и *sql.Tx
+type Querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// InsertAction создает действие с учетом политик подтверждения. Если под действие
+// подпадает политика, оно создается в статусе awaiting_approval и не выдается агенту
+// до подтверждения. createdBy — автор действия (nil для системных действий).
+// Возвращает ID и статус созданного действия.
+func InsertAction(q Querier, agentID uuid.UUID, actionType string, payloadJSON []byte, createdBy *uuid.UUID) (uuid.UUID, string, error) {
+	var payload struct {
+		Force bool `json:"force"`
+	}
+	// Payload может не содержать force или иметь другую структуру — это не ошибка
+	_ = json.Unmarshal(payloadJSON, &payload)
+
+	var policyID uuid.UUID
+	err := q.QueryRow(`
+		SELECT id FROM approval_policies
+		WHERE action_type = $1
+			AND (agent_id IS NULL OR agent_id = $2)
+			AND (force_only = false OR $3)
+		ORDER BY agent_id NULLS LAST
+		LIMIT 1
+	`, actionType, agentID, payload.Force).Scan(&policyID)
+	if err != nil && err != sql.ErrNoRows {
+		return uuid.Nil, "", fmt.Errorf("failed to check approval policies: %v", err)
+	}
+
+	var actionID uuid.UUID
+	if err == sql.ErrNoRows {
+		err = q.QueryRow(`
+			INSERT INTO actions (agent_id, type, payload, status, created, created_by)
+			VALUES ($1, $2, $3, $4, now(), $5)
+			RETURNING id
+		`, agentID, actionType, payloadJSON, models.ActionStatusPending, createdBy).Scan(&actionID)
+		if err != nil {
+			return uuid.Nil, "", fmt.Errorf("failed to create action: %v", err)
+		}
+		return actionID, models.ActionStatusPending, nil
+	}
+
+	// Действие и запрос на подтверждение создаются одним запросом
+	err = q.QueryRow(`
+		WITH a AS (
+			INSERT INTO actions (agent_id, type, payload, status, created, created_by)
+			VALUES ($1, $2, $3, $4, now(), $6)
+			RETURNING id
+		)
+		INSERT INTO action_approvals (action_id, policy_id, requested_by)
+		SELECT id, $5, $6 FROM a
+		RETURNING action_id
+	`, agentID, actionType, payloadJSON, models.ActionStatusAwaitingApproval, policyID, createdBy).Scan(&actionID)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("failed to create action: %v", err)
+	}
+
+	return actionID, models.ActionStatusAwaitingApproval, nil
+}
+
+// GetPolicies получает список политик подтверждения
+func (s *Service) GetPolicies() ([]models.ApprovalPolicy, error) {
+	rows, err := s.db.Query(`
+		SELECT p.id, p.action_type, p.agent_id, p.force_only, p.description, p.created, a.name
+		FROM approval_policies p
+		LEFT JOIN agents a ON p.agent_id = a.id
+		ORDER BY p.action_type, p.created
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval policies: %v", err)
+	}
+	defer rows.Close()
+
+	policies := []models.ApprovalPolicy{}
+	for rows.Next() {
+		var policy models.ApprovalPolicy
+		err := rows.Scan(
+			&policy.ID, &policy.ActionType, &policy.AgentID, &policy.ForceOnly,
+			&policy.Description, &policy.Created, &policy.AgentName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval policy: %v", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+// CreatePolicy создает политику подтверждения
+func (s *Service) CreatePolicy(req *models.CreateApprovalPolicyRequest) (*models.ApprovalPolicy, error) {
+	if req.ActionType == "" {
+		return nil, fmt.Errorf("%w: action_type is required", ErrInvalidPolicy)
+	}
+
+	policy := &models.ApprovalPolicy{}
+	err := s.db.QueryRow(`
+		INSERT INTO approval_policies (action_type, agent_id, force_only, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, action_type, agent_id, force_only, description, created
+	`, req.ActionType, req.AgentID, req.ForceOnly, req.Description).Scan(
+		&policy.ID, &policy.ActionType, &policy.AgentID, &policy.ForceOnly, &policy.Description, &policy.Created,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval policy: %v", err)
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy удаляет политику подтверждения. Уже удержанные действия
+// продолжают ждать решения.
+func (s *Service) DeletePolicy(id uuid.UUID) error {
+	_, err := s.db.Exec("DELETE FROM approval_policies WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete approval policy: %v", err)
+	}
+	return nil
+}
+
+// GetApprovals получает список запросов на подтверждение.
+// Если pendingOnly = true, возвращаются только ожидающие решения.
+func (s *Service) GetApprovals(pendingOnly bool) ([]models.ActionApproval, error) {
+	query := `
+		SELECT ap.id, ap.action_id, ap.policy_id, ap.requested_by, ap.decision, ap.decided_by,
+			ap.reason, ap.created, ap.decided,
+			a.id, a.agent_id, a.type, a.payload, a.status, a.created, a.completed, a.response, a.error, a.created_by,
+			ag.name, ru.username, du.username
+		FROM action_approvals ap
+		JOIN actions a ON ap.action_id = a.id
+		JOIN agents ag ON a.agent_id = ag.id
+		LEFT JOIN users ru ON ap.requested_by = ru.id
+		LEFT JOIN users du ON ap.decided_by = du.id`
+	if pendingOnly {
+		query += " WHERE ap.decision IS NULL"
+	}
+	query += " ORDER BY ap.created DESC"
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvals: %v", err)
+	}
+	defer rows.Close()
+
+	approvals := []models.ActionApproval{}
+	for rows.Next() {
+		var approval models.ActionApproval
+		var payloadJSON []byte
+		err := rows.Scan(
+			&approval.ID, &approval.ActionID, &approval.PolicyID, &approval.RequestedBy, &approval.Decision,
+			&approval.DecidedBy, &approval.Reason, &approval.Created, &approval.Decided,
+			&approval.Action.ID, &approval.Action.AgentID, &approval.Action.Type, &payloadJSON,
+			&approval.Action.Status, &approval.Action.Created, &approval.Action.Completed,
+			&approval.Action.Response, &approval.Action.Error, &approval.Action.CreatedBy,
+			&approval.AgentName, &approval.RequestedByUsername, &approval.DecidedByUsername,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %v", err)
+		}
+
+		if err := json.Unmarshal(payloadJSON, &approval.Action.Payload); err != nil {
+			return nil, fmt.Errorf("failed to parse action payload: %v", err)
+		}
+
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, nil
+}
+
+// Decide подтверждает или отклоняет удержанное действие. Подтвержденное действие
+// переходит в статус pending и будет выдано агенту, отклоненное — в статус rejected.
+func (s *Service) Decide(actionID, userID uuid.UUID, approve bool, reason string) error {
+	if reason == "" {
+		return ErrReasonRequired
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var approvalID uuid.UUID
+	var requestedBy *uuid.UUID
+	var actionStatus string
+	err = tx.QueryRow(`
+		SELECT ap.id, ap.requested_by, a.status
+		FROM action_approvals ap
+		JOIN actions a ON ap.action_id = a.id
+		WHERE ap.action_id = $1 AND ap.decision IS NULL
+		FOR UPDATE OF ap, a
+	`, actionID).Scan(&approvalID, &requestedBy, &actionStatus)
+	if err == sql.ErrNoRows || (err == nil && actionStatus != models.ActionStatusAwaitingApproval) {
+		return ErrNotAwaiting
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get approval: %v", err)
+	}
+
+	if requestedBy != nil && *requestedBy == userID {
+		return ErrSelfApproval
+	}
+
+	decision := models.ApprovalDecisionRejected
+	if approve {
+		decision = models.ApprovalDecisionApproved
+	}
+
+	_, err = tx.Exec(`
+		UPDATE action_approvals
+		SET decision = $1, decided_by = $2, reason = $3, decided = now()
+		WHERE id = $4
+	`, decision, userID, reason, approvalID)
+	if err != nil {
+		return fmt.Errorf("failed to update approval: %v", err)
+	}
+
+	if approve {
+		_, err = tx.Exec("UPDATE actions SET status = $1 WHERE id = $2", models.ActionStatusPending, actionID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE actions SET status = $1, completed = now(), error = $2 WHERE id = $3
+		`, models.ActionStatusRejected, "Rejected: "+reason, actionID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update action: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,324 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// redactedValue подставляется вместо значений секретных полей
+const redactedValue = "***"
+
+// sensitiveKeys — части имен полей, значения которых не попадают в журнал
+var sensitiveKeys = []string{"password", "token", "secret"}
+
+// trustedProxies — адреса reverse proxy, которым разрешено передавать IP клиента в заголовках
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies задает адреса reverse proxy: IP или подсети в нотации CIDR.
+// Заголовки X-Real-IP и X-Forwarded-For от остальных адресов игнорируются, иначе
+// клиент мог бы подменить свой IP в журнале аудита и обойти блокировку входа.
+func SetTrustedProxies(proxies []string) error {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %v", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	trustedProxies = networks
+	return nil
+}
+
+// trustedProxy проверяет, что адрес принадлежит доверенному reverse proxy
+func trustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Service ведет журнал аудита изменяющих API-вызовов
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// ClientIP возвращает IP клиента. Заголовки reverse proxy учитываются, только если
+// запрос пришел от доверенного прокси. В X-Forwarded-For берется последний адрес
+// перед цепочкой доверенных прокси: адреса левее мог подставить сам клиент.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !trustedProxy(host) {
+		return host
+	}
+
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		addresses := strings.Split(forwarded, ",")
+		for i := len(addresses) - 1; i >= 0; i-- {
+			address := strings.TrimSpace(addresses[i])
+			if address != "" && (i == 0 || !trustedProxy(address)) {
+				return address
+			}
+		}
+	}
+	return host
+}
+
+// toMap приводит значение к JSON-объекту и скрывает секретные поля
+func toMap(value interface{}) map[string]interface{} {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+
+	redact(result)
+	return result
+}
+
+// redact рекурсивно заменяет значения секретных полей
+func redact(m map[string]interface{}) {
+	for key, value := range m {
+		lower := strings.ToLower(key)
+		for _, sensitive := range sensitiveKeys {
+			if strings.Contains(lower, sensitive) {
+				if value != nil && value != "" {
+					m[key] = redactedValue
+				}
+				break
+			}
+		}
+		redactNested(m[key])
+	}
+}
+
+// redactNested скрывает секретные поля во вложенных объектах и массивах,
+// например в payload действий и шагах сценариев
+func redactNested(value interface{}) {
+	switch nested := value.(type) {
+	case map[string]interface{}:
+		redact(nested)
+	case []interface{}:
+		for _, item := range nested {
+			redactNested(item)
+		}
+	}
+}
+
+// diff вычисляет изменения между двумя состояниями, раскрывая вложенные объекты
+// в пути через точку
+func diff(before, after map[string]interface{}, prefix string, changes map[string]models.AuditChange) {
+	keys := make(map[string]bool)
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		oldValue, newValue := before[key], after[key]
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			diff(oldMap, newMap, prefix+key+".", changes)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[prefix+key] = models.AuditChange{Before: oldValue, After: newValue}
+		}
+	}
+}
+
+// Record записывает изменение в журнал аудита. Пользователь берется из JWT-контекста
+// запроса. Ошибка записи журнала логируется и не прерывает обработку запроса.
+func (s *Service) Record(r *http.Request, entityType, entityID, summary string, before, after interface{}) {
+	beforeMap, afterMap := toMap(before), toMap(after)
+	changes := make(map[string]models.AuditChange)
+	diff(beforeMap, afterMap, "", changes)
+
+	var userID, username interface{}
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		userID, username = claims.UserID, claims.Username
+	}
+
+	var entity interface{}
+	if entityID != "" {
+		entity = entityID
+	}
+
+	beforeJSON, err := marshalNullable(beforeMap)
+	if err != nil {
+		log.Printf("Error marshaling audit state: %v", err)
+		return
+	}
+	afterJSON, err := marshalNullable(afterMap)
+	if err != nil {
+		log.Printf("Error marshaling audit state: %v", err)
+		return
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		log.Printf("Error marshaling audit changes: %v", err)
+		return
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO audit_log (user_id, username, ip, method, path, entity_type, entity_id, summary, before, after, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, userID, username, ClientIP(r), r.Method, r.URL.Path, entityType, entity, summary, beforeJSON, afterJSON, changesJSON)
+	if err != nil {
+		log.Printf("Error writing audit log: %v", err)
+	}
+}
+
+// marshalNullable сериализует состояние в JSON; отсутствующее состояние сохраняется как NULL
+func marshalNullable(m map[string]interface{}) (interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetEntries получает записи журнала аудита с фильтрацией, новые первыми
+func (s *Service) GetEntries(filter models.AuditFilter) ([]models.AuditEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	argCount := 1
+
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argCount))
+		args = append(args, *filter.UserID)
+		argCount++
+	}
+	if filter.EntityType != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argCount))
+		args = append(args, filter.EntityType)
+		argCount++
+	}
+	if filter.EntityID != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", argCount))
+		args = append(args, filter.EntityID)
+		argCount++
+	}
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("created >= $%d", argCount))
+		args = append(args, *filter.From)
+		argCount++
+	}
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("created <= $%d", argCount))
+		args = append(args, *filter.To)
+		argCount++
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %v", err)
+	}
+
+	query := `
+		SELECT id, user_id, username, ip, method, path, entity_type, entity_id, summary, before, after, changes, created
+		FROM audit_log` + where + fmt.Sprintf(" ORDER BY created DESC LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit entries: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var beforeJSON, afterJSON, changesJSON []byte
+		err := rows.Scan(
+			&entry.ID, &entry.UserID, &entry.Username, &entry.IP, &entry.Method, &entry.Path,
+			&entry.EntityType, &entry.EntityID, &entry.Summary, &beforeJSON, &afterJSON, &changesJSON, &entry.Created,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %v", err)
+		}
+
+		for _, field := range []struct {
+			data   []byte
+			target interface{}
+		}{
+			{beforeJSON, &entry.Before},
+			{afterJSON, &entry.After},
+			{changesJSON, &entry.Changes},
+		} {
+			if field.data == nil {
+				continue
+			}
+			if err := json.Unmarshal(field.data, field.target); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse audit entry: %v", err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
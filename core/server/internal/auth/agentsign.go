@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Заголовки подписанного запроса агента. Подпись — HMAC-SHA256 с ключом подписи агента
+// над строкой "METHOD\nPATH\nTIMESTAMP\nNONCE\nhex(SHA-256(тело))".
+const (
+	HeaderAgentID        = "X-Agent-ID"
+	HeaderAgentTimestamp = "X-Agent-Timestamp"
+	HeaderAgentNonce     = "X-Agent-Nonce"
+	HeaderAgentSignature = "X-Agent-Signature"
+	// HeaderAgentSigningKey передает агенту ключ подписи в ответ на запрос с Bearer токеном
+	HeaderAgentSigningKey = "X-Agent-Signing-Key"
+
+	// agentSignatureVersion — версия схемы подписи в заголовке X-Agent-Signature: v2=<hex>.
+	// Подписи v1 с ключом SHA-256(токен) не принимаются: этот ключ совпадал с token_hash в базе.
+	agentSignatureVersion = "v2="
+	// AgentSignatureMaxSkew — допустимое расхождение времени запроса и часов сервера.
+	// Одноразовые значения хранятся вдвое дольше, чтобы повтор не прошел ни в какой момент окна.
+	AgentSignatureMaxSkew = 5 * time.Minute
+	// agentNonceMaxLength ограничивает длину одноразового значения
+	agentNonceMaxLength = 64
+)
+
+var (
+	// ErrInvalidSignature возвращается для неверной или неполной подписи запроса агента
+	ErrInvalidSignature = errors.New("invalid request signature")
+	// ErrStaleRequest возвращается, если время подписанного запроса вне допустимого окна
+	ErrStaleRequest = errors.New("request timestamp is outside the allowed window")
+	// ErrReplayedRequest возвращается при повторном использовании одноразового значения
+	ErrReplayedRequest = errors.New("request nonce has already been used")
+)
+
+// IsSignedAgentRequest сообщает, подписан ли запрос агента
+func IsSignedAgentRequest(r *http.Request) bool {
+	return r.Header.Get(HeaderAgentSignature) != ""
+}
+
+// agentSigningKey вычисляет ключ подписи агента как HMAC-SHA256 секрета сервера над хешем
+// токена. В базе ключ не хранится: без секрета сервера по token_hash подписать запрос нельзя.
+func (s *Service) agentSigningKey(tokenHash string) []byte {
+	mac := hmac.New(sha256.New, s.jwtSecret)
+	mac.Write([]byte("agent-request-signing\n" + tokenHash))
+	return mac.Sum(nil)
+}
+
+// AgentSigningKey возвращает ключ подписи для токена агента. Агент получает его вместе
+// с токеном: при регистрации, при ротации и в ответ на запрос с Bearer токеном.
+func (s *Service) AgentSigningKey(token string) string {
+	return hex.EncodeToString(s.agentSigningKey(hashToken(token)))
+}
+
+// verifyAgentSignature проверяет подпись запроса ключом, вычисленным из хеша токена
+func (s *Service) verifyAgentSignature(tokenHash string, r *http.Request, timestamp, nonce string, body, signature []byte) bool {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, s.agentSigningKey(tokenHash))
+	mac.Write([]byte(strings.Join([]string{r.Method, r.URL.Path, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")))
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// AuthenticateSignedAgent проверяет подпись запроса агента, время запроса и одноразовое значение.
+// В переходный период после ротации принимается и подпись старым токеном.
+func (s *Service) AuthenticateSignedAgent(r *http.Request, body []byte) (*AgentIdentity, error) {
+	agentID, err := uuid.Parse(r.Header.Get(HeaderAgentID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing or invalid %s", ErrInvalidSignature, HeaderAgentID)
+	}
+
+	nonce := r.Header.Get(HeaderAgentNonce)
+	if nonce == "" || len(nonce) > agentNonceMaxLength {
+		return nil, fmt.Errorf("%w: missing or invalid %s", ErrInvalidSignature, HeaderAgentNonce)
+	}
+
+	timestamp := r.Header.Get(HeaderAgentTimestamp)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing or invalid %s", ErrInvalidSignature, HeaderAgentTimestamp)
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > AgentSignatureMaxSkew || skew < -AgentSignatureMaxSkew {
+		return nil, ErrStaleRequest
+	}
+
+	header := r.Header.Get(HeaderAgentSignature)
+	if !strings.HasPrefix(header, agentSignatureVersion) {
+		return nil, fmt.Errorf("%w: unsupported signature format", ErrInvalidSignature)
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, agentSignatureVersion))
+	if err != nil {
+		return nil, fmt.Errorf("%w: unsupported signature format", ErrInvalidSignature)
+	}
+
+	agent := AgentIdentity{ID: agentID}
+	var tokenHash string
+	var previousHash sql.NullString
+	err = s.db.QueryRow(`
+		SELECT name, approved, require_signature, token_hash,
+			CASE WHEN previous_token_expires > NOW() THEN previous_token_hash END
+		FROM agents
+		WHERE id = $1 AND is_active = true AND token_hash IS NOT NULL
+	`, agentID).Scan(&agent.Name, &agent.Approved, &agent.RequireSignature, &tokenHash, &previousHash)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidSignature
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent: %v", err)
+	}
+
+	current := s.verifyAgentSignature(tokenHash, r, timestamp, nonce, body, signature)
+	if !current && !(previousHash.Valid && s.verifyAgentSignature(previousHash.String, r, timestamp, nonce, body, signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	// Одноразовое значение запоминается только после проверки подписи,
+	// иначе чужие запросы могли бы занять значения агента
+	result, err := s.db.Exec(`
+		INSERT INTO agent_request_nonces (agent_id, nonce) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, agentID, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store request nonce: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrReplayedRequest
+	}
+
+	// Время подписанного запроса обновляется не чаще раза в минуту; агент с новым
+	// токеном завершает переходный период так же, как при входе по Bearer токену
+	_, err = s.db.Exec(`
+		UPDATE agents SET
+			last_signed_request = NOW(),
+			previous_token_hash = CASE WHEN $2::boolean THEN NULL ELSE previous_token_hash END,
+			previous_token_expires = CASE WHEN $2::boolean THEN NULL ELSE previous_token_expires END
+		WHERE id = $1 AND (last_signed_request IS NULL OR last_signed_request < NOW() - INTERVAL '1 minute'
+			OR ($2::boolean AND previous_token_hash IS NOT NULL))
+	`, agentID, current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update agent: %v", err)
+	}
+
+	return &agent, nil
+}
+
+// PurgeAgentNonces удаляет одноразовые значения, время запросов которых уже вне допустимого окна
+func (s *Service) PurgeAgentNonces() error {
+	_, err := s.db.Exec(
+		"DELETE FROM agent_request_nonces WHERE created < NOW() - make_interval(secs => $1)",
+		(2 * AgentSignatureMaxSkew).Seconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to purge agent request nonces: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+const (
+	// AgentTokenPrefix отличает токены агентов от прочих токенов
+	AgentTokenPrefix = "msa_"
+	// agentTokenDisplayLength — длина начала токена, которое хранится открыто для узнавания агента
+	agentTokenDisplayLength = 12
+	// DefaultAgentTokenGracePeriod и MaxAgentTokenGracePeriod ограничивают время,
+	// в течение которого после ротации принимается и старый токен агента
+	DefaultAgentTokenGracePeriod = 24 * time.Hour
+	MaxAgentTokenGracePeriod     = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidAgentToken возвращается для неизвестного токена, токена отключенного агента
+	// или старого токена после окончания переходного периода
+	ErrInvalidAgentToken = errors.New("invalid agent token")
+	// ErrAgentNotFound возвращается, если агент не найден или отключен
+	ErrAgentNotFound = errors.New("agent not found")
+	// ErrInvalidRotation возвращается при некорректных параметрах ротации
+	ErrInvalidRotation = errors.New("invalid token rotation")
+)
+
+// AgentToken — новый токен агента. Значение показывается один раз, в базе хранятся начало и хеш.
+type AgentToken struct {
+	Value  string
+	Prefix string
+	Hash   string
+}
+
+// AgentIdentity — агент, которому принадлежит предъявленный токен
+type AgentIdentity struct {
+	ID       uuid.UUID
+	Name     string
+	Approved bool
+	// RequireSignature — агент должен подписывать запросы; Bearer токен не принимается
+	RequireSignature bool
+}
+
+// GenerateAgentToken генерирует токен для агента
+func (s *Service) GenerateAgentToken() (*AgentToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate agent token: %v", err)
+	}
+	value := AgentTokenPrefix + hex.EncodeToString(raw)
+	return &AgentToken{Value: value, Prefix: value[:agentTokenDisplayLength], Hash: hashToken(value)}, nil
+}
+
+// AuthenticateAgent находит активного агента по токену. В переходный период после ротации
+// принимается и предыдущий токен; первый запрос с новым токеном завершает переходный период.
+func (s *Service) AuthenticateAgent(value string) (*AgentIdentity, error) {
+	hash := hashToken(value)
+
+	var agent AgentIdentity
+	var current bool
+	var rotating bool
+	err := s.db.QueryRow(`
+		SELECT id, name, approved, require_signature, token_hash = $1, previous_token_hash IS NOT NULL
+		FROM agents
+		WHERE is_active = true
+			AND (token_hash = $1 OR (previous_token_hash = $1 AND previous_token_expires > NOW()))
+	`, hash).Scan(&agent.ID, &agent.Name, &agent.Approved, &agent.RequireSignature, &current, &rotating)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAgentToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent: %v", err)
+	}
+
+	// Агент перешел на новый токен — старый больше не нужен
+	if current && rotating {
+		_, err := s.db.Exec(`
+			UPDATE agents SET previous_token_hash = NULL, previous_token_expires = NULL
+			WHERE id = $1 AND token_hash = $2
+		`, agent.ID, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to finish token rotation: %v", err)
+		}
+	}
+
+	return &agent, nil
+}
+
+// RotateAgentToken выпускает агенту новый токен и создает действие rotate_token, которое
+// доставит его агенту. Старый токен принимается до конца переходного периода или до первого
+// запроса с новым токеном. Незавершенная предыдущая ротация отменяется: агент, не получивший
+// ее токен, продолжает работать со своим старым токеном.
+func (s *Service) RotateAgentToken(agentID uuid.UUID, gracePeriod time.Duration, createdBy *uuid.UUID) (*models.RotateAgentTokenResponse, error) {
+	if gracePeriod == 0 {
+		gracePeriod = DefaultAgentTokenGracePeriod
+	}
+	if gracePeriod < 0 || gracePeriod > MaxAgentTokenGracePeriod {
+		return nil, fmt.Errorf("%w: grace period must be between 1 and %d hours", ErrInvalidRotation, int(MaxAgentTokenGracePeriod.Hours()))
+	}
+
+	token, err := s.GenerateAgentToken()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	response := &models.RotateAgentTokenResponse{TokenPrefix: token.Prefix, Token: token.Value}
+	err = tx.QueryRow(`
+		UPDATE agents SET
+			previous_token_hash = CASE
+				WHEN previous_token_hash IS NOT NULL AND previous_token_expires > NOW() THEN previous_token_hash
+				ELSE token_hash
+			END,
+			previous_token_expires = NOW() + make_interval(secs => $4),
+			token_hash = $2,
+			token_prefix = $3
+		WHERE id = $1 AND is_active = true
+		RETURNING previous_token_expires
+	`, agentID, token.Hash, token.Prefix, gracePeriod.Seconds()).Scan(&response.PreviousTokenExpires)
+	if err == sql.ErrNoRows {
+		return nil, ErrAgentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate agent token: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE actions SET status = $3, completed = NOW(), error = 'superseded by a newer token rotation',
+			payload = payload - 'token' - 'signing_key'
+		WHERE agent_id = $1 AND type = $2 AND status = $4
+	`, agentID, models.ActionTypeRotateToken, models.ActionStatusFailed, models.ActionStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel previous token rotation: %v", err)
+	}
+
+	payload, err := json.Marshal(models.RotateTokenPayload{Token: token.Value, SigningKey: s.AgentSigningKey(token.Value)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	// Ротация не проходит через политики подтверждения: пока действие ждет,
+	// истекает переходный период старого токена
+	err = tx.QueryRow(`
+		INSERT INTO actions (agent_id, type, payload, status, created, created_by)
+		VALUES ($1, $2, $3, $4, NOW(), $5)
+		RETURNING id
+	`, agentID, models.ActionTypeRotateToken, payload, models.ActionStatusPending, createdBy).Scan(&response.ActionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create action: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// ClearRotationPayload удаляет новый токен и ключ подписи из завершенного действия rotate_token
+func (s *Service) ClearRotationPayload(actionID uuid.UUID) error {
+	_, err := s.db.Exec(
+		"UPDATE actions SET payload = payload - 'token' - 'signing_key' WHERE id = $1 AND type = $2",
+		actionID, models.ActionTypeRotateToken,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear rotation payload: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+// APITokenPrefix отличает персональные API-токены от JWT в заголовке Authorization
+const APITokenPrefix = "msk_"
+
+// apiTokenDisplayLength — длина начала токена, которое хранится открыто для узнавания в списке
+const apiTokenDisplayLength = 12
+
+var (
+	// ErrAPITokenNotFound возвращается, если токен не найден или принадлежит другому пользователю
+	ErrAPITokenNotFound = errors.New("api token not found")
+	// ErrInvalidAPIToken возвращается при некорректных параметрах токена
+	ErrInvalidAPIToken = errors.New("invalid api token")
+	// errAPITokenRejected возвращается при проверке отозванного, просроченного или неизвестного токена
+	errAPITokenRejected = errors.New("api token is revoked, expired or unknown")
+)
+
+const apiTokenColumns = "id, user_id, name, token_prefix, scopes, expires, last_used, revoked, created"
+
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (*models.APIToken, error) {
+	token := &models.APIToken{}
+	var scopes pq.StringArray
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
+		&token.Expires, &token.LastUsed, &token.Revoked, &token.Created,
+	)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = []string(scopes)
+	if token.Scopes == nil {
+		token.Scopes = []string{}
+	}
+	return token, nil
+}
+
+// hashToken возвращает SHA-256 хеш API-, refresh-токена или токена агента в hex
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken создает API-токен пользователя. Права токена не могут превышать права роли.
+func (s *Service) CreateAPIToken(userID uuid.UUID, role string, req *models.CreateAPITokenRequest) (*models.CreateAPITokenResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIToken)
+	}
+	if req.ExpiresInDays < 0 {
+		return nil, fmt.Errorf("%w: expires_in_days must not be negative", ErrInvalidAPIToken)
+	}
+	for _, scope := range req.Scopes {
+		if !HasPermission(role, scope) {
+			return nil, fmt.Errorf("%w: scope %q is not allowed for role %s", ErrInvalidAPIToken, scope, role)
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %v", err)
+	}
+	value := APITokenPrefix + hex.EncodeToString(raw)
+
+	var expires *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expires = &t
+	}
+
+	token, err := scanAPIToken(s.db.QueryRow(`
+		INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scopes, expires)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+apiTokenColumns,
+		userID, req.Name, value[:apiTokenDisplayLength], hashToken(value), pq.Array(req.Scopes), expires,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api token: %v", err)
+	}
+
+	return &models.CreateAPITokenResponse{APIToken: *token, Token: value}, nil
+}
+
+// GetAPITokens получает API-токены пользователя, включая отозванные
+func (s *Service) GetAPITokens(userID uuid.UUID) ([]models.APIToken, error) {
+	rows, err := s.db.Query("SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = $1 ORDER BY created DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api tokens: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api token: %v", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken отзывает API-токен пользователя. Повторный отзыв не меняет время отзыва.
+func (s *Service) RevokeAPIToken(userID, tokenID uuid.UUID) (*models.APIToken, error) {
+	token, err := scanAPIToken(s.db.QueryRow(`
+		UPDATE api_tokens SET revoked = COALESCE(revoked, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+apiTokenColumns,
+		tokenID, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api token: %v", err)
+	}
+	return token, nil
+}
+
+// authenticateAPIToken проверяет API-токен и возвращает claims его владельца.
+// Роль и активность владельца проверяются в JWTMiddleware так же, как для JWT.
+func (s *Service) authenticateAPIToken(value string) (*Claims, error) {
+	var (
+		tokenID uuid.UUID
+		userID  uuid.UUID
+		scopes  pq.StringArray
+	)
+	err := s.db.QueryRow(`
+		SELECT id, user_id, scopes FROM api_tokens
+		WHERE token_hash = $1 AND revoked IS NULL AND (expires IS NULL OR expires > NOW())
+	`, hashToken(value)).Scan(&tokenID, &userID, &scopes)
+	if err == sql.ErrNoRows {
+		return nil, errAPITokenRejected
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api token: %v", err)
+	}
+
+	// Время использования обновляем не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+	_, err = s.db.Exec(`
+		UPDATE api_tokens SET last_used = NOW()
+		WHERE id = $1 AND (last_used IS NULL OR last_used < NOW() - INTERVAL '1 minute')
+	`, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update api token usage: %v", err)
+	}
+
+	return &Claims{UserID: userID, APITokenID: &tokenID, TokenScopes: []string(scopes)}, nil
+}
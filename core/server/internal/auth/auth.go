@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"database/sql"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -13,12 +15,22 @@ import (
 
 type Service struct {
 	jwtSecret []byte
+	db        *sql.DB
 }
 
 type Claims struct {
 	UserID   uuid.UUID `json:"user_id"`
 	Username string    `json:"username"`
 	Role     string    `json:"role"`
+	// SessionID связывает JWT с сессией; отзыв сессии делает токен недействительным
+	SessionID uuid.UUID `json:"sid"`
+	// AgentIDs — агенты, доступные пользователю; пустой список — все агенты.
+	// Загружается из базы при каждом запросе и не попадает в токен.
+	AgentIDs []uuid.UUID `json:"-"`
+	// APITokenID и TokenScopes заполняются при входе по персональному API-токену;
+	// пустой список прав означает все права роли владельца.
+	APITokenID  *uuid.UUID `json:"-"`
+	TokenScopes []string   `json:"-"`
 	jwt.RegisteredClaims
 }
 
@@ -26,9 +38,10 @@ type contextKey string
 
 const UserContextKey contextKey = "user"
 
-func NewService(jwtSecret string) *Service {
+func NewService(jwtSecret string, db *sql.DB) *Service {
 	return &Service{
 		jwtSecret: []byte(jwtSecret),
+		db:        db,
 	}
 }
 
@@ -44,14 +57,15 @@ func (s *Service) CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateToken генерирует JWT токен
-func (s *Service) GenerateToken(userID uuid.UUID, username, role string) (string, error) {
+// GenerateToken генерирует короткоживущий JWT токен сессии
+func (s *Service) GenerateToken(userID uuid.UUID, username, role string, sessionID uuid.UUID) (string, error) {
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -92,24 +106,86 @@ func (s *Service) JWTMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		claims, err := s.ParseToken(bearerToken[1])
+		var claims *Claims
+		var err error
+		if strings.HasPrefix(bearerToken[1], APITokenPrefix) {
+			claims, err = s.authenticateAPIToken(bearerToken[1])
+			if err != nil && err != errAPITokenRejected {
+				log.Printf("Error checking api token: %v", err)
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			claims, err = s.ParseToken(bearerToken[1])
+		}
 		if err != nil {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
 
+		// JWT действует, пока не отозвана его сессия (выход, смена пароля, отзыв администратором)
+		if claims.APITokenID == nil {
+			active, err := s.sessionActive(claims)
+			if err != nil {
+				log.Printf("Error checking session %s: %v", claims.SessionID, err)
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				return
+			}
+			if !active {
+				http.Error(w, "Session expired or revoked", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// Токен деактивированного или удаленного пользователя перестает действовать сразу,
+		// а изменение роли применяется без повторного входа
+		var isActive bool
+		err = s.db.QueryRow(
+			"SELECT username, role, is_active FROM users WHERE id = $1", claims.UserID,
+		).Scan(&claims.Username, &claims.Role, &isActive)
+		if err == sql.ErrNoRows || (err == nil && !isActive) {
+			http.Error(w, "User is inactive", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking user %s: %v", claims.UserID, err)
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+
+		claims.AgentIDs, err = s.getAgentScopes(claims.UserID)
+		if err != nil {
+			log.Printf("Error loading agent scopes for user %s: %v", claims.UserID, err)
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), UserContextKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// getAgentScopes получает список агентов, которыми ограничен пользователь
+func (s *Service) getAgentScopes(userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := s.db.Query("SELECT agent_id FROM user_agent_scopes WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var agentIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		agentIDs = append(agentIDs, id)
+	}
+	return agentIDs, rows.Err()
+}
+
 // GetUserFromContext извлекает пользователя из контекста
 func GetUserFromContext(ctx context.Context) (*Claims, bool) {
 	user, ok := ctx.Value(UserContextKey).(*Claims)
 	return user, ok
 }
-
-// GenerateAgentToken генерирует токен для агента
-func (s *Service) GenerateAgentToken() (string, error) {
-	return uuid.New().String(), nil
-}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+// Права доступа, из которых складываются роли
+const (
+	PermissionView    = "view"    // просмотр агентов, метрик, действий, расписаний и сценариев
+	PermissionOperate = "operate" // создание действий, расписаний, сценариев и доменов
+	PermissionApprove = "approve" // подтверждение и отклонение удержанных действий
+	PermissionAdmin   = "admin"   // управление агентами, пользователями, политиками, уведомлениями и аудитом
+)
+
+// rolePermissions сопоставляет роли с набором прав
+var rolePermissions = map[string][]string{
+	models.UserRoleAdmin:    {PermissionView, PermissionOperate, PermissionApprove, PermissionAdmin},
+	models.UserRoleApprover: {PermissionView, PermissionOperate, PermissionApprove},
+	models.UserRoleOperator: {PermissionView, PermissionOperate},
+	models.UserRoleViewer:   {PermissionView},
+}
+
+// HasPermission проверяет, входит ли право в набор прав роли
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission проверяет право пользователя с учетом ограничений API-токена
+func (c *Claims) HasPermission(permission string) bool {
+	if !HasPermission(c.Role, permission) {
+		return false
+	}
+	if len(c.TokenScopes) == 0 {
+		return true
+	}
+	for _, scope := range c.TokenScopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessAgent проверяет, может ли пользователь работать с агентом.
+// Пустой список агентов означает доступ ко всем агентам.
+func (c *Claims) CanAccessAgent(agentID uuid.UUID) bool {
+	if c.Role == models.UserRoleAdmin || len(c.AgentIDs) == 0 {
+		return true
+	}
+	for _, id := range c.AgentIDs {
+		if id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission middleware пропускает только пользователей, чья роль (и API-токен) содержит право.
+// Должен использоваться после JWTMiddleware.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !claims.HasPermission(permission) {
+				http.Error(w, "Insufficient permissions", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAgentAccess middleware проверяет доступ пользователя к агенту из параметра маршрута
+func RequireAgentAccess(param string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			agentID, err := uuid.Parse(chi.URLParam(r, param))
+			if err != nil {
+				http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+				return
+			}
+			if !claims.CanAccessAgent(agentID) {
+				http.Error(w, "No access to this agent", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+const (
+	// AccessTokenTTL — время жизни JWT; после него клиент обновляет токены по refresh-токену
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL — время жизни сессии без обновления токенов
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	// refreshTokenPrefix отличает refresh-токены от прочих токенов
+	refreshTokenPrefix = "msr_"
+)
+
+var (
+	// ErrSessionNotFound возвращается, если сессия не найдена или принадлежит другому пользователю
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrInvalidRefreshToken возвращается для неизвестного, отозванного или просроченного refresh-токена
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
+const sessionColumns = "id, user_id, ip, user_agent, created, last_used, expires, revoked"
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*models.Session, error) {
+	session := &models.Session{}
+	err := row.Scan(
+		&session.ID, &session.UserID, &session.IP, &session.UserAgent,
+		&session.Created, &session.LastUsed, &session.Expires, &session.Revoked,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// newRefreshToken генерирует значение refresh-токена
+func newRefreshToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+	return refreshTokenPrefix + hex.EncodeToString(raw), nil
+}
+
+// nullableString возвращает nil для пустой строки, чтобы в базу попал NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// CreateSession открывает сессию пользователя и выдает JWT и refresh-токен
+func (s *Service) CreateSession(user *models.User, ip, userAgent string) (*models.LoginResponse, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionID uuid.UUID
+	err = s.db.QueryRow(`
+		INSERT INTO user_sessions (user_id, refresh_token_hash, ip, user_agent, expires)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, user.ID, hashToken(refreshToken), nullableString(ip), nullableString(userAgent), time.Now().Add(RefreshTokenTTL)).Scan(&sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+
+	token, err := s.GenerateToken(user.ID, user.Username, user.Role, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %v", err)
+	}
+
+	return &models.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+// RefreshSession обменивает refresh-токен на новую пару токенов той же сессии.
+// Повторное предъявление уже замененного refresh-токена означает его утечку,
+// поэтому сессия в этом случае отзывается.
+func (s *Service) RefreshSession(refreshToken, ip, userAgent string) (*models.LoginResponse, error) {
+	hash := hashToken(refreshToken)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var sessionID uuid.UUID
+	err = tx.QueryRow(`
+		SELECT id FROM user_sessions
+		WHERE refresh_token_hash = $1 AND revoked IS NULL AND expires > NOW()
+		FOR UPDATE
+	`, hash).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		result, err := s.db.Exec(`
+			UPDATE user_sessions SET revoked = NOW()
+			WHERE previous_token_hash = $1 AND revoked IS NULL
+		`, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %v", err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			log.Printf("Refresh token reuse detected, session revoked")
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %v", err)
+	}
+
+	user := &models.User{}
+	err = tx.QueryRow(`
+		SELECT u.id, u.username, u.email, u.is_active, u.role, u.created, u.last_login, u.auth_provider, u.totp_enabled
+		FROM users u JOIN user_sessions us ON us.user_id = u.id
+		WHERE us.id = $1
+	`, sessionID).Scan(&user.ID, &user.Username, &user.Email, &user.IsActive, &user.Role, &user.Created, &user.LastLogin, &user.AuthProvider, &user.TOTPEnabled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if !user.IsActive {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_sessions
+		SET refresh_token_hash = $1, previous_token_hash = $2, ip = $3, user_agent = $4,
+			last_used = NOW(), expires = $5
+		WHERE id = $6
+	`, hashToken(newToken), hash, nullableString(ip), nullableString(userAgent), time.Now().Add(RefreshTokenTTL), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	token, err := s.GenerateToken(user.ID, user.Username, user.Role, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %v", err)
+	}
+
+	return &models.LoginResponse{
+		Token:        token,
+		RefreshToken: newToken,
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+// GetSessions получает активные сессии пользователя
+func (s *Service) GetSessions(userID uuid.UUID) ([]models.Session, error) {
+	rows, err := s.db.Query(`
+		SELECT `+sessionColumns+` FROM user_sessions
+		WHERE user_id = $1 AND revoked IS NULL AND expires > NOW()
+		ORDER BY last_used DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %v", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+// RevokeSession отзывает сессию пользователя; выданные в ней JWT перестают действовать сразу
+func (s *Service) RevokeSession(userID, sessionID uuid.UUID) (*models.Session, error) {
+	session, err := scanSession(s.db.QueryRow(`
+		UPDATE user_sessions SET revoked = COALESCE(revoked, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING `+sessionColumns,
+		sessionID, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %v", err)
+	}
+	return session, nil
+}
+
+// RevokeSessions отзывает все активные сессии пользователя, кроме указанной, и возвращает их число
+func (s *Service) RevokeSessions(userID uuid.UUID, except *uuid.UUID) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE user_sessions SET revoked = NOW()
+		WHERE user_id = $1 AND revoked IS NULL AND ($2::uuid IS NULL OR id <> $2::uuid)
+	`, userID, except)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// sessionActive проверяет, что сессия JWT не отозвана и не истекла
+func (s *Service) sessionActive(claims *Claims) (bool, error) {
+	var active bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM user_sessions
+			WHERE id = $1 AND user_id = $2 AND revoked IS NULL AND expires > NOW()
+		)
+	`, claims.SessionID, claims.UserID).Scan(&active)
+	return active, err
+}
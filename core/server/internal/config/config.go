@@ -2,14 +2,36 @@ package config
 
 import (
 	"os"
+	"strings"
 )
 
 type Config struct {
 	DatabaseURL string
-	JWTSecret   string
+	JWTSecret   string // секрет подписи JWT и ключей подписи запросов агентов
 	Port        string
 	AdminUser   string
 	AdminPass   string
+	OIDC        OIDCConfig
+	// RequireAdmin2FA запрещает администраторам вход без двухфакторной аутентификации,
+	// в том числе через OIDC
+	RequireAdmin2FA bool
+	// TrustedProxies — IP или подсети reverse proxy, от которых принимаются X-Real-IP и X-Forwarded-For
+	TrustedProxies []string
+}
+
+// OIDCConfig содержит настройки входа через OpenID Connect; вход отключен, если не задан Issuer
+type OIDCConfig struct {
+	Issuer       string   // URL провайдера, например https://sso.example.com/realms/main
+	ClientID     string   // идентификатор клиента у провайдера
+	ClientSecret string   // секрет клиента; для публичных клиентов может быть пустым
+	RedirectURL  string   // адрес обратного вызова, например https://monitor.example.com/api/oidc/callback
+	Scopes       []string // запрашиваемые scope; openid добавляется всегда
+	GroupsClaim  string   // claim со списком групп пользователя
+	// RoleMapping сопоставляет группы провайдера с локальными ролями;
+	// при нескольких совпадениях выбирается роль с наибольшими правами
+	RoleMapping map[string]string
+	// DefaultRole назначается пользователю без подходящих групп; пустое значение запрещает вход
+	DefaultRole string
 }
 
 func Load() *Config {
@@ -22,7 +44,39 @@ func Load() *Config {
 		Port:        "8000",
 		AdminUser:   getEnv("USER", "admin"),
 		AdminPass:   getEnv("PASSWORD", "admin"),
+		OIDC: OIDCConfig{
+			Issuer:       getEnv("OIDC_ISSUER", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OIDC_REDIRECT_URL", ""),
+			Scopes:       splitList(getEnv("OIDC_SCOPES", "profile,email,groups")),
+			GroupsClaim:  getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			RoleMapping:  parseMapping(getEnv("OIDC_ROLE_MAPPING", "")),
+			DefaultRole:  getEnv("OIDC_DEFAULT_ROLE", ""),
+		},
+		RequireAdmin2FA: getEnv("REQUIRE_ADMIN_2FA", "false") == "true",
+		TrustedProxies:  splitList(getEnv("TRUSTED_PROXIES", "")),
+	}
+}
+
+// splitList разбирает список значений, разделенных запятыми или пробелами
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// parseMapping разбирает пары вида "группа=роль,группа=роль"
+func parseMapping(value string) map[string]string {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		mapping[strings.TrimSpace(key)] = strings.TrimSpace(val)
 	}
+	return mapping
 }
 
 func getEnv(key, defaultValue string) string {
@@ -232,6 +232,399 @@ func runMigrations(db *sql.DB) error {
 		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS last_ping timestamp;`,
 		`CREATE INDEX IF NOT EXISTS idx_agents_last_ping ON agents(last_ping);`,
 		`CREATE INDEX IF NOT EXISTS idx_agents_active_last_ping ON agents(is_active, last_ping);`,
+
+		// Миграция 004: таблица расписаний действий
+		`CREATE TABLE IF NOT EXISTS action_schedules (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+			name varchar(255) NOT NULL,
+			type varchar(100) NOT NULL,
+			payload jsonb NOT NULL DEFAULT '{}',
+			cron_expr varchar(255),
+			run_at timestamp,
+			is_paused boolean NOT NULL DEFAULT false,
+			next_run timestamp,
+			last_run timestamp,
+			last_action_id uuid REFERENCES actions(id) ON DELETE SET NULL,
+			created timestamp NOT NULL DEFAULT now(),
+			updated timestamp NOT NULL DEFAULT now(),
+			CHECK (cron_expr IS NOT NULL OR run_at IS NOT NULL)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_action_schedules_agent_id ON action_schedules(agent_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_action_schedules_due ON action_schedules(is_paused, next_run);`,
+
+		// Миграция 005: сценарии развертывания и история их запусков
+		`CREATE TABLE IF NOT EXISTS workflows (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			name varchar(255) NOT NULL UNIQUE,
+			description text,
+			variables jsonb NOT NULL DEFAULT '{}',
+			created timestamp NOT NULL DEFAULT now(),
+			updated timestamp NOT NULL DEFAULT now()
+		);`,
+		`CREATE TABLE IF NOT EXISTS workflow_steps (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			workflow_id uuid NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+			name varchar(100) NOT NULL,
+			type varchar(100) NOT NULL,
+			payload jsonb NOT NULL DEFAULT '{}',
+			depends_on text[] NOT NULL DEFAULT '{}',
+			compensation_type varchar(100),
+			compensation_payload jsonb,
+			position integer NOT NULL DEFAULT 0,
+			UNIQUE (workflow_id, name)
+		);`,
+		`CREATE TABLE IF NOT EXISTS workflow_runs (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			workflow_id uuid NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+			agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+			status varchar(20) NOT NULL DEFAULT 'running',
+			variables jsonb NOT NULL DEFAULT '{}',
+			error text,
+			created timestamp NOT NULL DEFAULT now(),
+			completed timestamp
+		);`,
+		`CREATE TABLE IF NOT EXISTS workflow_run_steps (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			run_id uuid NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+			name varchar(100) NOT NULL,
+			type varchar(100) NOT NULL,
+			payload jsonb NOT NULL DEFAULT '{}',
+			depends_on text[] NOT NULL DEFAULT '{}',
+			compensation_type varchar(100),
+			compensation_payload jsonb,
+			is_compensation boolean NOT NULL DEFAULT false,
+			status varchar(20) NOT NULL DEFAULT 'waiting',
+			action_id uuid REFERENCES actions(id) ON DELETE SET NULL,
+			position integer NOT NULL DEFAULT 0,
+			started timestamp,
+			completed timestamp
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_steps_workflow_id ON workflow_steps(workflow_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow_id ON workflow_runs(workflow_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_runs_status ON workflow_runs(status);`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_run_steps_run_id ON workflow_run_steps(run_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_workflow_run_steps_action_id ON workflow_run_steps(action_id);`,
+
+		// Миграция 006: политики подтверждения опасных действий
+		`CREATE TABLE IF NOT EXISTS approval_policies (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			action_type varchar(100) NOT NULL,
+			agent_id uuid REFERENCES agents(id) ON DELETE CASCADE,
+			force_only boolean NOT NULL DEFAULT false,
+			description text,
+			created timestamp NOT NULL DEFAULT now()
+		);`,
+		`CREATE TABLE IF NOT EXISTS action_approvals (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			action_id uuid NOT NULL UNIQUE REFERENCES actions(id) ON DELETE CASCADE,
+			policy_id uuid REFERENCES approval_policies(id) ON DELETE SET NULL,
+			requested_by uuid REFERENCES users(id) ON DELETE SET NULL,
+			decision varchar(20),
+			decided_by uuid REFERENCES users(id) ON DELETE SET NULL,
+			reason text,
+			created timestamp NOT NULL DEFAULT now(),
+			decided timestamp
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_approval_policies_action_type ON approval_policies(action_type);`,
+		`CREATE INDEX IF NOT EXISTS idx_action_approvals_decision ON action_approvals(decision);`,
+
+		// Миграция 007: авторство действий и журнал аудита
+		`ALTER TABLE actions ADD COLUMN IF NOT EXISTS created_by uuid REFERENCES users(id) ON DELETE SET NULL;`,
+		`ALTER TABLE action_schedules ADD COLUMN IF NOT EXISTS created_by uuid REFERENCES users(id) ON DELETE SET NULL;`,
+		`ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS started_by uuid REFERENCES users(id) ON DELETE SET NULL;`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+			username varchar(255),
+			ip varchar(64),
+			method varchar(10) NOT NULL,
+			path text NOT NULL,
+			entity_type varchar(50) NOT NULL,
+			entity_id varchar(64),
+			summary text NOT NULL,
+			before jsonb,
+			after jsonb,
+			changes jsonb,
+			created timestamp NOT NULL DEFAULT now()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_actions_created_by ON actions(created_by);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);`,
+
+		// Миграция 008: структурированный ответ агента на действие
+		`DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'actions' AND column_name = 'response' AND data_type = 'text'
+			) THEN
+				ALTER TABLE actions ALTER COLUMN response TYPE jsonb
+					USING CASE WHEN response IS NULL THEN NULL ELSE jsonb_build_object('message', response) END;
+			END IF;
+		END $$;`,
+
+		// Миграция 009: роли и ограничение пользователей агентами
+		`UPDATE users SET role = 'operator' WHERE role = 'user';`,
+		`ALTER TABLE users ALTER COLUMN role SET DEFAULT 'viewer';`,
+		`CREATE TABLE IF NOT EXISTS user_agent_scopes (
+			user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+			PRIMARY KEY (user_id, agent_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_agent_scopes_agent_id ON user_agent_scopes(agent_id);`,
+		// Миграция 010: персональные API-токены
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name varchar(255) NOT NULL,
+			token_prefix varchar(16) NOT NULL,
+			token_hash varchar(64) NOT NULL UNIQUE,
+			scopes text[] NOT NULL DEFAULT '{}',
+			expires timestamp,
+			last_used timestamp,
+			revoked timestamp,
+			created timestamp NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);`,
+		// Миграция 011: сессии пользователей и refresh-токены
+		`CREATE TABLE IF NOT EXISTS user_sessions (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			refresh_token_hash varchar(64) NOT NULL UNIQUE,
+			previous_token_hash varchar(64),
+			ip varchar(64),
+			user_agent text,
+			created timestamp NOT NULL DEFAULT NOW(),
+			last_used timestamp NOT NULL DEFAULT NOW(),
+			expires timestamp NOT NULL,
+			revoked timestamp
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token_hash ON user_sessions(previous_token_hash);`,
+		// Миграция 012: вход через OpenID Connect
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_provider varchar(20) NOT NULL DEFAULT 'local';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id varchar(512);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_id ON users(external_id) WHERE external_id IS NOT NULL;`,
+		`CREATE TABLE IF NOT EXISTS oidc_login_states (
+			state varchar(64) PRIMARY KEY,
+			nonce varchar(64) NOT NULL,
+			code_verifier varchar(128) NOT NULL,
+			created timestamp NOT NULL DEFAULT NOW()
+		);`,
+		// Миграция 013: двухфакторная аутентификация по TOTP
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret varchar(64);`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled boolean NOT NULL DEFAULT false;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step bigint;`,
+		`CREATE TABLE IF NOT EXISTS user_recovery_codes (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash varchar(64) NOT NULL,
+			used timestamp,
+			created timestamp NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE TABLE IF NOT EXISTS login_challenges (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash varchar(64) NOT NULL UNIQUE,
+			kind varchar(20) NOT NULL,
+			attempts integer NOT NULL DEFAULT 0,
+			expires timestamp NOT NULL,
+			created timestamp NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_challenges_user_id ON login_challenges(user_id);`,
+		// Миграция 014: журнал событий входа и защита от перебора паролей
+		`CREATE TABLE IF NOT EXISTS auth_events (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+			username varchar(255),
+			ip varchar(64),
+			user_agent text,
+			event varchar(30) NOT NULL,
+			detail text,
+			created timestamp NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE TABLE IF NOT EXISTS login_throttles (
+			key varchar(300) PRIMARY KEY,
+			failures integer NOT NULL DEFAULT 0,
+			last_failure timestamp NOT NULL,
+			locked_until timestamp
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_events_created ON auth_events(created);`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_events_user_id ON auth_events(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_events_username ON auth_events(username);`,
+		`CREATE INDEX IF NOT EXISTS idx_auth_events_ip ON auth_events(ip);`,
+		// Миграция 015: регистрация агентов по одноразовым токенам
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS approved boolean NOT NULL DEFAULT true;`,
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS hostname varchar(255);`,
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS facts jsonb;`,
+		`CREATE TABLE IF NOT EXISTS agent_enrollment_tokens (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			name varchar(255) NOT NULL,
+			token_prefix varchar(16) NOT NULL,
+			token_hash varchar(64) NOT NULL UNIQUE,
+			auto_approve boolean NOT NULL DEFAULT false,
+			expires timestamp NOT NULL,
+			used timestamp,
+			agent_id uuid REFERENCES agents(id) ON DELETE SET NULL,
+			revoked timestamp,
+			created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+			created timestamp NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_agents_approved ON agents(approved);`,
+		// Миграция 016: хеширование токенов агентов и ротация с переходным периодом
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS token_prefix varchar(16);`,
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS token_hash varchar(64);`,
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS previous_token_hash varchar(64);`,
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS previous_token_expires timestamp;`,
+		`ALTER TABLE agents ALTER COLUMN token DROP NOT NULL;`,
+		`UPDATE agents
+			SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex'),
+				token_prefix = left(token, 8),
+				token = NULL
+			WHERE token IS NOT NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_agents_token_hash ON agents(token_hash);`,
+		`CREATE INDEX IF NOT EXISTS idx_agents_previous_token_hash ON agents(previous_token_hash);`,
+		// Миграция 017: подпись запросов агентов с защитой от повтора
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS require_signature boolean NOT NULL DEFAULT false;`,
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS last_signed_request timestamp;`,
+		`CREATE TABLE IF NOT EXISTS agent_request_nonces (
+			agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+			nonce varchar(64) NOT NULL,
+			created timestamp NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (agent_id, nonce)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_agent_request_nonces_created ON agent_request_nonces(created);`,
+
+		// Миграция 018: заполненность файловых систем по точкам монтирования
+		`CREATE TABLE IF NOT EXISTS filesystem_metrics (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			ping_id uuid NOT NULL REFERENCES agent_pings(id) ON DELETE CASCADE,
+			mountpoint varchar(255) NOT NULL,
+			device varchar(255) NOT NULL,
+			fstype varchar(50) NOT NULL,
+			total_bytes bigint NOT NULL,
+			used_bytes bigint NOT NULL,
+			free_bytes bigint NOT NULL,
+			inodes_total bigint NOT NULL,
+			inodes_used bigint NOT NULL,
+			inodes_free bigint NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_filesystem_metrics_ping_id ON filesystem_metrics(ping_id);`,
+
+		// Миграция 019: скорости дисков и сети между соседними пингами
+		`ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS read_bytes_per_sec double precision;`,
+		`ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS write_bytes_per_sec double precision;`,
+		`ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS reads_per_sec double precision;`,
+		`ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS writes_per_sec double precision;`,
+		`ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_sent bigint;`,
+		`ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_received bigint;`,
+		`ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS sent_bytes_per_sec double precision;`,
+		`ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS received_bytes_per_sec double precision;`,
+		`ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_sent_per_sec double precision;`,
+		`ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_received_per_sec double precision;`,
+
+		// Миграция 020: сетевые метрики по интерфейсам хоста
+		`CREATE TABLE IF NOT EXISTS network_interface_metrics (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			ping_id uuid NOT NULL REFERENCES agent_pings(id) ON DELETE CASCADE,
+			name varchar(64) NOT NULL,
+			sent_bytes bigint NOT NULL,
+			received_bytes bigint NOT NULL,
+			packets_sent bigint NOT NULL,
+			packets_received bigint NOT NULL,
+			errors_in bigint NOT NULL,
+			errors_out bigint NOT NULL,
+			drops_in bigint NOT NULL,
+			drops_out bigint NOT NULL,
+			sent_bytes_per_sec double precision,
+			received_bytes_per_sec double precision,
+			packets_sent_per_sec double precision,
+			packets_received_per_sec double precision
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_network_interface_metrics_ping_id ON network_interface_metrics(ping_id);`,
+
+		// Миграция 021: нагрузка хоста и время обновления сведений о хосте
+		`ALTER TABLE agents ADD COLUMN IF NOT EXISTS facts_updated timestamp;`,
+		`CREATE INDEX IF NOT EXISTS idx_agents_facts ON agents USING gin (facts jsonb_path_ops);`,
+		`CREATE TABLE IF NOT EXISTS host_metrics (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			ping_id uuid NOT NULL REFERENCES agent_pings(id) ON DELETE CASCADE,
+			load1 double precision NOT NULL,
+			load5 double precision NOT NULL,
+			load15 double precision NOT NULL,
+			uptime_seconds bigint NOT NULL,
+			boot_time timestamp NOT NULL,
+			processes int NOT NULL,
+			threads int NOT NULL,
+			context_switches bigint NOT NULL,
+			cpu_user double precision NOT NULL,
+			cpu_nice double precision NOT NULL,
+			cpu_system double precision NOT NULL,
+			cpu_idle double precision NOT NULL,
+			cpu_iowait double precision NOT NULL,
+			cpu_irq double precision NOT NULL,
+			cpu_softirq double precision NOT NULL,
+			cpu_steal double precision NOT NULL,
+			context_switches_per_sec double precision,
+			cpu_user_percent double precision,
+			cpu_system_percent double precision,
+			cpu_iowait_percent double precision,
+			cpu_steal_percent double precision,
+			cpu_idle_percent double precision
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_host_metrics_ping_id ON host_metrics(ping_id);`,
+
+		// Миграция 022: лимит памяти, блочный ввод-вывод и число процессов контейнера
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS memory_limit_mb bigint;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS memory_percent double precision;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS block_read_bytes bigint;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS block_write_bytes bigint;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS pids integer;`,
+
+		// Миграция 023: состояние процесса контейнера
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS state varchar(20);`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS health_status varchar(20);`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS health_output text;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS health_failing_streak integer;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS exit_code integer;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS oom_killed boolean;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS started_at timestamp;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS finished_at timestamp;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS restart_policy varchar(32);`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS restart_max_retries integer;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS ports jsonb;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS mounts jsonb;`,
+		`ALTER TABLE containers ADD COLUMN IF NOT EXISTS labels jsonb;`,
+
+		// Миграция 024: события Docker между пингами
+		`CREATE TABLE IF NOT EXISTS container_events (
+			id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+			agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+			type varchar(20) NOT NULL,
+			action varchar(32) NOT NULL,
+			actor_id varchar(255) NOT NULL,
+			actor_name varchar(255),
+			image varchar(255),
+			status varchar(20),
+			exit_code integer,
+			signal varchar(20),
+			time timestamp NOT NULL,
+			created timestamp NOT NULL DEFAULT now()
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_container_events_unique ON container_events(agent_id, actor_id, action, time);`,
+		`CREATE INDEX IF NOT EXISTS idx_container_events_agent_time ON container_events(agent_id, time);`,
+
+		// Миграция 025: поток строки лога контейнера
+		`ALTER TABLE container_logs ADD COLUMN IF NOT EXISTS stream varchar(6);`,
+
+		// Миграция 026: длительность сбора данных агентом и неудачные запросы к Docker
+		`ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS collection_duration_ms double precision;`,
+		`ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS docker_duration_ms double precision;`,
+		`ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS collection_workers integer;`,
+		`ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS collection_failures jsonb;`,
 	}
 
 	for _, migration := range migrations {
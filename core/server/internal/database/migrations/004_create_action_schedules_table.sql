@@ -0,0 +1,21 @@
+-- Создание таблицы расписаний действий
+CREATE TABLE IF NOT EXISTS action_schedules (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    name varchar(255) NOT NULL,
+    type varchar(100) NOT NULL,
+    payload jsonb NOT NULL DEFAULT '{}',
+    cron_expr varchar(255),
+    run_at timestamp,
+    is_paused boolean NOT NULL DEFAULT false,
+    next_run timestamp,
+    last_run timestamp,
+    last_action_id uuid REFERENCES actions(id) ON DELETE SET NULL,
+    created timestamp NOT NULL DEFAULT now(),
+    updated timestamp NOT NULL DEFAULT now(),
+    CHECK (cron_expr IS NOT NULL OR run_at IS NOT NULL)
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_action_schedules_agent_id ON action_schedules(agent_id);
+CREATE INDEX IF NOT EXISTS idx_action_schedules_due ON action_schedules(is_paused, next_run);
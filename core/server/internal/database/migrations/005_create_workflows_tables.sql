@@ -0,0 +1,60 @@
+-- Создание таблицы сценариев
+CREATE TABLE IF NOT EXISTS workflows (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name varchar(255) NOT NULL UNIQUE,
+    description text,
+    variables jsonb NOT NULL DEFAULT '{}',
+    created timestamp NOT NULL DEFAULT now(),
+    updated timestamp NOT NULL DEFAULT now()
+);
+
+-- Создание таблицы шагов сценария
+CREATE TABLE IF NOT EXISTS workflow_steps (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id uuid NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    name varchar(100) NOT NULL,
+    type varchar(100) NOT NULL,
+    payload jsonb NOT NULL DEFAULT '{}',
+    depends_on text[] NOT NULL DEFAULT '{}',
+    compensation_type varchar(100),
+    compensation_payload jsonb,
+    position integer NOT NULL DEFAULT 0,
+    UNIQUE (workflow_id, name)
+);
+
+-- Создание таблицы запусков сценариев
+CREATE TABLE IF NOT EXISTS workflow_runs (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id uuid NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    status varchar(20) NOT NULL DEFAULT 'running',
+    variables jsonb NOT NULL DEFAULT '{}',
+    error text,
+    created timestamp NOT NULL DEFAULT now(),
+    completed timestamp
+);
+
+-- Создание таблицы шагов запусков
+CREATE TABLE IF NOT EXISTS workflow_run_steps (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id uuid NOT NULL REFERENCES workflow_runs(id) ON DELETE CASCADE,
+    name varchar(100) NOT NULL,
+    type varchar(100) NOT NULL,
+    payload jsonb NOT NULL DEFAULT '{}',
+    depends_on text[] NOT NULL DEFAULT '{}',
+    compensation_type varchar(100),
+    compensation_payload jsonb,
+    is_compensation boolean NOT NULL DEFAULT false,
+    status varchar(20) NOT NULL DEFAULT 'waiting',
+    action_id uuid REFERENCES actions(id) ON DELETE SET NULL,
+    position integer NOT NULL DEFAULT 0,
+    started timestamp,
+    completed timestamp
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_workflow_steps_workflow_id ON workflow_steps(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_workflow_id ON workflow_runs(workflow_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_runs_status ON workflow_runs(status);
+CREATE INDEX IF NOT EXISTS idx_workflow_run_steps_run_id ON workflow_run_steps(run_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_run_steps_action_id ON workflow_run_steps(action_id);
@@ -0,0 +1,26 @@
+-- Создание таблицы политик подтверждения действий
+CREATE TABLE IF NOT EXISTS approval_policies (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    action_type varchar(100) NOT NULL,
+    agent_id uuid REFERENCES agents(id) ON DELETE CASCADE,
+    force_only boolean NOT NULL DEFAULT false,
+    description text,
+    created timestamp NOT NULL DEFAULT now()
+);
+
+-- Создание таблицы подтверждений действий
+CREATE TABLE IF NOT EXISTS action_approvals (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    action_id uuid NOT NULL UNIQUE REFERENCES actions(id) ON DELETE CASCADE,
+    policy_id uuid REFERENCES approval_policies(id) ON DELETE SET NULL,
+    requested_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    decision varchar(20),
+    decided_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    reason text,
+    created timestamp NOT NULL DEFAULT now(),
+    decided timestamp
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_approval_policies_action_type ON approval_policies(action_type);
+CREATE INDEX IF NOT EXISTS idx_action_approvals_decision ON action_approvals(decision);
@@ -0,0 +1,27 @@
+-- Добавление автора действий, расписаний и запусков сценариев
+ALTER TABLE actions ADD COLUMN IF NOT EXISTS created_by uuid REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE action_schedules ADD COLUMN IF NOT EXISTS created_by uuid REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE workflow_runs ADD COLUMN IF NOT EXISTS started_by uuid REFERENCES users(id) ON DELETE SET NULL;
+
+-- Создание таблицы журнала аудита
+CREATE TABLE IF NOT EXISTS audit_log (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+    username varchar(255),
+    ip varchar(64),
+    method varchar(10) NOT NULL,
+    path text NOT NULL,
+    entity_type varchar(50) NOT NULL,
+    entity_id varchar(64),
+    summary text NOT NULL,
+    before jsonb,
+    after jsonb,
+    changes jsonb,
+    created timestamp NOT NULL DEFAULT now()
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_actions_created_by ON actions(created_by);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
@@ -0,0 +1,12 @@
+-- Перевод ответа агента на действие в структурированный JSON.
+-- Существующие текстовые ответы сохраняются в поле message.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'actions' AND column_name = 'response' AND data_type = 'text'
+    ) THEN
+        ALTER TABLE actions ALTER COLUMN response TYPE jsonb
+            USING CASE WHEN response IS NULL THEN NULL ELSE jsonb_build_object('message', response) END;
+    END IF;
+END $$;
@@ -0,0 +1,13 @@
+-- Роли admin, approver, operator и viewer; прежняя роль user соответствует operator
+UPDATE users SET role = 'operator' WHERE role = 'user';
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'viewer';
+
+-- Ограничение пользователей отдельными агентами; отсутствие записей — доступ ко всем агентам
+CREATE TABLE IF NOT EXISTS user_agent_scopes (
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, agent_id)
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_user_agent_scopes_agent_id ON user_agent_scopes(agent_id);
@@ -0,0 +1,16 @@
+-- Персональные API-токены для скриптов и CI; хранится только SHA-256 хеш токена
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name varchar(255) NOT NULL,
+    token_prefix varchar(16) NOT NULL,
+    token_hash varchar(64) NOT NULL UNIQUE,
+    scopes text[] NOT NULL DEFAULT '{}',
+    expires timestamp,
+    last_used timestamp,
+    revoked timestamp,
+    created timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
@@ -0,0 +1,18 @@
+-- Сессии пользователей: короткоживущий JWT выдается по ротируемому refresh-токену,
+-- хеш которого хранится здесь; отзыв сессии завершает ее на всех запросах сразу
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash varchar(64) NOT NULL UNIQUE,
+    previous_token_hash varchar(64), -- предыдущий refresh-токен; его повторное использование отзывает сессию
+    ip varchar(64),
+    user_agent text,
+    created timestamp NOT NULL DEFAULT NOW(),
+    last_used timestamp NOT NULL DEFAULT NOW(),
+    expires timestamp NOT NULL,
+    revoked timestamp
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token_hash ON user_sessions(previous_token_hash);
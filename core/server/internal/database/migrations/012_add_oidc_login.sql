@@ -0,0 +1,12 @@
+-- Пользователи, созданные при входе через OpenID Connect; external_id — "issuer|sub"
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_provider varchar(20) NOT NULL DEFAULT 'local';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id varchar(512);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_id ON users(external_id) WHERE external_id IS NOT NULL;
+
+-- Незавершенные входы через OIDC: state, nonce и PKCE code_verifier живут несколько минут
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state varchar(64) PRIMARY KEY,
+    nonce varchar(64) NOT NULL,
+    code_verifier varchar(128) NOT NULL,
+    created timestamp NOT NULL DEFAULT NOW()
+);
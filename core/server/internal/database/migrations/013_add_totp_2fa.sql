@@ -0,0 +1,29 @@
+-- Двухфакторная аутентификация по TOTP; totp_secret задается при подключении,
+-- totp_enabled — после подтверждения первым кодом
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret varchar(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled boolean NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step bigint; -- последний принятый интервал, защищает от повторного использования кода
+
+-- Одноразовые коды восстановления; хранится SHA-256 хеш
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash varchar(64) NOT NULL,
+    used timestamp,
+    created timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Незавершенные двухшаговые входы: пароль проверен, ожидается код второго фактора
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash varchar(64) NOT NULL UNIQUE,
+    kind varchar(20) NOT NULL, -- totp (ввод кода) или enroll (обязательное подключение 2FA)
+    attempts integer NOT NULL DEFAULT 0,
+    expires timestamp NOT NULL,
+    created timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user_id ON login_challenges(user_id);
@@ -0,0 +1,25 @@
+-- Журнал событий входа: успехи, ошибки, блокировки
+CREATE TABLE IF NOT EXISTS auth_events (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+    username varchar(255), -- как введено при входе; пользователь может не существовать
+    ip varchar(64),
+    user_agent text,
+    event varchar(30) NOT NULL, -- login_success, login_failure, mfa_failure, lockout, login_blocked, ...
+    detail text,
+    created timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Счетчики неудачных попыток входа по имени пользователя ("user:<имя>") и IP ("ip:<адрес>")
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key varchar(300) PRIMARY KEY,
+    failures integer NOT NULL DEFAULT 0,
+    last_failure timestamp NOT NULL,
+    locked_until timestamp
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_auth_events_created ON auth_events(created);
+CREATE INDEX IF NOT EXISTS idx_auth_events_user_id ON auth_events(user_id);
+CREATE INDEX IF NOT EXISTS idx_auth_events_username ON auth_events(username);
+CREATE INDEX IF NOT EXISTS idx_auth_events_ip ON auth_events(ip);
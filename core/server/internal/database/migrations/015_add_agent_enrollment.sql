@@ -0,0 +1,23 @@
+-- Самостоятельная регистрация агентов по одноразовым токенам.
+-- approved = false — агент зарегистрирован и ждет подтверждения администратором
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS approved boolean NOT NULL DEFAULT true;
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS hostname varchar(255);
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS facts jsonb; -- сведения о хосте, переданные агентом
+
+-- Одноразовые токены регистрации; хранится SHA-256 хеш
+CREATE TABLE IF NOT EXISTS agent_enrollment_tokens (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name varchar(255) NOT NULL,
+    token_prefix varchar(16) NOT NULL,
+    token_hash varchar(64) NOT NULL UNIQUE,
+    auto_approve boolean NOT NULL DEFAULT false, -- агент начинает работу без подтверждения
+    expires timestamp NOT NULL,
+    used timestamp,
+    agent_id uuid REFERENCES agents(id) ON DELETE SET NULL, -- агент, зарегистрированный по токену
+    revoked timestamp,
+    created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    created timestamp NOT NULL DEFAULT NOW()
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_agents_approved ON agents(approved);
@@ -0,0 +1,18 @@
+-- Токены агентов хранятся в виде SHA-256 хеша; открыто хранится только начало токена.
+-- previous_token_* — старый токен, который принимается в переходный период после ротации
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS token_prefix varchar(16);
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS token_hash varchar(64);
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS previous_token_hash varchar(64);
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS previous_token_expires timestamp;
+
+-- Перенос существующих токенов: колонка token больше не используется и очищается
+ALTER TABLE agents ALTER COLUMN token DROP NOT NULL;
+UPDATE agents
+SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex'),
+    token_prefix = left(token, 8),
+    token = NULL
+WHERE token IS NOT NULL;
+
+-- Создание индексов
+CREATE UNIQUE INDEX IF NOT EXISTS idx_agents_token_hash ON agents(token_hash);
+CREATE INDEX IF NOT EXISTS idx_agents_previous_token_hash ON agents(previous_token_hash);
@@ -0,0 +1,15 @@
+-- Подпись запросов агентов HMAC с защитой от повтора.
+-- require_signature = true — запросы с одним Bearer токеном отклоняются
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS require_signature boolean NOT NULL DEFAULT false;
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS last_signed_request timestamp; -- обновляется не чаще раза в минуту
+
+-- Одноразовые значения подписанных запросов; хранятся 10 минут
+CREATE TABLE IF NOT EXISTS agent_request_nonces (
+    agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    nonce varchar(64) NOT NULL,
+    created timestamp NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (agent_id, nonce)
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_agent_request_nonces_created ON agent_request_nonces(created);
@@ -0,0 +1,17 @@
+-- Заполненность файловых систем хоста по точкам монтирования
+CREATE TABLE IF NOT EXISTS filesystem_metrics (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    ping_id uuid NOT NULL REFERENCES agent_pings(id) ON DELETE CASCADE,
+    mountpoint varchar(255) NOT NULL,
+    device varchar(255) NOT NULL,
+    fstype varchar(50) NOT NULL,
+    total_bytes bigint NOT NULL,
+    used_bytes bigint NOT NULL,
+    free_bytes bigint NOT NULL, -- доступно пользователям, без блоков, зарезервированных для root
+    inodes_total bigint NOT NULL,
+    inodes_used bigint NOT NULL,
+    inodes_free bigint NOT NULL
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_filesystem_metrics_ping_id ON filesystem_metrics(ping_id);
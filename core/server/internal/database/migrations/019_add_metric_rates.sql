@@ -0,0 +1,14 @@
+-- Скорости накопительных счетчиков, рассчитанные сервером между соседними пингами.
+-- NULL — первый пинг агента или счетчик сброшен (например, после перезагрузки хоста)
+ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS read_bytes_per_sec double precision;
+ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS write_bytes_per_sec double precision;
+ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS reads_per_sec double precision;
+ALTER TABLE disk_metrics ADD COLUMN IF NOT EXISTS writes_per_sec double precision;
+
+-- Счетчики пакетов; NULL — агент старой версии их не присылает
+ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_sent bigint;
+ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_received bigint;
+ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS sent_bytes_per_sec double precision;
+ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS received_bytes_per_sec double precision;
+ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_sent_per_sec double precision;
+ALTER TABLE network_metrics ADD COLUMN IF NOT EXISTS packets_received_per_sec double precision;
@@ -0,0 +1,22 @@
+-- Сетевые метрики по интерфейсам хоста. network_metrics хранит их сумму по учитываемым интерфейсам
+CREATE TABLE IF NOT EXISTS network_interface_metrics (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    ping_id uuid NOT NULL REFERENCES agent_pings(id) ON DELETE CASCADE,
+    name varchar(64) NOT NULL,
+    sent_bytes bigint NOT NULL,
+    received_bytes bigint NOT NULL,
+    packets_sent bigint NOT NULL,
+    packets_received bigint NOT NULL,
+    errors_in bigint NOT NULL,
+    errors_out bigint NOT NULL,
+    drops_in bigint NOT NULL,
+    drops_out bigint NOT NULL,
+    -- Скорости с прошлого пинга; NULL для первого пинга и после сброса счетчиков
+    sent_bytes_per_sec double precision,
+    received_bytes_per_sec double precision,
+    packets_sent_per_sec double precision,
+    packets_received_per_sec double precision
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_network_interface_metrics_ping_id ON network_interface_metrics(ping_id);
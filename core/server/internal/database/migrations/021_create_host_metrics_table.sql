@@ -0,0 +1,38 @@
+-- Время последнего обновления сведений о хосте; агент присылает их только при изменении
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS facts_updated timestamp;
+
+-- Индекс для отбора агентов по сведениям о хосте (facts @> '{"os": "linux"}')
+CREATE INDEX IF NOT EXISTS idx_agents_facts ON agents USING gin (facts jsonb_path_ops);
+
+-- Нагрузка и состояние хоста
+CREATE TABLE IF NOT EXISTS host_metrics (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    ping_id uuid NOT NULL REFERENCES agent_pings(id) ON DELETE CASCADE,
+    load1 double precision NOT NULL,
+    load5 double precision NOT NULL,
+    load15 double precision NOT NULL,
+    uptime_seconds bigint NOT NULL,
+    boot_time timestamp NOT NULL,
+    processes int NOT NULL,
+    threads int NOT NULL,
+    context_switches bigint NOT NULL, -- накопительный счетчик
+    -- Накопительное время всех CPU по режимам в секундах
+    cpu_user double precision NOT NULL,
+    cpu_nice double precision NOT NULL,
+    cpu_system double precision NOT NULL,
+    cpu_idle double precision NOT NULL,
+    cpu_iowait double precision NOT NULL,
+    cpu_irq double precision NOT NULL,
+    cpu_softirq double precision NOT NULL,
+    cpu_steal double precision NOT NULL,
+    -- Скорости и доли с прошлого пинга; NULL для первого пинга и после перезагрузки хоста
+    context_switches_per_sec double precision,
+    cpu_user_percent double precision,
+    cpu_system_percent double precision,
+    cpu_iowait_percent double precision,
+    cpu_steal_percent double precision,
+    cpu_idle_percent double precision
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_host_metrics_ping_id ON host_metrics(ping_id);
@@ -0,0 +1,6 @@
+-- Лимит памяти, блочный ввод-вывод и число процессов контейнера
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS memory_limit_mb bigint;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS memory_percent double precision; -- процент от лимита
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS block_read_bytes bigint;  -- накопительный счетчик
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS block_write_bytes bigint; -- накопительный счетчик
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS pids integer;
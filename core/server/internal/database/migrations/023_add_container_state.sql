@@ -0,0 +1,14 @@
+-- Состояние процесса контейнера: проверка здоровья, код выхода, OOM и конфигурация
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS state varchar(20); -- created, running, paused, restarting, exited, dead
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS health_status varchar(20); -- starting, healthy, unhealthy; NULL — нет HEALTHCHECK
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS health_output text; -- вывод последней проверки
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS health_failing_streak integer;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS exit_code integer;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS oom_killed boolean;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS started_at timestamp;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS finished_at timestamp;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS restart_policy varchar(32);
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS restart_max_retries integer;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS ports jsonb; -- опубликованные и внутренние порты
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS mounts jsonb;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS labels jsonb;
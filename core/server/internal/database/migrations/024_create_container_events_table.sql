@@ -0,0 +1,19 @@
+-- События Docker между пингами: падения, OOM, перезапуски, смена здоровья, загрузка и удаление образов
+CREATE TABLE IF NOT EXISTS container_events (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+    type varchar(20) NOT NULL, -- container, image
+    action varchar(32) NOT NULL, -- die, oom, kill, restart, health_status, pull, destroy
+    actor_id varchar(255) NOT NULL, -- ID контейнера в Docker или ссылка на образ
+    actor_name varchar(255),
+    image varchar(255),
+    status varchar(20), -- результат health_status: healthy, unhealthy
+    exit_code integer,
+    signal varchar(20),
+    time timestamp NOT NULL, -- время события на хосте агента
+    created timestamp NOT NULL DEFAULT now()
+);
+
+-- Агент повторяет события, если пинг не дошел: повтор не создает дубликат
+CREATE UNIQUE INDEX IF NOT EXISTS idx_container_events_unique ON container_events(agent_id, actor_id, action, time);
+CREATE INDEX IF NOT EXISTS idx_container_events_agent_time ON container_events(agent_id, time);
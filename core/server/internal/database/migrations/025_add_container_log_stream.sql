@@ -0,0 +1,2 @@
+-- Поток строки лога контейнера. timestamp теперь время Docker, а не время приема пинга
+ALTER TABLE container_logs ADD COLUMN IF NOT EXISTS stream varchar(6); -- stdout, stderr; NULL у строк от старых агентов
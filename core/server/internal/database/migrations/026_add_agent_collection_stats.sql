@@ -0,0 +1,6 @@
+-- Длительность сбора данных агентом и неудачные запросы к Docker. Если сбор дольше
+-- интервала, пинги приходят реже: по этим колонкам видно почему
+ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS collection_duration_ms double precision; -- NULL у старых агентов
+ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS docker_duration_ms double precision;
+ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS collection_workers integer;
+ALTER TABLE agent_pings ADD COLUMN IF NOT EXISTS collection_failures jsonb; -- [{container_id, name, call, error}]
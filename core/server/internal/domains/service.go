@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"monitoring-system/core/server/internal/approvals"
 	"monitoring-system/core/server/internal/models"
 
 	"github.com/google/uuid"
@@ -15,21 +16,17 @@ type Service struct {
 	db *sql.DB
 }
 
-// createAction создает действие для агента
-func (s *Service) createAction(agentID uuid.UUID, actionType string, payload map[string]interface{}) error {
+// createAction создает действие для агента от имени пользователя userID
+func (s *Service) createAction(agentID uuid.UUID, actionType string, payload map[string]interface{}, userID *uuid.UUID) error {
 	// Сериализуем payload в JSON
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %v", err)
 	}
 
-	_, err = s.db.Exec(`
-		INSERT INTO actions (id, agent_id, type, payload, status, created)
-		VALUES (gen_random_uuid(), $1, $2, $3, 'pending', NOW())
-	`, agentID, actionType, payloadJSON)
-
+	_, _, err = approvals.InsertAction(s.db, agentID, actionType, payloadJSON, userID)
 	if err != nil {
-		return fmt.Errorf("failed to create action: %v", err)
+		return err
 	}
 
 	return nil
@@ -151,7 +148,7 @@ func (s *Service) GetDomains() ([]*models.DomainDetail, error) {
 }
 
 // UpdateDomain обновляет домен
-func (s *Service) UpdateDomain(id uuid.UUID, req *models.UpdateDomainRequest) (*models.Domain, error) {
+func (s *Service) UpdateDomain(id uuid.UUID, req *models.UpdateDomainRequest, userID *uuid.UUID) (*models.Domain, error) {
 	// Получаем текущий домен
 	domain, err := s.GetDomainByID(id)
 	if err != nil {
@@ -201,7 +198,7 @@ func (s *Service) UpdateDomain(id uuid.UUID, req *models.UpdateDomainRequest) (*
 
 	// Создаем действие для обновления nginx конфигурации при изменении SSL
 	if req.SSLEnabled != nil {
-		if err := s.createNginxUpdateAction(id); err != nil {
+		if err := s.createNginxUpdateAction(id, userID); err != nil {
 			// Логируем ошибку, но не прерываем обновление домена
 			fmt.Printf("Warning: failed to create nginx update action: %v\n", err)
 		}
@@ -220,7 +217,7 @@ func (s *Service) DeleteDomain(id uuid.UUID) error {
 }
 
 // CreateDomainRoute создает маршрут для домена
-func (s *Service) CreateDomainRoute(req *models.CreateDomainRouteRequest) (*models.DomainRoute, error) {
+func (s *Service) CreateDomainRoute(req *models.CreateDomainRouteRequest, userID *uuid.UUID) (*models.DomainRoute, error) {
 	route := &models.DomainRoute{
 		ID:            uuid.New(),
 		DomainID:      req.DomainID,
@@ -246,7 +243,7 @@ func (s *Service) CreateDomainRoute(req *models.CreateDomainRouteRequest) (*mode
 	}
 
 	// Создаем действие для обновления nginx конфигурации
-	if err := s.createNginxUpdateAction(req.DomainID); err != nil {
+	if err := s.createNginxUpdateAction(req.DomainID, userID); err != nil {
 		// Логируем ошибку, но не прерываем создание маршрута
 		fmt.Printf("Warning: failed to create nginx update action: %v\n", err)
 	}
@@ -282,8 +279,23 @@ func (s *Service) GetDomainRoutes(domainID uuid.UUID) ([]models.DomainRoute, err
 	return routes, nil
 }
 
+// GetDomainRouteByID получает маршрут домена по ID
+func (s *Service) GetDomainRouteByID(id uuid.UUID) (*models.DomainRoute, error) {
+	var route models.DomainRoute
+	err := s.db.QueryRow(`
+		SELECT id, domain_id, container_name, port, path, is_active, created, updated
+		FROM domain_routes WHERE id = $1
+	`, id).Scan(
+		&route.ID, &route.DomainID, &route.ContainerName, &route.Port, &route.Path, &route.IsActive, &route.Created, &route.Updated,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain route: %v", err)
+	}
+	return &route, nil
+}
+
 // UpdateDomainRoute обновляет маршрут домена
-func (s *Service) UpdateDomainRoute(id uuid.UUID, req *models.UpdateDomainRouteRequest) (*models.DomainRoute, error) {
+func (s *Service) UpdateDomainRoute(id uuid.UUID, req *models.UpdateDomainRouteRequest, userID *uuid.UUID) (*models.DomainRoute, error) {
 	// Получаем текущий маршрут
 	var route models.DomainRoute
 	err := s.db.QueryRow(`
@@ -323,7 +335,7 @@ func (s *Service) UpdateDomainRoute(id uuid.UUID, req *models.UpdateDomainRouteR
 	}
 
 	// Создаем действие для обновления nginx конфигурации
-	if err := s.createNginxUpdateAction(route.DomainID); err != nil {
+	if err := s.createNginxUpdateAction(route.DomainID, userID); err != nil {
 		// Логируем ошибку, но не прерываем обновление маршрута
 		fmt.Printf("Warning: failed to create nginx update action: %v\n", err)
 	}
@@ -332,7 +344,7 @@ func (s *Service) UpdateDomainRoute(id uuid.UUID, req *models.UpdateDomainRouteR
 }
 
 // DeleteDomainRoute удаляет маршрут домена
-func (s *Service) DeleteDomainRoute(id uuid.UUID) error {
+func (s *Service) DeleteDomainRoute(id uuid.UUID, userID *uuid.UUID) error {
 	// Получаем domain_id перед удалением
 	var domainID uuid.UUID
 	err := s.db.QueryRow("SELECT domain_id FROM domain_routes WHERE id = $1", id).Scan(&domainID)
@@ -347,7 +359,7 @@ func (s *Service) DeleteDomainRoute(id uuid.UUID) error {
 	}
 
 	// Создаем действие для обновления nginx конфигурации
-	if err := s.createNginxUpdateAction(domainID); err != nil {
+	if err := s.createNginxUpdateAction(domainID, userID); err != nil {
 		// Логируем ошибку, но не прерываем удаление маршрута
 		fmt.Printf("Warning: failed to create nginx update action: %v\n", err)
 	}
@@ -356,7 +368,7 @@ func (s *Service) DeleteDomainRoute(id uuid.UUID) error {
 }
 
 // createNginxUpdateAction создает действие для обновления nginx конфигурации
-func (s *Service) createNginxUpdateAction(domainID uuid.UUID) error {
+func (s *Service) createNginxUpdateAction(domainID uuid.UUID, userID *uuid.UUID) error {
 	// Получаем информацию о домене
 	var domainName string
 	var agentID uuid.UUID
@@ -408,7 +420,7 @@ func (s *Service) createNginxUpdateAction(domainID uuid.UUID) error {
 	}
 
 	// Создаем действие
-	return s.createAction(agentID, "update_nginx_config", payload)
+	return s.createAction(agentID, models.ActionTypeUpdateNginxConfig, payload, userID)
 }
 
 // GetAgentNginxConfig получает конфигурацию nginx для агента
@@ -0,0 +1,239 @@
+package enrollment
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+const (
+	// TokenPrefix отличает токены регистрации от постоянных токенов агентов
+	TokenPrefix = "mse_"
+	// tokenDisplayLength — длина начала токена, которое хранится открыто для узнавания в списке
+	tokenDisplayLength = 12
+	// defaultTokenTTL и maxTokenTTL ограничивают срок действия токена регистрации
+	defaultTokenTTL = 24 * time.Hour
+	maxTokenTTL     = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrTokenNotFound возвращается, если токен регистрации не найден
+	ErrTokenNotFound = errors.New("enrollment token not found")
+	// ErrInvalidRequest возвращается при некорректных параметрах токена или регистрации
+	ErrInvalidRequest = errors.New("invalid enrollment request")
+	// ErrInvalidToken возвращается для неизвестного, использованного, отозванного или просроченного токена
+	ErrInvalidToken = errors.New("enrollment token is invalid, used, revoked or expired")
+	// ErrAgentNotFound возвращается, если подтверждаемый агент не найден
+	ErrAgentNotFound = errors.New("agent not found")
+)
+
+// Service выдает одноразовые токены регистрации и регистрирует по ним агентов
+type Service struct {
+	db   *sql.DB
+	auth *auth.Service
+}
+
+func NewService(db *sql.DB, authService *auth.Service) *Service {
+	return &Service{db: db, auth: authService}
+}
+
+// hashToken возвращает SHA-256 хеш токена регистрации в hex
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+const tokenColumns = "id, name, token_prefix, auto_approve, expires, used, agent_id, revoked, created_by, created"
+
+func scanToken(row interface{ Scan(...interface{}) error }) (*models.AgentEnrollmentToken, error) {
+	token := &models.AgentEnrollmentToken{}
+	err := row.Scan(
+		&token.ID, &token.Name, &token.Prefix, &token.AutoApprove, &token.Expires,
+		&token.Used, &token.AgentID, &token.Revoked, &token.CreatedBy, &token.Created,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// CreateToken создает токен регистрации агента
+func (s *Service) CreateToken(createdBy *uuid.UUID, req *models.CreateEnrollmentTokenRequest) (*models.CreateEnrollmentTokenResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+
+	ttl := defaultTokenTTL
+	if req.ExpiresInHours < 0 {
+		return nil, fmt.Errorf("%w: expires_in_hours must not be negative", ErrInvalidRequest)
+	}
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl > maxTokenTTL {
+		return nil, fmt.Errorf("%w: expires_in_hours must not exceed %d", ErrInvalidRequest, int(maxTokenTTL.Hours()))
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %v", err)
+	}
+	value := TokenPrefix + hex.EncodeToString(raw)
+
+	token, err := scanToken(s.db.QueryRow(`
+		INSERT INTO agent_enrollment_tokens (name, token_prefix, token_hash, auto_approve, expires, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+tokenColumns,
+		req.Name, value[:tokenDisplayLength], hashToken(value), req.AutoApprove, time.Now().Add(ttl), createdBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create enrollment token: %v", err)
+	}
+
+	return &models.CreateEnrollmentTokenResponse{AgentEnrollmentToken: *token, Token: value}, nil
+}
+
+// GetTokens получает токены регистрации, включая использованные и отозванные
+func (s *Service) GetTokens() ([]models.AgentEnrollmentToken, error) {
+	rows, err := s.db.Query("SELECT " + tokenColumns + " FROM agent_enrollment_tokens ORDER BY created DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrollment tokens: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.AgentEnrollmentToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan enrollment token: %v", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, nil
+}
+
+// RevokeToken отзывает токен регистрации. Повторный отзыв не меняет время отзыва.
+func (s *Service) RevokeToken(tokenID uuid.UUID) (*models.AgentEnrollmentToken, error) {
+	token, err := scanToken(s.db.QueryRow(`
+		UPDATE agent_enrollment_tokens SET revoked = COALESCE(revoked, NOW())
+		WHERE id = $1
+		RETURNING `+tokenColumns,
+		tokenID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke enrollment token: %v", err)
+	}
+	return token, nil
+}
+
+// Register погашает токен регистрации и создает агента с постоянным токеном.
+// Если токен выдан без автоподтверждения, агент ждет подтверждения администратором.
+func (s *Service) Register(value string, req *models.AgentRegisterRequest) (*models.AgentRegisterResponse, error) {
+	if !strings.HasPrefix(value, TokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	var hostname string
+	var facts interface{}
+	if req.Facts != nil {
+		hostname = strings.TrimSpace(req.Facts.Hostname)
+		data, err := json.Marshal(req.Facts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal agent facts: %v", err)
+		}
+		facts = data
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = hostname
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: name or facts.hostname is required", ErrInvalidRequest)
+	}
+
+	agentToken, err := s.auth.GenerateAgentToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate agent token: %v", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Токен погашается атомарно: при одновременной регистрации он достанется только одному агенту
+	var tokenID uuid.UUID
+	var autoApprove bool
+	err = tx.QueryRow(`
+		UPDATE agent_enrollment_tokens SET used = NOW()
+		WHERE token_hash = $1 AND used IS NULL AND revoked IS NULL AND expires > NOW()
+		RETURNING id, auto_approve
+	`, hashToken(value)).Scan(&tokenID, &autoApprove)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to use enrollment token: %v", err)
+	}
+
+	var hostnameValue interface{}
+	if hostname != "" {
+		hostnameValue = hostname
+	}
+
+	response := &models.AgentRegisterResponse{Name: name, Token: agentToken.Value, Approved: autoApprove}
+	err = tx.QueryRow(`
+		INSERT INTO agents (name, token_prefix, token_hash, is_active, approved, hostname, facts, facts_updated, created)
+		VALUES ($1, $2, $3, true, $4, $5, $6, CASE WHEN $6::jsonb IS NOT NULL THEN NOW() END, NOW())
+		RETURNING id
+	`, name, agentToken.Prefix, agentToken.Hash, autoApprove, hostnameValue, facts).Scan(&response.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE agent_enrollment_tokens SET agent_id = $1 WHERE id = $2", response.AgentID, tokenID); err != nil {
+		return nil, fmt.Errorf("failed to link enrollment token: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return response, nil
+}
+
+// Approve подтверждает зарегистрированного агента. changed = false, если агент уже был подтвержден.
+func (s *Service) Approve(agentID uuid.UUID) (changed bool, err error) {
+	result, err := s.db.Exec("UPDATE agents SET approved = true WHERE id = $1 AND approved = false", agentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to approve agent: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM agents WHERE id = $1)", agentID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to get agent: %v", err)
+	}
+	if !exists {
+		return false, ErrAgentNotFound
+	}
+	return false, nil
+}
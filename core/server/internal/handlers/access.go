@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// canAccessAgent проверяет, может ли текущий пользователь работать с агентом
+func canAccessAgent(r *http.Request, agentID uuid.UUID) bool {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	return ok && claims.CanAccessAgent(agentID)
+}
+
+// requireAgentAccess отвечает 403, если агент недоступен текущему пользователю
+func requireAgentAccess(w http.ResponseWriter, r *http.Request, agentID uuid.UUID) bool {
+	if !canAccessAgent(r, agentID) {
+		http.Error(w, "No access to this agent", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// agentScope возвращает параметр для фильтра "($n::uuid[] IS NULL OR agent_id = ANY($n))"
+// в сводных запросах: NULL, если пользователю доступны все агенты, иначе список его агентов
+func agentScope(r *http.Request) interface{} {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		return pq.Array([]string{})
+	}
+	if claims.Role == models.UserRoleAdmin || len(claims.AgentIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(claims.AgentIDs))
+	for i, id := range claims.AgentIDs {
+		ids[i] = id.String()
+	}
+	return pq.Array(ids)
+}
+
+// hasPermission проверяет, есть ли у текущего пользователя право
+func hasPermission(r *http.Request, permission string) bool {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	return ok && claims.HasPermission(permission)
+}
+
+// requireDomainAccess отвечает 404 или 403, если домен не найден или размещен на недоступном агенте
+func (h *Handlers) requireDomainAccess(w http.ResponseWriter, r *http.Request, domainID uuid.UUID) bool {
+	domain, err := h.domain.GetDomainByID(domainID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return false
+	}
+	return requireAgentAccess(w, r, domain.AgentID)
+}
+
+// requireDomainRouteAccess проверяет доступ к агенту домена, которому принадлежит маршрут
+func (h *Handlers) requireDomainRouteAccess(w http.ResponseWriter, r *http.Request, routeID uuid.UUID) bool {
+	route, err := h.domain.GetDomainRouteByID(routeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return false
+	}
+	return h.requireDomainAccess(w, r, route.DomainID)
+}
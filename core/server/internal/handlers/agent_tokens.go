@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// RotateAgentToken выпускает агенту новый токен
+// @Summary Ротация токена агента
+// @Description Выпускает новый токен и создает действие rotate_token, которое доставит его агенту при следующем пинге. До конца переходного периода или до первого запроса с новым токеном принимается и старый токен. Незавершенная предыдущая ротация отменяется. Доступно только администраторам.
+// @Tags agents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID агента"
+// @Param request body models.RotateAgentTokenRequest false "Переходный период"
+// @Success 200 {object} models.RotateAgentTokenResponse "Новый токен выпущен"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 404 {string} string "Агент не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /agents/{id}/rotate-token [post]
+func (h *Handlers) RotateAgentToken(w http.ResponseWriter, r *http.Request) {
+	agentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+		return
+	}
+
+	// Тело запроса необязательно: без него используется переходный период по умолчанию
+	var req models.RotateAgentTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.getAgentRecord(agentID)
+
+	response, err := h.auth.RotateAgentToken(agentID, time.Duration(req.GracePeriodHours)*time.Hour, currentUserID(r))
+	if err != nil {
+		writeAgentTokenError(w, err)
+		return
+	}
+
+	if after, err := h.getAgentRecord(agentID); err == nil {
+		h.audit.Record(r, models.AuditEntityAgent, agentID.String(),
+			fmt.Sprintf("Rotated token of agent %s, old token valid until %s", after.Name, response.PreviousTokenExpires.Format(time.RFC3339)),
+			before, after)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeAgentTokenError сопоставляет ошибки токенов агентов с HTTP-статусами
+func writeAgentTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidRotation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, auth.ErrAgentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
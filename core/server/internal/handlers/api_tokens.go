@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// GetAPITokens получает API-токены текущего пользователя
+// @Summary Получение своих API-токенов
+// @Description Возвращает персональные API-токены, включая отозванные. Значения токенов не возвращаются.
+// @Tags profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.APITokenListResponse "Список токенов"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Управление токенами по API-токену запрещено"
+// @Router /profile/tokens [get]
+func (h *Handlers) GetAPITokens(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+	h.writeAPITokens(w, claims.UserID)
+}
+
+// CreateAPIToken создает API-токен текущего пользователя
+// @Summary Создание API-токена
+// @Description Создает долгоживущий токен для скриптов и CI. Токен передается в заголовке Authorization: Bearer и показывается только в этом ответе. Права токена (scopes) не могут превышать права роли; пустой список — все права роли.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateAPITokenRequest true "Параметры токена"
+// @Success 201 {object} models.CreateAPITokenResponse "Токен создан"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Управление токенами по API-токену запрещено"
+// @Router /profile/tokens [post]
+func (h *Handlers) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.auth.CreateAPIToken(claims.UserID, claims.Role, &req)
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityAPIToken, created.ID.String(), fmt.Sprintf("Created API token %s", created.Name), nil, created.APIToken)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// RevokeAPIToken отзывает API-токен текущего пользователя
+// @Summary Отзыв своего API-токена
+// @Tags profile
+// @Security BearerAuth
+// @Param id path string true "ID токена"
+// @Success 204 "Токен отозван"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Управление токенами по API-токену запрещено"
+// @Failure 404 {string} string "Токен не найден"
+// @Router /profile/tokens/{id} [delete]
+func (h *Handlers) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+	h.revokeAPIToken(w, r, claims.UserID, chi.URLParam(r, "id"))
+}
+
+// GetUserAPITokens получает API-токены пользователя
+// @Summary Получение API-токенов пользователя
+// @Description Доступно только администраторам
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.APITokenListResponse "Список токенов"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Router /users/{id}/tokens [get]
+func (h *Handlers) GetUserAPITokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	h.writeAPITokens(w, userID)
+}
+
+// RevokeUserAPIToken отзывает API-токен пользователя
+// @Summary Отзыв API-токена пользователя
+// @Description Доступно только администраторам
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Param token_id path string true "ID токена"
+// @Success 204 "Токен отозван"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Токен не найден"
+// @Router /users/{id}/tokens/{token_id} [delete]
+func (h *Handlers) RevokeUserAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	h.revokeAPIToken(w, r, userID, chi.URLParam(r, "token_id"))
+}
+
+// requireInteractiveUser отвечает 403, если запрос выполнен по API-токену:
+// утекший токен не должен позволять выпускать новые токены, менять пароль,
+// 2FA и профиль или распоряжаться сессиями владельца
+func requireInteractiveUser(w http.ResponseWriter, r *http.Request) (*auth.Claims, bool) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	if claims.APITokenID != nil {
+		http.Error(w, "Not allowed with an API token", http.StatusForbidden)
+		return nil, false
+	}
+	return claims, true
+}
+
+func (h *Handlers) writeAPITokens(w http.ResponseWriter, userID uuid.UUID) {
+	tokens, err := h.auth.GetAPITokens(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := models.APITokenListResponse{
+		Tokens: tokens,
+		Total:  len(tokens),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handlers) revokeAPIToken(w http.ResponseWriter, r *http.Request, userID uuid.UUID, rawTokenID string) {
+	tokenID, err := uuid.Parse(rawTokenID)
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.auth.RevokeAPIToken(userID, tokenID)
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityAPIToken, tokenID.String(), fmt.Sprintf("Revoked API token %s", token.Name), nil, token)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeAPITokenError сопоставляет ошибки API-токенов с HTTP-статусами
+func writeAPITokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrAPITokenNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, auth.ErrInvalidAPIToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
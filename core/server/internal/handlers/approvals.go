@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/approvals"
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// GetApprovalPolicies получает список политик подтверждения
+// @Summary Получение политик подтверждения
+// @Description Возвращает политики, по которым действия удерживаются до подтверждения
+// @Tags approvals
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.ApprovalPolicyListResponse "Список политик"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /approval-policies [get]
+func (h *Handlers) GetApprovalPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.approval.GetPolicies()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := models.ApprovalPolicyListResponse{
+		Policies: policies,
+		Total:    len(policies),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateApprovalPolicy создает политику подтверждения
+// @Summary Создание политики подтверждения
+// @Description Действия указанного типа (на указанном агенте или на всех агентах) будут создаваться в статусе awaiting_approval. Доступно только администраторам.
+// @Tags approvals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateApprovalPolicyRequest true "Данные политики"
+// @Success 201 {object} models.ApprovalPolicy "Политика создана"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /approval-policies [post]
+func (h *Handlers) CreateApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateApprovalPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.approval.CreatePolicy(&req)
+	if err != nil {
+		if errors.Is(err, approvals.ErrInvalidPolicy) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityApprovalPolicy, policy.ID.String(),
+		fmt.Sprintf("Created approval policy for %s", policy.ActionType), nil, policy)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// DeleteApprovalPolicy удаляет политику подтверждения
+// @Summary Удаление политики подтверждения
+// @Description Доступно только администраторам
+// @Tags approvals
+// @Security BearerAuth
+// @Param id path string true "ID политики"
+// @Success 204 "Политика удалена"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /approval-policies/{id} [delete]
+func (h *Handlers) DeleteApprovalPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid policy ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.approval.DeletePolicy(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityApprovalPolicy, id.String(), "Deleted approval policy", nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetApprovals получает список запросов на подтверждение
+// @Summary Получение запросов на подтверждение
+// @Description По умолчанию возвращает только действия, ожидающие решения; status=all возвращает также историю решений
+// @Tags approvals
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending (по умолчанию) или all"
+// @Success 200 {object} models.ActionApprovalListResponse "Список запросов"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /approvals [get]
+func (h *Handlers) GetApprovals(w http.ResponseWriter, r *http.Request) {
+	pendingOnly := r.URL.Query().Get("status") != "all"
+
+	list, err := h.approval.GetApprovals(pendingOnly)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	visible := []models.ActionApproval{}
+	for _, approval := range list {
+		if canAccessAgent(r, approval.Action.AgentID) {
+			visible = append(visible, approval)
+		}
+	}
+
+	response := models.ActionApprovalListResponse{
+		Approvals: visible,
+		Total:     len(visible),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ApproveAction подтверждает удержанное действие
+// @Summary Подтверждение действия
+// @Description Переводит действие в статус pending, после чего оно будет выдано агенту. Требуется роль approver или admin; подтвердить собственное действие нельзя.
+// @Tags approvals
+// @Accept json
+// @Security BearerAuth
+// @Param id path string true "ID действия"
+// @Param request body models.ApprovalDecisionRequest true "Причина решения"
+// @Success 204 "Действие подтверждено"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 409 {string} string "Действие не ожидает подтверждения"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /actions/{id}/approve [post]
+func (h *Handlers) ApproveAction(w http.ResponseWriter, r *http.Request) {
+	h.decideAction(w, r, true)
+}
+
+// RejectAction отклоняет удержанное действие
+// @Summary Отклонение действия
+// @Description Переводит действие в статус rejected. Требуется роль approver или admin; отклонить собственное действие нельзя.
+// @Tags approvals
+// @Accept json
+// @Security BearerAuth
+// @Param id path string true "ID действия"
+// @Param request body models.ApprovalDecisionRequest true "Причина решения"
+// @Success 204 "Действие отклонено"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 409 {string} string "Действие не ожидает подтверждения"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /actions/{id}/reject [post]
+func (h *Handlers) RejectAction(w http.ResponseWriter, r *http.Request) {
+	h.decideAction(w, r, false)
+}
+
+func (h *Handlers) decideAction(w http.ResponseWriter, r *http.Request, approve bool) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid action ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ApprovalDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.getAction(id)
+	if err != nil {
+		http.Error(w, "Action not found", http.StatusNotFound)
+		return
+	}
+	if !requireAgentAccess(w, r, before.AgentID) {
+		return
+	}
+
+	err = h.approval.Decide(id, claims.UserID, approve, req.Reason)
+	switch {
+	case err == nil:
+	case errors.Is(err, approvals.ErrReasonRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, approvals.ErrSelfApproval):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, approvals.ErrNotAwaiting):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if after, err := h.getAction(id); err == nil {
+		decision := "Rejected"
+		if approve {
+			decision = "Approved"
+		}
+		h.audit.Record(r, models.AuditEntityAction, id.String(),
+			fmt.Sprintf("%s action %s: %s", decision, after.Type, req.Reason), before, after)
+	}
+
+	// Отклоненное действие может быть шагом сценария — продвигаем его
+	if !approve {
+		if err := h.workflow.HandleActionUpdate(id); err != nil {
+			log.Printf("Error advancing workflow for action %s: %v", id, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
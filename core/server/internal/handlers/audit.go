@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// currentUserID возвращает ID пользователя из JWT-контекста запроса
+func currentUserID(r *http.Request) *uuid.UUID {
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		return &claims.UserID
+	}
+	return nil
+}
+
+// GetAuditLog получает журнал аудита
+// @Summary Получение журнала аудита
+// @Description Возвращает записи об изменяющих API-вызовах: пользователь, IP, описание и изменения полей
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "ID пользователя"
+// @Param entity_type query string false "Тип сущности (agent, domain, action, notification_settings, ...)"
+// @Param entity_id query string false "ID сущности"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param limit query int false "Лимит записей (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.AuditListResponse "Записи журнала аудита"
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /audit [get]
+func (h *Handlers) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		Limit:      100,
+	}
+
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if !parseListParams(w, query, &filter.From, &filter.To, &filter.Limit, &filter.Offset) {
+		return
+	}
+
+	entries, total, err := h.audit.GetEntries(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := models.AuditListResponse{
+		Entries: entries,
+		Total:   total,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseListParams разбирает общие параметры журналов: период from/to (RFC3339)
+// и пагинацию limit/offset (limit не больше 1000). При ошибке отвечает 400.
+func parseListParams(w http.ResponseWriter, query url.Values, from, to **time.Time, limit, offset *int) bool {
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{"from", from},
+		{"to", to},
+	} {
+		if value := query.Get(param.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, "Invalid "+param.name+" parameter", http.StatusBadRequest)
+				return false
+			}
+			*param.target = &t
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value <= 0 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return false
+		}
+		if value > 1000 {
+			value = 1000
+		}
+		*limit = value
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		value, err := strconv.Atoi(offsetStr)
+		if err != nil || value < 0 {
+			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+			return false
+		}
+		*offset = value
+	}
+
+	return true
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/audit"
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// reserveLoginAttempt отклоняет попытку входа, пока для имени пользователя или IP
+// действует задержка или блокировка, иначе заранее учитывает ее как неудачную.
+// Попытка завершается через loginFailed или releaseLoginAttempt.
+// Возвращает false, если ответ уже отправлен.
+func (h *Handlers) reserveLoginAttempt(w http.ResponseWriter, r *http.Request, userID *uuid.UUID, username string) bool {
+	wait, err := h.guard.Reserve(username, audit.ClientIP(r))
+	if err != nil {
+		log.Printf("Error reserving login attempt: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	if wait <= 0 {
+		return true
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	h.guard.RecordEvent(r, userID, username, models.AuthEventBlocked, fmt.Sprintf("retry after %ds", seconds))
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
+	return false
+}
+
+// releaseLoginAttempt снимает учтенную попытку входа, которая не оказалась неудачной
+func (h *Handlers) releaseLoginAttempt(r *http.Request, username string) {
+	if err := h.guard.Release(username, audit.ClientIP(r)); err != nil {
+		log.Printf("Error releasing login attempt: %v", err)
+	}
+}
+
+// loginFailed подтверждает неудачную попытку входа и записывает события входа
+func (h *Handlers) loginFailed(r *http.Request, userID *uuid.UUID, username, event, detail string) {
+	h.guard.RecordEvent(r, userID, username, event, detail)
+
+	locked, err := h.guard.RecordFailure(username, audit.ClientIP(r))
+	if err != nil {
+		log.Printf("Error recording login failure: %v", err)
+		return
+	}
+	if locked {
+		log.Printf("Login temporarily locked for user %q from %s", username, audit.ClientIP(r))
+		h.guard.RecordEvent(r, userID, username, models.AuthEventLockout, "too many failed login attempts")
+	}
+}
+
+// GetAuthEvents получает журнал событий входа
+// @Summary Получение событий входа
+// @Description Возвращает успешные и неудачные входы, задержки и блокировки, выходы из системы
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "ID пользователя"
+// @Param username query string false "Имя пользователя, как введено при входе"
+// @Param ip query string false "IP клиента"
+// @Param event query string false "Тип события (login_success, login_failure, mfa_failure, lockout, login_blocked, unlock, logout)"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param limit query int false "Лимит записей (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.AuthEventListResponse "События входа"
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /auth-events [get]
+func (h *Handlers) GetAuthEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuthEventFilter{
+		Username: query.Get("username"),
+		IP:       query.Get("ip"),
+		Event:    query.Get("event"),
+		Limit:    100,
+	}
+
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if !parseListParams(w, query, &filter.From, &filter.To, &filter.Limit, &filter.Offset) {
+		return
+	}
+
+	h.writeAuthEvents(w, filter)
+}
+
+// GetMyAuthEvents получает события входа текущего пользователя
+// @Summary Получение своих событий входа
+// @Description Позволяет заметить чужие попытки входа в учетную запись
+// @Tags profile
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param limit query int false "Лимит записей (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.AuthEventListResponse "События входа"
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 401 {string} string "Не авторизован"
+// @Router /profile/auth-events [get]
+func (h *Handlers) GetMyAuthEvents(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter := models.AuthEventFilter{UserID: &claims.UserID, Limit: 100}
+	if !parseListParams(w, r.URL.Query(), &filter.From, &filter.To, &filter.Limit, &filter.Offset) {
+		return
+	}
+
+	h.writeAuthEvents(w, filter)
+}
+
+func (h *Handlers) writeAuthEvents(w http.ResponseWriter, filter models.AuthEventFilter) {
+	events, total, err := h.guard.GetEvents(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.AuthEventListResponse{
+		Events: events,
+		Total:  total,
+	})
+}
+
+// UnlockUser снимает блокировку входа пользователя
+// @Summary Снятие блокировки входа
+// @Description Сбрасывает счетчик неудачных попыток входа пользователя и снимает временную блокировку. Блокировка по IP не снимается. Доступно только администраторам.
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 204 "Блокировка снята"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Router /users/{id}/unlock [post]
+func (h *Handlers) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.user.GetUser(id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	locked, err := h.guard.Unlock(user.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if locked {
+		h.guard.RecordEvent(r, &user.ID, user.Username, models.AuthEventUnlock, "")
+		h.audit.Record(r, models.AuditEntityUser, user.ID.String(), fmt.Sprintf("Unlocked login for user %s", user.Username), nil, nil)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+// containerStateColumns — колонки состояния контейнера в порядке containerStateValues
+const containerStateColumns = `state, health_status, health_output, health_failing_streak, exit_code, oom_killed,
+	started_at, finished_at, restart_policy, restart_max_retries, ports, mounts, labels`
+
+// containerStateSelect — те же колонки для выборки из containers c, порядок как у containerStateScan
+const containerStateSelect = `c.state, c.health_status, c.health_output, c.health_failing_streak, c.exit_code, c.oom_killed,
+	c.started_at, c.finished_at, c.restart_policy, c.restart_max_retries, c.ports, c.mounts, c.labels`
+
+// containerStateValues возвращает значения колонок состояния контейнера для записи.
+// Старые агенты состояние не присылают: колонки остаются NULL.
+func containerStateValues(container models.ContainerInfo) ([]interface{}, error) {
+	if container.State == "" {
+		return make([]interface{}, 13), nil
+	}
+
+	var healthStatus, healthOutput sql.NullString
+	var healthFailingStreak sql.NullInt64
+	if container.Health != nil {
+		healthStatus = sql.NullString{String: container.Health.Status, Valid: true}
+		healthOutput = sql.NullString{String: container.Health.LastOutput, Valid: true}
+		healthFailingStreak = sql.NullInt64{Int64: int64(container.Health.FailingStreak), Valid: true}
+	}
+
+	ports, err := json.Marshal(container.Ports)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := json.Marshal(container.Mounts)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := json.Marshal(container.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{
+		container.State, healthStatus, healthOutput, healthFailingStreak,
+		container.ExitCode, container.OOMKilled,
+		optionalTime(container.StartedAt), optionalTime(container.FinishedAt),
+		container.RestartPolicy.Name, container.RestartPolicy.MaximumRetryCount,
+		ports, mounts, labels,
+	}, nil
+}
+
+// optionalTime разбирает время RFC3339 от агента; пустая строка — события не было
+func optionalTime(value string) sql.NullTime {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: parsed, Valid: true}
+}
+
+// containerStateScan принимает колонки containerStateSelect; jsonb-колонки разбирает apply
+type containerStateScan struct {
+	ports, mounts, labels []byte
+}
+
+// targets возвращает приемники для rows.Scan
+func (s *containerStateScan) targets(container *models.Container) []interface{} {
+	return []interface{}{
+		&container.State, &container.HealthStatus, &container.HealthOutput, &container.HealthFailingStreak,
+		&container.ExitCode, &container.OOMKilled, &container.StartedAt, &container.FinishedAt,
+		&container.RestartPolicy, &container.RestartMaxRetries, &s.ports, &s.mounts, &s.labels,
+	}
+}
+
+// apply разбирает порты, тома и метки контейнера
+func (s *containerStateScan) apply(container *models.Container) {
+	for _, column := range []struct {
+		data   []byte
+		target interface{}
+	}{
+		{s.ports, &container.Ports},
+		{s.mounts, &container.Mounts},
+		{s.labels, &container.Labels},
+	} {
+		if len(column.data) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(column.data, column.target); err != nil {
+			log.Printf("Error parsing container state: %v", err)
+		}
+	}
+}
+
+// containerStopped сообщает, что контейнер остановился с прошлого пинга. Контейнер,
+// остановленный и в прошлом пинге, уже был учтен и повторно не сообщается.
+func (p *previousCounters) containerStopped(container models.ContainerInfo) bool {
+	if p == nil || !container.Stopped() {
+		return false
+	}
+	previous, ok := p.containerStates[container.ID]
+	return ok && previous != "exited" && previous != "dead"
+}
@@ -133,7 +133,7 @@ func (h *DomainHandler) UpdateDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	domain, err := h.domainService.UpdateDomain(id, &req)
+	domain, err := h.domainService.UpdateDomain(id, &req, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -187,7 +187,7 @@ func (h *DomainHandler) CreateDomainRoute(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	route, err := h.domainService.CreateDomainRoute(&req)
+	route, err := h.domainService.CreateDomainRoute(&req, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -257,7 +257,7 @@ func (h *DomainHandler) UpdateDomainRoute(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	route, err := h.domainService.UpdateDomainRoute(id, &req)
+	route, err := h.domainService.UpdateDomainRoute(id, &req, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -284,7 +284,7 @@ func (h *DomainHandler) DeleteDomainRoute(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = h.domainService.DeleteDomainRoute(id)
+	err = h.domainService.DeleteDomainRoute(id, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/enrollment"
+	"monitoring-system/core/server/internal/models"
+)
+
+// parseAgentFacts разбирает сведения о хосте из JSONB; пустое или некорректное значение — nil
+func parseAgentFacts(data []byte) *models.AgentFacts {
+	if len(data) == 0 {
+		return nil
+	}
+	var facts models.AgentFacts
+	if err := json.Unmarshal(data, &facts); err != nil {
+		log.Printf("Error parsing agent facts: %v", err)
+		return nil
+	}
+	return &facts
+}
+
+// agentFactsFilterFields — сведения о хосте, по которым можно отбирать агентов
+var agentFactsFilterFields = []string{
+	"os", "platform", "platform_version", "kernel_version", "architecture", "cpu_model", "docker_version", "agent_version",
+}
+
+// agentFactsFilter собирает из параметров запроса JSON для отбора агентов по facts @>.
+// Пустая строка (NULL) — отбор не задан.
+func agentFactsFilter(query url.Values) (sql.NullString, error) {
+	filter := make(map[string]string)
+	for _, field := range agentFactsFilterFields {
+		if value := query.Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	if len(filter) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// RegisterAgent регистрирует агента по одноразовому токену
+// @Summary Регистрация агента
+// @Description Агент, запущенный с токеном регистрации, сообщает имя хоста и сведения о нем и получает постоянный токен. Токен регистрации действует один раз. Если токен выдан без auto_approve, пинги агента отклоняются (403) до подтверждения администратором.
+// @Tags agent-data
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer токен регистрации (mse_...)"
+// @Param request body models.AgentRegisterRequest true "Имя и сведения о хосте"
+// @Success 201 {object} models.AgentRegisterResponse "Постоянные учетные данные агента"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 401 {string} string "Токен регистрации недействителен"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /agent/register [post]
+func (h *Handlers) RegisterAgent(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AgentRegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.enrollment.Register(token, &req)
+	if err != nil {
+		writeEnrollmentError(w, err)
+		return
+	}
+	response.SigningKey = h.auth.AgentSigningKey(response.Token)
+
+	summary := fmt.Sprintf("Registered agent %s via enrollment token", response.Name)
+	if !response.Approved {
+		summary += ", awaiting approval"
+	}
+	h.audit.Record(r, models.AuditEntityAgent, response.AgentID.String(), summary, nil, nil)
+	log.Printf("Agent %s (%s) registered, approved: %v", response.Name, response.AgentID, response.Approved)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetEnrollmentTokens получает токены регистрации агентов
+// @Summary Получение токенов регистрации
+// @Description Возвращает токены регистрации агентов, включая использованные и отозванные. Сами токены не возвращаются. Доступно только администраторам.
+// @Tags agents
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.EnrollmentTokenListResponse "Список токенов"
+// @Failure 401 {string} string "Не авторизован"
+// @Router /agents/enrollment-tokens [get]
+func (h *Handlers) GetEnrollmentTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.enrollment.GetTokens()
+	if err != nil {
+		writeEnrollmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.EnrollmentTokenListResponse{
+		Tokens: tokens,
+		Total:  len(tokens),
+	})
+}
+
+// CreateEnrollmentToken создает токен регистрации агента
+// @Summary Создание токена регистрации
+// @Description Создает одноразовый токен, с которым агент регистрируется сам (переменная ENROLLMENT_TOKEN). Токен показывается только в ответе на этот запрос. Доступно только администраторам.
+// @Tags agents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateEnrollmentTokenRequest true "Параметры токена"
+// @Success 201 {object} models.CreateEnrollmentTokenResponse "Токен создан"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 401 {string} string "Не авторизован"
+// @Router /agents/enrollment-tokens [post]
+func (h *Handlers) CreateEnrollmentToken(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateEnrollmentTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.enrollment.CreateToken(currentUserID(r), &req)
+	if err != nil {
+		writeEnrollmentError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityEnrollmentToken, token.ID.String(), fmt.Sprintf("Created agent enrollment token %s", token.Name), nil, token.AgentEnrollmentToken)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// RevokeEnrollmentToken отзывает токен регистрации агента
+// @Summary Отзыв токена регистрации
+// @Description Доступно только администраторам
+// @Tags agents
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID токена"
+// @Success 200 {object} models.AgentEnrollmentToken "Токен отозван"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Токен не найден"
+// @Router /agents/enrollment-tokens/{id} [delete]
+func (h *Handlers) RevokeEnrollmentToken(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.enrollment.RevokeToken(id)
+	if err != nil {
+		writeEnrollmentError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityEnrollmentToken, token.ID.String(), fmt.Sprintf("Revoked agent enrollment token %s", token.Name), nil, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+// ApproveAgent подтверждает зарегистрированного агента
+// @Summary Подтверждение агента
+// @Description Разрешает агенту, зарегистрированному по токену без auto_approve, отправлять данные. Чтобы отклонить регистрацию, удалите агента. Доступно только администраторам.
+// @Tags agents
+// @Security BearerAuth
+// @Param id path string true "ID агента"
+// @Success 204 "Агент подтвержден"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Агент не найден"
+// @Router /agents/{id}/approve [post]
+func (h *Handlers) ApproveAgent(w http.ResponseWriter, r *http.Request) {
+	agentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.getAgentRecord(agentID)
+
+	changed, err := h.enrollment.Approve(agentID)
+	if err != nil {
+		writeEnrollmentError(w, err)
+		return
+	}
+
+	if changed {
+		if after, err := h.getAgentRecord(agentID); err == nil {
+			h.audit.Record(r, models.AuditEntityAgent, agentID.String(), fmt.Sprintf("Approved agent %s", after.Name), before, after)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeEnrollmentError сопоставляет ошибки регистрации агентов с HTTP-статусами
+func writeEnrollmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, enrollment.ErrInvalidToken):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, enrollment.ErrInvalidRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, enrollment.ErrTokenNotFound), errors.Is(err, enrollment.ErrAgentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
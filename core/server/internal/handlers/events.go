@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+// saveContainerEvents сохраняет события Docker из пинга. Агент повторяет события,
+// если прошлый пинг не дошел, поэтому уже сохраненные события пропускаются.
+func saveContainerEvents(tx *sql.Tx, agentID uuid.UUID, events []models.DockerEventInfo) error {
+	for _, event := range events {
+		eventTime := optionalTime(event.Time)
+		if !eventTime.Valid || event.Action == "" || event.ID == "" {
+			log.Printf("Skipping malformed docker event from agent %s: %+v", agentID, event)
+			continue
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO container_events (agent_id, type, action, actor_id, actor_name, image, status, exit_code, signal, time)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, NULLIF($9, ''), $10)
+			ON CONFLICT (agent_id, actor_id, action, time) DO NOTHING
+		`, agentID, event.Type, event.Action, event.ID, event.Name, event.Image, event.Status,
+			event.ExitCode, event.Signal, eventTime)
+		if err != nil {
+			return fmt.Errorf("failed to save docker event: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetAgentEvents возвращает события Docker агента
+// @Summary Получить события Docker агента
+// @Description Возвращает события Docker между пингами (падения, OOM, перезапуски, смена здоровья, загрузка образов), новые первыми
+// @Tags agents
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID агента"
+// @Param container_id query string false "ID контейнера в Docker"
+// @Param type query string false "Тип события (container, image)"
+// @Param action query string false "Действие (die, oom, kill, restart, health_status, pull, destroy)"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param limit query int false "Лимит записей (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.ContainerEventListResponse "События Docker"
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 403 {string} string "Нет доступа к агенту"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /agents/{id}/events [get]
+func (h *Handlers) GetAgentEvents(w http.ResponseWriter, r *http.Request) {
+	agentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.ContainerEventFilter{
+		AgentID: agentID,
+		ActorID: query.Get("container_id"),
+		Type:    query.Get("type"),
+		Action:  query.Get("action"),
+		Limit:   100,
+	}
+	if !parseListParams(w, query, &filter.From, &filter.To, &filter.Limit, &filter.Offset) {
+		return
+	}
+
+	h.writeContainerEvents(w, filter)
+}
+
+// GetContainerEvents возвращает события Docker контейнера
+// @Summary Получить события контейнера
+// @Description Возвращает хронологию событий контейнера: падения с кодом выхода, OOM, kill с сигналом, перезапуски и смену здоровья, новые первыми
+// @Tags containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID контейнера"
+// @Param action query string false "Действие (die, oom, kill, restart, health_status, destroy)"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param limit query int false "Лимит записей (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} models.ContainerEventListResponse "События контейнера"
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 403 {string} string "Нет доступа к агенту"
+// @Failure 404 {string} string "Контейнер не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /containers/{id}/events [get]
+func (h *Handlers) GetContainerEvents(w http.ResponseWriter, r *http.Request) {
+	containerID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid container ID", http.StatusBadRequest)
+		return
+	}
+
+	filter := models.ContainerEventFilter{
+		Type:  "container",
+		Limit: 100,
+	}
+	err = h.db.QueryRow(`
+		SELECT ap.agent_id, c.container_id FROM containers c
+		JOIN agent_pings ap ON c.ping_id = ap.id
+		WHERE c.id = $1
+	`, containerID).Scan(&filter.AgentID, &filter.ActorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Container not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !requireAgentAccess(w, r, filter.AgentID) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter.Action = query.Get("action")
+	if !parseListParams(w, query, &filter.From, &filter.To, &filter.Limit, &filter.Offset) {
+		return
+	}
+
+	h.writeContainerEvents(w, filter)
+}
+
+// writeContainerEvents отвечает списком событий Docker по фильтру
+func (h *Handlers) writeContainerEvents(w http.ResponseWriter, filter models.ContainerEventFilter) {
+	events, total, err := h.getContainerEvents(filter)
+	if err != nil {
+		log.Printf("Error getting docker events: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.ContainerEventListResponse{
+		Events: events,
+		Total:  total,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// getContainerEvents выбирает события Docker агента по фильтру, новые первыми
+func (h *Handlers) getContainerEvents(filter models.ContainerEventFilter) ([]models.ContainerEvent, int, error) {
+	conditions := []string{"agent_id = $1"}
+	args := []interface{}{filter.AgentID}
+	argCount := 2
+
+	for _, condition := range []struct {
+		column string
+		value  string
+	}{
+		{"actor_id", filter.ActorID},
+		{"type", filter.Type},
+		{"action", filter.Action},
+	} {
+		if condition.value != "" {
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", condition.column, argCount))
+			args = append(args, condition.value)
+			argCount++
+		}
+	}
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("time >= $%d", argCount))
+		args = append(args, *filter.From)
+		argCount++
+	}
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("time <= $%d", argCount))
+		args = append(args, *filter.To)
+		argCount++
+	}
+
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM container_events"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count docker events: %v", err)
+	}
+
+	query := `
+		SELECT id, agent_id, type, action, actor_id, actor_name, image, status, exit_code, signal, time, created
+		FROM container_events` + where + fmt.Sprintf(" ORDER BY time DESC LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get docker events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []models.ContainerEvent{}
+	for rows.Next() {
+		var event models.ContainerEvent
+		err := rows.Scan(
+			&event.ID, &event.AgentID, &event.Type, &event.Action, &event.ActorID, &event.ActorName,
+			&event.Image, &event.Status, &event.ExitCode, &event.Signal, &event.Time, &event.Created,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan docker event: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, total, rows.Err()
+}
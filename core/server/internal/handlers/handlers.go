@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -14,10 +17,19 @@ import (
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 
+	"monitoring-system/core/server/internal/approvals"
+	"monitoring-system/core/server/internal/audit"
 	"monitoring-system/core/server/internal/auth"
 	"monitoring-system/core/server/internal/domains"
+	"monitoring-system/core/server/internal/enrollment"
+	"monitoring-system/core/server/internal/loginguard"
+	"monitoring-system/core/server/internal/mfa"
 	"monitoring-system/core/server/internal/models"
 	"monitoring-system/core/server/internal/notifications"
+	"monitoring-system/core/server/internal/oidc"
+	"monitoring-system/core/server/internal/scheduler"
+	"monitoring-system/core/server/internal/users"
+	"monitoring-system/core/server/internal/workflows"
 )
 
 type Handlers struct {
@@ -25,14 +37,32 @@ type Handlers struct {
 	auth         *auth.Service
 	notification *notifications.Service
 	domain       *domains.Service
+	scheduler    *scheduler.Service
+	workflow     *workflows.Service
+	approval     *approvals.Service
+	audit        *audit.Service
+	user         *users.Service
+	oidc         *oidc.Service
+	mfa          *mfa.Service
+	guard        *loginguard.Service
+	enrollment   *enrollment.Service
 }
 
-func New(db *sql.DB, authService *auth.Service, domainService *domains.Service) *Handlers {
+func New(db *sql.DB, authService *auth.Service, domainService *domains.Service, schedulerService *scheduler.Service, workflowService *workflows.Service, approvalService *approvals.Service, auditService *audit.Service, userService *users.Service, oidcService *oidc.Service, mfaService *mfa.Service, guardService *loginguard.Service, enrollmentService *enrollment.Service) *Handlers {
 	h := &Handlers{
 		db:           db,
 		auth:         authService,
 		notification: notifications.New(),
 		domain:       domainService,
+		scheduler:    schedulerService,
+		workflow:     workflowService,
+		approval:     approvalService,
+		audit:        auditService,
+		user:         userService,
+		oidc:         oidcService,
+		mfa:          mfaService,
+		guard:        guardService,
+		enrollment:   enrollmentService,
 	}
 
 	// Создаем админа по умолчанию
@@ -72,7 +102,9 @@ func (h *Handlers) createDefaultAdmin() {
 
 // Login обрабатывает авторизацию
 // @Summary Аутентификация пользователя
-// @Description Выполняет вход в систему и возвращает JWT токен
+// @Description Выполняет вход в систему, открывает сессию и возвращает короткоживущий JWT токен и refresh-токен.
+// @Description Если у пользователя включена 2FA или она обязательна для его роли, вместо токенов возвращается challenge_token для POST /login/2fa (challenge_type=totp) или POST /login/2fa/enroll и /login/2fa/activate (challenge_type=enroll).
+// @Description После 3 неудачных попыток для имени пользователя или IP следующие попытки откладываются на 1, 2, 4, ... секунд (до 30), после 10 ошибок для пользователя или 30 для IP вход блокируется на 15 минут.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -80,6 +112,7 @@ func (h *Handlers) createDefaultAdmin() {
 // @Success 200 {object} models.LoginResponse "Успешная аутентификация"
 // @Failure 400 {string} string "Неверный JSON"
 // @Failure 401 {string} string "Неверные учетные данные"
+// @Failure 429 {string} string "Слишком много неудачных попыток, см. заголовок Retry-After"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /login [post]
 func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
@@ -89,92 +122,194 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.reserveLoginAttempt(w, r, nil, req.Username) {
+		return
+	}
+
 	var user models.User
 	err := h.db.QueryRow(`
-		SELECT id, username, password_hash, email, is_active, role, created, last_login
+		SELECT id, username, password_hash, email, is_active, role, created, last_login, auth_provider, totp_enabled
 		FROM users 
-		WHERE username = $1 AND is_active = true
-	`, req.Username).Scan(
+		WHERE username = $1 AND is_active = true AND auth_provider = $2
+	`, req.Username, models.AuthProviderLocal).Scan(
 		&user.ID, &user.Username, &user.PasswordHash, &user.Email,
-		&user.IsActive, &user.Role, &user.Created, &user.LastLogin,
+		&user.IsActive, &user.Role, &user.Created, &user.LastLogin, &user.AuthProvider, &user.TOTPEnabled,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
+			h.loginFailed(r, nil, req.Username, models.AuthEventLoginFailure, "unknown or inactive user")
 			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		} else {
+			h.releaseLoginAttempt(r, req.Username)
 			http.Error(w, "Database error", http.StatusInternalServerError)
 		}
 		return
 	}
 
 	if !h.auth.CheckPassword(req.Password, user.PasswordHash) {
+		h.loginFailed(r, &user.ID, req.Username, models.AuthEventLoginFailure, "invalid password")
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
+	h.releaseLoginAttempt(r, req.Username)
+
+	// Пароль верен, но для входа нужен второй фактор
+	if challengeType := h.loginChallengeType(&user); challengeType != "" {
+		challengeToken, err := h.mfa.CreateChallenge(user.ID, challengeType)
+		if err != nil {
+			log.Printf("Error creating login challenge: %v", err)
+			http.Error(w, "Error generating token", http.StatusInternalServerError)
+			return
+		}
 
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.LoginResponse{
+			MFARequired:    true,
+			ChallengeToken: challengeToken,
+			ChallengeType:  challengeType,
+		})
+		return
+	}
+
+	h.completeLogin(w, r, &user, nil)
+}
+
+// loginChallengeType возвращает тип второго шага входа; пустая строка — второй фактор не нужен
+func (h *Handlers) loginChallengeType(user *models.User) string {
+	if user.TOTPEnabled {
+		return mfa.ChallengeTOTP
+	}
+	if h.mfa.Required(user.Role) {
+		return mfa.ChallengeEnroll
+	}
+	return ""
+}
+
+// completeLogin обновляет время последнего входа, открывает сессию и отправляет токены
+func (h *Handlers) completeLogin(w http.ResponseWriter, r *http.Request, user *models.User, recoveryCodes []string) {
 	// Обновляем время последнего входа
 	now := time.Now()
-	_, err = h.db.Exec("UPDATE users SET last_login = $1 WHERE id = $2", now, user.ID)
+	_, err := h.db.Exec("UPDATE users SET last_login = $1 WHERE id = $2", now, user.ID)
 	if err != nil {
 		log.Printf("Error updating last login: %v", err)
 	}
 	user.LastLogin = &now
 
-	token, err := h.auth.GenerateToken(user.ID, user.Username, user.Role)
+	response, err := h.auth.CreateSession(user, audit.ClientIP(r), r.UserAgent())
 	if err != nil {
+		log.Printf("Error creating session: %v", err)
 		http.Error(w, "Error generating token", http.StatusInternalServerError)
 		return
 	}
+	response.RecoveryCodes = recoveryCodes
 
-	response := models.LoginResponse{
-		Token: token,
-		User:  user,
+	if err := h.guard.RecordSuccess(user.Username); err != nil {
+		log.Printf("Error resetting login throttle: %v", err)
 	}
+	h.guard.RecordEvent(r, &user.ID, user.Username, models.AuthEventLoginSuccess, "")
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// bearerToken извлекает токен из заголовка Authorization: Bearer <token>
+func bearerToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		return "", false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+		return "", false
+	}
+
+	return parts[1], true
+}
+
+// authenticateAgent проверяет подписанный запрос или Bearer токен агента и возвращает
+// ID и имя агента. Агенты, ожидающие подтверждения после регистрации, получают 403.
+func (h *Handlers) authenticateAgent(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	var agent *auth.AgentIdentity
+	if auth.IsSignedAgentRequest(r) {
+		// Тело нужно для проверки подписи, обработчик затем читает его из буфера
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Error reading request body", http.StatusBadRequest)
+			return uuid.Nil, "", false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		agent, err = h.auth.AuthenticateSignedAgent(r, body)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidSignature) || errors.Is(err, auth.ErrStaleRequest) || errors.Is(err, auth.ErrReplayedRequest) {
+				log.Printf("Rejected signed agent request from %s: %v", audit.ClientIP(r), err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			} else {
+				log.Printf("Error authenticating agent: %v", err)
+				http.Error(w, "Database error", http.StatusInternalServerError)
+			}
+			return uuid.Nil, "", false
+		}
+	} else {
+		token, ok := bearerToken(w, r)
+		if !ok {
+			return uuid.Nil, "", false
+		}
+
+		var err error
+		agent, err = h.auth.AuthenticateAgent(token)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidAgentToken) {
+				http.Error(w, "Invalid agent token", http.StatusUnauthorized)
+			} else {
+				log.Printf("Error authenticating agent: %v", err)
+				http.Error(w, "Database error", http.StatusInternalServerError)
+			}
+			return uuid.Nil, "", false
+		}
+
+		// ID агента и ключ подписи агент узнает из ответа на запрос с токеном
+		w.Header().Set(auth.HeaderAgentID, agent.ID.String())
+		w.Header().Set(auth.HeaderAgentSigningKey, h.auth.AgentSigningKey(token))
+		if agent.RequireSignature {
+			http.Error(w, "Signed request required", http.StatusUnauthorized)
+			return uuid.Nil, "", false
+		}
+	}
+
+	if !agent.Approved {
+		http.Error(w, "Agent is awaiting approval", http.StatusForbidden)
+		return uuid.Nil, "", false
+	}
+
+	return agent.ID, agent.Name, true
+}
+
 // AgentPing обрабатывает пинги от агентов
 // @Summary Пинг от агента
 // @Description Получает данные мониторинга от агента и сохраняет их в базе данных, возвращает список невыполненных действий
 // @Tags agent-data
 // @Accept json
 // @Produce json
-// @Param Authorization header string true "Bearer токен агента"
+// @Param Authorization header string false "Bearer токен агента (для неподписанных запросов)"
+// @Param X-Agent-ID header string false "ID агента (для подписанных запросов)"
+// @Param X-Agent-Timestamp header int false "Время запроса, Unix-секунды"
+// @Param X-Agent-Nonce header string false "Одноразовое значение"
+// @Param X-Agent-Signature header string false "v2=<hex HMAC-SHA256>"
 // @Param request body models.AgentData true "Данные мониторинга от агента"
 // @Success 200 {object} []models.Action "Список невыполненных действий"
 // @Failure 400 {string} string "Неверные данные"
-// @Failure 401 {string} string "Неверный токен агента"
+// @Failure 401 {string} string "Неверный токен агента, подпись, устаревший или повторный запрос"
+// @Failure 403 {string} string "Агент ждет подтверждения администратором"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /agent/ping [post]
 func (h *Handlers) AgentPing(w http.ResponseWriter, r *http.Request) {
-	// Проверяем Bearer токен агента
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "Authorization header required", http.StatusUnauthorized)
-		return
-	}
-
-	bearerToken := strings.Split(authHeader, " ")
-	if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-		http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-		return
-	}
-
-	token := bearerToken[1]
-
-	// Проверяем существование агента с таким токеном
-	var agentID uuid.UUID
-	var agentName string
-	err := h.db.QueryRow("SELECT id, name FROM agents WHERE token = $1 AND is_active = true", token).Scan(&agentID, &agentName)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Invalid agent token", http.StatusUnauthorized)
-		} else {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-		}
+	agentID, agentName, ok := h.authenticateAgent(w, r)
+	if !ok {
 		return
 	}
 
@@ -186,14 +321,18 @@ func (h *Handlers) AgentPing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Сохраняем данные в БД
-	if err := h.saveAgentData(agentID, &agentData); err != nil {
+	stopped, err := h.saveAgentData(agentID, &agentData)
+	if err != nil {
 		log.Printf("Error saving agent data: %v", err)
 		http.Error(w, "Error saving data", http.StatusInternalServerError)
 		return
 	}
 
-	// Проверяем уведомления
-	h.checkNotifications(agentID, agentName, &agentData)
+	// Проверяем уведомления только по свежим данным: пинги из очереди агента описывают
+	// прошлое, и оповещать о давно прошедших событиях незачем
+	if time.Since(sampleTime(agentData.Timestamp)) <= maxNotificationDelay {
+		h.checkNotifications(agentID, agentName, &agentData, stopped)
+	}
 
 	// Получаем список невыполненных действий для агента
 	pendingActions, err := h.getPendingActions(agentID)
@@ -208,29 +347,104 @@ func (h *Handlers) AgentPing(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(pendingActions)
 }
 
-// saveAgentData сохраняет данные от агента в БД
-func (h *Handlers) saveAgentData(agentID uuid.UUID, data *models.AgentData) error {
+// maxSampleClockSkew — насколько время сбора от агента может опережать часы сервера
+const maxSampleClockSkew = 5 * time.Minute
+
+// maxNotificationDelay — насколько данные могут отставать от текущего времени, чтобы по ним
+// проверялись уведомления. Совпадает со временем, после которого агент считается недоступным.
+const maxNotificationDelay = 2 * time.Minute
+
+// sampleTime возвращает время сбора данных агентом. Пинги из очереди агента приходят
+// с задержкой, и время их приема не совпадает со временем сбора. Старые агенты время
+// не присылают, а время из будущего говорит о сбитых часах: в обоих случаях берем время приема.
+func sampleTime(timestamp string) time.Time {
+	now := time.Now()
+	parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil || parsed.After(now.Add(maxSampleClockSkew)) {
+		return now
+	}
+	return parsed
+}
+
+// saveAgentData сохраняет данные от агента в БД и возвращает контейнеры, остановившиеся с прошлого пинга
+func (h *Handlers) saveAgentData(agentID uuid.UUID, data *models.AgentData) ([]models.ContainerInfo, error) {
 	tx, err := h.db.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Обновляем время последнего пинга агента
+	// Обновляем время последнего пинга агента: это время связи, а не сбора данных
 	_, err = tx.Exec("UPDATE agents SET last_ping = now() WHERE id = $1", agentID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	created := sampleTime(data.Timestamp)
+
+	// Агент повторяет пинг из очереди, если не получил ответ: сохраненный пинг пропускаем
+	if data.Timestamp != "" {
+		var exists bool
+		err = tx.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM agent_pings WHERE agent_id = $1 AND created = $2::timestamptz)
+		`, agentID, created).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, tx.Commit()
+		}
+	}
+
+	// Сведения о хосте агент присылает только при изменении
+	if data.Facts != nil {
+		facts, err := json.Marshal(data.Facts)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+			UPDATE agents SET facts = $2, hostname = COALESCE(NULLIF($3, ''), hostname), facts_updated = now()
+			WHERE id = $1
+		`, agentID, facts, data.Facts.Hostname)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Счетчики прошлого пинга нужны для расчета скоростей
+	previous, err := loadPreviousCounters(tx, agentID, created)
+	if err != nil {
+		return nil, err
+	}
+
+	// Длительность сбора и неудачные запросы к Docker; старые агенты их не присылают
+	var collectionDuration, dockerDuration sql.NullFloat64
+	var collectionWorkers sql.NullInt64
+	var collectionFailures interface{}
+	if data.Collection != nil {
+		collectionDuration = sql.NullFloat64{Float64: data.Collection.DurationMs, Valid: true}
+		dockerDuration = sql.NullFloat64{Float64: data.Collection.DockerDurationMs, Valid: true}
+		collectionWorkers = sql.NullInt64{Int64: int64(data.Collection.Workers), Valid: true}
+		failures := data.Collection.Failures
+		if failures == nil {
+			failures = []models.CollectionFailure{}
+		}
+		failuresJSON, err := json.Marshal(failures)
+		if err != nil {
+			return nil, err
+		}
+		collectionFailures = failuresJSON
 	}
 
 	// Создаем запись пинга
 	var pingID uuid.UUID
 	err = tx.QueryRow(`
-		INSERT INTO agent_pings (agent_id, created) 
-		VALUES ($1, now()) 
+		INSERT INTO agent_pings (agent_id, created, collection_duration_ms, docker_duration_ms, collection_workers, collection_failures)
+		VALUES ($1, $2::timestamptz, $3, $4, $5, $6)
 		RETURNING id
-	`, agentID).Scan(&pingID)
+	`, agentID, created, collectionDuration, dockerDuration, collectionWorkers, collectionFailures).Scan(&pingID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Сохраняем метрики CPU
@@ -240,7 +454,7 @@ func (h *Handlers) saveAgentData(agentID uuid.UUID, data *models.AgentData) erro
 			VALUES ($1, $2, $3)
 		`, pingID, cpu.Name, cpu.Usage)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -251,30 +465,116 @@ func (h *Handlers) saveAgentData(agentID uuid.UUID, data *models.AgentData) erro
 	`, pingID, data.Metrics.Memory.RAM.Total, data.Metrics.Memory.RAM.Usage,
 		data.Metrics.Memory.Swap.Total, data.Metrics.Memory.Swap.Usage)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Сохраняем метрики дисков
 	for _, disk := range data.Metrics.Disk {
+		readRate, writeRate, readsRate, writesRate := previous.diskRates(disk.Name, diskCounters{
+			readBytes:  int64(disk.ReadBytes),
+			writeBytes: int64(disk.WriteBytes),
+			reads:      int64(disk.Reads),
+			writes:     int64(disk.Writes),
+		})
 		_, err = tx.Exec(`
-			INSERT INTO disk_metrics (ping_id, disk_name, read_bytes, write_bytes, reads, writes)
-			VALUES ($1, $2, $3, $4, $5, $6)
-		`, pingID, disk.Name, disk.ReadBytes, disk.WriteBytes, disk.Reads, disk.Writes)
+			INSERT INTO disk_metrics (
+				ping_id, disk_name, read_bytes, write_bytes, reads, writes,
+				read_bytes_per_sec, write_bytes_per_sec, reads_per_sec, writes_per_sec
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, pingID, disk.Name, disk.ReadBytes, disk.WriteBytes, disk.Reads, disk.Writes,
+			readRate, writeRate, readsRate, writesRate)
 		if err != nil {
-			return err
+			return nil, err
+		}
+	}
+
+	// Сохраняем нагрузку хоста
+	if host := data.Metrics.Host; host != nil {
+		rates := previous.hostRates(hostCounters{
+			uptime:          int64(host.Uptime),
+			contextSwitches: int64(host.ContextSwitches),
+			cpu:             host.CPUTimes,
+		})
+		_, err = tx.Exec(`
+			INSERT INTO host_metrics (
+				ping_id, load1, load5, load15, uptime_seconds, boot_time, processes, threads, context_switches,
+				cpu_user, cpu_nice, cpu_system, cpu_idle, cpu_iowait, cpu_irq, cpu_softirq, cpu_steal,
+				context_switches_per_sec, cpu_user_percent, cpu_system_percent, cpu_iowait_percent,
+				cpu_steal_percent, cpu_idle_percent
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
+		`, pingID, host.Load1, host.Load5, host.Load15, host.Uptime, time.Unix(host.BootTime, 0),
+			host.Processes, host.Threads, host.ContextSwitches,
+			host.CPUTimes.User, host.CPUTimes.Nice, host.CPUTimes.System, host.CPUTimes.Idle,
+			host.CPUTimes.Iowait, host.CPUTimes.Irq, host.CPUTimes.Softirq, host.CPUTimes.Steal,
+			rates.contextSwitches, rates.user, rates.system, rates.iowait, rates.steal, rates.idle)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Сохраняем заполненность файловых систем
+	for _, fs := range data.Metrics.Filesystems {
+		_, err = tx.Exec(`
+			INSERT INTO filesystem_metrics (
+				ping_id, mountpoint, device, fstype, total_bytes, used_bytes, free_bytes,
+				inodes_total, inodes_used, inodes_free
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, pingID, fs.Mountpoint, fs.Device, fs.Fstype, fs.Total, fs.Used, fs.Free,
+			fs.InodesTotal, fs.InodesUsed, fs.InodesFree)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	// Сохраняем метрики сети
+	network := networkCounters{
+		sent:            int64(data.Metrics.Network.Sent),
+		received:        int64(data.Metrics.Network.Received),
+		packetsSent:     optionalCounter(data.Metrics.Network.PacketsSent),
+		packetsReceived: optionalCounter(data.Metrics.Network.PacketsReceived),
+	}
+	sentRate, receivedRate, packetsSentRate, packetsReceivedRate := previous.networkRates(network)
 	_, err = tx.Exec(`
-		INSERT INTO network_metrics (ping_id, public_ip, sent_bytes, received_bytes)
-		VALUES ($1, $2, $3, $4)
-	`, pingID, data.Metrics.Network.PublicIP, data.Metrics.Network.Sent, data.Metrics.Network.Received)
+		INSERT INTO network_metrics (
+			ping_id, public_ip, sent_bytes, received_bytes, packets_sent, packets_received,
+			sent_bytes_per_sec, received_bytes_per_sec, packets_sent_per_sec, packets_received_per_sec
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, pingID, data.Metrics.Network.PublicIP, network.sent, network.received, network.packetsSent, network.packetsReceived,
+		sentRate, receivedRate, packetsSentRate, packetsReceivedRate)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Сохраняем метрики сетевых интерфейсов
+	for _, iface := range data.Metrics.Network.Interfaces {
+		counters := networkCounters{
+			sent:            int64(iface.Sent),
+			received:        int64(iface.Received),
+			packetsSent:     sql.NullInt64{Int64: int64(iface.PacketsSent), Valid: true},
+			packetsReceived: sql.NullInt64{Int64: int64(iface.PacketsReceived), Valid: true},
+		}
+		sentRate, receivedRate, packetsSentRate, packetsReceivedRate := previous.interfaceRates(iface.Name, counters)
+		_, err = tx.Exec(`
+			INSERT INTO network_interface_metrics (
+				ping_id, name, sent_bytes, received_bytes, packets_sent, packets_received,
+				errors_in, errors_out, drops_in, drops_out,
+				sent_bytes_per_sec, received_bytes_per_sec, packets_sent_per_sec, packets_received_per_sec
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, pingID, iface.Name, iface.Sent, iface.Received, iface.PacketsSent, iface.PacketsReceived,
+			iface.ErrorsIn, iface.ErrorsOut, iface.DropsIn, iface.DropsOut,
+			sentRate, receivedRate, packetsSentRate, packetsReceivedRate)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Сохраняем контейнеры
+	var stopped []models.ContainerInfo
 	for _, container := range data.Docker.Containers {
 		containerCreatedAt, _ := time.Parse(time.RFC3339Nano, container.Created)
 
@@ -284,33 +584,51 @@ func (h *Handlers) saveAgentData(agentID uuid.UUID, data *models.AgentData) erro
 			memory = &memoryMB
 		}
 
+		state, err := containerStateValues(container)
+		if err != nil {
+			return nil, err
+		}
+		args := append([]interface{}{
+			pingID, container.ID, container.Name, container.Image, container.Status,
+			container.RestartCount, containerCreatedAt, container.IP, container.MAC,
+			container.CPU, memory, container.Network.Sent, container.Network.Received,
+			optionalCounter(container.MemoryLimit), container.MemoryPercent,
+			optionalCounter(container.BlockRead), optionalCounter(container.BlockWrite),
+			optionalCounter(container.PIDs),
+		}, state...)
+
 		var containerDBID uuid.UUID
 		err = tx.QueryRow(`
 			INSERT INTO containers (
 				ping_id, container_id, name, image_id, status, restart_count, 
 				created_at, ip_address, mac_address, cpu_usage_percent, 
-				memory_usage_mb, network_sent_bytes, network_received_bytes
+				memory_usage_mb, network_sent_bytes, network_received_bytes,
+				memory_limit_mb, memory_percent, block_read_bytes, block_write_bytes, pids,
+				`+containerStateColumns+`
 			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+				$19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)
 			RETURNING id
-		`, pingID, container.ID, container.Name, container.Image, container.Status,
-			container.RestartCount, containerCreatedAt, container.IP, container.MAC,
-			container.CPU, memory, container.Network.Sent, container.Network.Received).Scan(&containerDBID)
+		`, args...).Scan(&containerDBID)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if previous.containerStopped(container) {
+			stopped = append(stopped, container)
 		}
 
-		// Сохраняем логи контейнера
+		// Сохраняем логи контейнера; старые агенты не присылают время строки — берем время пинга
 		for _, logLine := range container.Logs {
 			// Очищаем строку от null-байтов
-			cleanLogLine := strings.ReplaceAll(logLine, "\x00", "")
+			cleanLogLine := strings.ReplaceAll(logLine.Line, "\x00", "")
 			if cleanLogLine != "" {
 				_, err = tx.Exec(`
-				INSERT INTO container_logs (container_id, log_line, timestamp)
-				VALUES ($1, $2, now())
-			`, containerDBID, cleanLogLine)
+				INSERT INTO container_logs (container_id, log_line, stream, timestamp)
+				VALUES ($1, $2, NULLIF($3, ''), COALESCE($4, now()))
+			`, containerDBID, cleanLogLine, logLine.Stream, optionalTime(logLine.Time))
 				if err != nil {
-					return err
+					return nil, err
 				}
 			}
 		}
@@ -327,7 +645,7 @@ func (h *Handlers) saveAgentData(agentID uuid.UUID, data *models.AgentData) erro
 		RETURNING id
 	`, pingID, image.ID, imageCreatedAt, image.Size, image.Architecture).Scan(&imageDBID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Сохраняем теги образа
@@ -337,28 +655,51 @@ func (h *Handlers) saveAgentData(agentID uuid.UUID, data *models.AgentData) erro
 			VALUES ($1, $2)
 		`, imageDBID, tag)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
 
-	return tx.Commit()
+	// Сохраняем события Docker
+	if err := saveContainerEvents(tx, agentID, data.Events); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return stopped, nil
 }
 
 // GetAgents возвращает список агентов
 // @Summary Получить список агентов
-// @Description Возвращает список всех активных агентов
+// @Description Возвращает список всех активных агентов. Параметры отбирают агентов по сведениям о хосте (точное совпадение).
 // @Tags agents
 // @Produce json
 // @Security BearerAuth
+// @Param os query string false "ОС (linux)"
+// @Param platform query string false "Дистрибутив (ubuntu)"
+// @Param platform_version query string false "Версия дистрибутива"
+// @Param kernel_version query string false "Версия ядра"
+// @Param architecture query string false "Архитектура (x86_64)"
+// @Param cpu_model query string false "Модель CPU"
+// @Param docker_version query string false "Версия Docker"
+// @Param agent_version query string false "Версия агента"
 // @Success 200 {array} models.Agent "Список агентов"
 // @Failure 401 {string} string "Не авторизован"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /agents [get]
 func (h *Handlers) GetAgents(w http.ResponseWriter, r *http.Request) {
+	factsFilter, err := agentFactsFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid filter", http.StatusBadRequest)
+		return
+	}
+
 	rows, err := h.db.Query(`
-		SELECT a.id, a.name, a.token, a.is_active, a.created,
-			   ap.created as last_ping,
+		SELECT a.id, a.name, a.token_prefix, a.is_active, a.approved, a.hostname, a.facts, a.facts_updated,
+			   a.previous_token_expires, a.require_signature, a.last_signed_request, a.created,
+			   a.last_ping,
 			   COALESCE(nm.public_ip::text, '0.0.0.0') as public_ip
 		FROM agents a
 		LEFT JOIN (
@@ -367,9 +708,9 @@ func (h *Handlers) GetAgents(w http.ResponseWriter, r *http.Request) {
 			ORDER BY agent_id, created DESC
 		) ap ON a.id = ap.agent_id
 		LEFT JOIN network_metrics nm ON ap.id = nm.ping_id
-		WHERE a.is_active = true
+		WHERE a.is_active = true AND ($1::jsonb IS NULL OR a.facts @> $1::jsonb)
 		ORDER BY a.created DESC
-	`)
+	`, factsFilter)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -382,21 +723,30 @@ func (h *Handlers) GetAgents(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var agent models.Agent
 		var publicIP string
+		var facts []byte
 		err := rows.Scan(
-			&agent.ID, &agent.Name, &agent.Token, &agent.IsActive, &agent.Created,
+			&agent.ID, &agent.Name, &agent.TokenPrefix, &agent.IsActive, &agent.Approved, &agent.Hostname, &facts, &agent.FactsUpdated,
+			&agent.PreviousTokenExpires, &agent.RequireSignature, &agent.LastSignedRequest, &agent.Created,
 			&agent.LastPing, &publicIP,
 		)
 		if err != nil {
 			log.Printf("Error scanning agent: %v", err)
 			continue
 		}
+		agent.Facts = parseAgentFacts(facts)
+
+		if !canAccessAgent(r, agent.ID) {
+			continue
+		}
 
 		if publicIP != "" && publicIP != "0.0.0.0" {
 			agent.PublicIP = &publicIP
 		}
 
 		// Определяем статус агента
-		if agent.LastPing != nil {
+		if !agent.Approved {
+			agent.Status = "pending"
+		} else if agent.LastPing != nil {
 			if time.Since(*agent.LastPing) < 2*time.Minute {
 				agent.Status = "online"
 			} else {
@@ -415,13 +765,13 @@ func (h *Handlers) GetAgents(w http.ResponseWriter, r *http.Request) {
 
 // CreateAgent создает нового агента
 // @Summary Создать нового агента
-// @Description Создает нового агента мониторинга и возвращает токен для подключения
+// @Description Создает нового агента мониторинга и возвращает токен для подключения. Токен показывается только в ответе на этот запрос.
 // @Tags agents
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body models.CreateAgentRequest true "Данные для создания агента"
-// @Success 201 {object} models.Agent "Созданный агент"
+// @Success 201 {object} models.CreateAgentResponse "Созданный агент и его токен"
 // @Failure 400 {string} string "Неверные данные"
 // @Failure 401 {string} string "Не авторизован"
 // @Failure 500 {string} string "Ошибка сервера"
@@ -445,14 +795,15 @@ func (h *Handlers) CreateAgent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Создаем агента
-	var agent models.Agent
+	// Создаем агента; в базе хранится только хеш токена
+	response := models.CreateAgentResponse{Token: token.Value}
+	agent := &response.Agent
 	err = h.db.QueryRow(`
-		INSERT INTO agents (name, token, is_active, created)
-		VALUES ($1, $2, true, now())
-		RETURNING id, name, token, is_active, created
-	`, req.Name, token).Scan(
-		&agent.ID, &agent.Name, &agent.Token, &agent.IsActive, &agent.Created,
+		INSERT INTO agents (name, token_prefix, token_hash, is_active, created)
+		VALUES ($1, $2, $3, true, now())
+		RETURNING id, name, token_prefix, is_active, approved, created
+	`, req.Name, token.Prefix, token.Hash).Scan(
+		&agent.ID, &agent.Name, &agent.TokenPrefix, &agent.IsActive, &agent.Approved, &agent.Created,
 	)
 	if err != nil {
 		http.Error(w, "Error creating agent", http.StatusInternalServerError)
@@ -461,13 +812,34 @@ func (h *Handlers) CreateAgent(w http.ResponseWriter, r *http.Request) {
 
 	agent.Status = "unknown"
 
+	h.audit.Record(r, models.AuditEntityAgent, agent.ID.String(), fmt.Sprintf("Created agent %s", agent.Name), nil, agent)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(agent)
+	json.NewEncoder(w).Encode(response)
+}
+
+// getAgentRecord получает запись агента для журнала аудита
+func (h *Handlers) getAgentRecord(agentID uuid.UUID) (*models.Agent, error) {
+	var agent models.Agent
+	var facts []byte
+	err := h.db.QueryRow(`
+		SELECT id, name, token_prefix, is_active, approved, hostname, facts, facts_updated, previous_token_expires,
+			require_signature, last_signed_request, created, last_ping
+		FROM agents WHERE id = $1
+	`, agentID).Scan(
+		&agent.ID, &agent.Name, &agent.TokenPrefix, &agent.IsActive, &agent.Approved, &agent.Hostname, &facts, &agent.FactsUpdated,
+		&agent.PreviousTokenExpires, &agent.RequireSignature, &agent.LastSignedRequest, &agent.Created, &agent.LastPing,
+	)
+	if err != nil {
+		return nil, err
+	}
+	agent.Facts = parseAgentFacts(facts)
+	return &agent, nil
 }
 
 // UpdateAgent обновляет агента
 // @Summary Обновить агента
-// @Description Обновляет имя и статус активности агента
+// @Description Обновляет имя, статус активности агента и обязательность подписи его запросов (require_signature). Перед включением подписи убедитесь, что агент уже подписывает запросы (last_signed_request).
 // @Tags agents
 // @Accept json
 // @Produce json
@@ -490,6 +862,8 @@ func (h *Handlers) UpdateAgent(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Name     *string `json:"name"`
 		IsActive *bool   `json:"is_active"`
+		// RequireSignature включает обязательную подпись запросов агента
+		RequireSignature *bool `json:"require_signature"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -514,6 +888,12 @@ func (h *Handlers) UpdateAgent(w http.ResponseWriter, r *http.Request) {
 		argCount++
 	}
 
+	if req.RequireSignature != nil {
+		setParts = append(setParts, fmt.Sprintf("require_signature = $%d", argCount))
+		args = append(args, *req.RequireSignature)
+		argCount++
+	}
+
 	if len(setParts) == 0 {
 		http.Error(w, "No fields to update", http.StatusBadRequest)
 		return
@@ -522,12 +902,18 @@ func (h *Handlers) UpdateAgent(w http.ResponseWriter, r *http.Request) {
 	query := fmt.Sprintf("UPDATE agents SET %s WHERE id = $%d", strings.Join(setParts, ", "), argCount)
 	args = append(args, agentID)
 
+	before, _ := h.getAgentRecord(agentID)
+
 	_, err = h.db.Exec(query, args...)
 	if err != nil {
 		http.Error(w, "Error updating agent", http.StatusInternalServerError)
 		return
 	}
 
+	if after, err := h.getAgentRecord(agentID); err == nil {
+		h.audit.Record(r, models.AuditEntityAgent, agentID.String(), fmt.Sprintf("Updated agent %s", after.Name), before, after)
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -550,18 +936,24 @@ func (h *Handlers) DeleteAgent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before, _ := h.getAgentRecord(agentID)
+
 	_, err = h.db.Exec("DELETE FROM agents WHERE id = $1", agentID)
 	if err != nil {
 		http.Error(w, "Error deleting agent", http.StatusInternalServerError)
 		return
 	}
 
+	if before != nil {
+		h.audit.Record(r, models.AuditEntityAgent, agentID.String(), fmt.Sprintf("Deleted agent %s", before.Name), before, nil)
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
 // GetAgentMetrics возвращает метрики агента
 // @Summary Получить метрики агента
-// @Description Возвращает историю метрик агента (CPU, память, сеть)
+// @Description Возвращает историю метрик агента (память, сеть, скорости дисков и сети, нагрузка хоста)
 // @Tags agents
 // @Produce json
 // @Security BearerAuth
@@ -590,10 +982,22 @@ func (h *Handlers) GetAgentMetrics(w http.ResponseWriter, r *http.Request) {
 	// Последние метрики
 	rows, err := h.db.Query(`
 		SELECT ap.created, nm.public_ip, mm.ram_total_mb, mm.ram_usage_mb,
-			   mm.swap_total_mb, mm.swap_usage_mb
+			   mm.swap_total_mb, mm.swap_usage_mb,
+			   dm.read_speed, dm.write_speed, dm.iops,
+			   nm.sent_bytes_per_sec, nm.received_bytes_per_sec,
+			   nm.packets_sent_per_sec, nm.packets_received_per_sec,
+			   hm.load1, hm.load5, hm.load15, hm.context_switches_per_sec,
+			   hm.cpu_iowait_percent, hm.cpu_steal_percent,
+			   ap.collection_duration_ms, jsonb_array_length(ap.collection_failures)
 		FROM agent_pings ap
 		JOIN memory_metrics mm ON ap.id = mm.ping_id
 		JOIN network_metrics nm ON ap.id = nm.ping_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(read_bytes_per_sec) as read_speed, SUM(write_bytes_per_sec) as write_speed,
+				   SUM(reads_per_sec + writes_per_sec) as iops
+			FROM disk_metrics WHERE ping_id = ap.id
+		) dm ON true
+		LEFT JOIN host_metrics hm ON ap.id = hm.ping_id
 		WHERE ap.agent_id = $1
 		ORDER BY ap.created DESC
 		LIMIT $2
@@ -611,6 +1015,24 @@ func (h *Handlers) GetAgentMetrics(w http.ResponseWriter, r *http.Request) {
 		RAMUsageMB  int64     `json:"ram_usage_mb"`
 		SwapTotalMB int64     `json:"swap_total_mb"`
 		SwapUsageMB int64     `json:"swap_usage_mb"`
+		// Скорости с прошлого пинга; null для первого пинга и после сброса счетчиков
+		DiskReadSpeed               *float64 `json:"disk_read_speed"`
+		DiskWriteSpeed              *float64 `json:"disk_write_speed"`
+		DiskIOPS                    *float64 `json:"disk_iops"`
+		NetworkSentSpeed            *float64 `json:"network_sent_speed"`
+		NetworkReceivedSpeed        *float64 `json:"network_received_speed"`
+		NetworkPacketsSentSpeed     *float64 `json:"network_packets_sent_speed"`
+		NetworkPacketsReceivedSpeed *float64 `json:"network_packets_received_speed"`
+		// Нагрузка хоста; null, если агент ее не присылает
+		Load1                *float64 `json:"load1"`
+		Load5                *float64 `json:"load5"`
+		Load15               *float64 `json:"load15"`
+		ContextSwitchesSpeed *float64 `json:"context_switches_speed"`
+		CPUIowaitPercent     *float64 `json:"cpu_iowait_percent"`
+		CPUStealPercent      *float64 `json:"cpu_steal_percent"`
+		// Сбор данных агентом; null, если агент его не присылает
+		CollectionDurationMs *float64 `json:"collection_duration_ms"`
+		CollectionFailures   *int     `json:"collection_failures"`
 	}
 
 	var metrics []MetricPoint
@@ -619,6 +1041,12 @@ func (h *Handlers) GetAgentMetrics(w http.ResponseWriter, r *http.Request) {
 		err := rows.Scan(
 			&metric.Timestamp, &metric.PublicIP, &metric.RAMTotalMB,
 			&metric.RAMUsageMB, &metric.SwapTotalMB, &metric.SwapUsageMB,
+			&metric.DiskReadSpeed, &metric.DiskWriteSpeed, &metric.DiskIOPS,
+			&metric.NetworkSentSpeed, &metric.NetworkReceivedSpeed,
+			&metric.NetworkPacketsSentSpeed, &metric.NetworkPacketsReceivedSpeed,
+			&metric.Load1, &metric.Load5, &metric.Load15, &metric.ContextSwitchesSpeed,
+			&metric.CPUIowaitPercent, &metric.CPUStealPercent,
+			&metric.CollectionDurationMs, &metric.CollectionFailures,
 		)
 		if err != nil {
 			log.Printf("Error scanning metric: %v", err)
@@ -655,7 +1083,9 @@ func (h *Handlers) GetAgentContainers(w http.ResponseWriter, r *http.Request) {
 	rows, err := h.db.Query(`
 		SELECT c.container_id, c.name, c.image_id, c.status, c.restart_count,
 			   c.created_at, c.ip_address, c.mac_address, c.cpu_usage_percent,
-			   c.memory_usage_mb, c.network_sent_bytes, c.network_received_bytes
+			   c.memory_usage_mb, c.network_sent_bytes, c.network_received_bytes,
+			   c.memory_limit_mb, c.memory_percent, c.block_read_bytes, c.block_write_bytes, c.pids,
+			   `+containerStateSelect+`
 		FROM containers c
 		JOIN agent_pings ap ON c.ping_id = ap.id
 		WHERE ap.agent_id = $1 AND ap.created = (
@@ -672,16 +1102,19 @@ func (h *Handlers) GetAgentContainers(w http.ResponseWriter, r *http.Request) {
 	var containers []models.Container
 	for rows.Next() {
 		var container models.Container
-		err := rows.Scan(
+		var state containerStateScan
+		err := rows.Scan(append([]interface{}{
 			&container.ContainerID, &container.Name, &container.ImageID,
 			&container.Status, &container.RestartCount, &container.CreatedAt,
 			&container.IPAddress, &container.MACAddress, &container.CPUUsagePercent,
 			&container.MemoryUsageMB, &container.NetworkSentBytes, &container.NetworkReceivedBytes,
-		)
+			&container.MemoryLimitMB, &container.MemoryPercent, &container.BlockReadBytes, &container.BlockWriteBytes, &container.PIDs,
+		}, state.targets(&container)...)...)
 		if err != nil {
 			log.Printf("Error scanning container: %v", err)
 			continue
 		}
+		state.apply(&container)
 		containers = append(containers, container)
 	}
 
@@ -710,22 +1143,18 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 	dashboard.TopContainersMemory = []models.TopContainer{}
 	dashboard.AgentsSummary = []models.AgentSummary{}
 
-	// Получаем KPI метрики
+	// Получаем KPI метрики по доступным пользователю агентам
 	var kpis models.KPIMetrics
+	scope := agentScope(r)
 
 	// Сначала получаем статистику агентов
 	err := h.db.QueryRow(`
 		SELECT 
-			COUNT(CASE WHEN ap.created > now() - interval '2 minutes' THEN 1 END) as agents_online,
+			COUNT(CASE WHEN a.last_ping > now() - interval '2 minutes' THEN 1 END) as agents_online,
 			COUNT(*) as agents_total
 		FROM agents a
-		LEFT JOIN (
-			SELECT DISTINCT ON (agent_id) agent_id, created
-			FROM agent_pings
-			ORDER BY agent_id, created DESC
-		) ap ON a.id = ap.agent_id
-		WHERE a.is_active = true
-	`).Scan(&kpis.AgentsOnline, &kpis.AgentsTotal)
+		WHERE a.is_active = true AND ($1::uuid[] IS NULL OR a.id = ANY($1))
+	`, scope).Scan(&kpis.AgentsOnline, &kpis.AgentsTotal)
 	if err != nil {
 		log.Printf("Error getting agent stats: %v", err)
 	}
@@ -742,7 +1171,8 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 			FROM agent_pings
 			ORDER BY agent_id, created DESC
 		) latest_pings ON c.ping_id = latest_pings.id
-	`).Scan(&kpis.ContainersTotal, &kpis.ContainersRunning, &kpis.ContainersStopped)
+		WHERE $1::uuid[] IS NULL OR latest_pings.agent_id = ANY($1)
+	`, scope).Scan(&kpis.ContainersTotal, &kpis.ContainersRunning, &kpis.ContainersStopped)
 	if err != nil {
 		log.Printf("Error getting container stats: %v", err)
 	}
@@ -756,14 +1186,15 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 		LEFT JOIN cpu_metrics cm ON ap.id = cm.ping_id
 		LEFT JOIN memory_metrics mm ON ap.id = mm.ping_id
 		WHERE ap.created > now() - interval '5 minutes'
-	`).Scan(&kpis.AvgCPUUsage, &kpis.AvgMemoryUsage)
+		  AND ($1::uuid[] IS NULL OR ap.agent_id = ANY($1))
+	`, scope).Scan(&kpis.AvgCPUUsage, &kpis.AvgMemoryUsage)
 	if err != nil {
 		log.Printf("Error getting resource stats: %v", err)
 	}
 	dashboard.Kpis = kpis
 
 	// Получаем историю использования ресурсов (последние 20 точек)
-	resourceUsage, err := h.getResourceUsageHistory()
+	resourceUsage, err := h.getResourceUsageHistory(scope)
 	if err != nil {
 		log.Printf("Error getting resource usage: %v", err)
 	} else {
@@ -771,7 +1202,7 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Получаем историю сетевой активности
-	networkActivity, err := h.getNetworkActivityHistory()
+	networkActivity, err := h.getNetworkActivityHistory(scope)
 	if err != nil {
 		log.Printf("Error getting network activity: %v", err)
 	} else {
@@ -779,7 +1210,7 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Получаем топ 5 контейнеров по CPU
-	topCPU, err := h.getTopContainersCPU()
+	topCPU, err := h.getTopContainersCPU(scope)
 	if err != nil {
 		log.Printf("Error getting top CPU containers: %v", err)
 	} else {
@@ -787,7 +1218,7 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Получаем топ 5 контейнеров по памяти
-	topMemory, err := h.getTopContainersMemory()
+	topMemory, err := h.getTopContainersMemory(scope)
 	if err != nil {
 		log.Printf("Error getting top memory containers: %v", err)
 	} else {
@@ -799,7 +1230,11 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Error getting agents summary: %v", err)
 	} else {
-		dashboard.AgentsSummary = agentsSummary
+		for _, summary := range agentsSummary {
+			if canAccessAgent(r, summary.ID) {
+				dashboard.AgentsSummary = append(dashboard.AgentsSummary, summary)
+			}
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -808,8 +1243,8 @@ func (h *Handlers) GetDashboardData(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handlers) getAgentList() ([]models.Agent, error) {
 	rows, err := h.db.Query(`
-		SELECT a.id, a.name, a.token, a.is_active, a.created,
-			   ap.created as last_ping, nm.public_ip::text
+		SELECT a.id, a.name, a.token_prefix, a.is_active, a.approved, a.hostname, a.facts, a.created,
+			   a.last_ping, nm.public_ip::text
 		FROM agents a
 		LEFT JOIN (
 			SELECT DISTINCT ON (agent_id) agent_id, created, id
@@ -830,20 +1265,24 @@ func (h *Handlers) getAgentList() ([]models.Agent, error) {
 	for rows.Next() {
 		var agent models.Agent
 		var publicIP sql.NullString
+		var facts []byte
 		err := rows.Scan(
-			&agent.ID, &agent.Name, &agent.Token, &agent.IsActive, &agent.Created,
+			&agent.ID, &agent.Name, &agent.TokenPrefix, &agent.IsActive, &agent.Approved, &agent.Hostname, &facts, &agent.Created,
 			&agent.LastPing, &publicIP,
 		)
 		if err != nil {
 			continue
 		}
+		agent.Facts = parseAgentFacts(facts)
 
 		if publicIP.Valid && publicIP.String != "0.0.0.0" {
 			agent.PublicIP = &publicIP.String
 		}
 
 		// Определяем статус
-		if agent.LastPing != nil {
+		if !agent.Approved {
+			agent.Status = "pending"
+		} else if agent.LastPing != nil {
 			if time.Since(*agent.LastPing) < 2*time.Minute {
 				agent.Status = "online"
 			} else {
@@ -968,16 +1407,16 @@ func (h *Handlers) GetAgentDetail(w http.ResponseWriter, r *http.Request) {
 	// Получаем базовую информацию об агенте
 	var agent models.Agent
 	err = h.db.QueryRow(`
-		SELECT a.id, a.name, a.token, a.is_active, a.created,
-			   MAX(ap.created) as last_ping,
+		SELECT a.id, a.name, a.token_prefix, a.is_active, a.created,
+			   a.last_ping,
 			   COALESCE(nm.public_ip::text, '0.0.0.0') as public_ip
 		FROM agents a
 		LEFT JOIN agent_pings ap ON a.id = ap.agent_id
 		LEFT JOIN network_metrics nm ON ap.id = nm.ping_id
 		WHERE a.id = $1
-		GROUP BY a.id, a.name, a.token, a.is_active, a.created, nm.public_ip
+		GROUP BY a.id, a.name, a.token_prefix, a.is_active, a.created, a.last_ping, nm.public_ip
 	`, agentID).Scan(
-		&agent.ID, &agent.Name, &agent.Token, &agent.IsActive, &agent.Created,
+		&agent.ID, &agent.Name, &agent.TokenPrefix, &agent.IsActive, &agent.Created,
 		&agent.LastPing, &agent.PublicIP,
 	)
 	if err != nil {
@@ -1081,9 +1520,10 @@ func (h *Handlers) GetContainers(w http.ResponseWriter, r *http.Request) {
 		SELECT c.id, c.ping_id, c.container_id, c.name, c.image_id, c.status, 
 			   c.restart_count, c.created_at, c.ip_address, c.mac_address, 
 			   c.cpu_usage_percent, c.memory_usage_mb, c.network_sent_bytes, 
-			   c.network_received_bytes, 
+			   c.network_received_bytes, c.memory_limit_mb, c.memory_percent, c.block_read_bytes, c.block_write_bytes, c.pids,
+			   ` + containerStateSelect + `,
 			   a.id as agent_id, a.name as agent_name, a.is_active, a.created as agent_created, 
-			   lp.created as last_ping, '' as public_ip
+			   COALESCE(a.last_ping, lp.created) as last_ping, '' as public_ip
 		FROM containers c
 		JOIN latest_pings lp ON c.ping_id = lp.id
 		JOIN agents a ON lp.agent_id = a.id
@@ -1122,18 +1562,26 @@ func (h *Handlers) GetContainers(w http.ResponseWriter, r *http.Request) {
 		var agentLastPing time.Time
 		var agentPublicIP string
 
-		err := rows.Scan(
+		var state containerStateScan
+		targets := append([]interface{}{
 			&container.ID, &container.PingID, &container.ContainerID, &container.Name,
 			&container.ImageID, &container.Status, &container.RestartCount,
 			&container.CreatedAt, &container.IPAddress, &container.MACAddress,
 			&container.CPUUsagePercent, &container.MemoryUsageMB,
 			&container.NetworkSentBytes, &container.NetworkReceivedBytes,
+			&container.MemoryLimitMB, &container.MemoryPercent, &container.BlockReadBytes, &container.BlockWriteBytes, &container.PIDs,
+		}, state.targets(&container.Container)...)
+		err := rows.Scan(append(targets,
 			&agentID, &agentName, &agentIsActive, &agentCreated, &agentLastPing, &agentPublicIP,
-		)
+		)...)
 		if err != nil {
 			log.Printf("Error scanning container: %v", err)
 			continue
 		}
+		state.apply(&container.Container)
+		if !canAccessAgent(r, agentID) {
+			continue
+		}
 
 		// Определяем статус агента
 		status := "unknown"
@@ -1251,6 +1699,9 @@ func (h *Handlers) GetImages(w http.ResponseWriter, r *http.Request) {
 			log.Printf("Error scanning image: %v", err)
 			continue
 		}
+		if !canAccessAgent(r, agentID) {
+			continue
+		}
 
 		image.Agent = models.Agent{
 			ID:   agentID,
@@ -1301,6 +1752,7 @@ func (h *Handlers) GetContainerDetail(w http.ResponseWriter, r *http.Request) {
 
 	// Получаем информацию о контейнере
 	var container models.ContainerDetail
+	var state containerStateScan
 	var agentID uuid.UUID
 	var agentName string
 
@@ -1308,19 +1760,23 @@ func (h *Handlers) GetContainerDetail(w http.ResponseWriter, r *http.Request) {
 		SELECT c.id, c.ping_id, c.container_id, c.name, c.image_id, c.status, 
 			   c.restart_count, c.created_at, c.ip_address, c.mac_address, 
 			   c.cpu_usage_percent, c.memory_usage_mb, c.network_sent_bytes, 
-			   c.network_received_bytes, a.id as agent_id, a.name as agent_name
+			   c.network_received_bytes, c.memory_limit_mb, c.memory_percent, c.block_read_bytes, c.block_write_bytes, c.pids,
+			   `+containerStateSelect+`,
+			   a.id as agent_id, a.name as agent_name
 		FROM containers c
 		JOIN agent_pings ap ON c.ping_id = ap.id
 		JOIN agents a ON ap.agent_id = a.id
 		WHERE c.id = $1
-	`, containerID).Scan(
+	`, containerID).Scan(append(append([]interface{}{
 		&container.ID, &container.PingID, &container.ContainerID, &container.Name,
 		&container.ImageID, &container.Status, &container.RestartCount,
 		&container.CreatedAt, &container.IPAddress, &container.MACAddress,
 		&container.CPUUsagePercent, &container.MemoryUsageMB,
 		&container.NetworkSentBytes, &container.NetworkReceivedBytes,
+		&container.MemoryLimitMB, &container.MemoryPercent, &container.BlockReadBytes, &container.BlockWriteBytes, &container.PIDs,
+	}, state.targets(&container.Container)...),
 		&agentID, &agentName,
-	)
+	)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Container not found", http.StatusNotFound)
@@ -1330,6 +1786,12 @@ func (h *Handlers) GetContainerDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	state.apply(&container.Container)
+
+	if !requireAgentAccess(w, r, agentID) {
+		return
+	}
+
 	container.Agent = models.Agent{
 		ID:   agentID,
 		Name: agentName,
@@ -1373,6 +1835,24 @@ func (h *Handlers) GetContainerLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var agentID uuid.UUID
+	err = h.db.QueryRow(`
+		SELECT ap.agent_id FROM containers c
+		JOIN agent_pings ap ON c.ping_id = ap.id
+		WHERE c.id = $1
+	`, containerID).Scan(&agentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Container not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !requireAgentAccess(w, r, agentID) {
+		return
+	}
+
 	logs, err := h.getContainerLogs(containerID)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
@@ -1435,7 +1915,9 @@ func (h *Handlers) getAgentCurrentMetrics(agentID uuid.UUID) (models.AgentMetric
 
 	// Получаем метрики дисков
 	diskRows, err := h.db.Query(`
-		SELECT dm.disk_name, dm.read_bytes, dm.write_bytes
+		SELECT dm.disk_name, dm.read_bytes, dm.write_bytes,
+			   COALESCE(dm.read_bytes_per_sec, 0), COALESCE(dm.write_bytes_per_sec, 0),
+			   COALESCE(dm.reads_per_sec, 0), COALESCE(dm.writes_per_sec, 0)
 		FROM disk_metrics dm
 		JOIN agent_pings ap ON dm.ping_id = ap.id
 		WHERE ap.agent_id = $1 AND ap.created = (
@@ -1450,47 +1932,182 @@ func (h *Handlers) getAgentCurrentMetrics(agentID uuid.UUID) (models.AgentMetric
 
 	for diskRows.Next() {
 		var disk models.DiskMetricCurrent
-		err := diskRows.Scan(&disk.Name, &disk.ReadBytes, &disk.WriteBytes)
+		var readSpeed, writeSpeed float64
+		err := diskRows.Scan(&disk.Name, &disk.ReadBytes, &disk.WriteBytes,
+			&readSpeed, &writeSpeed, &disk.ReadIOPS, &disk.WriteIOPS)
 		if err != nil {
 			continue
 		}
+		disk.ReadSpeed = int64(readSpeed)
+		disk.WriteSpeed = int64(writeSpeed)
 		metrics.Disk = append(metrics.Disk, disk)
 	}
 
+	// Получаем заполненность файловых систем
+	fsRows, err := h.db.Query(`
+		SELECT fm.mountpoint, fm.device, fm.fstype, fm.total_bytes, fm.used_bytes, fm.free_bytes,
+			   fm.inodes_total, fm.inodes_used, fm.inodes_free
+		FROM filesystem_metrics fm
+		JOIN agent_pings ap ON fm.ping_id = ap.id
+		WHERE ap.agent_id = $1 AND ap.created = (
+			SELECT MAX(created) FROM agent_pings WHERE agent_id = $1
+		)
+		ORDER BY fm.mountpoint
+	`, agentID)
+	if err != nil {
+		return metrics, err
+	}
+	defer fsRows.Close()
+
+	for fsRows.Next() {
+		var fs models.FilesystemMetricCurrent
+		err := fsRows.Scan(&fs.Mountpoint, &fs.Device, &fs.Fstype, &fs.TotalBytes, &fs.UsedBytes, &fs.FreeBytes,
+			&fs.InodesTotal, &fs.InodesUsed, &fs.InodesFree)
+		if err != nil {
+			continue
+		}
+		// Как в df: процент от места, доступного пользователям
+		if fs.UsedBytes+fs.FreeBytes > 0 {
+			fs.UsedPercent = float64(fs.UsedBytes) / float64(fs.UsedBytes+fs.FreeBytes) * 100
+		}
+		if fs.InodesTotal > 0 {
+			fs.InodesUsedPercent = float64(fs.InodesUsed) / float64(fs.InodesTotal) * 100
+		}
+		metrics.Filesystems = append(metrics.Filesystems, fs)
+	}
+
 	// Получаем метрики сети
+	var sentSpeed, receivedSpeed float64
 	err = h.db.QueryRow(`
-		SELECT nm.public_ip, nm.sent_bytes, nm.received_bytes
+		SELECT nm.public_ip, nm.sent_bytes, nm.received_bytes,
+			   COALESCE(nm.sent_bytes_per_sec, 0), COALESCE(nm.received_bytes_per_sec, 0),
+			   COALESCE(nm.packets_sent_per_sec, 0), COALESCE(nm.packets_received_per_sec, 0)
 		FROM network_metrics nm
 		JOIN agent_pings ap ON nm.ping_id = ap.id
 		WHERE ap.agent_id = $1 AND ap.created = (
 			SELECT MAX(created) FROM agent_pings WHERE agent_id = $1
 		)
-	`, agentID).Scan(&metrics.Network.PublicIP, &metrics.Network.SentBytes, &metrics.Network.ReceivedBytes)
+	`, agentID).Scan(&metrics.Network.PublicIP, &metrics.Network.SentBytes, &metrics.Network.ReceivedBytes,
+		&sentSpeed, &receivedSpeed, &metrics.Network.PacketsSentSpeed, &metrics.Network.PacketsReceivedSpeed)
+	if err != nil && err != sql.ErrNoRows {
+		return metrics, err
+	}
+	metrics.Network.SentSpeed = int64(sentSpeed)
+	metrics.Network.ReceivedSpeed = int64(receivedSpeed)
+
+	// Получаем метрики сетевых интерфейсов
+	interfaceRows, err := h.db.Query(`
+		SELECT nim.name, nim.sent_bytes, nim.received_bytes, nim.packets_sent, nim.packets_received,
+			   nim.errors_in, nim.errors_out, nim.drops_in, nim.drops_out,
+			   COALESCE(nim.sent_bytes_per_sec, 0), COALESCE(nim.received_bytes_per_sec, 0),
+			   COALESCE(nim.packets_sent_per_sec, 0), COALESCE(nim.packets_received_per_sec, 0)
+		FROM network_interface_metrics nim
+		JOIN agent_pings ap ON nim.ping_id = ap.id
+		WHERE ap.agent_id = $1 AND ap.created = (
+			SELECT MAX(created) FROM agent_pings WHERE agent_id = $1
+		)
+		ORDER BY nim.name
+	`, agentID)
+	if err != nil {
+		return metrics, err
+	}
+	defer interfaceRows.Close()
+
+	for interfaceRows.Next() {
+		var iface models.NetworkInterfaceMetricCurrent
+		var ifaceSentSpeed, ifaceReceivedSpeed float64
+		err := interfaceRows.Scan(&iface.Name, &iface.SentBytes, &iface.ReceivedBytes, &iface.PacketsSent, &iface.PacketsReceived,
+			&iface.ErrorsIn, &iface.ErrorsOut, &iface.DropsIn, &iface.DropsOut,
+			&ifaceSentSpeed, &ifaceReceivedSpeed, &iface.PacketsSentSpeed, &iface.PacketsReceivedSpeed)
+		if err != nil {
+			continue
+		}
+		iface.SentSpeed = int64(ifaceSentSpeed)
+		iface.ReceivedSpeed = int64(ifaceReceivedSpeed)
+		metrics.Network.Interfaces = append(metrics.Network.Interfaces, iface)
+	}
+
+	// Получаем нагрузку хоста; старые агенты ее не присылают
+	var host models.HostMetricCurrent
+	err = h.db.QueryRow(`
+		SELECT hm.load1, hm.load5, hm.load15, hm.uptime_seconds, hm.boot_time, hm.processes, hm.threads,
+			   hm.context_switches_per_sec, hm.cpu_user_percent, hm.cpu_system_percent,
+			   hm.cpu_iowait_percent, hm.cpu_steal_percent, hm.cpu_idle_percent
+		FROM host_metrics hm
+		JOIN agent_pings ap ON hm.ping_id = ap.id
+		WHERE ap.agent_id = $1 AND ap.created = (
+			SELECT MAX(created) FROM agent_pings WHERE agent_id = $1
+		)
+	`, agentID).Scan(&host.Load1, &host.Load5, &host.Load15, &host.Uptime, &host.BootTime, &host.Processes, &host.Threads,
+		&host.ContextSwitchesSpeed, &host.CPUUserPercent, &host.CPUSystemPercent,
+		&host.CPUIowaitPercent, &host.CPUStealPercent, &host.CPUIdlePercent)
 	if err != nil && err != sql.ErrNoRows {
 		return metrics, err
 	}
+	if err == nil {
+		metrics.Host = &host
+	}
+
+	// Последний сбор данных агентом; старые агенты его не присылают
+	var collection models.CollectionMetricCurrent
+	var failures []byte
+	err = h.db.QueryRow(`
+		SELECT collection_duration_ms, docker_duration_ms, collection_workers, collection_failures
+		FROM agent_pings
+		WHERE agent_id = $1 AND collection_duration_ms IS NOT NULL
+		ORDER BY created DESC
+		LIMIT 1
+	`, agentID).Scan(&collection.DurationMs, &collection.DockerDurationMs, &collection.Workers, &failures)
+	if err != nil && err != sql.ErrNoRows {
+		return metrics, err
+	}
+	if err == nil {
+		collection.Failures = []models.CollectionFailure{}
+		if len(failures) > 0 {
+			if err := json.Unmarshal(failures, &collection.Failures); err != nil {
+				log.Printf("Error parsing collection failures: %v", err)
+			}
+		}
+		metrics.Collection = &collection
+	}
 
 	return metrics, nil
 }
 
 func (h *Handlers) getAgentSystemMetrics(agentID uuid.UUID) ([]models.SystemMetric, error) {
+	// Метрики каждой таблицы агрегируются отдельно: общий JOIN размножил бы строки
+	// дисков на число CPU и контейнеров
 	rows, err := h.db.Query(`
-		SELECT ap.created, 
-			   COALESCE(AVG(cm.usage_percent), 0) as avg_cpu,
-			   COALESCE(AVG(CASE WHEN mm.ram_total_mb > 0 THEN (mm.ram_usage_mb::float / mm.ram_total_mb::float) * 100 END), 0) as avg_ram,
-			   COALESCE(SUM(dm.read_bytes), 0) as disk_read,
-			   COALESCE(SUM(dm.write_bytes), 0) as disk_write,
-			   COALESCE(SUM(c.network_sent_bytes), 0) as network_sent,
-			   COALESCE(SUM(c.network_received_bytes), 0) as network_received,
-			   COALESCE(nm.public_ip, '0.0.0.0') as public_ip
+		SELECT ap.created,
+			   COALESCE(cm.avg_cpu, 0) as avg_cpu,
+			   COALESCE(CASE WHEN mm.ram_total_mb > 0 THEN (mm.ram_usage_mb::float / mm.ram_total_mb::float) * 100 END, 0) as avg_ram,
+			   COALESCE(dm.read_bytes, 0) as disk_read,
+			   COALESCE(dm.write_bytes, 0) as disk_write,
+			   COALESCE(c.sent_bytes, 0) as network_sent,
+			   COALESCE(c.received_bytes, 0) as network_received,
+			   COALESCE(nm.public_ip, '0.0.0.0') as public_ip,
+			   dm.read_speed, dm.write_speed, dm.iops,
+			   nm.sent_bytes_per_sec, nm.received_bytes_per_sec,
+			   nm.packets_sent_per_sec, nm.packets_received_per_sec,
+			   hm.load1, hm.cpu_iowait_percent, hm.cpu_steal_percent
 		FROM agent_pings ap
-		LEFT JOIN cpu_metrics cm ON ap.id = cm.ping_id
+		LEFT JOIN LATERAL (
+			SELECT AVG(usage_percent) as avg_cpu FROM cpu_metrics WHERE ping_id = ap.id
+		) cm ON true
 		LEFT JOIN memory_metrics mm ON ap.id = mm.ping_id
-		LEFT JOIN disk_metrics dm ON ap.id = dm.ping_id
-		LEFT JOIN containers c ON ap.id = c.ping_id
+		LEFT JOIN LATERAL (
+			SELECT SUM(read_bytes) as read_bytes, SUM(write_bytes) as write_bytes,
+				   SUM(read_bytes_per_sec) as read_speed, SUM(write_bytes_per_sec) as write_speed,
+				   SUM(reads_per_sec + writes_per_sec) as iops
+			FROM disk_metrics WHERE ping_id = ap.id
+		) dm ON true
+		LEFT JOIN LATERAL (
+			SELECT SUM(network_sent_bytes) as sent_bytes, SUM(network_received_bytes) as received_bytes
+			FROM containers WHERE ping_id = ap.id
+		) c ON true
 		LEFT JOIN network_metrics nm ON ap.id = nm.ping_id
+		LEFT JOIN host_metrics hm ON ap.id = hm.ping_id
 		WHERE ap.agent_id = $1 AND ap.created > now() - interval '1 hour'
-		GROUP BY ap.created, nm.public_ip
 		ORDER BY ap.created DESC
 		LIMIT 50
 	`, agentID)
@@ -1503,7 +2120,11 @@ func (h *Handlers) getAgentSystemMetrics(agentID uuid.UUID) ([]models.SystemMetr
 	for rows.Next() {
 		var metric models.SystemMetric
 		err := rows.Scan(&metric.Timestamp, &metric.CPUUsage, &metric.RAMUsage,
-			&metric.DiskRead, &metric.DiskWrite, &metric.NetworkSent, &metric.NetworkReceived, &metric.PublicIP)
+			&metric.DiskRead, &metric.DiskWrite, &metric.NetworkSent, &metric.NetworkReceived, &metric.PublicIP,
+			&metric.DiskReadSpeed, &metric.DiskWriteSpeed, &metric.DiskIOPS,
+			&metric.NetworkSentSpeed, &metric.NetworkReceivedSpeed,
+			&metric.NetworkPacketsSentSpeed, &metric.NetworkPacketsReceivedSpeed,
+			&metric.Load1, &metric.CPUIowaitPercent, &metric.CPUStealPercent)
 		if err != nil {
 			continue
 		}
@@ -1518,7 +2139,8 @@ func (h *Handlers) getAgentContainersDetailed(agentID uuid.UUID) ([]models.Conta
 		SELECT c.id, c.ping_id, c.container_id, c.name, c.image_id, c.status, 
 			   c.restart_count, c.created_at, c.ip_address, c.mac_address, 
 			   c.cpu_usage_percent, c.memory_usage_mb, c.network_sent_bytes, 
-			   c.network_received_bytes
+			   c.network_received_bytes, c.memory_limit_mb, c.memory_percent, c.block_read_bytes, c.block_write_bytes, c.pids,
+			   `+containerStateSelect+`
 		FROM containers c
 		JOIN agent_pings ap ON c.ping_id = ap.id
 		WHERE ap.agent_id = $1 AND ap.created = (
@@ -1534,16 +2156,19 @@ func (h *Handlers) getAgentContainersDetailed(agentID uuid.UUID) ([]models.Conta
 	var containers []models.ContainerDetail
 	for rows.Next() {
 		var container models.ContainerDetail
-		err := rows.Scan(
+		var state containerStateScan
+		err := rows.Scan(append([]interface{}{
 			&container.ID, &container.PingID, &container.ContainerID, &container.Name,
 			&container.ImageID, &container.Status, &container.RestartCount,
 			&container.CreatedAt, &container.IPAddress, &container.MACAddress,
 			&container.CPUUsagePercent, &container.MemoryUsageMB,
 			&container.NetworkSentBytes, &container.NetworkReceivedBytes,
-		)
+			&container.MemoryLimitMB, &container.MemoryPercent, &container.BlockReadBytes, &container.BlockWriteBytes, &container.PIDs,
+		}, state.targets(&container.Container)...)...)
 		if err != nil {
 			continue
 		}
+		state.apply(&container.Container)
 		containers = append(containers, container)
 	}
 
@@ -1594,7 +2219,7 @@ func (h *Handlers) getAgentImages(agentID uuid.UUID) ([]models.ImageDetail, erro
 
 func (h *Handlers) getContainerLogs(containerID uuid.UUID) ([]models.ContainerLog, error) {
 	rows, err := h.db.Query(`
-		SELECT id, log_line, timestamp FROM container_logs 
+		SELECT id, log_line, stream, timestamp FROM container_logs 
 		WHERE container_id = $1 
 		ORDER BY timestamp DESC 
 		LIMIT 100
@@ -1607,7 +2232,7 @@ func (h *Handlers) getContainerLogs(containerID uuid.UUID) ([]models.ContainerLo
 	var logs []models.ContainerLog
 	for rows.Next() {
 		var log models.ContainerLog
-		err := rows.Scan(&log.ID, &log.LogLine, &log.Timestamp)
+		err := rows.Scan(&log.ID, &log.LogLine, &log.Stream, &log.Timestamp)
 		if err != nil {
 			continue
 		}
@@ -1647,7 +2272,7 @@ func (h *Handlers) getContainerHistory(containerID uuid.UUID) ([]models.Containe
 	return history, nil
 }
 
-func (h *Handlers) getTopContainersCPU() ([]models.TopContainer, error) {
+func (h *Handlers) getTopContainersCPU(scope interface{}) ([]models.TopContainer, error) {
 	rows, err := h.db.Query(`
 		SELECT DISTINCT c.name, a.name as agent_name, c.cpu_usage_percent, 
 			   COALESCE(c.memory_usage_mb, 0), c.status
@@ -1659,9 +2284,10 @@ func (h *Handlers) getTopContainersCPU() ([]models.TopContainer, error) {
 		) latest_pings ON c.ping_id = latest_pings.id
 		JOIN agents a ON latest_pings.agent_id = a.id
 		WHERE c.cpu_usage_percent IS NOT NULL
+		  AND ($1::uuid[] IS NULL OR latest_pings.agent_id = ANY($1))
 		ORDER BY c.cpu_usage_percent DESC
 		LIMIT 5
-	`)
+	`, scope)
 	if err != nil {
 		return []models.TopContainer{}, err
 	}
@@ -1729,20 +2355,43 @@ func (h *Handlers) getTopContainersRAM() ([]models.TopContainer, error) {
 }
 
 // getResourceUsageHistory возвращает историю использования ресурсов
-func (h *Handlers) getResourceUsageHistory() ([]models.ResourceUsagePoint, error) {
+func (h *Handlers) getResourceUsageHistory(scope interface{}) ([]models.ResourceUsagePoint, error) {
+	// Скорости дисков усредняются по пингам агента за минуту и складываются по агентам
 	rows, err := h.db.Query(`
+		WITH disk_speeds AS (
+			SELECT minute, SUM(read_speed) as read_speed, SUM(write_speed) as write_speed, SUM(iops) as iops
+			FROM (
+				SELECT DATE_TRUNC('minute', ap.created) as minute, ap.agent_id,
+					AVG(dm.read_speed) as read_speed, AVG(dm.write_speed) as write_speed, AVG(dm.iops) as iops
+				FROM agent_pings ap
+				JOIN LATERAL (
+					SELECT SUM(read_bytes_per_sec) as read_speed, SUM(write_bytes_per_sec) as write_speed,
+						SUM(reads_per_sec + writes_per_sec) as iops
+					FROM disk_metrics WHERE ping_id = ap.id
+				) dm ON true
+				WHERE ap.created > now() - interval '2 hours'
+				  AND ($1::uuid[] IS NULL OR ap.agent_id = ANY($1))
+				GROUP BY 1, 2
+			) per_agent
+			GROUP BY minute
+		)
 		SELECT 
 			DATE_TRUNC('minute', ap.created) as timestamp,
 			COALESCE(AVG(cm.usage_percent), 0) as avg_cpu,
-			COALESCE(AVG(CASE WHEN mm.ram_total_mb > 0 THEN (mm.ram_usage_mb::float / mm.ram_total_mb::float) * 100 END), 0) as avg_memory
+			COALESCE(AVG(CASE WHEN mm.ram_total_mb > 0 THEN (mm.ram_usage_mb::float / mm.ram_total_mb::float) * 100 END), 0) as avg_memory,
+			COALESCE(MAX(ds.read_speed), 0) as disk_read_speed,
+			COALESCE(MAX(ds.write_speed), 0) as disk_write_speed,
+			COALESCE(MAX(ds.iops), 0) as disk_iops
 		FROM agent_pings ap
 		LEFT JOIN cpu_metrics cm ON ap.id = cm.ping_id
 		LEFT JOIN memory_metrics mm ON ap.id = mm.ping_id
+		LEFT JOIN disk_speeds ds ON ds.minute = DATE_TRUNC('minute', ap.created)
 		WHERE ap.created > now() - interval '2 hours'
+		  AND ($1::uuid[] IS NULL OR ap.agent_id = ANY($1))
 		GROUP BY DATE_TRUNC('minute', ap.created)
 		ORDER BY timestamp DESC
 		LIMIT 20
-	`)
+	`, scope)
 	if err != nil {
 		return []models.ResourceUsagePoint{}, err
 	}
@@ -1751,7 +2400,8 @@ func (h *Handlers) getResourceUsageHistory() ([]models.ResourceUsagePoint, error
 	var points []models.ResourceUsagePoint
 	for rows.Next() {
 		var point models.ResourceUsagePoint
-		err := rows.Scan(&point.Timestamp, &point.CPU, &point.Memory)
+		err := rows.Scan(&point.Timestamp, &point.CPU, &point.Memory,
+			&point.DiskReadSpeed, &point.DiskWriteSpeed, &point.DiskIOPS)
 		if err != nil {
 			continue
 		}
@@ -1772,19 +2422,41 @@ func (h *Handlers) getResourceUsageHistory() ([]models.ResourceUsagePoint, error
 }
 
 // getNetworkActivityHistory возвращает историю сетевой активности
-func (h *Handlers) getNetworkActivityHistory() ([]models.NetworkActivityPoint, error) {
+func (h *Handlers) getNetworkActivityHistory(scope interface{}) ([]models.NetworkActivityPoint, error) {
+	// Скорости сети усредняются по пингам агента за минуту и складываются по агентам
 	rows, err := h.db.Query(`
+		WITH network_speeds AS (
+			SELECT minute, SUM(sent) as sent, SUM(received) as received,
+				SUM(packets_sent) as packets_sent, SUM(packets_received) as packets_received
+			FROM (
+				SELECT DATE_TRUNC('minute', ap.created) as minute, ap.agent_id,
+					AVG(nm.sent_bytes_per_sec) as sent, AVG(nm.received_bytes_per_sec) as received,
+					AVG(nm.packets_sent_per_sec) as packets_sent, AVG(nm.packets_received_per_sec) as packets_received
+				FROM agent_pings ap
+				JOIN network_metrics nm ON ap.id = nm.ping_id
+				WHERE ap.created > now() - interval '2 hours'
+				  AND ($1::uuid[] IS NULL OR ap.agent_id = ANY($1))
+				GROUP BY 1, 2
+			) per_agent
+			GROUP BY minute
+		)
 		SELECT 
 			DATE_TRUNC('minute', ap.created) as timestamp,
 			COALESCE(SUM(c.network_sent_bytes), 0) as total_sent,
-			COALESCE(SUM(c.network_received_bytes), 0) as total_received
+			COALESCE(SUM(c.network_received_bytes), 0) as total_received,
+			COALESCE(MAX(ns.sent), 0) as sent_speed,
+			COALESCE(MAX(ns.received), 0) as received_speed,
+			COALESCE(MAX(ns.packets_sent), 0) as packets_sent_speed,
+			COALESCE(MAX(ns.packets_received), 0) as packets_received_speed
 		FROM agent_pings ap
 		LEFT JOIN containers c ON ap.id = c.ping_id
+		LEFT JOIN network_speeds ns ON ns.minute = DATE_TRUNC('minute', ap.created)
 		WHERE ap.created > now() - interval '2 hours'
+		  AND ($1::uuid[] IS NULL OR ap.agent_id = ANY($1))
 		GROUP BY DATE_TRUNC('minute', ap.created)
 		ORDER BY timestamp DESC
 		LIMIT 20
-	`)
+	`, scope)
 	if err != nil {
 		return []models.NetworkActivityPoint{}, err
 	}
@@ -1793,7 +2465,8 @@ func (h *Handlers) getNetworkActivityHistory() ([]models.NetworkActivityPoint, e
 	var points []models.NetworkActivityPoint
 	for rows.Next() {
 		var point models.NetworkActivityPoint
-		err := rows.Scan(&point.Timestamp, &point.Sent, &point.Received)
+		err := rows.Scan(&point.Timestamp, &point.Sent, &point.Received,
+			&point.SentSpeed, &point.ReceivedSpeed, &point.PacketsSentSpeed, &point.PacketsReceivedSpeed)
 		if err != nil {
 			continue
 		}
@@ -1814,7 +2487,7 @@ func (h *Handlers) getNetworkActivityHistory() ([]models.NetworkActivityPoint, e
 }
 
 // getTopContainersMemory возвращает топ контейнеров по использованию памяти
-func (h *Handlers) getTopContainersMemory() ([]models.TopContainer, error) {
+func (h *Handlers) getTopContainersMemory(scope interface{}) ([]models.TopContainer, error) {
 	rows, err := h.db.Query(`
 		SELECT 
 			c.name,
@@ -1826,9 +2499,10 @@ func (h *Handlers) getTopContainersMemory() ([]models.TopContainer, error) {
 		JOIN agents a ON ap.agent_id = a.id
 		WHERE c.memory_usage_mb IS NOT NULL
 		  AND ap.created > now() - interval '5 minutes'
+		  AND ($1::uuid[] IS NULL OR ap.agent_id = ANY($1))
 		ORDER BY c.memory_usage_mb DESC
 		LIMIT 5
-	`)
+	`, scope)
 	if err != nil {
 		return []models.TopContainer{}, err
 	}
@@ -1860,7 +2534,7 @@ func (h *Handlers) getAgentsSummary() ([]models.AgentSummary, error) {
 		SELECT 
 			a.id,
 			a.name,
-			latest_ping.created as last_ping,
+			a.last_ping,
 			COALESCE(COUNT(DISTINCT c.container_id), 0) as containers_count,
 			COALESCE(AVG(cm.usage_percent), 0) as avg_cpu,
 			COALESCE(AVG(CASE WHEN mm.ram_total_mb > 0 THEN (mm.ram_usage_mb::float / mm.ram_total_mb::float) * 100 END), 0) as avg_memory
@@ -1874,7 +2548,7 @@ func (h *Handlers) getAgentsSummary() ([]models.AgentSummary, error) {
 		LEFT JOIN cpu_metrics cm ON latest_ping.id = cm.ping_id
 		LEFT JOIN memory_metrics mm ON latest_ping.id = mm.ping_id
 		WHERE a.is_active = true
-		GROUP BY a.id, a.name, latest_ping.created
+		GROUP BY a.id, a.name, a.last_ping
 		ORDER BY a.name
 	`)
 	if err != nil {
@@ -1917,7 +2591,7 @@ func (h *Handlers) getAgentsSummary() ([]models.AgentSummary, error) {
 // getPendingActions получает список невыполненных действий для агента
 func (h *Handlers) getPendingActions(agentID uuid.UUID) ([]models.Action, error) {
 	rows, err := h.db.Query(`
-		SELECT id, agent_id, type, payload, status, created, completed, response, error
+		SELECT id, agent_id, type, payload, status, created, completed, response, error, created_by
 		FROM actions 
 		WHERE agent_id = $1 AND status = $2
 		ORDER BY created ASC
@@ -1934,7 +2608,7 @@ func (h *Handlers) getPendingActions(agentID uuid.UUID) ([]models.Action, error)
 		err := rows.Scan(
 			&action.ID, &action.AgentID, &action.Type, &payloadJSON,
 			&action.Status, &action.Created, &action.Completed,
-			&action.Response, &action.Error,
+			&action.Response, &action.Error, &action.CreatedBy,
 		)
 		if err != nil {
 			return nil, err
@@ -1953,7 +2627,7 @@ func (h *Handlers) getPendingActions(agentID uuid.UUID) ([]models.Action, error)
 
 // CreateAction создает новое действие для агента
 // @Summary Создание действия
-// @Description Создает новое действие для выполнения агентом
+// @Description Создает новое действие для выполнения агентом. Если под действие подпадает политика подтверждения, оно создается в статусе awaiting_approval
 // @Tags actions
 // @Accept json
 // @Produce json
@@ -1969,6 +2643,11 @@ func (h *Handlers) CreateAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Type == models.ActionTypeRotateToken {
+		http.Error(w, "Use POST /agents/{id}/rotate-token to rotate agent tokens", http.StatusBadRequest)
+		return
+	}
+
 	// Проверяем существование агента
 	agentID, err := uuid.Parse(req.AgentID)
 	if err != nil {
@@ -1986,6 +2665,9 @@ func (h *Handlers) CreateAction(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Agent not found", http.StatusNotFound)
 		return
 	}
+	if !requireAgentAccess(w, r, agentID) {
+		return
+	}
 
 	// Создаем действие
 	payloadJSON, err := json.Marshal(req.Payload)
@@ -1994,32 +2676,58 @@ func (h *Handlers) CreateAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var action models.Action
-	err = h.db.QueryRow(`
-		INSERT INTO actions (agent_id, type, payload, status, created)
-		VALUES ($1, $2, $3, $4, now())
-		RETURNING id, agent_id, type, payload, status, created, completed, response, error
-	`, agentID, req.Type, payloadJSON, models.ActionStatusPending).Scan(
-		&action.ID, &action.AgentID, &action.Type, &payloadJSON,
-		&action.Status, &action.Created, &action.Completed,
-		&action.Response, &action.Error,
-	)
+	// Действие может быть удержано до подтверждения согласно политикам
+	actionID, _, err := approvals.InsertAction(h.db, agentID, req.Type, payloadJSON, currentUserID(r))
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	// Парсим payload обратно
-	if err := json.Unmarshal(payloadJSON, &action.Payload); err != nil {
-		http.Error(w, "Error processing action", http.StatusInternalServerError)
+	action, err := h.getAction(actionID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
+	h.audit.Record(r, models.AuditEntityAction, action.ID.String(),
+		fmt.Sprintf("Created action %s (%s)", action.Type, action.Status), nil, action)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(action)
 }
 
+// getAction получает действие по ID
+func (h *Handlers) getAction(actionID uuid.UUID) (*models.Action, error) {
+	var action models.Action
+	var payloadJSON []byte
+	err := h.db.QueryRow(`
+		SELECT id, agent_id, type, payload, status, created, completed, response, error, created_by
+		FROM actions WHERE id = $1
+	`, actionID).Scan(
+		&action.ID, &action.AgentID, &action.Type, &payloadJSON,
+		&action.Status, &action.Created, &action.Completed,
+		&action.Response, &action.Error, &action.CreatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(payloadJSON, &action.Payload); err != nil {
+		return nil, err
+	}
+	redactActionPayload(&action)
+
+	return &action, nil
+}
+
+// redactActionPayload скрывает новый токен агента в еще не выполненном действии rotate_token
+func redactActionPayload(action *models.Action) {
+	if action.Type == models.ActionTypeRotateToken {
+		delete(action.Payload, "token")
+	}
+}
+
 // GetActions получает список действий
 // @Summary Получение списка действий
 // @Description Получает список действий с фильтрацией
@@ -2028,6 +2736,7 @@ func (h *Handlers) CreateAction(w http.ResponseWriter, r *http.Request) {
 // @Param agent_id query string false "ID агента"
 // @Param status query string false "Статус действия"
 // @Param type query string false "Тип действия"
+// @Param created_by query string false "ID пользователя, создавшего действие"
 // @Success 200 {object} models.ActionListResponse "Список действий"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /actions [get]
@@ -2035,9 +2744,10 @@ func (h *Handlers) GetActions(w http.ResponseWriter, r *http.Request) {
 	agentID := r.URL.Query().Get("agent_id")
 	status := r.URL.Query().Get("status")
 	actionType := r.URL.Query().Get("type")
+	createdBy := r.URL.Query().Get("created_by")
 
 	query := `
-		SELECT id, agent_id, type, payload, status, created, completed, response, error
+		SELECT id, agent_id, type, payload, status, created, completed, response, error, created_by
 		FROM actions 
 		WHERE 1=1
 	`
@@ -2063,6 +2773,12 @@ func (h *Handlers) GetActions(w http.ResponseWriter, r *http.Request) {
 		argCount++
 	}
 
+	if createdBy != "" {
+		conditions = append(conditions, fmt.Sprintf("created_by = $%d", argCount))
+		args = append(args, createdBy)
+		argCount++
+	}
+
 	if len(conditions) > 0 {
 		query += " AND " + strings.Join(conditions, " AND ")
 	}
@@ -2083,18 +2799,23 @@ func (h *Handlers) GetActions(w http.ResponseWriter, r *http.Request) {
 		err := rows.Scan(
 			&action.ID, &action.AgentID, &action.Type, &payloadJSON,
 			&action.Status, &action.Created, &action.Completed,
-			&action.Response, &action.Error,
+			&action.Response, &action.Error, &action.CreatedBy,
 		)
 		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
 
+		if !canAccessAgent(r, action.AgentID) {
+			continue
+		}
+
 		// Парсим JSON payload
 		if err := json.Unmarshal(payloadJSON, &action.Payload); err != nil {
 			http.Error(w, "Error processing action", http.StatusInternalServerError)
 			return
 		}
+		redactActionPayload(&action)
 
 		actions = append(actions, action)
 	}
@@ -2110,44 +2831,27 @@ func (h *Handlers) GetActions(w http.ResponseWriter, r *http.Request) {
 
 // UpdateActionStatus обновляет статус действия
 // @Summary Обновление статуса действия
-// @Description Обновляет статус действия и сохраняет ответ от агента
+// @Description Обновляет статус действия и сохраняет структурированный ответ агента (JSON-объект)
 // @Tags actions
 // @Accept json
 // @Produce json
 // @Param id path string true "ID действия"
-// @Param Authorization header string true "Bearer токен агента"
+// @Param Authorization header string false "Bearer токен агента (для неподписанных запросов)"
+// @Param X-Agent-ID header string false "ID агента (для подписанных запросов)"
+// @Param X-Agent-Timestamp header int false "Время запроса, Unix-секунды"
+// @Param X-Agent-Nonce header string false "Одноразовое значение"
+// @Param X-Agent-Signature header string false "v2=<hex HMAC-SHA256>"
 // @Param request body models.ActionResponse true "Ответ агента"
 // @Success 200 {object} models.Action "Действие обновлено"
 // @Failure 400 {string} string "Неверные данные"
-// @Failure 401 {string} string "Неверный токен агента"
+// @Failure 401 {string} string "Неверный токен агента, подпись, устаревший или повторный запрос"
 // @Failure 404 {string} string "Действие не найдено"
+// @Failure 409 {string} string "Действие не ожидает выполнения"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /actions/{id}/status [put]
 func (h *Handlers) UpdateActionStatus(w http.ResponseWriter, r *http.Request) {
-	// Проверяем Bearer токен агента
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "Authorization header required", http.StatusUnauthorized)
-		return
-	}
-
-	bearerToken := strings.Split(authHeader, " ")
-	if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-		http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-		return
-	}
-
-	token := bearerToken[1]
-
-	// Проверяем существование агента с таким токеном
-	var agentID uuid.UUID
-	err := h.db.QueryRow("SELECT id FROM agents WHERE token = $1 AND is_active = true", token).Scan(&agentID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Invalid agent token", http.StatusUnauthorized)
-		} else {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-		}
+	agentID, _, ok := h.authenticateAgent(w, r)
+	if !ok {
 		return
 	}
 
@@ -2163,9 +2867,22 @@ func (h *Handlers) UpdateActionStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Агент сообщает только итог выполнения
+	if req.Status != models.ActionStatusCompleted && req.Status != models.ActionStatusFailed {
+		http.Error(w, "Status must be completed or failed", http.StatusBadRequest)
+		return
+	}
+
+	result, err := normalizeActionResult(req.Response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Проверяем, что действие принадлежит этому агенту
 	var actionAgentID uuid.UUID
-	err = h.db.QueryRow("SELECT agent_id FROM actions WHERE id = $1", actionID).Scan(&actionAgentID)
+	var actionType string
+	err = h.db.QueryRow("SELECT agent_id, type FROM actions WHERE id = $1", actionID).Scan(&actionAgentID, &actionType)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Action not found", http.StatusNotFound)
@@ -2180,26 +2897,66 @@ func (h *Handlers) UpdateActionStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Обновляем статус действия
-	var completed *time.Time
-	if req.Status == models.ActionStatusCompleted || req.Status == models.ActionStatusFailed {
-		now := time.Now()
-		completed = &now
-	}
-
-	_, err = h.db.Exec(`
+	// Обновляем статус действия. Завершить можно только выданное агенту действие:
+	// отклоненное, ожидающее подтверждения или уже завершенное не меняется
+	updated, err := h.db.Exec(`
 		UPDATE actions 
 		SET status = $1, completed = $2, response = $3, error = $4
-		WHERE id = $5
-	`, req.Status, completed, req.Response, req.Error, actionID)
+		WHERE id = $5 AND status = $6
+	`, req.Status, time.Now(), result, req.Error, actionID, models.ActionStatusPending)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	if rows, _ := updated.RowsAffected(); rows == 0 {
+		http.Error(w, "Action is not pending", http.StatusConflict)
+		return
+	}
+
+	// Новый токен агента не хранится дольше, чем нужно для доставки
+	if id, err := uuid.Parse(actionID); err == nil && actionType == models.ActionTypeRotateToken {
+		if err := h.auth.ClearRotationPayload(id); err != nil {
+			log.Printf("Error clearing token rotation %s: %v", actionID, err)
+		}
+	}
+
+	// Продвигаем сценарий, если действие является его шагом
+	if id, err := uuid.Parse(actionID); err == nil {
+		if err := h.workflow.HandleActionUpdate(id); err != nil {
+			log.Printf("Error advancing workflow for action %s: %v", actionID, err)
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// normalizeActionResult приводит ответ агента к JSON-объекту для колонки jsonb.
+// Старые агенты присылают текстовый ответ — он сохраняется как {"message": "..."}.
+// Отсутствующий ответ сохраняется как NULL.
+func normalizeActionResult(raw json.RawMessage) (interface{}, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+
+	switch trimmed[0] {
+	case '{':
+		return []byte(trimmed), nil
+	case '"':
+		var message string
+		if err := json.Unmarshal(trimmed, &message); err != nil {
+			return nil, fmt.Errorf("invalid response: %v", err)
+		}
+		wrapped, err := json.Marshal(map[string]string{"message": message})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response: %v", err)
+		}
+		return wrapped, nil
+	default:
+		return nil, fmt.Errorf("response must be a JSON object")
+	}
+}
+
 // GetNotificationSettings получает настройки уведомлений
 // @Summary Получение настроек уведомлений
 // @Description Получает текущие настройки уведомлений
@@ -2234,8 +2991,11 @@ func (h *Handlers) UpdateNotificationSettings(w http.ResponseWriter, r *http.Req
 	}
 
 	// Обновляем настройки в сервисе
+	before := h.notification.GetSettings()
 	h.notification.UpdateSettings(&settings)
 
+	h.audit.Record(r, models.AuditEntityNotificationSettings, "", "Updated notification settings", before, &settings)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(settings)
 }
@@ -2265,7 +3025,7 @@ func (h *Handlers) SendTestNotification(w http.ResponseWriter, r *http.Request)
 }
 
 // checkNotifications проверяет условия для отправки уведомлений
-func (h *Handlers) checkNotifications(agentID uuid.UUID, agentName string, agentData *models.AgentData) {
+func (h *Handlers) checkNotifications(agentID uuid.UUID, agentName string, agentData *models.AgentData, stopped []models.ContainerInfo) {
 	// Проверяем CPU
 	if len(agentData.Metrics.CPU) > 0 {
 		totalCPU := 0.0
@@ -2288,12 +3048,17 @@ func (h *Handlers) checkNotifications(agentID uuid.UUID, agentName string, agent
 		}
 	}
 
-	// Проверяем контейнеры
-	for _, container := range agentData.Docker.Containers {
-		if container.Status == "exited" || container.Status == "stopped" {
-			if err := h.notification.CheckContainerStopped(container.Name, agentName); err != nil {
-				log.Printf("Error sending container stopped notification: %v", err)
-			}
+	// Проверяем заполненность файловых систем: место и inode
+	for _, fs := range agentData.Metrics.Filesystems {
+		if err := h.notification.CheckDiskThreshold(agentName, fs.Mountpoint, fs.UsedPercent(), fs.InodesUsedPercent()); err != nil {
+			log.Printf("Error sending disk threshold notification: %v", err)
+		}
+	}
+
+	// Проверяем контейнеры, остановившиеся с прошлого пинга
+	for _, container := range stopped {
+		if err := h.notification.CheckContainerStopped(container.Name, agentName, container.ExitCode, container.OOMKilled); err != nil {
+			log.Printf("Error sending container stopped notification: %v", err)
 		}
 	}
 }
@@ -2304,7 +3069,7 @@ func (h *Handlers) CheckOfflineAgents() {
 	rows, err := h.db.Query(`
 		SELECT id, name 
 		FROM agents 
-		WHERE is_active = true 
+		WHERE is_active = true AND approved = true
 		AND (last_ping IS NULL OR last_ping < NOW() - INTERVAL '60 seconds')
 	`)
 	if err != nil {
@@ -2356,13 +3121,15 @@ func (h *Handlers) GetDomains(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := models.DomainListResponse{
-		Domains: make([]models.DomainDetail, len(domains)),
-		Total:   len(domains),
+		Domains: []models.DomainDetail{},
 	}
 
-	for i, domain := range domains {
-		response.Domains[i] = *domain
+	for _, domain := range domains {
+		if canAccessAgent(r, domain.AgentID) {
+			response.Domains = append(response.Domains, *domain)
+		}
 	}
+	response.Total = len(response.Domains)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -2397,12 +3164,18 @@ func (h *Handlers) CreateDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !requireAgentAccess(w, r, req.AgentID) {
+		return
+	}
+
 	domain, err := h.domain.CreateDomain(&req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	h.audit.Record(r, models.AuditEntityDomain, domain.ID.String(), fmt.Sprintf("Created domain %s", domain.Name), nil, domain)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(domain)
@@ -2422,6 +3195,9 @@ func (h *Handlers) GetDomain(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	if !requireAgentAccess(w, r, domain.AgentID) {
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(domain)
@@ -2442,12 +3218,27 @@ func (h *Handlers) UpdateDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	domain, err := h.domain.UpdateDomain(id, &req)
+	if !h.requireDomainAccess(w, r, id) {
+		return
+	}
+	if req.AgentID != nil && !requireAgentAccess(w, r, *req.AgentID) {
+		return
+	}
+
+	before, _ := h.domain.GetDomainByID(id)
+
+	domain, err := h.domain.UpdateDomain(id, &req, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	var beforeDomain *models.Domain
+	if before != nil {
+		beforeDomain = &before.Domain
+	}
+	h.audit.Record(r, models.AuditEntityDomain, id.String(), fmt.Sprintf("Updated domain %s", domain.Name), beforeDomain, domain)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(domain)
 }
@@ -2461,12 +3252,22 @@ func (h *Handlers) DeleteDomain(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.requireDomainAccess(w, r, id) {
+		return
+	}
+
+	before, _ := h.domain.GetDomainByID(id)
+
 	err = h.domain.DeleteDomain(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if before != nil {
+		h.audit.Record(r, models.AuditEntityDomain, id.String(), fmt.Sprintf("Deleted domain %s", before.Name), before, nil)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -2479,6 +3280,10 @@ func (h *Handlers) GetDomainStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.requireDomainAccess(w, r, id) {
+		return
+	}
+
 	status, err := h.domain.GetDomainStatus(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -2497,12 +3302,19 @@ func (h *Handlers) CreateDomainRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	route, err := h.domain.CreateDomainRoute(&req)
+	if !h.requireDomainAccess(w, r, req.DomainID) {
+		return
+	}
+
+	route, err := h.domain.CreateDomainRoute(&req, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	h.audit.Record(r, models.AuditEntityDomainRoute, route.ID.String(),
+		fmt.Sprintf("Created route %s -> %s:%s", route.Path, route.ContainerName, route.Port), nil, route)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(route)
@@ -2517,6 +3329,10 @@ func (h *Handlers) GetDomainRoutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.requireDomainAccess(w, r, domainID) {
+		return
+	}
+
 	routes, err := h.domain.GetDomainRoutes(domainID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -2547,12 +3363,21 @@ func (h *Handlers) UpdateDomainRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	route, err := h.domain.UpdateDomainRoute(id, &req)
+	if !h.requireDomainRouteAccess(w, r, id) {
+		return
+	}
+
+	before, _ := h.domain.GetDomainRouteByID(id)
+
+	route, err := h.domain.UpdateDomainRoute(id, &req, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	h.audit.Record(r, models.AuditEntityDomainRoute, id.String(),
+		fmt.Sprintf("Updated route %s -> %s:%s", route.Path, route.ContainerName, route.Port), before, route)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(route)
 }
@@ -2566,11 +3391,22 @@ func (h *Handlers) DeleteDomainRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.domain.DeleteDomainRoute(id)
+	if !h.requireDomainRouteAccess(w, r, id) {
+		return
+	}
+
+	before, _ := h.domain.GetDomainRouteByID(id)
+
+	err = h.domain.DeleteDomainRoute(id, currentUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if before != nil {
+		h.audit.Record(r, models.AuditEntityDomainRoute, id.String(),
+			fmt.Sprintf("Deleted route %s -> %s:%s", before.Path, before.ContainerName, before.Port), before, nil)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
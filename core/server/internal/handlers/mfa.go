@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/mfa"
+	"monitoring-system/core/server/internal/models"
+)
+
+// LoginTOTP завершает двухшаговый вход кодом второго фактора
+// @Summary Второй шаг входа
+// @Description Принимает challenge_token из ответа /login и код из приложения-аутентификатора или код восстановления. На один вход дается 5 попыток.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginChallengeRequest true "Токен второго шага и код"
+// @Success 200 {object} models.LoginResponse "Успешная аутентификация"
+// @Failure 400 {string} string "Неверный JSON"
+// @Failure 401 {string} string "Неверный код или токен второго шага"
+// @Failure 429 {string} string "Слишком много неудачных попыток, см. заголовок Retry-After"
+// @Router /login/2fa [post]
+func (h *Handlers) LoginTOTP(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, challengeID, err := h.mfa.CheckChallenge(req.ChallengeToken, mfa.ChallengeTOTP)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	user, ok := h.challengeUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.mfa.Verify(userID, req.Code); err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			h.loginFailed(r, &user.ID, user.Username, models.AuthEventMFAFailure, "invalid second factor code")
+		} else {
+			h.releaseLoginAttempt(r, user.Username)
+		}
+		writeMFAError(w, err)
+		return
+	}
+	h.releaseLoginAttempt(r, user.Username)
+
+	h.finishChallenge(w, r, user, challengeID, nil)
+}
+
+// LoginEnrollTOTP начинает обязательное подключение 2FA во время входа
+// @Summary Подключение 2FA при входе
+// @Description Для challenge_type=enroll: возвращает секрет TOTP, который нужно подтвердить через /login/2fa/activate
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginChallengeRequest true "Токен второго шага"
+// @Success 200 {object} models.TOTPEnrollment "Секрет TOTP"
+// @Failure 400 {string} string "Неверный JSON"
+// @Failure 401 {string} string "Токен второго шага недействителен"
+// @Failure 429 {string} string "Слишком много неудачных попыток, см. заголовок Retry-After"
+// @Router /login/2fa/enroll [post]
+func (h *Handlers) LoginEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, _, err := h.mfa.CheckChallenge(req.ChallengeToken, mfa.ChallengeEnroll)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	user, ok := h.challengeUser(w, r, userID)
+	if !ok {
+		return
+	}
+	// Подключение не проверяет код, поэтому попытка сразу снимается
+	h.releaseLoginAttempt(r, user.Username)
+
+	enrollment, err := h.mfa.BeginEnrollment(userID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// LoginActivateTOTP подтверждает подключение 2FA и завершает вход
+// @Summary Подтверждение 2FA при входе
+// @Description Для challenge_type=enroll: включает 2FA по первому коду из приложения и выдает токены вместе с кодами восстановления
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginChallengeRequest true "Токен второго шага и код"
+// @Success 200 {object} models.LoginResponse "Успешная аутентификация и коды восстановления"
+// @Failure 400 {string} string "Неверный JSON или подключение не начато"
+// @Failure 401 {string} string "Неверный код или токен второго шага"
+// @Failure 429 {string} string "Слишком много неудачных попыток, см. заголовок Retry-After"
+// @Router /login/2fa/activate [post]
+func (h *Handlers) LoginActivateTOTP(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, challengeID, err := h.mfa.CheckChallenge(req.ChallengeToken, mfa.ChallengeEnroll)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	user, ok := h.challengeUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfa.Activate(userID, req.Code)
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			h.loginFailed(r, &user.ID, user.Username, models.AuthEventMFAFailure, "invalid code while enabling second factor")
+		} else {
+			h.releaseLoginAttempt(r, user.Username)
+		}
+		writeMFAError(w, err)
+		return
+	}
+	h.releaseLoginAttempt(r, user.Username)
+
+	h.audit.Record(r, models.AuditEntityMFA, userID.String(), "Enabled two-factor authentication at login", nil, nil)
+
+	h.finishChallenge(w, r, user, challengeID, codes)
+}
+
+// challengeUser получает пользователя второго шага входа и резервирует попытку входа
+func (h *Handlers) challengeUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (*models.User, bool) {
+	user, err := h.user.GetUser(userID)
+	if err != nil || !user.IsActive {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return nil, false
+	}
+	if !h.reserveLoginAttempt(w, r, &user.ID, user.Username) {
+		return nil, false
+	}
+	return user, true
+}
+
+// finishChallenge закрывает второй шаг входа и открывает сессию
+func (h *Handlers) finishChallenge(w http.ResponseWriter, r *http.Request, user *models.User, challengeID uuid.UUID, recoveryCodes []string) {
+	if err := h.mfa.CompleteChallenge(challengeID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.completeLogin(w, r, user, recoveryCodes)
+}
+
+// GetMFAStatus получает состояние 2FA текущего пользователя
+// @Summary Состояние 2FA
+// @Tags profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.MFAStatusResponse "Состояние 2FA"
+// @Failure 401 {string} string "Не авторизован"
+// @Router /profile/2fa [get]
+func (h *Handlers) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.user.GetUser(claims.UserID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	left, err := h.mfa.RecoveryCodesLeft(claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.MFAStatusResponse{
+		Enabled:           user.TOTPEnabled,
+		Required:          h.mfa.Required(user.Role),
+		RecoveryCodesLeft: left,
+	})
+}
+
+// EnrollTOTP начинает подключение 2FA
+// @Summary Подключение 2FA
+// @Description Возвращает новый секрет TOTP; 2FA включается после подтверждения кодом через /profile/2fa/activate
+// @Tags profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TOTPEnrollment "Секрет TOTP"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 409 {string} string "2FA уже включена"
+// @Router /profile/2fa/enroll [post]
+func (h *Handlers) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfa.BeginEnrollment(claims.UserID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// ActivateTOTP подтверждает подключение 2FA
+// @Summary Подтверждение 2FA
+// @Description Включает 2FA по первому коду из приложения и возвращает коды восстановления (показываются один раз)
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TOTPCodeRequest true "Код из приложения"
+// @Success 200 {object} models.RecoveryCodesResponse "Коды восстановления"
+// @Failure 400 {string} string "Подключение не начато"
+// @Failure 401 {string} string "Неверный код"
+// @Failure 409 {string} string "2FA уже включена"
+// @Router /profile/2fa/activate [post]
+func (h *Handlers) ActivateTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.mfa.Activate(claims.UserID, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityMFA, claims.UserID.String(), "Enabled two-factor authentication", nil, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP отключает 2FA текущего пользователя
+// @Summary Отключение 2FA
+// @Description Требует действующий код из приложения или код восстановления. Недоступно, если 2FA обязательна для роли.
+// @Tags profile
+// @Accept json
+// @Security BearerAuth
+// @Param request body models.TOTPCodeRequest true "Код подтверждения"
+// @Success 204 "2FA отключена"
+// @Failure 400 {string} string "2FA не включена"
+// @Failure 401 {string} string "Неверный код"
+// @Failure 409 {string} string "2FA обязательна для роли"
+// @Router /profile/2fa [delete]
+func (h *Handlers) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	if h.mfa.Required(claims.Role) {
+		writeMFAError(w, mfa.ErrRequired)
+		return
+	}
+
+	var req models.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.mfa.Verify(claims.UserID, req.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	if err := h.mfa.Disable(claims.UserID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityMFA, claims.UserID.String(), "Disabled two-factor authentication", nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes выдает новый набор кодов восстановления
+// @Summary Новые коды восстановления
+// @Description Прежние коды перестают действовать. Требует действующий код из приложения или код восстановления.
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TOTPCodeRequest true "Код подтверждения"
+// @Success 200 {object} models.RecoveryCodesResponse "Коды восстановления"
+// @Failure 400 {string} string "2FA не включена"
+// @Failure 401 {string} string "Неверный код"
+// @Router /profile/2fa/recovery-codes [post]
+func (h *Handlers) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.mfa.Verify(claims.UserID, req.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	codes, err := h.mfa.RegenerateRecoveryCodes(claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityMFA, claims.UserID.String(), "Regenerated recovery codes", nil, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetUserTOTP сбрасывает 2FA пользователя
+// @Summary Сброс 2FA пользователя
+// @Description Например, при утере телефона. Если 2FA обязательна для роли, пользователь подключит ее заново при следующем входе. Доступно только администраторам.
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 204 "2FA сброшена"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Router /users/{id}/2fa [delete]
+func (h *Handlers) ResetUserTOTP(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.user.GetUser(id); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	if err := h.mfa.Disable(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityMFA, id.String(), "Reset user two-factor authentication", nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeMFAError сопоставляет ошибки 2FA с HTTP-статусами
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode), errors.Is(err, mfa.ErrInvalidChallenge):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, mfa.ErrNotEnrolled):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, mfa.ErrAlreadyEnabled), errors.Is(err, mfa.ErrRequired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
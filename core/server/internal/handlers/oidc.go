@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"monitoring-system/core/server/internal/audit"
+	"monitoring-system/core/server/internal/models"
+	"monitoring-system/core/server/internal/oidc"
+)
+
+// oidcLoginPage — страница приложения, которая принимает результат входа из фрагмента URL
+const oidcLoginPage = "/login"
+
+// oidcStateCookie хранит state начатого входа. Обратный вызов принимается только в браузере
+// с этой cookie: иначе ссылку на завершение своего входа злоумышленник мог бы отправить
+// жертве, и та вошла бы под его учетной записью.
+const oidcStateCookie = "oidc_state"
+
+// GetOIDCStatus сообщает, доступен ли вход через OIDC
+// @Summary Доступность входа через OIDC
+// @Description Позволяет странице входа показать кнопку единого входа
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.OIDCStatusResponse "Статус"
+// @Router /oidc [get]
+func (h *Handlers) GetOIDCStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.OIDCStatusResponse{Enabled: h.oidc.Enabled()})
+}
+
+// OIDCLogin начинает вход через OIDC
+// @Summary Вход через OIDC
+// @Description Перенаправляет на провайдера (authorization code flow с PKCE); state сохраняется в HttpOnly cookie oidc_state, без которой обратный вызов отклоняется
+// @Tags auth
+// @Success 302 "Перенаправление на провайдера"
+// @Failure 404 {string} string "Вход через OIDC не настроен"
+// @Failure 502 {string} string "Провайдер недоступен"
+// @Router /oidc/login [get]
+func (h *Handlers) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.oidc.Enabled() {
+		http.Error(w, "OIDC login is not configured", http.StatusNotFound)
+		return
+	}
+
+	authURL, state, err := h.oidc.AuthCodeURL()
+	if err != nil {
+		log.Printf("Error starting OIDC login: %v", err)
+		http.Error(w, "Identity provider is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	setOIDCStateCookie(w, state, int(oidc.StateTTL.Seconds()))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback завершает вход через OIDC
+// @Summary Обратный вызов OIDC
+// @Description Принимает код авторизации, создает пользователя при первом входе, открывает сессию и перенаправляет на страницу входа приложения с токенами во фрагменте URL (#token=...&refresh_token=...), с токеном второго шага, если нужна 2FA (#challenge_token=...&challenge_type=...), или с ошибкой (#error=...)
+// @Tags auth
+// @Param code query string false "Код авторизации"
+// @Param state query string false "State"
+// @Success 302 "Перенаправление в приложение"
+// @Router /oidc/callback [get]
+func (h *Handlers) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// state одноразовый: cookie больше не нужна при любом исходе
+	cookie, cookieErr := r.Cookie(oidcStateCookie)
+	setOIDCStateCookie(w, "", -1)
+
+	if providerError := query.Get("error"); providerError != "" {
+		reason := fmt.Sprintf("%s: %s", providerError, query.Get("error_description"))
+		log.Printf("OIDC provider returned an error: %s", reason)
+		h.guard.RecordEvent(r, nil, "", models.AuthEventLoginFailure, "oidc: "+reason)
+		redirectOIDCError(w, r, "Login was cancelled or denied by the identity provider")
+		return
+	}
+
+	state := query.Get("state")
+	if cookieErr != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		log.Printf("Rejected OIDC callback: state does not match the browser that started the login")
+		h.guard.RecordEvent(r, nil, "", models.AuthEventLoginFailure, "oidc: state does not match login cookie")
+		redirectOIDCError(w, r, oidcErrorMessage(oidc.ErrInvalidState))
+		return
+	}
+
+	user, created, err := h.oidc.Callback(r.Context(), state, query.Get("code"))
+	if err != nil {
+		log.Printf("Error completing OIDC login: %v", err)
+		h.guard.RecordEvent(r, nil, "", models.AuthEventLoginFailure, "oidc: "+err.Error())
+		redirectOIDCError(w, r, oidcErrorMessage(err))
+		return
+	}
+
+	if created {
+		h.audit.Record(r, models.AuditEntityUser, user.ID.String(), fmt.Sprintf("Provisioned user %s via OIDC", user.Username), nil, user)
+	}
+
+	// Токены передаются во фрагменте: он не отправляется на сервер и не попадает в журналы прокси
+	fragment := url.Values{}
+
+	// Второй фактор запрашивается так же, как при входе по паролю: приложение
+	// продолжает вход через /login/2fa с токеном второго шага
+	if challengeType := h.loginChallengeType(user); challengeType != "" {
+		challengeToken, err := h.mfa.CreateChallenge(user.ID, challengeType)
+		if err != nil {
+			log.Printf("Error creating login challenge: %v", err)
+			redirectOIDCError(w, r, "Error generating token")
+			return
+		}
+		fragment.Set("challenge_token", challengeToken)
+		fragment.Set("challenge_type", challengeType)
+		http.Redirect(w, r, oidcLoginPage+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+
+	response, err := h.auth.CreateSession(user, audit.ClientIP(r), r.UserAgent())
+	if err != nil {
+		log.Printf("Error creating session: %v", err)
+		redirectOIDCError(w, r, "Error generating token")
+		return
+	}
+	h.guard.RecordEvent(r, &user.ID, user.Username, models.AuthEventLoginSuccess, "oidc")
+
+	fragment.Set("token", response.Token)
+	fragment.Set("refresh_token", response.RefreshToken)
+	fragment.Set("expires_in", strconv.Itoa(response.ExpiresIn))
+	http.Redirect(w, r, oidcLoginPage+"#"+fragment.Encode(), http.StatusFound)
+}
+
+// oidcErrorMessage возвращает текст ошибки для страницы входа. Подробности остальных
+// ошибок (ответы провайдера, ошибки базы) остаются в журнале и событиях входа.
+func oidcErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, oidc.ErrDisabled):
+		return "OIDC login is not configured"
+	case errors.Is(err, oidc.ErrInvalidState):
+		return "Login session has expired, please try again"
+	case errors.Is(err, oidc.ErrNoRole):
+		return "Your account is not allowed to access this system"
+	case errors.Is(err, oidc.ErrUsernameTaken):
+		return "Username is already used by another account"
+	case errors.Is(err, oidc.ErrUserInactive):
+		return "User is inactive"
+	default:
+		return "Login failed"
+	}
+}
+
+// setOIDCStateCookie сохраняет state входа в браузере; maxAge < 0 удаляет cookie
+func setOIDCStateCookie(w http.ResponseWriter, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// redirectOIDCError возвращает пользователя на страницу входа с текстом ошибки
+func redirectOIDCError(w http.ResponseWriter, r *http.Request, message string) {
+	fragment := url.Values{}
+	fragment.Set("error", message)
+	http.Redirect(w, r, oidcLoginPage+"#"+fragment.Encode(), http.StatusFound)
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+// diskCounters — накопительные счетчики диска из прошлого пинга
+type diskCounters struct {
+	readBytes, writeBytes, reads, writes int64
+}
+
+// networkCounters — накопительные счетчики сети из прошлого пинга
+type networkCounters struct {
+	sent, received               int64
+	packetsSent, packetsReceived sql.NullInt64
+}
+
+// hostCounters — накопительные счетчики хоста: переключения контекста и время CPU по режимам
+type hostCounters struct {
+	uptime          int64
+	contextSwitches int64
+	cpu             models.CPUTimesInfo
+}
+
+// previousCounters — счетчики и состояние контейнеров прошлого пинга агента и время, прошедшее с него
+type previousCounters struct {
+	elapsed    float64
+	disks      map[string]diskCounters
+	network    *networkCounters
+	interfaces map[string]networkCounters
+	host       *hostCounters
+	// containerStates — состояние контейнеров (running, exited, ...) по ID Docker
+	containerStates map[string]string
+}
+
+// loadPreviousCounters читает счетчики пинга агента, предшествующего сбору в момент created.
+// Вызывается в транзакции до создания нового пинга. nil — более ранних пингов нет.
+func loadPreviousCounters(tx *sql.Tx, agentID uuid.UUID, created time.Time) (*previousCounters, error) {
+	var pingID uuid.UUID
+	previous := &previousCounters{
+		disks:           make(map[string]diskCounters),
+		interfaces:      make(map[string]networkCounters),
+		containerStates: make(map[string]string),
+	}
+	err := tx.QueryRow(`
+		SELECT id, EXTRACT(EPOCH FROM $2::timestamptz - created)
+		FROM agent_pings
+		WHERE agent_id = $1 AND created < $2::timestamptz
+		ORDER BY created DESC
+		LIMIT 1
+	`, agentID, created).Scan(&pingID, &previous.elapsed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous ping: %v", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT disk_name, read_bytes, write_bytes, reads, writes
+		FROM disk_metrics
+		WHERE ping_id = $1
+	`, pingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous disk metrics: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var counters diskCounters
+		if err := rows.Scan(&name, &counters.readBytes, &counters.writeBytes, &counters.reads, &counters.writes); err != nil {
+			return nil, fmt.Errorf("failed to scan previous disk metrics: %v", err)
+		}
+		previous.disks[name] = counters
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get previous disk metrics: %v", err)
+	}
+
+	var network networkCounters
+	err = tx.QueryRow(`
+		SELECT sent_bytes, received_bytes, packets_sent, packets_received
+		FROM network_metrics
+		WHERE ping_id = $1
+	`, pingID).Scan(&network.sent, &network.received, &network.packetsSent, &network.packetsReceived)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get previous network metrics: %v", err)
+	}
+	if err == nil {
+		previous.network = &network
+	}
+
+	interfaceRows, err := tx.Query(`
+		SELECT name, sent_bytes, received_bytes, packets_sent, packets_received
+		FROM network_interface_metrics
+		WHERE ping_id = $1
+	`, pingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous interface metrics: %v", err)
+	}
+	defer interfaceRows.Close()
+
+	for interfaceRows.Next() {
+		var name string
+		var counters networkCounters
+		if err := interfaceRows.Scan(&name, &counters.sent, &counters.received, &counters.packetsSent, &counters.packetsReceived); err != nil {
+			return nil, fmt.Errorf("failed to scan previous interface metrics: %v", err)
+		}
+		previous.interfaces[name] = counters
+	}
+	if err := interfaceRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get previous interface metrics: %v", err)
+	}
+
+	var host hostCounters
+	err = tx.QueryRow(`
+		SELECT uptime_seconds, context_switches, cpu_user, cpu_nice, cpu_system, cpu_idle,
+			cpu_iowait, cpu_irq, cpu_softirq, cpu_steal
+		FROM host_metrics
+		WHERE ping_id = $1
+	`, pingID).Scan(&host.uptime, &host.contextSwitches, &host.cpu.User, &host.cpu.Nice, &host.cpu.System, &host.cpu.Idle,
+		&host.cpu.Iowait, &host.cpu.Irq, &host.cpu.Softirq, &host.cpu.Steal)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get previous host metrics: %v", err)
+	}
+	if err == nil {
+		previous.host = &host
+	}
+
+	containerRows, err := tx.Query(`
+		SELECT container_id, state
+		FROM containers
+		WHERE ping_id = $1 AND state IS NOT NULL
+	`, pingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous container states: %v", err)
+	}
+	defer containerRows.Close()
+
+	for containerRows.Next() {
+		var containerID, state string
+		if err := containerRows.Scan(&containerID, &state); err != nil {
+			return nil, fmt.Errorf("failed to scan previous container states: %v", err)
+		}
+		previous.containerStates[containerID] = state
+	}
+	if err := containerRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get previous container states: %v", err)
+	}
+
+	return previous, nil
+}
+
+// counterRate возвращает скорость роста накопительного счетчика в секунду. Счетчик,
+// уменьшившийся с прошлого пинга, был сброшен (перезагрузка хоста, пересоздание
+// устройства): сколько он вырос за интервал, неизвестно, поэтому скорость не считается.
+func counterRate(previous, current int64, elapsed float64) *float64 {
+	if elapsed <= 0 || current < previous {
+		return nil
+	}
+	rate := float64(current-previous) / elapsed
+	return &rate
+}
+
+// diskRates возвращает скорости чтения и записи диска в байтах и операциях в секунду
+func (p *previousCounters) diskRates(name string, current diskCounters) (readBytes, writeBytes, reads, writes *float64) {
+	if p == nil {
+		return nil, nil, nil, nil
+	}
+	previous, ok := p.disks[name]
+	if !ok {
+		return nil, nil, nil, nil
+	}
+	return counterRate(previous.readBytes, current.readBytes, p.elapsed),
+		counterRate(previous.writeBytes, current.writeBytes, p.elapsed),
+		counterRate(previous.reads, current.reads, p.elapsed),
+		counterRate(previous.writes, current.writes, p.elapsed)
+}
+
+// networkRates возвращает скорости сети в байтах и пакетах в секунду. Скорость пакетов
+// не считается, если счетчиков пакетов нет в одном из пингов.
+func (p *previousCounters) networkRates(current networkCounters) (sent, received, packetsSent, packetsReceived *float64) {
+	if p == nil || p.network == nil {
+		return nil, nil, nil, nil
+	}
+	return p.trafficRates(*p.network, current)
+}
+
+// interfaceRates возвращает скорости интерфейса в байтах и пакетах в секунду
+func (p *previousCounters) interfaceRates(name string, current networkCounters) (sent, received, packetsSent, packetsReceived *float64) {
+	if p == nil {
+		return nil, nil, nil, nil
+	}
+	previous, ok := p.interfaces[name]
+	if !ok {
+		return nil, nil, nil, nil
+	}
+	return p.trafficRates(previous, current)
+}
+
+// trafficRates считает скорости сетевых счетчиков относительно прошлого пинга
+func (p *previousCounters) trafficRates(previous, current networkCounters) (sent, received, packetsSent, packetsReceived *float64) {
+	sent = counterRate(previous.sent, current.sent, p.elapsed)
+	received = counterRate(previous.received, current.received, p.elapsed)
+	if previous.packetsSent.Valid && current.packetsSent.Valid {
+		packetsSent = counterRate(previous.packetsSent.Int64, current.packetsSent.Int64, p.elapsed)
+	}
+	if previous.packetsReceived.Valid && current.packetsReceived.Valid {
+		packetsReceived = counterRate(previous.packetsReceived.Int64, current.packetsReceived.Int64, p.elapsed)
+	}
+	return sent, received, packetsSent, packetsReceived
+}
+
+// optionalCounter преобразует необязательный счетчик агента для записи в базу
+func optionalCounter(value *uint64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*value), Valid: true}
+}
+
+// hostRates — скорость переключений контекста и доли времени CPU по режимам в процентах
+type hostRates struct {
+	contextSwitches                   *float64
+	user, system, iowait, steal, idle *float64
+}
+
+// hostRates считает скорости хоста с прошлого пинга. После перезагрузки хоста (время работы
+// уменьшилось) счетчики начинаются заново, и скорости не считаются.
+func (p *previousCounters) hostRates(current hostCounters) hostRates {
+	var rates hostRates
+	if p == nil || p.host == nil || current.uptime < p.host.uptime {
+		return rates
+	}
+	previous := p.host
+
+	rates.contextSwitches = counterRate(previous.contextSwitches, current.contextSwitches, p.elapsed)
+
+	// Доли считаются от суммарного прироста времени всех режимов
+	user := current.cpu.User + current.cpu.Nice - previous.cpu.User - previous.cpu.Nice
+	system := current.cpu.System + current.cpu.Irq + current.cpu.Softirq - previous.cpu.System - previous.cpu.Irq - previous.cpu.Softirq
+	iowait := current.cpu.Iowait - previous.cpu.Iowait
+	steal := current.cpu.Steal - previous.cpu.Steal
+	idle := current.cpu.Idle - previous.cpu.Idle
+	total := user + system + iowait + steal + idle
+	if total <= 0 || user < 0 || system < 0 || iowait < 0 || steal < 0 || idle < 0 {
+		return rates
+	}
+
+	percent := func(value float64) *float64 {
+		result := value / total * 100
+		return &result
+	}
+	rates.user = percent(user)
+	rates.system = percent(system)
+	rates.iowait = percent(iowait)
+	rates.steal = percent(steal)
+	rates.idle = percent(idle)
+	return rates
+}
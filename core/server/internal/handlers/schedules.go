@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+	"monitoring-system/core/server/internal/scheduler"
+)
+
+// GetSchedules получает список расписаний
+// @Summary Получение списка расписаний
+// @Description Возвращает расписания действий с временем следующего и последнего запуска
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param agent_id query string false "ID агента"
+// @Success 200 {object} models.ScheduleListResponse "Список расписаний"
+// @Failure 400 {string} string "Неверный ID агента"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /schedules [get]
+func (h *Handlers) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	var agentID *uuid.UUID
+	if agentIDStr := r.URL.Query().Get("agent_id"); agentIDStr != "" {
+		id, err := uuid.Parse(agentIDStr)
+		if err != nil {
+			http.Error(w, "Invalid agent ID", http.StatusBadRequest)
+			return
+		}
+		agentID = &id
+	}
+
+	list, err := h.scheduler.GetSchedules(agentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	schedules := []models.ActionSchedule{}
+	for _, schedule := range list {
+		if canAccessAgent(r, schedule.AgentID) {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	response := models.ScheduleListResponse{
+		Schedules: schedules,
+		Total:     len(schedules),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateSchedule создает расписание действия
+// @Summary Создание расписания
+// @Description Создает периодическое (cron_expr) или однократное (run_at) расписание действия
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateScheduleRequest true "Данные расписания"
+// @Success 201 {object} models.ActionSchedule "Расписание создано"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 404 {string} string "Агент не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /schedules [post]
+func (h *Handlers) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !requireAgentAccess(w, r, req.AgentID) {
+		return
+	}
+
+	schedule, err := h.scheduler.CreateSchedule(&req, currentUserID(r))
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntitySchedule, schedule.ID.String(), fmt.Sprintf("Created schedule %s", schedule.Name), nil, schedule)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// GetSchedule получает расписание по ID
+// @Summary Получение расписания
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID расписания"
+// @Success 200 {object} models.ActionSchedule "Расписание"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Расписание не найдено"
+// @Router /schedules/{id} [get]
+func (h *Handlers) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.scheduler.GetSchedule(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !requireAgentAccess(w, r, schedule.AgentID) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// UpdateSchedule обновляет расписание
+// @Summary Обновление расписания
+// @Description Обновляет параметры расписания и пересчитывает время следующего запуска
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID расписания"
+// @Param request body models.UpdateScheduleRequest true "Поля для обновления"
+// @Success 200 {object} models.ActionSchedule "Расписание обновлено"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /schedules/{id} [put]
+func (h *Handlers) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.scheduler.GetSchedule(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !requireAgentAccess(w, r, before.AgentID) {
+		return
+	}
+
+	schedule, err := h.scheduler.UpdateSchedule(id, &req)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntitySchedule, id.String(), fmt.Sprintf("Updated schedule %s", schedule.Name), before, schedule)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// PauseSchedule приостанавливает расписание
+// @Summary Приостановка расписания
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID расписания"
+// @Success 200 {object} models.ActionSchedule "Расписание приостановлено"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /schedules/{id}/pause [post]
+func (h *Handlers) PauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setSchedulePaused(w, r, true)
+}
+
+// ResumeSchedule возобновляет расписание
+// @Summary Возобновление расписания
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID расписания"
+// @Success 200 {object} models.ActionSchedule "Расписание возобновлено"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /schedules/{id}/resume [post]
+func (h *Handlers) ResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setSchedulePaused(w, r, false)
+}
+
+func (h *Handlers) setSchedulePaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.scheduler.GetSchedule(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !requireAgentAccess(w, r, before.AgentID) {
+		return
+	}
+
+	schedule, err := h.scheduler.SetPaused(id, paused)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	operation := "Resumed"
+	if paused {
+		operation = "Paused"
+	}
+	h.audit.Record(r, models.AuditEntitySchedule, id.String(), fmt.Sprintf("%s schedule %s", operation, schedule.Name), before, schedule)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// DeleteSchedule удаляет расписание
+// @Summary Удаление расписания
+// @Tags schedules
+// @Security BearerAuth
+// @Param id path string true "ID расписания"
+// @Success 204 "Расписание удалено"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /schedules/{id} [delete]
+func (h *Handlers) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.scheduler.GetSchedule(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !requireAgentAccess(w, r, before.AgentID) {
+		return
+	}
+
+	if err := h.scheduler.DeleteSchedule(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntitySchedule, id.String(), fmt.Sprintf("Deleted schedule %s", before.Name), before, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeScheduleError возвращает 400 для ошибок валидации, 404 для неизвестного агента и 500 для остальных
+func writeScheduleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, scheduler.ErrInvalidSchedule) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, scheduler.ErrAgentNotFound) {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
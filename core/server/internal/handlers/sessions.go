@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/audit"
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+)
+
+// Refresh обменивает refresh-токен на новую пару токенов
+// @Summary Обновление токенов
+// @Description Выдает новый JWT и новый refresh-токен той же сессии; предъявленный refresh-токен становится недействительным. Повторное использование старого refresh-токена отзывает сессию.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} models.LoginResponse "Новые токены"
+// @Failure 400 {string} string "Неверный JSON"
+// @Failure 401 {string} string "Refresh-токен недействителен"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /refresh [post]
+func (h *Handlers) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.auth.RefreshSession(req.RefreshToken, audit.ClientIP(r), r.UserAgent())
+	if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("Error refreshing session: %v", err)
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Logout завершает текущую сессию
+// @Summary Выход из системы
+// @Description Отзывает текущую сессию: ее JWT и refresh-токен перестают действовать сразу
+// @Tags auth
+// @Security BearerAuth
+// @Success 204 "Сессия завершена"
+// @Failure 400 {string} string "Запрос выполнен не от имени сессии"
+// @Failure 401 {string} string "Не авторизован"
+// @Router /logout [post]
+func (h *Handlers) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if claims.APITokenID != nil {
+		http.Error(w, "API tokens have no session; revoke the token instead", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.auth.RevokeSession(claims.UserID, claims.SessionID); err != nil {
+		writeSessionError(w, err)
+		return
+	}
+	h.guard.RecordEvent(r, &claims.UserID, claims.Username, models.AuthEventLogout, "")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSessions получает активные сессии текущего пользователя
+// @Summary Получение своих сессий
+// @Description Возвращает активные сессии с IP и User-Agent; текущая сессия отмечена полем current
+// @Tags profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.SessionListResponse "Список сессий"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Недоступно по API-токену"
+// @Router /profile/sessions [get]
+func (h *Handlers) GetSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+	h.writeSessions(w, claims.UserID, claims.SessionID)
+}
+
+// RevokeSession завершает сессию текущего пользователя
+// @Summary Завершение своей сессии
+// @Tags profile
+// @Security BearerAuth
+// @Param id path string true "ID сессии"
+// @Success 204 "Сессия завершена"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 404 {string} string "Сессия не найдена"
+// @Failure 403 {string} string "Недоступно по API-токену"
+// @Router /profile/sessions/{id} [delete]
+func (h *Handlers) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.auth.RevokeSession(claims.UserID, sessionID)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntitySession, sessionID.String(), "Revoked own session", nil, session)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAllSessions завершает все сессии текущего пользователя
+// @Summary Выход из всех сессий
+// @Description Отзывает все сессии текущего пользователя, включая текущую. API-токены не затрагиваются.
+// @Tags profile
+// @Security BearerAuth
+// @Success 204 "Сессии завершены"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Недоступно по API-токену"
+// @Router /profile/sessions [delete]
+func (h *Handlers) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.auth.RevokeSessions(claims.UserID, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntitySession, claims.UserID.String(), fmt.Sprintf("Logged out of all sessions (%d)", count), nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserSessions получает активные сессии пользователя
+// @Summary Получение сессий пользователя
+// @Description Доступно только администраторам
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.SessionListResponse "Список сессий"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Router /users/{id}/sessions [get]
+func (h *Handlers) GetUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var current uuid.UUID
+	if claims, ok := auth.GetUserFromContext(r.Context()); ok {
+		current = claims.SessionID
+	}
+	h.writeSessions(w, userID, current)
+}
+
+// RevokeUserSessions завершает все сессии пользователя
+// @Summary Завершение всех сессий пользователя
+// @Description Например, при утере ноутбука. Доступно только администраторам.
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 204 "Сессии завершены"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Router /users/{id}/sessions [delete]
+func (h *Handlers) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.auth.RevokeSessions(userID, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntitySession, userID.String(), fmt.Sprintf("Revoked all user sessions (%d)", count), nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) writeSessions(w http.ResponseWriter, userID, currentSessionID uuid.UUID) {
+	sessions, err := h.auth.GetSessions(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	response := models.SessionListResponse{
+		Sessions: sessions,
+		Total:    len(sessions),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeSessionError сопоставляет ошибки сессий с HTTP-статусами
+func writeSessionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
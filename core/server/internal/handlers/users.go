@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+	"monitoring-system/core/server/internal/users"
+)
+
+// GetUsers получает список пользователей
+// @Summary Получение списка пользователей
+// @Description Доступно только администраторам
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.UserListResponse "Список пользователей"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /users [get]
+func (h *Handlers) GetUsers(w http.ResponseWriter, r *http.Request) {
+	list, err := h.user.GetUsers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := models.UserListResponse{
+		Users: list,
+		Total: len(list),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateUser создает пользователя
+// @Summary Создание пользователя
+// @Description Создает активного пользователя с указанной ролью (admin, approver, operator, viewer) и, при необходимости, ограничивает его списком агентов. Доступно только администраторам.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateUserRequest true "Данные пользователя"
+// @Success 201 {object} models.User "Пользователь создан"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 409 {string} string "Имя пользователя занято"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /users [post]
+func (h *Handlers) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.user.CreateUser(&req)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityUser, user.ID.String(), fmt.Sprintf("Created user %s", user.Username), nil, user)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// GetUser получает пользователя по ID
+// @Summary Получение пользователя
+// @Description Доступно только администраторам
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} models.User "Пользователь"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Router /users/{id} [get]
+func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.user.GetUser(id)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// UpdateUser изменяет пользователя
+// @Summary Изменение пользователя
+// @Description Изменяет email, роль, активность и доступные агенты пользователя. Токены деактивированного пользователя перестают действовать сразу. Доступно только администраторам.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Param request body models.UpdateUserRequest true "Поля для обновления"
+// @Success 200 {object} models.User "Пользователь обновлен"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 409 {string} string "Нельзя лишить систему последнего администратора"
+// @Router /users/{id} [put]
+func (h *Handlers) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.user.GetUser(id)
+
+	user, err := h.user.UpdateUser(id, &req)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	// Сессии деактивированного пользователя завершаются, чтобы их нельзя было продлить после повторной активации
+	if !user.IsActive {
+		if _, err := h.auth.RevokeSessions(id, nil); err != nil {
+			log.Printf("Error revoking sessions of user %s: %v", id, err)
+		}
+	}
+
+	h.audit.Record(r, models.AuditEntityUser, id.String(), fmt.Sprintf("Updated user %s", user.Username), before, user)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// DeleteUser удаляет пользователя
+// @Summary Удаление пользователя
+// @Description Удаляет пользователя; созданные им действия и записи аудита сохраняются. Удалить самого себя нельзя. Доступно только администраторам.
+// @Tags users
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 204 "Пользователь удален"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Failure 409 {string} string "Нельзя удалить последнего администратора"
+// @Router /users/{id} [delete]
+func (h *Handlers) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if current := currentUserID(r); current != nil && *current == id {
+		http.Error(w, "Cannot delete yourself", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.user.GetUser(id)
+
+	if err := h.user.DeleteUser(id); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	if before != nil {
+		h.audit.Record(r, models.AuditEntityUser, id.String(), fmt.Sprintf("Deleted user %s", before.Username), before, nil)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetUserPassword устанавливает пароль пользователя
+// @Summary Установка пароля пользователя
+// @Description Устанавливает новый пароль без проверки текущего и завершает все сессии пользователя. Доступно только администраторам.
+// @Tags users
+// @Accept json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Param request body models.SetPasswordRequest true "Новый пароль"
+// @Success 204 "Пароль изменен"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Пользователь не найден"
+// @Router /users/{id}/password [put]
+func (h *Handlers) SetUserPassword(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.user.SetPassword(id, req.Password); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	// Сброс пароля завершает все сессии пользователя
+	if _, err := h.auth.RevokeSessions(id, nil); err != nil {
+		log.Printf("Error revoking sessions of user %s: %v", id, err)
+	}
+
+	h.audit.Record(r, models.AuditEntityUser, id.String(), "Reset user password", nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProfile получает профиль текущего пользователя
+// @Summary Получение своего профиля
+// @Tags profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.User "Профиль"
+// @Failure 401 {string} string "Не авторизован"
+// @Router /profile [get]
+func (h *Handlers) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.user.GetUser(*userID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// UpdateProfile изменяет профиль текущего пользователя
+// @Summary Изменение своего профиля
+// @Description Пользователь может изменить свой email; роль и активность меняет только администратор
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.UpdateProfileRequest true "Поля профиля"
+// @Success 200 {object} models.User "Профиль обновлен"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Недоступно по API-токену"
+// @Router /profile [put]
+func (h *Handlers) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+	userID := claims.UserID
+
+	var req models.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.user.GetUser(userID)
+
+	user, err := h.user.UpdateProfile(userID, &req)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityUser, userID.String(), "Updated own profile", before, user)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// ChangePassword меняет пароль текущего пользователя
+// @Summary Смена своего пароля
+// @Description Меняет пароль и завершает остальные сессии пользователя
+// @Tags profile
+// @Accept json
+// @Security BearerAuth
+// @Param request body models.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 204 "Пароль изменен"
+// @Failure 400 {string} string "Неверные данные или неверный текущий пароль"
+// @Failure 401 {string} string "Не авторизован"
+// @Failure 403 {string} string "Недоступно по API-токену"
+// @Router /profile/password [put]
+func (h *Handlers) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireInteractiveUser(w, r)
+	if !ok {
+		return
+	}
+	userID := claims.UserID
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.user.ChangePassword(userID, req.CurrentPassword, req.NewPassword); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	// Смена пароля завершает остальные сессии пользователя
+	if _, err := h.auth.RevokeSessions(userID, &claims.SessionID); err != nil {
+		log.Printf("Error revoking sessions of user %s: %v", userID, err)
+	}
+
+	h.audit.Record(r, models.AuditEntityUser, userID.String(), "Changed own password", nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeUserError сопоставляет ошибки сервиса пользователей с HTTP-статусами
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, users.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, users.ErrInvalidUser), errors.Is(err, users.ErrWrongPassword):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, users.ErrUsernameTaken), errors.Is(err, users.ErrLastAdmin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"monitoring-system/core/server/internal/models"
+	"monitoring-system/core/server/internal/workflows"
+)
+
+// GetWorkflows получает список сценариев
+// @Summary Получение списка сценариев
+// @Description Возвращает сценарии развертывания вместе с шагами
+// @Tags workflows
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.WorkflowListResponse "Список сценариев"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /workflows [get]
+func (h *Handlers) GetWorkflows(w http.ResponseWriter, r *http.Request) {
+	list, err := h.workflow.GetWorkflows()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := models.WorkflowListResponse{
+		Workflows: list,
+		Total:     len(list),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateWorkflow создает сценарий
+// @Summary Создание сценария
+// @Description Создает сценарий из шагов-действий с зависимостями (DAG), переменными {{name}} и необязательными компенсациями
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateWorkflowRequest true "Данные сценария"
+// @Success 201 {object} models.Workflow "Сценарий создан"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /workflows [post]
+func (h *Handlers) CreateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	workflow, err := h.workflow.CreateWorkflow(&req)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityWorkflow, workflow.ID.String(), fmt.Sprintf("Created workflow %s", workflow.Name), nil, workflow)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// GetWorkflow получает сценарий по ID
+// @Summary Получение сценария
+// @Tags workflows
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID сценария"
+// @Success 200 {object} models.Workflow "Сценарий"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Сценарий не найден"
+// @Router /workflows/{id} [get]
+func (h *Handlers) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	workflow, err := h.workflow.GetWorkflow(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// UpdateWorkflow обновляет сценарий
+// @Summary Обновление сценария
+// @Description Обновляет сценарий; переданный список шагов полностью заменяет текущий
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID сценария"
+// @Param request body models.UpdateWorkflowRequest true "Поля для обновления"
+// @Success 200 {object} models.Workflow "Сценарий обновлен"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /workflows/{id} [put]
+func (h *Handlers) UpdateWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.workflow.GetWorkflow(id)
+
+	workflow, err := h.workflow.UpdateWorkflow(id, &req)
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityWorkflow, id.String(), fmt.Sprintf("Updated workflow %s", workflow.Name), before, workflow)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// DeleteWorkflow удаляет сценарий
+// @Summary Удаление сценария
+// @Description Удаляет сценарий вместе с историей запусков
+// @Tags workflows
+// @Security BearerAuth
+// @Param id path string true "ID сценария"
+// @Success 204 "Сценарий удален"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /workflows/{id} [delete]
+func (h *Handlers) DeleteWorkflow(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.workflow.GetWorkflow(id)
+
+	if err := h.workflow.DeleteWorkflow(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if before != nil {
+		h.audit.Record(r, models.AuditEntityWorkflow, id.String(), fmt.Sprintf("Deleted workflow %s", before.Name), before, nil)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StartWorkflowRun запускает сценарий на агенте
+// @Summary Запуск сценария
+// @Description Создает запуск сценария на агенте и отправляет шаги без зависимостей
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID сценария"
+// @Param request body models.StartWorkflowRunRequest true "Агент и переменные запуска"
+// @Success 201 {object} models.WorkflowRun "Запуск создан"
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /workflows/{id}/runs [post]
+func (h *Handlers) StartWorkflowRun(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.StartWorkflowRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !requireAgentAccess(w, r, req.AgentID) {
+		return
+	}
+
+	run, err := h.workflow.StartRun(id, &req, currentUserID(r))
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+
+	h.audit.Record(r, models.AuditEntityWorkflowRun, run.ID.String(), fmt.Sprintf("Started workflow %s", run.WorkflowName), nil, run)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(run)
+}
+
+// GetWorkflowRuns получает историю запусков сценария
+// @Summary История запусков сценария
+// @Tags workflows
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID сценария"
+// @Success 200 {object} models.WorkflowRunListResponse "Список запусков"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /workflows/{id}/runs [get]
+func (h *Handlers) GetWorkflowRuns(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid workflow ID", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.workflow.GetRuns(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	runs := []models.WorkflowRun{}
+	for _, run := range list {
+		if canAccessAgent(r, run.AgentID) {
+			runs = append(runs, run)
+		}
+	}
+
+	response := models.WorkflowRunListResponse{
+		Runs:  runs,
+		Total: len(runs),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetWorkflowRun получает запуск сценария с результатами шагов
+// @Summary Получение запуска сценария
+// @Tags workflows
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID запуска"
+// @Success 200 {object} models.WorkflowRun "Запуск с результатами шагов"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Запуск не найден"
+// @Router /workflows/runs/{id} [get]
+func (h *Handlers) GetWorkflowRun(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid run ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.workflow.GetRun(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !requireAgentAccess(w, r, run.AgentID) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// writeWorkflowError возвращает 400 для ошибок валидации и 500 для остальных
+func writeWorkflowError(w http.ResponseWriter, err error) {
+	if errors.Is(err, workflows.ErrInvalidWorkflow) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,309 @@
+package loginguard
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"monitoring-system/core/server/internal/audit"
+	"monitoring-system/core/server/internal/models"
+)
+
+const (
+	// failureWindow — через сколько после последней ошибки счетчик начинается заново
+	failureWindow = 15 * time.Minute
+	// delayAfter — число ошибок, после которого каждая следующая попытка откладывается
+	delayAfter = 3
+	// maxDelay ограничивает прогрессивную задержку (1, 2, 4, ... секунд)
+	maxDelay = 30 * time.Second
+	// userLockoutThreshold и ipLockoutThreshold — число ошибок до временной блокировки.
+	// Порог для IP выше: за одним адресом (NAT, прокси) могут работать несколько пользователей.
+	userLockoutThreshold = 10
+	ipLockoutThreshold   = 30
+	// lockoutDuration — длительность временной блокировки
+	lockoutDuration = 15 * time.Minute
+)
+
+// Service защищает вход от перебора паролей: считает неудачные попытки по имени
+// пользователя и по IP, задерживает повторные попытки и временно блокирует вход.
+// Также ведет журнал событий входа.
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// userKey и ipKey — ключи счетчиков в login_throttles
+func userKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Reserve атомарно проверяет задержку и блокировку для имени пользователя и IP
+// и, если попытка разрешена, заранее учитывает ее как неудачную. Так параллельные
+// попытки видят друг друга и не обходят задержки. Результат попытки сообщается
+// через RecordFailure или Release. Нулевое значение wait означает, что попытка
+// разрешена и учтена.
+func (s *Service) Reserve(username, ip string) (wait time.Duration, err error) {
+	// Ключи блокируются в одном порядке ("ip:" < "user:"), чтобы исключить взаимоблокировки
+	keys := []string{ipKey(ip), userKey(username)}
+	now := time.Now()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Пустые счетчики создаются заранее, чтобы первые параллельные попытки тоже ждали блокировки строки
+	_, err = tx.Exec(`
+		INSERT INTO login_throttles (key, failures, last_failure) VALUES ($1, 0, $3), ($2, 0, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, keys[0], keys[1], now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create login throttles: %v", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT failures, last_failure, locked_until FROM login_throttles
+		WHERE key = ANY($1) ORDER BY key FOR UPDATE
+	`, pq.Array(keys))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get login throttles: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var failures int
+		var lastFailure time.Time
+		var lockedUntil *time.Time
+		if err := rows.Scan(&failures, &lastFailure, &lockedUntil); err != nil {
+			return 0, fmt.Errorf("failed to scan login throttle: %v", err)
+		}
+
+		var until time.Time
+		switch {
+		case lockedUntil != nil && lockedUntil.After(now):
+			until = *lockedUntil
+		case failures >= delayAfter && now.Sub(lastFailure) < failureWindow:
+			until = lastFailure.Add(delay(failures))
+		}
+		if d := until.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read login throttles: %v", err)
+	}
+	rows.Close()
+
+	if wait > 0 {
+		return wait, nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE login_throttles SET
+			failures = CASE WHEN last_failure < $2 THEN 1 ELSE failures + 1 END,
+			last_failure = $3
+		WHERE key = ANY($1)
+	`, pq.Array(keys), now.Add(-failureWindow), now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve login attempt: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return 0, nil
+}
+
+// delay вычисляет прогрессивную задержку после указанного числа ошибок
+func delay(failures int) time.Duration {
+	d := time.Duration(math.Pow(2, float64(failures-delayAfter))) * time.Second
+	if d > maxDelay || d <= 0 {
+		return maxDelay
+	}
+	return d
+}
+
+// RecordFailure подтверждает, что попытка, учтенная в Reserve, оказалась неудачной.
+// locked сообщает, что попытка привела к временной блокировке имени пользователя или IP.
+func (s *Service) RecordFailure(username, ip string) (locked bool, err error) {
+	now := time.Now()
+
+	// Заодно удаляем устаревшие счетчики
+	_, err = s.db.Exec(`
+		DELETE FROM login_throttles
+		WHERE last_failure < $1 AND (locked_until IS NULL OR locked_until < $2)
+	`, now.Add(-failureWindow), now)
+	if err != nil {
+		return false, fmt.Errorf("failed to clean up login throttles: %v", err)
+	}
+
+	for _, counter := range []struct {
+		key       string
+		threshold int
+	}{
+		{userKey(username), userLockoutThreshold},
+		{ipKey(ip), ipLockoutThreshold},
+	} {
+		// При блокировке счетчик сбрасывается: после ее окончания задержки растут заново
+		result, err := s.db.Exec(`
+			UPDATE login_throttles SET failures = 0, locked_until = $3
+			WHERE key = $1 AND failures >= $2
+		`, counter.key, counter.threshold, now.Add(lockoutDuration))
+		if err != nil {
+			return false, fmt.Errorf("failed to record login failure: %v", err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			locked = true
+		}
+	}
+
+	return locked, nil
+}
+
+// Release снимает со счетчиков попытку, учтенную в Reserve, если она не была неудачной:
+// пароль или код оказались верными либо запрос не дошел до проверки.
+func (s *Service) Release(username, ip string) error {
+	_, err := s.db.Exec(`
+		UPDATE login_throttles SET failures = GREATEST(failures - 1, 0)
+		WHERE key = ANY($1)
+	`, pq.Array([]string{ipKey(ip), userKey(username)}))
+	if err != nil {
+		return fmt.Errorf("failed to release login attempt: %v", err)
+	}
+	return nil
+}
+
+// RecordSuccess сбрасывает счетчик ошибок пользователя после успешного входа.
+// Счетчик IP не сбрасывается, чтобы вход в одну учетную запись не открывал перебор других.
+func (s *Service) RecordSuccess(username string) error {
+	if _, err := s.db.Exec("DELETE FROM login_throttles WHERE key = $1", userKey(username)); err != nil {
+		return fmt.Errorf("failed to reset login throttle: %v", err)
+	}
+	return nil
+}
+
+// Unlock снимает задержку и блокировку входа для пользователя.
+// locked сообщает, была ли учетная запись заблокирована.
+func (s *Service) Unlock(username string) (locked bool, err error) {
+	var lockedUntil *time.Time
+	err = s.db.QueryRow(
+		"DELETE FROM login_throttles WHERE key = $1 RETURNING locked_until", userKey(username),
+	).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock user: %v", err)
+	}
+	return lockedUntil != nil && lockedUntil.After(time.Now()), nil
+}
+
+// RecordEvent записывает событие входа. Ошибка записи логируется и не прерывает
+// обработку запроса.
+func (s *Service) RecordEvent(r *http.Request, userID *uuid.UUID, username, event, detail string) {
+	var name, description interface{}
+	if username != "" {
+		name = username
+	}
+	if detail != "" {
+		description = detail
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO auth_events (user_id, username, ip, user_agent, event, detail)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, userID, name, audit.ClientIP(r), r.UserAgent(), event, description)
+	if err != nil {
+		log.Printf("Error writing auth event: %v", err)
+	}
+}
+
+// GetEvents получает события входа с фильтрацией, новые первыми
+func (s *Service) GetEvents(filter models.AuthEventFilter) ([]models.AuthEvent, int, error) {
+	var conditions []string
+	var args []interface{}
+	argCount := 1
+
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argCount))
+		args = append(args, *filter.UserID)
+		argCount++
+	}
+	if filter.Username != "" {
+		conditions = append(conditions, fmt.Sprintf("LOWER(username) = LOWER($%d)", argCount))
+		args = append(args, filter.Username)
+		argCount++
+	}
+	if filter.IP != "" {
+		conditions = append(conditions, fmt.Sprintf("ip = $%d", argCount))
+		args = append(args, filter.IP)
+		argCount++
+	}
+	if filter.Event != "" {
+		conditions = append(conditions, fmt.Sprintf("event = $%d", argCount))
+		args = append(args, filter.Event)
+		argCount++
+	}
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("created >= $%d", argCount))
+		args = append(args, *filter.From)
+		argCount++
+	}
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("created <= $%d", argCount))
+		args = append(args, *filter.To)
+		argCount++
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM auth_events"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count auth events: %v", err)
+	}
+
+	query := `
+		SELECT id, user_id, username, ip, user_agent, event, detail, created
+		FROM auth_events` + where + fmt.Sprintf(" ORDER BY created DESC LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get auth events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuthEvent{}
+	for rows.Next() {
+		var event models.AuthEvent
+		err := rows.Scan(
+			&event.ID, &event.UserID, &event.Username, &event.IP, &event.UserAgent,
+			&event.Event, &event.Detail, &event.Created,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan auth event: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, total, nil
+}
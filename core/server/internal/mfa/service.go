@@ -0,0 +1,351 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	"monitoring-system/core/server/internal/models"
+)
+
+const (
+	// totpIssuer отображается в приложении-аутентификаторе
+	totpIssuer = "Monitoring System"
+	// totpPeriod — длительность интервала TOTP
+	totpPeriod = 30
+	// totpSkew — сколько соседних интервалов принимается из-за расхождения часов
+	totpSkew = 1
+	// recoveryCodeCount — число кодов восстановления в наборе
+	recoveryCodeCount = 10
+	// challengeTTL — сколько живет незавершенный двухшаговый вход
+	challengeTTL = 5 * time.Minute
+	// maxChallengeAttempts — число попыток ввода кода на один вход
+	maxChallengeAttempts = 5
+	// challengeTokenPrefix отличает токены второго шага входа от прочих токенов
+	challengeTokenPrefix = "msc_"
+)
+
+// Типы второго шага входа
+const (
+	ChallengeTOTP   = "totp"   // пользователь вводит код TOTP или код восстановления
+	ChallengeEnroll = "enroll" // роль требует 2FA, пользователь подключает ее перед входом
+)
+
+var (
+	// ErrInvalidCode возвращается для неверного или уже использованного кода
+	ErrInvalidCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge возвращается для неизвестного, просроченного или исчерпанного challenge
+	ErrInvalidChallenge = errors.New("login challenge is invalid or expired")
+	// ErrNotEnrolled возвращается, если 2FA не подключена или подключение не начато
+	ErrNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	// ErrAlreadyEnabled возвращается при повторном подключении включенной 2FA
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrRequired возвращается при попытке отключить обязательную для роли 2FA
+	ErrRequired = errors.New("two-factor authentication is required for this role")
+)
+
+// Service управляет двухфакторной аутентификацией по TOTP и двухшаговым входом
+type Service struct {
+	db               *sql.DB
+	requireForAdmins bool
+}
+
+func NewService(db *sql.DB, requireForAdmins bool) *Service {
+	return &Service{db: db, requireForAdmins: requireForAdmins}
+}
+
+// Required сообщает, обязательна ли 2FA для роли
+func (s *Service) Required(role string) bool {
+	return s.requireForAdmins && role == models.UserRoleAdmin
+}
+
+// hashCode возвращает SHA-256 хеш кода или токена в hex
+func hashCode(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode убирает пробелы и дефисы, которые пользователи вставляют вместе с кодом
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// BeginEnrollment создает новый секрет TOTP; 2FA включается после подтверждения кодом
+func (s *Service) BeginEnrollment(userID uuid.UUID) (*models.TOTPEnrollment, error) {
+	var username string
+	var enabled bool
+	err := s.db.QueryRow("SELECT username, totp_enabled FROM users WHERE id = $1", userID).Scan(&username, &enabled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: username,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %v", err)
+	}
+
+	_, err = s.db.Exec("UPDATE users SET totp_secret = $1, totp_last_step = NULL WHERE id = $2", key.Secret(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save totp secret: %v", err)
+	}
+
+	return &models.TOTPEnrollment{Secret: key.Secret(), OTPAuthURL: key.URL()}, nil
+}
+
+// Activate включает 2FA после проверки кода из приложения и возвращает коды восстановления
+func (s *Service) Activate(userID uuid.UUID, code string) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var secret sql.NullString
+	var enabled bool
+	var lastStep sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&secret, &enabled, &lastStep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if enabled {
+		return nil, ErrAlreadyEnabled
+	}
+	if !secret.Valid {
+		return nil, ErrNotEnrolled
+	}
+
+	step, ok := validateTOTP(secret.String, code, lastStep)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	if _, err := tx.Exec("UPDATE users SET totp_enabled = true, totp_last_step = $1 WHERE id = $2", step, userID); err != nil {
+		return nil, fmt.Errorf("failed to enable totp: %v", err)
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return codes, nil
+}
+
+// Disable отключает 2FA и удаляет коды восстановления
+func (s *Service) Disable(userID uuid.UUID) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_step = NULL WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable totp: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления новым набором
+func (s *Service) RegenerateRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return codes, nil
+}
+
+// replaceRecoveryCodes генерирует новый набор кодов восстановления вместо прежнего
+func replaceRecoveryCodes(tx *sql.Tx, userID uuid.UUID) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %v", err)
+		}
+		code := hex.EncodeToString(raw)
+		if _, err := tx.Exec(`
+			INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hashCode(code)); err != nil {
+			return nil, fmt.Errorf("failed to save recovery code: %v", err)
+		}
+		// Показываем код группами по 5 символов, как принято в приложениях
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// Verify проверяет код TOTP или одноразовый код восстановления включенной 2FA
+func (s *Service) Verify(userID uuid.UUID, code string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var secret sql.NullString
+	var enabled bool
+	var lastStep sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&secret, &enabled, &lastStep)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %v", err)
+	}
+	if !enabled || !secret.Valid {
+		return ErrNotEnrolled
+	}
+
+	if step, ok := validateTOTP(secret.String, code, lastStep); ok {
+		if _, err := tx.Exec("UPDATE users SET totp_last_step = $1 WHERE id = $2", step, userID); err != nil {
+			return fmt.Errorf("failed to update totp step: %v", err)
+		}
+	} else {
+		result, err := tx.Exec(`
+			UPDATE user_recovery_codes SET used = NOW()
+			WHERE user_id = $1 AND code_hash = $2 AND used IS NULL
+		`, userID, hashCode(normalizeCode(code)))
+		if err != nil {
+			return fmt.Errorf("failed to use recovery code: %v", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return ErrInvalidCode
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// validateTOTP проверяет код в пределах допустимого расхождения часов и возвращает его интервал.
+// Код интервала, не превышающего последний принятый, отклоняется как повторный.
+func validateTOTP(secret, code string, lastStep sql.NullInt64) (int64, bool) {
+	code = normalizeCode(code)
+	now := time.Now()
+	current := now.Unix() / totpPeriod
+	for offset := -totpSkew; offset <= totpSkew; offset++ {
+		step := current + int64(offset)
+		if lastStep.Valid && step <= lastStep.Int64 {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, now.Add(time.Duration(offset*totpPeriod)*time.Second), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// CreateChallenge начинает второй шаг входа после проверки пароля и возвращает его токен
+func (s *Service) CreateChallenge(userID uuid.UUID, kind string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate challenge token: %v", err)
+	}
+	token := challengeTokenPrefix + hex.EncodeToString(raw)
+
+	// Заодно удаляем брошенные входы
+	if _, err := s.db.Exec("DELETE FROM login_challenges WHERE expires < NOW()"); err != nil {
+		return "", fmt.Errorf("failed to clean up login challenges: %v", err)
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO login_challenges (user_id, token_hash, kind, expires) VALUES ($1, $2, $3, $4)
+	`, userID, hashCode(token), kind, time.Now().Add(challengeTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to create login challenge: %v", err)
+	}
+	return token, nil
+}
+
+// CheckChallenge проверяет токен второго шага и засчитывает попытку.
+// Возвращает пользователя и идентификатор challenge для CompleteChallenge.
+func (s *Service) CheckChallenge(token, kind string) (userID uuid.UUID, challengeID uuid.UUID, err error) {
+	err = s.db.QueryRow(`
+		UPDATE login_challenges SET attempts = attempts + 1
+		WHERE token_hash = $1 AND kind = $2 AND expires > NOW() AND attempts < $3
+		RETURNING user_id, id
+	`, hashCode(token), kind, maxChallengeAttempts).Scan(&userID, &challengeID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, uuid.Nil, ErrInvalidChallenge
+	}
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("failed to check login challenge: %v", err)
+	}
+	return userID, challengeID, nil
+}
+
+// CompleteChallenge удаляет challenge после успешного второго шага, чтобы его нельзя было использовать повторно
+func (s *Service) CompleteChallenge(challengeID uuid.UUID) error {
+	if _, err := s.db.Exec("DELETE FROM login_challenges WHERE id = $1", challengeID); err != nil {
+		return fmt.Errorf("failed to complete login challenge: %v", err)
+	}
+	return nil
+}
+
+// RecoveryCodesLeft возвращает число неиспользованных кодов восстановления
+func (s *Service) RecoveryCodesLeft(userID uuid.UUID) (int, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %v", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAgentResponse представляет созданного агента вместе с его токеном
+type CreateAgentResponse struct {
+	Agent
+	Token string `json:"token" example:"msa_1a2b3c4d..."` // показывается один раз
+}
+
+// RotateAgentTokenRequest представляет запрос на ротацию токена агента
+type RotateAgentTokenRequest struct {
+	// GracePeriodHours — сколько часов принимается старый токен; 0 — 24 часа, максимум 168
+	GracePeriodHours int `json:"grace_period_hours" example:"24"`
+}
+
+// RotateAgentTokenResponse представляет результат ротации токена агента
+type RotateAgentTokenResponse struct {
+	ActionID    uuid.UUID `json:"action_id"` // действие rotate_token, которое доставит токен агенту
+	TokenPrefix string    `json:"token_prefix" example:"msa_1a2b3c4d"`
+	// Token показывается один раз — для ручной настройки агентов, не поддерживающих rotate_token
+	Token                string    `json:"token" example:"msa_1a2b3c4d..."`
+	PreviousTokenExpires time.Time `json:"previous_token_expires"` // до этого времени принимается и старый токен
+}
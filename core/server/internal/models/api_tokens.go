@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIToken представляет персональный API-токен пользователя.
+// Сам токен показывается только при создании, в базе хранится его хеш.
+type APIToken struct {
+	ID       uuid.UUID  `json:"id"`
+	UserID   uuid.UUID  `json:"user_id"`
+	Name     string     `json:"name"`
+	Prefix   string     `json:"prefix" example:"msk_1a2b3c4d"` // начало токена для узнавания в списке
+	Scopes   []string   `json:"scopes"`                        // права токена; пустой список — все права роли владельца
+	Expires  *time.Time `json:"expires"`
+	LastUsed *time.Time `json:"last_used"`
+	Revoked  *time.Time `json:"revoked"`
+	Created  time.Time  `json:"created"`
+}
+
+// CreateAPITokenRequest представляет запрос на создание API-токена
+type CreateAPITokenRequest struct {
+	Name string `json:"name" example:"ci-deploy"`
+	// Scopes ограничивает токен правами view, operate, approve, admin; пустой список — все права роли
+	Scopes []string `json:"scopes" example:"view,operate"`
+	// ExpiresInDays задает срок действия токена; 0 — бессрочный
+	ExpiresInDays int `json:"expires_in_days" example:"90"`
+}
+
+// CreateAPITokenResponse представляет созданный API-токен вместе с его значением
+type CreateAPITokenResponse struct {
+	APIToken
+	Token string `json:"token" example:"msk_1a2b3c4d..."` // показывается один раз
+}
+
+// APITokenListResponse представляет ответ со списком API-токенов
+type APITokenListResponse struct {
+	Tokens []APIToken `json:"tokens"`
+	Total  int        `json:"total"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApprovalPolicy представляет политику, удерживающую действия до подтверждения
+type ApprovalPolicy struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ActionType  string     `json:"action_type" db:"action_type"`
+	AgentID     *uuid.UUID `json:"agent_id" db:"agent_id"`       // nil — политика действует для всех агентов
+	ForceOnly   bool       `json:"force_only" db:"force_only"`   // только для действий с payload.force = true
+	Description *string    `json:"description" db:"description"` // например: "production"
+	Created     time.Time  `json:"created" db:"created"`
+	// Дополнительные поля для совместимости с frontend
+	AgentName *string `json:"agent_name"`
+}
+
+// CreateApprovalPolicyRequest представляет запрос на создание политики подтверждения
+// @Description Запрос на создание политики подтверждения действий
+type CreateApprovalPolicyRequest struct {
+	ActionType  string     `json:"action_type" example:"remove_container"`
+	AgentID     *uuid.UUID `json:"agent_id,omitempty"`
+	ForceOnly   bool       `json:"force_only" example:"true"`
+	Description *string    `json:"description,omitempty" example:"production"`
+}
+
+// ApprovalPolicyListResponse представляет ответ со списком политик подтверждения
+type ApprovalPolicyListResponse struct {
+	Policies []ApprovalPolicy `json:"policies"`
+	Total    int              `json:"total"`
+}
+
+// ActionApproval представляет запрос на подтверждение действия и принятое решение
+type ActionApproval struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ActionID    uuid.UUID  `json:"action_id" db:"action_id"`
+	PolicyID    *uuid.UUID `json:"policy_id" db:"policy_id"`
+	RequestedBy *uuid.UUID `json:"requested_by" db:"requested_by"`
+	Decision    *string    `json:"decision" db:"decision"` // approved, rejected; nil — ожидает решения
+	DecidedBy   *uuid.UUID `json:"decided_by" db:"decided_by"`
+	Reason      *string    `json:"reason" db:"reason"`
+	Created     time.Time  `json:"created" db:"created"`
+	Decided     *time.Time `json:"decided" db:"decided"`
+	// Дополнительные поля для совместимости с frontend
+	Action              Action  `json:"action"`
+	AgentName           *string `json:"agent_name"`
+	RequestedByUsername *string `json:"requested_by_username"`
+	DecidedByUsername   *string `json:"decided_by_username"`
+}
+
+// ApprovalDecisionRequest представляет решение по действию
+type ApprovalDecisionRequest struct {
+	Reason string `json:"reason" example:"Planned maintenance"`
+}
+
+// ActionApprovalListResponse представляет ответ со списком подтверждений
+type ActionApprovalListResponse struct {
+	Approvals []ActionApproval `json:"approvals"`
+	Total     int              `json:"total"`
+}
+
+// Константы для решений по действиям
+const (
+	ApprovalDecisionApproved = "approved"
+	ApprovalDecisionRejected = "rejected"
+)
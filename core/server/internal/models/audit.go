@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry представляет запись журнала аудита об изменяющем API-вызове
+type AuditEntry struct {
+	ID         uuid.UUID              `json:"id" db:"id"`
+	UserID     *uuid.UUID             `json:"user_id" db:"user_id"`
+	Username   *string                `json:"username" db:"username"`
+	IP         *string                `json:"ip" db:"ip"`
+	Method     string                 `json:"method" db:"method"`
+	Path       string                 `json:"path" db:"path"`
+	EntityType string                 `json:"entity_type" db:"entity_type"` // agent, domain, action, ...
+	EntityID   *string                `json:"entity_id" db:"entity_id"`
+	Summary    string                 `json:"summary" db:"summary"`
+	Before     map[string]interface{} `json:"before" db:"before"`
+	After      map[string]interface{} `json:"after" db:"after"`
+	Changes    map[string]AuditChange `json:"changes" db:"changes"` // ключ — путь к полю, например "email_settings.smtp_host"
+	Created    time.Time              `json:"created" db:"created"`
+}
+
+// AuditChange представляет изменение одного поля
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter представляет параметры выборки журнала аудита
+type AuditFilter struct {
+	UserID     *uuid.UUID
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// AuditListResponse представляет ответ со списком записей журнала аудита
+type AuditListResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Total   int          `json:"total"`
+}
+
+// Константы для типов сущностей журнала аудита
+const (
+	AuditEntityAgent                = "agent"
+	AuditEntityDomain               = "domain"
+	AuditEntityDomainRoute          = "domain_route"
+	AuditEntityNotificationSettings = "notification_settings"
+	AuditEntityAction               = "action"
+	AuditEntitySchedule             = "schedule"
+	AuditEntityWorkflow             = "workflow"
+	AuditEntityWorkflowRun          = "workflow_run"
+	AuditEntityApprovalPolicy       = "approval_policy"
+	AuditEntityUser                 = "user"
+	AuditEntityAPIToken             = "api_token"
+	AuditEntitySession              = "session"
+	AuditEntityMFA                  = "mfa"
+	AuditEntityEnrollmentToken      = "agent_enrollment_token"
+)
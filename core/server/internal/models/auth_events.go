@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Константы для типов событий входа
+const (
+	AuthEventLoginSuccess = "login_success" // успешный вход (по паролю, 2FA или OIDC)
+	AuthEventLoginFailure = "login_failure" // неверное имя пользователя или пароль
+	AuthEventMFAFailure   = "mfa_failure"   // неверный код второго фактора
+	AuthEventLockout      = "lockout"       // превышен порог неудачных попыток, вход временно заблокирован
+	AuthEventBlocked      = "login_blocked" // попытка входа во время задержки или блокировки
+	AuthEventUnlock       = "unlock"        // блокировка снята администратором
+	AuthEventLogout       = "logout"        // завершение сессии пользователем
+)
+
+// AuthEvent представляет событие входа
+type AuthEvent struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    *uuid.UUID `json:"user_id" db:"user_id"`
+	Username  *string    `json:"username" db:"username"` // как введено при входе
+	IP        *string    `json:"ip" db:"ip"`
+	UserAgent *string    `json:"user_agent" db:"user_agent"`
+	Event     string     `json:"event" db:"event"`
+	Detail    *string    `json:"detail" db:"detail"`
+	Created   time.Time  `json:"created" db:"created"`
+}
+
+// AuthEventFilter представляет параметры выборки событий входа
+type AuthEventFilter struct {
+	UserID   *uuid.UUID
+	Username string
+	IP       string
+	Event    string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+// AuthEventListResponse представляет ответ со списком событий входа
+type AuthEventListResponse struct {
+	Events []AuthEvent `json:"events"`
+	Total  int         `json:"total"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AgentFacts представляет сведения о хосте, которые передает агент
+type AgentFacts struct {
+	Hostname        string `json:"hostname" example:"web-01"`
+	OS              string `json:"os" example:"linux"`
+	Platform        string `json:"platform" example:"ubuntu"`
+	PlatformVersion string `json:"platform_version" example:"22.04"`
+	KernelVersion   string `json:"kernel_version" example:"5.15.0-91-generic"`
+	Architecture    string `json:"architecture" example:"x86_64"`
+	CPUModel        string `json:"cpu_model" example:"Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz"`
+	CPUCores        int    `json:"cpu_cores" example:"4"`
+	DockerVersion   string `json:"docker_version" example:"28.3.2"`
+	AgentVersion    string `json:"agent_version" example:"1.0.0"`
+}
+
+// AgentEnrollmentToken представляет одноразовый токен регистрации агента.
+// Сам токен показывается только при создании, в базе хранится его хеш.
+type AgentEnrollmentToken struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix" example:"mse_1a2b3c4d"` // начало токена для узнавания в списке
+	AutoApprove bool       `json:"auto_approve"`                  // агент начинает работу без подтверждения администратором
+	Expires     time.Time  `json:"expires"`
+	Used        *time.Time `json:"used"`
+	AgentID     *uuid.UUID `json:"agent_id"` // агент, зарегистрированный по токену
+	Revoked     *time.Time `json:"revoked"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	Created     time.Time  `json:"created"`
+}
+
+// CreateEnrollmentTokenRequest представляет запрос на создание токена регистрации
+type CreateEnrollmentTokenRequest struct {
+	Name string `json:"name" example:"web-01"`
+	// ExpiresInHours задает срок действия токена; 0 — 24 часа, максимум 30 дней
+	ExpiresInHours int  `json:"expires_in_hours" example:"24"`
+	AutoApprove    bool `json:"auto_approve"`
+}
+
+// CreateEnrollmentTokenResponse представляет созданный токен регистрации вместе с его значением
+type CreateEnrollmentTokenResponse struct {
+	AgentEnrollmentToken
+	Token string `json:"token" example:"mse_1a2b3c4d..."` // показывается один раз
+}
+
+// EnrollmentTokenListResponse представляет ответ со списком токенов регистрации
+type EnrollmentTokenListResponse struct {
+	Tokens []AgentEnrollmentToken `json:"tokens"`
+	Total  int                    `json:"total"`
+}
+
+// AgentRegisterRequest представляет запрос агента на регистрацию
+type AgentRegisterRequest struct {
+	Name  string      `json:"name" example:"web-01"` // пусто — используется имя хоста
+	Facts *AgentFacts `json:"facts"`
+}
+
+// AgentRegisterResponse представляет постоянные учетные данные зарегистрированного агента
+type AgentRegisterResponse struct {
+	AgentID    uuid.UUID `json:"agent_id"`
+	Name       string    `json:"name"`
+	Token      string    `json:"token"`       // постоянный токен агента, показывается один раз
+	SigningKey string    `json:"signing_key"` // ключ подписи запросов агента, показывается один раз
+	Approved   bool      `json:"approved"`    // false — пинги отклоняются до подтверждения администратором
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DockerEventInfo представляет событие Docker, присланное агентом в пинге
+type DockerEventInfo struct {
+	Time     string `json:"time"`   // RFC3339 с наносекундами, время на хосте агента
+	Type     string `json:"type"`   // container, image
+	Action   string `json:"action"` // die, oom, kill, restart, health_status, pull, destroy
+	ID       string `json:"id"`     // ID контейнера в Docker или ссылка на образ
+	Name     string `json:"name"`
+	Image    string `json:"image,omitempty"`
+	Status   string `json:"status,omitempty"` // результат health_status: healthy, unhealthy
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Signal   string `json:"signal,omitempty"`
+}
+
+// ContainerEvent представляет сохраненное событие Docker
+type ContainerEvent struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	AgentID   uuid.UUID `json:"agent_id" db:"agent_id"`
+	Type      string    `json:"type" db:"type"`
+	Action    string    `json:"action" db:"action"`
+	ActorID   string    `json:"actor_id" db:"actor_id"`
+	ActorName *string   `json:"actor_name" db:"actor_name"`
+	Image     *string   `json:"image" db:"image"`
+	Status    *string   `json:"status" db:"status"`
+	ExitCode  *int      `json:"exit_code" db:"exit_code"`
+	Signal    *string   `json:"signal" db:"signal"`
+	Time      time.Time `json:"time" db:"time"`
+	Created   time.Time `json:"created" db:"created"`
+}
+
+// ContainerEventFilter представляет параметры выборки событий Docker
+type ContainerEventFilter struct {
+	AgentID uuid.UUID
+	ActorID string
+	Type    string
+	Action  string
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
+
+// ContainerEventListResponse представляет ответ со списком событий Docker
+type ContainerEventListResponse struct {
+	Events []ContainerEvent `json:"events"`
+	Total  int              `json:"total"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// TOTPEnrollment представляет секрет TOTP для добавления в приложение-аутентификатор
+type TOTPEnrollment struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURL string `json:"otpauth_url" example:"otpauth://totp/Monitoring%20System:admin?secret=JBSWY3DPEHPK3PXP&issuer=Monitoring%20System"` // для QR-кода
+}
+
+// MFAStatusResponse представляет состояние двухфакторной аутентификации пользователя
+type MFAStatusResponse struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"` // 2FA обязательна для роли пользователя
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// TOTPCodeRequest представляет запрос с кодом из приложения или кодом восстановления
+type TOTPCodeRequest struct {
+	Code string `json:"code" example:"123456"`
+}
+
+// RecoveryCodesResponse представляет новый набор кодов восстановления; показывается один раз
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"3f9a1-0c2b7"`
+}
+
+// LoginChallengeRequest представляет запрос второго шага входа
+type LoginChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" example:"msc_9a1e..."`
+	Code           string `json:"code" example:"123456"` // не нужен для начала обязательного подключения
+}
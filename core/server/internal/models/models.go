@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,26 +9,41 @@ import (
 
 // User представляет пользователя системы
 type User struct {
-	ID           uuid.UUID  `json:"id" db:"id"`
-	Username     string     `json:"username" db:"username"`
-	PasswordHash string     `json:"-" db:"password_hash"`
-	Email        *string    `json:"email" db:"email"`
-	IsActive     bool       `json:"is_active" db:"is_active"`
-	Role         string     `json:"role" db:"role"`
-	Created      time.Time  `json:"created" db:"created"`
-	LastLogin    *time.Time `json:"last_login" db:"last_login"`
+	ID           uuid.UUID   `json:"id" db:"id"`
+	Username     string      `json:"username" db:"username"`
+	PasswordHash string      `json:"-" db:"password_hash"`
+	Email        *string     `json:"email" db:"email"`
+	IsActive     bool        `json:"is_active" db:"is_active"`
+	Role         string      `json:"role" db:"role"`
+	Created      time.Time   `json:"created" db:"created"`
+	LastLogin    *time.Time  `json:"last_login" db:"last_login"`
+	AuthProvider string      `json:"auth_provider" db:"auth_provider"` // local или oidc
+	TOTPEnabled  bool        `json:"totp_enabled" db:"totp_enabled"`   // включена двухфакторная аутентификация
+	AgentIDs     []uuid.UUID `json:"agent_ids"`                        // агенты, доступные пользователю; пустой список — все агенты
 }
 
 // Agent представляет агент мониторинга
 type Agent struct {
-	ID       uuid.UUID  `json:"id" db:"id"`
-	Name     string     `json:"name" db:"name"`
-	Token    string     `json:"token" db:"token"`
-	IsActive bool       `json:"is_active" db:"is_active"`
-	Created  time.Time  `json:"created" db:"created"`
-	LastPing *time.Time `json:"last_ping" db:"last_ping"`
-	PublicIP *string    `json:"public_ip,omitempty"`
-	Status   string     `json:"status"` // online, offline, unknown
+	ID   uuid.UUID `json:"id" db:"id"`
+	Name string    `json:"name" db:"name"`
+	// TokenPrefix — начало токена для узнавания; сам токен показывается только при создании
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix" example:"msa_1a2b3c4d"`
+	IsActive    bool       `json:"is_active" db:"is_active"`
+	Created     time.Time  `json:"created" db:"created"`
+	LastPing    *time.Time `json:"last_ping" db:"last_ping"`
+	PublicIP    *string    `json:"public_ip,omitempty"`
+	Status      string     `json:"status"` // online, offline, unknown, pending
+	// Approved = false — агент зарегистрировался сам и ждет подтверждения администратором
+	Approved bool        `json:"approved" db:"approved"`
+	Hostname *string     `json:"hostname" db:"hostname"`
+	Facts    *AgentFacts `json:"facts" db:"facts"`
+	// FactsUpdated — когда агент последний раз прислал изменившиеся сведения о хосте
+	FactsUpdated *time.Time `json:"facts_updated" db:"facts_updated"`
+	// PreviousTokenExpires задан, пока после ротации принимается и старый токен
+	PreviousTokenExpires *time.Time `json:"previous_token_expires" db:"previous_token_expires"`
+	// RequireSignature — запросы агента принимаются только подписанными
+	RequireSignature  bool       `json:"require_signature" db:"require_signature"`
+	LastSignedRequest *time.Time `json:"last_signed_request" db:"last_signed_request"` // с точностью до минуты
 }
 
 // AgentPing представляет пинг от агента
@@ -64,6 +80,11 @@ type DiskMetric struct {
 	WriteBytes int64     `json:"write_bytes" db:"write_bytes"`
 	Reads      int64     `json:"reads" db:"reads"`
 	Writes     int64     `json:"writes" db:"writes"`
+	// Скорости с прошлого пинга; nil для первого пинга и после сброса счетчиков
+	ReadBytesPerSec  *float64 `json:"read_bytes_per_sec" db:"read_bytes_per_sec"`
+	WriteBytesPerSec *float64 `json:"write_bytes_per_sec" db:"write_bytes_per_sec"`
+	ReadsPerSec      *float64 `json:"reads_per_sec" db:"reads_per_sec"`
+	WritesPerSec     *float64 `json:"writes_per_sec" db:"writes_per_sec"`
 }
 
 // NetworkMetric представляет метрику сети
@@ -73,6 +94,14 @@ type NetworkMetric struct {
 	PublicIP      string    `json:"public_ip" db:"public_ip"`
 	SentBytes     int64     `json:"sent_bytes" db:"sent_bytes"`
 	ReceivedBytes int64     `json:"received_bytes" db:"received_bytes"`
+	// Счетчики пакетов не присылают агенты старых версий
+	PacketsSent     *int64 `json:"packets_sent" db:"packets_sent"`
+	PacketsReceived *int64 `json:"packets_received" db:"packets_received"`
+	// Скорости с прошлого пинга; nil для первого пинга и после сброса счетчиков
+	SentBytesPerSec       *float64 `json:"sent_bytes_per_sec" db:"sent_bytes_per_sec"`
+	ReceivedBytesPerSec   *float64 `json:"received_bytes_per_sec" db:"received_bytes_per_sec"`
+	PacketsSentPerSec     *float64 `json:"packets_sent_per_sec" db:"packets_sent_per_sec"`
+	PacketsReceivedPerSec *float64 `json:"packets_received_per_sec" db:"packets_received_per_sec"`
 }
 
 // Container представляет контейнер Docker
@@ -91,6 +120,25 @@ type Container struct {
 	MemoryUsageMB        *int64    `json:"memory_usage_mb" db:"memory_usage_mb"`
 	NetworkSentBytes     *int64    `json:"network_sent_bytes" db:"network_sent_bytes"`
 	NetworkReceivedBytes *int64    `json:"network_received_bytes" db:"network_received_bytes"`
+	MemoryLimitMB        *int64    `json:"memory_limit_mb" db:"memory_limit_mb"`
+	MemoryPercent        *float64  `json:"memory_percent" db:"memory_percent"` // процент от лимита памяти
+	BlockReadBytes       *int64    `json:"block_read_bytes" db:"block_read_bytes"`
+	BlockWriteBytes      *int64    `json:"block_write_bytes" db:"block_write_bytes"`
+	PIDs                 *int64    `json:"pids" db:"pids"`
+	// Состояние процесса; nil у контейнеров, присланных старыми агентами
+	State               *string           `json:"state" db:"state"`
+	HealthStatus        *string           `json:"health_status" db:"health_status"`
+	HealthOutput        *string           `json:"health_output" db:"health_output"`
+	HealthFailingStreak *int              `json:"health_failing_streak" db:"health_failing_streak"`
+	ExitCode            *int              `json:"exit_code" db:"exit_code"`
+	OOMKilled           *bool             `json:"oom_killed" db:"oom_killed"`
+	StartedAt           *time.Time        `json:"started_at" db:"started_at"`
+	FinishedAt          *time.Time        `json:"finished_at" db:"finished_at"`
+	RestartPolicy       *string           `json:"restart_policy" db:"restart_policy"`
+	RestartMaxRetries   *int              `json:"restart_max_retries" db:"restart_max_retries"`
+	Ports               []ContainerPort   `json:"ports" db:"ports"`
+	Mounts              []ContainerMount  `json:"mounts" db:"mounts"`
+	Labels              map[string]string `json:"labels" db:"labels"`
 	// Дополнительные поля для совместимости с frontend
 	AgentID   *uuid.UUID `json:"agent_id"`
 	AgentName *string    `json:"agent_name"`
@@ -98,15 +146,69 @@ type Container struct {
 
 // AgentData представляет данные от агента (соответствует JSON от агента)
 type AgentData struct {
-	Metrics Metrics    `json:"metrics"`
-	Docker  DockerInfo `json:"docker"`
+	// Timestamp — время сбора данных (RFC3339); пинги из очереди агента приходят позже.
+	// Старые агенты не присылают: время сбора — время приема
+	Timestamp string     `json:"timestamp,omitempty"`
+	Metrics   Metrics    `json:"metrics"`
+	Docker    DockerInfo `json:"docker"`
+	// Facts агент присылает только при изменении сведений о хосте
+	Facts *AgentFacts `json:"facts,omitempty"`
+	// Events — события Docker, накопленные агентом с прошлого пинга
+	Events []DockerEventInfo `json:"events,omitempty"`
+	// Collection — длительность сбора данных агентом; старые агенты не присылают
+	Collection *CollectionInfo `json:"collection,omitempty"`
+}
+
+// CollectionInfo представляет сведения о сборе данных агентом
+type CollectionInfo struct {
+	DurationMs       float64             `json:"duration_ms"`
+	DockerDurationMs float64             `json:"docker_duration_ms"`
+	Containers       int                 `json:"containers"`
+	Workers          int                 `json:"workers"`
+	Failures         []CollectionFailure `json:"failures"`
+}
+
+// CollectionFailure представляет неудачный запрос агента к Docker по контейнеру
+type CollectionFailure struct {
+	ContainerID string `json:"container_id"`
+	Name        string `json:"name"`
+	Call        string `json:"call"` // inspect, logs, stats
+	Error       string `json:"error"`
 }
 
 type Metrics struct {
-	CPU     []CPUInfo   `json:"cpu"`
-	Memory  MemoryInfo  `json:"memory"`
-	Disk    []DiskInfo  `json:"disk"`
-	Network NetworkInfo `json:"network"`
+	CPU         []CPUInfo        `json:"cpu"`
+	Memory      MemoryInfo       `json:"memory"`
+	Disk        []DiskInfo       `json:"disk"`
+	Filesystems []FilesystemInfo `json:"filesystems"`
+	Network     NetworkInfo      `json:"network"`
+	// nil — агент старой версии или нагрузку хоста собрать не удалось
+	Host *HostMetricsInfo `json:"host"`
+}
+
+// HostMetricsInfo представляет нагрузку и состояние хоста
+type HostMetricsInfo struct {
+	Load1           float64      `json:"load1"`
+	Load5           float64      `json:"load5"`
+	Load15          float64      `json:"load15"`
+	Uptime          uint64       `json:"uptime"`    // секунды
+	BootTime        int64        `json:"boot_time"` // unix-время
+	Processes       int          `json:"processes"`
+	Threads         int          `json:"threads"`
+	ContextSwitches uint64       `json:"context_switches"` // накопительный счетчик
+	CPUTimes        CPUTimesInfo `json:"cpu_times"`
+}
+
+// CPUTimesInfo представляет накопительное время всех CPU хоста по режимам в секундах
+type CPUTimesInfo struct {
+	User    float64 `json:"user"`
+	Nice    float64 `json:"nice"`
+	System  float64 `json:"system"`
+	Idle    float64 `json:"idle"`
+	Iowait  float64 `json:"iowait"`
+	Irq     float64 `json:"irq"`
+	Softirq float64 `json:"softirq"`
+	Steal   float64 `json:"steal"`
 }
 
 type CPUInfo struct {
@@ -137,10 +239,58 @@ type DiskInfo struct {
 	Writes     uint64 `json:"writes"`
 }
 
+// FilesystemInfo представляет заполненность файловой системы хоста в байтах и inode
+type FilesystemInfo struct {
+	Mountpoint  string `json:"mountpoint"`
+	Device      string `json:"device"`
+	Fstype      string `json:"fstype"`
+	Total       uint64 `json:"total"`
+	Used        uint64 `json:"used"`
+	Free        uint64 `json:"free"`
+	InodesTotal uint64 `json:"inodes_total"`
+	InodesUsed  uint64 `json:"inodes_used"`
+	InodesFree  uint64 `json:"inodes_free"`
+}
+
+// UsedPercent возвращает занятое место в процентах так же, как df: от места, доступного
+// пользователям, без зарезервированных для root блоков
+func (f FilesystemInfo) UsedPercent() float64 {
+	if f.Used+f.Free == 0 {
+		return 0
+	}
+	return float64(f.Used) / float64(f.Used+f.Free) * 100
+}
+
+// InodesUsedPercent возвращает занятые inode в процентах
+func (f FilesystemInfo) InodesUsedPercent() float64 {
+	if f.InodesTotal == 0 {
+		return 0
+	}
+	return float64(f.InodesUsed) / float64(f.InodesTotal) * 100
+}
+
 type NetworkInfo struct {
 	PublicIP string `json:"public_ip"`
 	Sent     uint64 `json:"sent"`
 	Received uint64 `json:"received"`
+	// nil — агент старой версии не присылает счетчики пакетов
+	PacketsSent     *uint64 `json:"packets_sent"`
+	PacketsReceived *uint64 `json:"packets_received"`
+	// Счетчики по интерфейсам хоста; итоговые счетчики — их сумма
+	Interfaces []InterfaceInfo `json:"interfaces"`
+}
+
+// InterfaceInfo представляет накопительные счетчики сетевого интерфейса хоста
+type InterfaceInfo struct {
+	Name            string `json:"name"`
+	Sent            uint64 `json:"sent"`
+	Received        uint64 `json:"received"`
+	PacketsSent     uint64 `json:"packets_sent"`
+	PacketsReceived uint64 `json:"packets_received"`
+	ErrorsIn        uint64 `json:"errors_in"`
+	ErrorsOut       uint64 `json:"errors_out"`
+	DropsIn         uint64 `json:"drops_in"`
+	DropsOut        uint64 `json:"drops_out"`
 }
 
 type DockerInfo struct {
@@ -149,18 +299,89 @@ type DockerInfo struct {
 }
 
 type ContainerInfo struct {
-	ID           string               `json:"id"`
-	Created      string               `json:"created"`
-	Status       string               `json:"status"`
-	RestartCount int                  `json:"restart_count"`
-	Image        string               `json:"image"`
-	Name         string               `json:"name"`
-	IP           *string              `json:"ip"`
-	MAC          *string              `json:"mac"`
-	CPU          *float64             `json:"cpu"`
-	Memory       *uint64              `json:"memory"`
-	Network      ContainerNetworkInfo `json:"network"`
-	Logs         []string             `json:"logs"`
+	ID            string               `json:"id"`
+	Created       string               `json:"created"`
+	Status        string               `json:"status"`
+	RestartCount  int                  `json:"restart_count"`
+	Image         string               `json:"image"`
+	Name          string               `json:"name"`
+	IP            *string              `json:"ip"`
+	MAC           *string              `json:"mac"`
+	CPU           *float64             `json:"cpu"`    // доля одного ядра с прошлого пинга
+	Memory        *uint64              `json:"memory"` // MB, без неактивного файлового кеша
+	MemoryLimit   *uint64              `json:"memory_limit"`
+	MemoryPercent *float64             `json:"memory_percent"`
+	BlockRead     *uint64              `json:"block_read"`
+	BlockWrite    *uint64              `json:"block_write"`
+	PIDs          *uint64              `json:"pids"`
+	Network       ContainerNetworkInfo `json:"network"`
+	Logs          []ContainerLogInfo   `json:"logs"`
+	// Состояние процесса; старые агенты присылают только status
+	State         string                 `json:"state"`
+	Health        *ContainerHealthInfo   `json:"health"`
+	ExitCode      int                    `json:"exit_code"`
+	OOMKilled     bool                   `json:"oom_killed"`
+	StartedAt     string                 `json:"started_at"`
+	FinishedAt    string                 `json:"finished_at"`
+	Ports         []ContainerPort        `json:"ports"`
+	Mounts        []ContainerMount       `json:"mounts"`
+	Labels        map[string]string      `json:"labels"`
+	RestartPolicy ContainerRestartPolicy `json:"restart_policy"`
+}
+
+// Stopped сообщает, что процесс контейнера завершился
+func (c ContainerInfo) Stopped() bool {
+	return c.State == "exited" || c.State == "dead"
+}
+
+// ContainerLogInfo представляет строку лога контейнера от агента
+type ContainerLogInfo struct {
+	Time   string `json:"time"`   // RFC3339 с наносекундами, время Docker
+	Stream string `json:"stream"` // stdout, stderr
+	Line   string `json:"line"`
+}
+
+// UnmarshalJSON принимает и строки логов старых агентов: без времени и потока
+func (l *ContainerLogInfo) UnmarshalJSON(data []byte) error {
+	var line string
+	if err := json.Unmarshal(data, &line); err == nil {
+		*l = ContainerLogInfo{Line: line}
+		return nil
+	}
+
+	type logInfo ContainerLogInfo
+	return json.Unmarshal(data, (*logInfo)(l))
+}
+
+// ContainerHealthInfo представляет состояние проверки здоровья (HEALTHCHECK) контейнера
+type ContainerHealthInfo struct {
+	Status        string `json:"status"` // starting, healthy, unhealthy
+	FailingStreak int    `json:"failing_streak"`
+	LastExitCode  int    `json:"last_exit_code"`
+	LastOutput    string `json:"last_output"`
+	LastCheck     string `json:"last_check"`
+}
+
+// ContainerPort представляет порт контейнера и его публикацию на хосте
+type ContainerPort struct {
+	IP          string `json:"ip"`
+	PrivatePort int    `json:"private_port"`
+	PublicPort  int    `json:"public_port"` // 0 — порт не опубликован
+	Type        string `json:"type"`
+}
+
+// ContainerMount представляет том или каталог хоста, смонтированный в контейнер
+type ContainerMount struct {
+	Type        string `json:"type"` // bind, volume, tmpfs
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	RW          bool   `json:"rw"`
+}
+
+// ContainerRestartPolicy представляет политику перезапуска контейнера
+type ContainerRestartPolicy struct {
+	Name              string `json:"name"` // no, always, unless-stopped, on-failure
+	MaximumRetryCount int    `json:"maximum_retry_count"`
 }
 
 type ContainerNetworkInfo struct {
@@ -184,10 +405,20 @@ type LoginRequest struct {
 }
 
 // LoginResponse представляет ответ на вход
-// @Description Ответ с JWT токеном и информацией о пользователе
+// @Description Ответ с JWT токеном, refresh-токеном и информацией о пользователе
+// Если для входа нужен второй фактор, вместо токенов возвращается challenge_token
 type LoginResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User  User   `json:"user"`
+	Token        string `json:"token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token,omitempty" example:"msr_5f2c..."` // одноразовый, заменяется при каждом обновлении
+	ExpiresIn    int    `json:"expires_in,omitempty" example:"900"`            // время жизни JWT в секундах
+	User         *User  `json:"user,omitempty"`
+	// MFARequired сообщает, что вход нужно завершить кодом второго фактора по challenge_token
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty" example:"msc_9a1e..."`
+	// ChallengeType: totp — ввести код, enroll — сначала подключить 2FA (обязательно для роли)
+	ChallengeType string `json:"challenge_type,omitempty" example:"totp"`
+	// RecoveryCodes возвращаются один раз при подключении 2FA во время входа
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 // CreateAgentRequest представляет запрос на создание агента
@@ -244,6 +475,7 @@ type ContainerLog struct {
 	ID          uuid.UUID `json:"id" db:"id"`
 	ContainerID uuid.UUID `json:"container_id" db:"container_id"`
 	LogLine     string    `json:"log_line" db:"log_line"`
+	Stream      *string   `json:"stream" db:"stream"` // stdout, stderr; NULL у строк от старых агентов
 	Timestamp   time.Time `json:"timestamp" db:"timestamp"`
 }
 
@@ -282,10 +514,40 @@ type AgentDetail struct {
 
 // AgentMetrics представляет текущие метрики агента
 type AgentMetrics struct {
-	CPU     []CPUMetricCurrent   `json:"cpu"`
-	Memory  MemoryMetricCurrent  `json:"memory"`
-	Disk    []DiskMetricCurrent  `json:"disk"`
-	Network NetworkMetricCurrent `json:"network"`
+	CPU         []CPUMetricCurrent        `json:"cpu"`
+	Memory      MemoryMetricCurrent       `json:"memory"`
+	Disk        []DiskMetricCurrent       `json:"disk"`
+	Filesystems []FilesystemMetricCurrent `json:"filesystems"`
+	Network     NetworkMetricCurrent      `json:"network"`
+	Host        *HostMetricCurrent        `json:"host"`
+	Collection  *CollectionMetricCurrent  `json:"collection"`
+}
+
+// CollectionMetricCurrent представляет последний сбор данных агентом
+type CollectionMetricCurrent struct {
+	DurationMs       float64             `json:"duration_ms"`
+	DockerDurationMs float64             `json:"docker_duration_ms"`
+	Workers          int                 `json:"workers"`
+	Failures         []CollectionFailure `json:"failures"`
+}
+
+// HostMetricCurrent представляет текущую нагрузку хоста. Доли времени CPU и скорость
+// переключений контекста считаются с прошлого пинга; nil — первый пинг или перезагрузка хоста.
+type HostMetricCurrent struct {
+	Load1                float64   `json:"load1"`
+	Load5                float64   `json:"load5"`
+	Load15               float64   `json:"load15"`
+	Uptime               int64     `json:"uptime"`
+	BootTime             time.Time `json:"boot_time"`
+	Processes            int       `json:"processes"`
+	Threads              int       `json:"threads"`
+	ContextSwitchesSpeed *float64  `json:"context_switches_speed"`
+	// user включает nice, system — обработку прерываний (irq, softirq)
+	CPUUserPercent   *float64 `json:"cpu_user_percent"`
+	CPUSystemPercent *float64 `json:"cpu_system_percent"`
+	CPUIowaitPercent *float64 `json:"cpu_iowait_percent"`
+	CPUStealPercent  *float64 `json:"cpu_steal_percent"`
+	CPUIdlePercent   *float64 `json:"cpu_idle_percent"`
 }
 
 // CPUMetricCurrent представляет текущую метрику CPU
@@ -306,20 +568,57 @@ type MemoryMetricCurrent struct {
 
 // DiskMetricCurrent представляет текущую метрику диска
 type DiskMetricCurrent struct {
-	Name       string `json:"name"`
-	ReadBytes  int64  `json:"read_bytes"`
-	WriteBytes int64  `json:"write_bytes"`
-	ReadSpeed  int64  `json:"read_speed"`
-	WriteSpeed int64  `json:"write_speed"`
+	Name       string  `json:"name"`
+	ReadBytes  int64   `json:"read_bytes"`
+	WriteBytes int64   `json:"write_bytes"`
+	ReadSpeed  int64   `json:"read_speed"`
+	WriteSpeed int64   `json:"write_speed"`
+	ReadIOPS   float64 `json:"read_iops"`
+	WriteIOPS  float64 `json:"write_iops"`
+}
+
+// FilesystemMetricCurrent представляет текущую заполненность файловой системы
+type FilesystemMetricCurrent struct {
+	Mountpoint        string  `json:"mountpoint"`
+	Device            string  `json:"device"`
+	Fstype            string  `json:"fstype"`
+	TotalBytes        int64   `json:"total_bytes"`
+	UsedBytes         int64   `json:"used_bytes"`
+	FreeBytes         int64   `json:"free_bytes"`
+	UsedPercent       float64 `json:"used_percent"`
+	InodesTotal       int64   `json:"inodes_total"`
+	InodesUsed        int64   `json:"inodes_used"`
+	InodesFree        int64   `json:"inodes_free"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
 }
 
 // NetworkMetricCurrent представляет текущую метрику сети
 type NetworkMetricCurrent struct {
-	PublicIP      string `json:"public_ip"`
-	SentBytes     int64  `json:"sent_bytes"`
-	ReceivedBytes int64  `json:"received_bytes"`
-	SentSpeed     int64  `json:"sent_speed"`
-	ReceivedSpeed int64  `json:"received_speed"`
+	PublicIP             string                          `json:"public_ip"`
+	SentBytes            int64                           `json:"sent_bytes"`
+	ReceivedBytes        int64                           `json:"received_bytes"`
+	SentSpeed            int64                           `json:"sent_speed"`
+	ReceivedSpeed        int64                           `json:"received_speed"`
+	PacketsSentSpeed     float64                         `json:"packets_sent_speed"`
+	PacketsReceivedSpeed float64                         `json:"packets_received_speed"`
+	Interfaces           []NetworkInterfaceMetricCurrent `json:"interfaces"`
+}
+
+// NetworkInterfaceMetricCurrent представляет текущие метрики сетевого интерфейса хоста
+type NetworkInterfaceMetricCurrent struct {
+	Name                 string  `json:"name"`
+	SentBytes            int64   `json:"sent_bytes"`
+	ReceivedBytes        int64   `json:"received_bytes"`
+	PacketsSent          int64   `json:"packets_sent"`
+	PacketsReceived      int64   `json:"packets_received"`
+	ErrorsIn             int64   `json:"errors_in"`
+	ErrorsOut            int64   `json:"errors_out"`
+	DropsIn              int64   `json:"drops_in"`
+	DropsOut             int64   `json:"drops_out"`
+	SentSpeed            int64   `json:"sent_speed"`
+	ReceivedSpeed        int64   `json:"received_speed"`
+	PacketsSentSpeed     float64 `json:"packets_sent_speed"`
+	PacketsReceivedSpeed float64 `json:"packets_received_speed"`
 }
 
 // SystemMetric представляет историческую метрику системы
@@ -332,6 +631,19 @@ type SystemMetric struct {
 	NetworkSent     int64     `json:"network_sent"`
 	NetworkReceived int64     `json:"network_received"`
 	PublicIP        string    `json:"public_ip"`
+	// Скорости в байтах, операциях и пакетах в секунду с прошлого пинга;
+	// nil для первого пинга и после сброса счетчиков
+	DiskReadSpeed               *float64 `json:"disk_read_speed"`
+	DiskWriteSpeed              *float64 `json:"disk_write_speed"`
+	DiskIOPS                    *float64 `json:"disk_iops"`
+	NetworkSentSpeed            *float64 `json:"network_sent_speed"`
+	NetworkReceivedSpeed        *float64 `json:"network_received_speed"`
+	NetworkPacketsSentSpeed     *float64 `json:"network_packets_sent_speed"`
+	NetworkPacketsReceivedSpeed *float64 `json:"network_packets_received_speed"`
+	// Нагрузка хоста; nil для агентов старых версий
+	Load1            *float64 `json:"load1"`
+	CPUIowaitPercent *float64 `json:"cpu_iowait_percent"`
+	CPUStealPercent  *float64 `json:"cpu_steal_percent"`
 }
 
 // ContainerListResponse представляет ответ со списком контейнеров
@@ -389,6 +701,10 @@ type ResourceUsagePoint struct {
 	Timestamp time.Time `json:"timestamp"`
 	CPU       float64   `json:"cpu"`
 	Memory    float64   `json:"memory"`
+	// Суммарная дисковая активность агентов: байт и операций в секунду
+	DiskReadSpeed  float64 `json:"disk_read_speed"`
+	DiskWriteSpeed float64 `json:"disk_write_speed"`
+	DiskIOPS       float64 `json:"disk_iops"`
 }
 
 // NetworkActivityPoint представляет точку сетевой активности
@@ -396,6 +712,11 @@ type NetworkActivityPoint struct {
 	Timestamp time.Time `json:"timestamp"`
 	Sent      int64     `json:"sent"`
 	Received  int64     `json:"received"`
+	// Суммарный трафик агентов: байт и пакетов в секунду
+	SentSpeed            float64 `json:"sent_speed"`
+	ReceivedSpeed        float64 `json:"received_speed"`
+	PacketsSentSpeed     float64 `json:"packets_sent_speed"`
+	PacketsReceivedSpeed float64 `json:"packets_received_speed"`
 }
 
 // AgentSummary представляет сводку по агенту
@@ -454,25 +775,54 @@ type Action struct {
 	Status    string                 `json:"status" db:"status"`
 	Created   time.Time              `json:"created" db:"created"`
 	Completed *time.Time             `json:"completed" db:"completed"`
-	Response  *string                `json:"response" db:"response"`
+	Response  *json.RawMessage       `json:"response" db:"response" swaggertype:"object"` // структурированный результат от агента
 	Error     *string                `json:"error" db:"error"`
+	CreatedBy *uuid.UUID             `json:"created_by" db:"created_by"` // nil — создано системой
 }
 
 // Константы для типов действий
 const (
-	ActionTypeStartContainer  = "start_container"
-	ActionTypeStopContainer   = "stop_container"
-	ActionTypeRemoveContainer = "remove_container"
-	ActionTypeRemoveImage     = "remove_image"
-	ActionTypeRestartNginx    = "restart_nginx"
-	ActionTypeWriteFile       = "write_file"
+	ActionTypeStartContainer    = "start_container"
+	ActionTypeStopContainer     = "stop_container"
+	ActionTypeRemoveContainer   = "remove_container"
+	ActionTypeRemoveImage       = "remove_image"
+	ActionTypePruneImages       = "prune_images"
+	ActionTypeRestartNginx      = "restart_nginx"
+	ActionTypeWriteFile         = "write_file"
+	ActionTypeRestartContainer  = "restart_container"
+	ActionTypePullImage         = "pull_image"
+	ActionTypeCreateNginxConfig = "create_nginx_config"
+	ActionTypeUpdateNginxConfig = "update_nginx_config"
+	ActionTypeDeleteNginxConfig = "delete_nginx_config"
+	ActionTypeGetNginxConfig    = "get_nginx_config"
+	// ActionTypeRotateToken создается только сервером при ротации токена агента
+	ActionTypeRotateToken = "rotate_token"
 )
 
+// AgentActionTypes — действия, которые агент умеет выполнять и которые можно
+// запускать по расписанию и в сценариях. rotate_token создается только сервером,
+// restart_nginx и write_file агент не выполняет.
+var AgentActionTypes = map[string]bool{
+	ActionTypeStartContainer:    true,
+	ActionTypeStopContainer:     true,
+	ActionTypeRestartContainer:  true,
+	ActionTypeRemoveContainer:   true,
+	ActionTypeRemoveImage:       true,
+	ActionTypePullImage:         true,
+	ActionTypePruneImages:       true,
+	ActionTypeCreateNginxConfig: true,
+	ActionTypeUpdateNginxConfig: true,
+	ActionTypeDeleteNginxConfig: true,
+	ActionTypeGetNginxConfig:    true,
+}
+
 // Константы для статусов действий
 const (
-	ActionStatusPending   = "pending"
-	ActionStatusCompleted = "completed"
-	ActionStatusFailed    = "failed"
+	ActionStatusPending          = "pending"
+	ActionStatusAwaitingApproval = "awaiting_approval" // ждет подтверждения, агенту не выдается
+	ActionStatusRejected         = "rejected"
+	ActionStatusCompleted        = "completed"
+	ActionStatusFailed           = "failed"
 )
 
 // Payload для запуска контейнера
@@ -509,6 +859,11 @@ type PullImagePayload struct {
 	Tag   string `json:"tag,omitempty"`
 }
 
+// Payload для очистки неиспользуемых образов
+type PruneImagesPayload struct {
+	All bool `json:"all,omitempty"` // удалять все неиспользуемые образы, а не только dangling
+}
+
 // Payload для записи файла
 type RestartContainerPayload struct {
 	ContainerID string `json:"container_id"`
@@ -528,12 +883,18 @@ type DeleteNginxConfigPayload struct {
 	Domain string `json:"domain"`
 }
 
+// Payload для ротации токена агента; токен удаляется из payload после выполнения действия
+type RotateTokenPayload struct {
+	Token      string `json:"token"`
+	SigningKey string `json:"signing_key"` // ключ подписи запросов для нового токена
+}
+
 // ActionResponse представляет ответ агента на действие
 type ActionResponse struct {
-	ID       string  `json:"id"`
-	Status   string  `json:"status"`
-	Response *string `json:"response"`
-	Error    *string `json:"error"`
+	ID       string          `json:"id"`
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response" swaggertype:"object"` // JSON-объект; строка от старых агентов сохраняется как {"message": ...}
+	Error    *string         `json:"error"`
 }
 
 // CreateActionRequest запрос на создание действия
@@ -573,10 +934,11 @@ type EmailSettings struct {
 
 // NotificationConfigurations представляет конфигурации различных типов уведомлений
 type NotificationConfigurations struct {
-	AgentOffline     NotificationConfig `json:"agent_offline"`
-	ContainerStopped NotificationConfig `json:"container_stopped"`
-	CPUThreshold     CPUThresholdConfig `json:"cpu_threshold"`
-	RAMThreshold     RAMThresholdConfig `json:"ram_threshold"`
+	AgentOffline     NotificationConfig  `json:"agent_offline"`
+	ContainerStopped NotificationConfig  `json:"container_stopped"`
+	CPUThreshold     CPUThresholdConfig  `json:"cpu_threshold"`
+	RAMThreshold     RAMThresholdConfig  `json:"ram_threshold"`
+	DiskThreshold    DiskThresholdConfig `json:"disk_threshold"`
 }
 
 // NotificationConfig представляет базовую конфигурацию уведомления
@@ -599,6 +961,14 @@ type RAMThresholdConfig struct {
 	Message   string `json:"message"`
 }
 
+// DiskThresholdConfig представляет конфигурацию уведомления о заполнении файловой системы.
+// Порог сравнивается и с занятым местом, и с занятыми inode.
+type DiskThresholdConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Threshold int    `json:"threshold"`
+	Message   string `json:"message"`
+}
+
 // NotificationEvent представляет событие для отправки уведомления
 type NotificationEvent struct {
 	Type      string            `json:"type"`
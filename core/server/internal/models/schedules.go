@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActionSchedule представляет расписание действия для агента
+type ActionSchedule struct {
+	ID           uuid.UUID              `json:"id" db:"id"`
+	AgentID      uuid.UUID              `json:"agent_id" db:"agent_id"`
+	Name         string                 `json:"name" db:"name"`
+	Type         string                 `json:"type" db:"type"`
+	Payload      map[string]interface{} `json:"payload" db:"payload"`
+	CronExpr     *string                `json:"cron_expr" db:"cron_expr"` // например: "0 3 * * *" или "@weekly"
+	RunAt        *time.Time             `json:"run_at" db:"run_at"`       // время однократного запуска
+	IsPaused     bool                   `json:"is_paused" db:"is_paused"`
+	NextRun      *time.Time             `json:"next_run" db:"next_run"`
+	LastRun      *time.Time             `json:"last_run" db:"last_run"`
+	LastActionID *uuid.UUID             `json:"last_action_id" db:"last_action_id"`
+	CreatedBy    *uuid.UUID             `json:"created_by" db:"created_by"` // автор создаваемых действий
+	Created      time.Time              `json:"created" db:"created"`
+	Updated      time.Time              `json:"updated" db:"updated"`
+	// Дополнительные поля для совместимости с frontend
+	AgentName *string `json:"agent_name"`
+}
+
+// CreateScheduleRequest представляет запрос на создание расписания
+// @Description Запрос на создание расписания действия (cron_expr или run_at)
+type CreateScheduleRequest struct {
+	AgentID  uuid.UUID              `json:"agent_id"`
+	Name     string                 `json:"name" example:"Nightly restart"`
+	Type     string                 `json:"type" example:"restart_container"`
+	Payload  map[string]interface{} `json:"payload"`
+	CronExpr *string                `json:"cron_expr,omitempty" example:"0 3 * * *"`
+	RunAt    *time.Time             `json:"run_at,omitempty"`
+}
+
+// UpdateScheduleRequest представляет запрос на обновление расписания
+type UpdateScheduleRequest struct {
+	Name     *string                `json:"name,omitempty"`
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+	CronExpr *string                `json:"cron_expr,omitempty"`
+	RunAt    *time.Time             `json:"run_at,omitempty"`
+	IsPaused *bool                  `json:"is_paused,omitempty"`
+}
+
+// ScheduleListResponse представляет ответ со списком расписаний
+type ScheduleListResponse struct {
+	Schedules []ActionSchedule `json:"schedules"`
+	Total     int              `json:"total"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session представляет сессию пользователя, выданную при входе
+type Session struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	IP        *string    `json:"ip"`
+	UserAgent *string    `json:"user_agent"`
+	Created   time.Time  `json:"created"`
+	LastUsed  time.Time  `json:"last_used"` // время последнего обновления токенов
+	Expires   time.Time  `json:"expires"`
+	Revoked   *time.Time `json:"revoked"`
+	Current   bool       `json:"current"` // сессия, от имени которой выполнен запрос
+}
+
+// RefreshRequest представляет запрос на обновление токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"msr_5f2c..."`
+}
+
+// SessionListResponse представляет ответ со списком сессий
+type SessionListResponse struct {
+	Sessions []Session `json:"sessions"`
+	Total    int       `json:"total"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "github.com/google/uuid"
+
+// Константы для ролей пользователей
+const (
+	UserRoleAdmin    = "admin"    // полный доступ, включая пользователей, агентов и настройки
+	UserRoleApprover = "approver" // оператор, который также подтверждает и отклоняет действия
+	UserRoleOperator = "operator" // создает действия, расписания, сценарии и домены
+	UserRoleViewer   = "viewer"   // только просмотр; роль по умолчанию
+)
+
+// Константы для способов входа пользователей
+const (
+	AuthProviderLocal = "local" // вход по паролю
+	AuthProviderOIDC  = "oidc"  // вход через OpenID Connect, пользователь создается при первом входе
+)
+
+// UserRoles — допустимые роли пользователей
+var UserRoles = []string{UserRoleAdmin, UserRoleApprover, UserRoleOperator, UserRoleViewer}
+
+// CreateUserRequest представляет запрос на создание пользователя
+type CreateUserRequest struct {
+	Username string  `json:"username" example:"operator"`
+	Password string  `json:"password" example:"s3cret-passw0rd"`
+	Email    *string `json:"email" example:"operator@example.com"`
+	Role     string  `json:"role" example:"operator"` // по умолчанию viewer
+	// AgentIDs ограничивает пользователя указанными агентами; пустой список — все агенты
+	AgentIDs []uuid.UUID `json:"agent_ids"`
+}
+
+// UpdateUserRequest представляет запрос на изменение пользователя администратором
+type UpdateUserRequest struct {
+	Email    *string `json:"email"`
+	Role     *string `json:"role"`
+	IsActive *bool   `json:"is_active"` // false — деактивация, токены пользователя перестают действовать сразу
+	// AgentIDs заменяет список доступных агентов; пустой список снимает ограничение
+	AgentIDs *[]uuid.UUID `json:"agent_ids"`
+}
+
+// SetPasswordRequest представляет запрос на установку пароля администратором
+type SetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// UpdateProfileRequest представляет запрос на изменение собственного профиля
+type UpdateProfileRequest struct {
+	Email *string `json:"email"`
+}
+
+// ChangePasswordRequest представляет запрос на смену собственного пароля
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// UserListResponse представляет ответ со списком пользователей
+type UserListResponse struct {
+	Users []User `json:"users"`
+	Total int    `json:"total"`
+}
+
+// OIDCStatusResponse сообщает, настроен ли вход через OpenID Connect
+type OIDCStatusResponse struct {
+	Enabled bool `json:"enabled"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workflow представляет многошаговый сценарий из шаблонов действий (DAG)
+type Workflow struct {
+	ID          uuid.UUID         `json:"id" db:"id"`
+	Name        string            `json:"name" db:"name"`
+	Description *string           `json:"description" db:"description"`
+	Variables   map[string]string `json:"variables" db:"variables"` // значения переменных по умолчанию
+	Created     time.Time         `json:"created" db:"created"`
+	Updated     time.Time         `json:"updated" db:"updated"`
+	Steps       []WorkflowStep    `json:"steps"`
+}
+
+// WorkflowStep представляет шаг сценария — шаблон действия с зависимостями
+type WorkflowStep struct {
+	ID           uuid.UUID              `json:"id" db:"id"`
+	WorkflowID   uuid.UUID              `json:"workflow_id" db:"workflow_id"`
+	Name         string                 `json:"name" db:"name"` // уникальное имя шага в сценарии
+	Type         string                 `json:"type" db:"type"`
+	Payload      map[string]interface{} `json:"payload" db:"payload"`       // может содержать {{переменные}}
+	DependsOn    []string               `json:"depends_on" db:"depends_on"` // имена шагов-предшественников
+	Compensation *WorkflowCompensation  `json:"compensation,omitempty"`
+	Position     int                    `json:"position" db:"position"`
+}
+
+// WorkflowCompensation представляет компенсирующее действие, выполняемое при сбое сценария
+type WorkflowCompensation struct {
+	Type    string                 `json:"type"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// WorkflowStepRequest представляет шаг в запросе на создание или обновление сценария
+type WorkflowStepRequest struct {
+	Name         string                 `json:"name" example:"pull"`
+	Type         string                 `json:"type" example:"pull_image"`
+	Payload      map[string]interface{} `json:"payload"`
+	DependsOn    []string               `json:"depends_on,omitempty"`
+	Compensation *WorkflowCompensation  `json:"compensation,omitempty"`
+}
+
+// CreateWorkflowRequest представляет запрос на создание сценария
+// @Description Запрос на создание сценария развертывания
+type CreateWorkflowRequest struct {
+	Name        string                `json:"name" example:"Deploy web"`
+	Description *string               `json:"description,omitempty"`
+	Variables   map[string]string     `json:"variables,omitempty"`
+	Steps       []WorkflowStepRequest `json:"steps"`
+}
+
+// UpdateWorkflowRequest представляет запрос на обновление сценария
+type UpdateWorkflowRequest struct {
+	Name        *string               `json:"name,omitempty"`
+	Description *string               `json:"description,omitempty"`
+	Variables   map[string]string     `json:"variables,omitempty"`
+	Steps       []WorkflowStepRequest `json:"steps,omitempty"` // если указаны, заменяют все шаги
+}
+
+// WorkflowListResponse представляет ответ со списком сценариев
+type WorkflowListResponse struct {
+	Workflows []Workflow `json:"workflows"`
+	Total     int        `json:"total"`
+}
+
+// WorkflowRun представляет запуск сценария на агенте
+type WorkflowRun struct {
+	ID           uuid.UUID         `json:"id" db:"id"`
+	WorkflowID   uuid.UUID         `json:"workflow_id" db:"workflow_id"`
+	WorkflowName string            `json:"workflow_name"`
+	AgentID      uuid.UUID         `json:"agent_id" db:"agent_id"`
+	Status       string            `json:"status" db:"status"`
+	Variables    map[string]string `json:"variables" db:"variables"`
+	Error        *string           `json:"error" db:"error"`
+	StartedBy    *uuid.UUID        `json:"started_by" db:"started_by"`
+	Created      time.Time         `json:"created" db:"created"`
+	Completed    *time.Time        `json:"completed" db:"completed"`
+	Steps        []WorkflowRunStep `json:"steps,omitempty"`
+}
+
+// WorkflowRunStep представляет шаг запуска сценария и его результат
+type WorkflowRunStep struct {
+	ID             uuid.UUID              `json:"id" db:"id"`
+	RunID          uuid.UUID              `json:"run_id" db:"run_id"`
+	Name           string                 `json:"name" db:"name"`
+	Type           string                 `json:"type" db:"type"`
+	Payload        map[string]interface{} `json:"payload" db:"payload"`
+	DependsOn      []string               `json:"depends_on" db:"depends_on"`
+	IsCompensation bool                   `json:"is_compensation" db:"is_compensation"`
+	Status         string                 `json:"status" db:"status"`
+	ActionID       *uuid.UUID             `json:"action_id" db:"action_id"`
+	Response       *json.RawMessage       `json:"response" swaggertype:"object"`
+	Error          *string                `json:"error"`
+	Started        *time.Time             `json:"started" db:"started"`
+	Completed      *time.Time             `json:"completed" db:"completed"`
+}
+
+// StartWorkflowRunRequest представляет запрос на запуск сценария
+type StartWorkflowRunRequest struct {
+	AgentID   uuid.UUID         `json:"agent_id"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// WorkflowRunListResponse представляет ответ со списком запусков сценария
+type WorkflowRunListResponse struct {
+	Runs  []WorkflowRun `json:"runs"`
+	Total int           `json:"total"`
+}
+
+// Константы для статусов запуска сценария
+const (
+	WorkflowRunStatusRunning      = "running"
+	WorkflowRunStatusCompensating = "compensating"
+	WorkflowRunStatusCompleted    = "completed"
+	WorkflowRunStatusFailed       = "failed"
+)
+
+// Константы для статусов шагов запуска
+const (
+	WorkflowStepStatusWaiting   = "waiting"
+	WorkflowStepStatusRunning   = "running"
+	WorkflowStepStatusCompleted = "completed"
+	WorkflowStepStatusFailed    = "failed"
+	WorkflowStepStatusSkipped   = "skipped"
+)
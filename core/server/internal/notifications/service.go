@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -46,7 +47,7 @@ func New() *Service {
 				},
 				ContainerStopped: models.NotificationConfig{
 					Enabled: false,
-					Message: "⚠️ Контейнер {CONTAINER_NAME} остановился на агенте {AGENT_NAME}",
+					Message: "⚠️ Контейнер {CONTAINER_NAME} остановился на агенте {AGENT_NAME}: {REASON}",
 				},
 				CPUThreshold: models.CPUThresholdConfig{
 					Enabled:   false,
@@ -58,6 +59,11 @@ func New() *Service {
 					Threshold: 80,
 					Message:   "💾 Высокое использование RAM: {AGENT_NAME} - {RAM_USAGE}%",
 				},
+				DiskThreshold: models.DiskThresholdConfig{
+					Enabled:   false,
+					Threshold: 90,
+					Message:   "🗄 Заканчивается место: {AGENT_NAME} {MOUNTPOINT} - {DISK_USAGE}%, inode {INODE_USAGE}%",
+				},
 			},
 		},
 		client: &http.Client{
@@ -126,8 +132,9 @@ func (s *Service) CheckAgentOffline(agentName string) error {
 	return nil
 }
 
-// CheckContainerStopped проверяет, нужно ли отправить уведомление об остановке контейнера
-func (s *Service) CheckContainerStopped(containerName, agentName string) error {
+// CheckContainerStopped проверяет, нужно ли отправить уведомление об остановке контейнера.
+// Код выхода и OOM попадают в {REASON}, чтобы штатную остановку было видно сразу.
+func (s *Service) CheckContainerStopped(containerName, agentName string, exitCode int, oomKilled bool) error {
 	if !s.settings.Notifications.ContainerStopped.Enabled {
 		return nil
 	}
@@ -135,6 +142,8 @@ func (s *Service) CheckContainerStopped(containerName, agentName string) error {
 	message := s.replaceVariables(s.settings.Notifications.ContainerStopped.Message, map[string]string{
 		"CONTAINER_NAME": containerName,
 		"AGENT_NAME":     agentName,
+		"EXIT_CODE":      strconv.Itoa(exitCode),
+		"REASON":         containerStopReason(exitCode, oomKilled),
 	})
 
 	// Отправляем в Telegram
@@ -155,6 +164,18 @@ func (s *Service) CheckContainerStopped(containerName, agentName string) error {
 	return nil
 }
 
+// containerStopReason описывает причину остановки контейнера
+func containerStopReason(exitCode int, oomKilled bool) string {
+	switch {
+	case oomKilled:
+		return fmt.Sprintf("убит из-за нехватки памяти (код %d)", exitCode)
+	case exitCode == 0:
+		return "штатная остановка (код 0)"
+	default:
+		return fmt.Sprintf("аварийное завершение (код %d)", exitCode)
+	}
+}
+
 // CheckCPUThreshold проверяет, нужно ли отправить уведомление о превышении CPU
 func (s *Service) CheckCPUThreshold(agentName string, cpuUsage float64) error {
 	if !s.settings.Notifications.CPUThreshold.Enabled {
@@ -217,6 +238,41 @@ func (s *Service) CheckRAMThreshold(agentName string, ramUsage float64) error {
 	return nil
 }
 
+// CheckDiskThreshold проверяет, нужно ли отправить уведомление о заполнении файловой системы.
+// Файловая система без свободных inode так же непригодна для записи, как и без места.
+func (s *Service) CheckDiskThreshold(agentName, mountpoint string, diskUsage, inodeUsage float64) error {
+	if !s.settings.Notifications.DiskThreshold.Enabled {
+		return nil
+	}
+
+	threshold := float64(s.settings.Notifications.DiskThreshold.Threshold)
+	if diskUsage > threshold || inodeUsage > threshold {
+		message := s.replaceVariables(s.settings.Notifications.DiskThreshold.Message, map[string]string{
+			"AGENT_NAME":  agentName,
+			"MOUNTPOINT":  mountpoint,
+			"DISK_USAGE":  fmt.Sprintf("%.1f", diskUsage),
+			"INODE_USAGE": fmt.Sprintf("%.1f", inodeUsage),
+		})
+
+		// Отправляем в Telegram
+		if s.settings.TelegramBotToken != "" && s.settings.TelegramChatID != "" {
+			if err := s.sendTelegramMessage(message); err != nil {
+				log.Printf("Error sending Telegram disk threshold notification: %v", err)
+			}
+		}
+
+		// Отправляем email
+		if s.settings.EmailSettings.Enabled {
+			if err := s.sendEmailMessage("Заканчивается место на диске", message); err != nil {
+				log.Printf("Error sending email disk threshold notification: %v", err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // sendTelegramMessage отправляет сообщение в Telegram
 func (s *Service) sendTelegramMessage(text string) error {
 	if s.settings.TelegramBotToken == "" {
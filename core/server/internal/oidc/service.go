@@ -0,0 +1,324 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/oauth2"
+
+	"monitoring-system/core/server/internal/config"
+	"monitoring-system/core/server/internal/models"
+)
+
+const (
+	// StateTTL — сколько живет незавершенный вход через провайдера
+	StateTTL = 10 * time.Minute
+	// httpTimeout ограничивает запросы к провайдеру
+	httpTimeout = 10 * time.Second
+)
+
+var (
+	// ErrDisabled возвращается, если вход через OIDC не настроен
+	ErrDisabled = errors.New("oidc login is not configured")
+	// ErrInvalidState возвращается для неизвестного или просроченного state
+	ErrInvalidState = errors.New("oidc login state is invalid or expired")
+	// ErrNoRole возвращается, если группам пользователя не сопоставлена роль и роль по умолчанию не задана
+	ErrNoRole = errors.New("no role is mapped for the user's groups")
+	// ErrUsernameTaken возвращается, если имя пользователя занято локальной учетной записью
+	ErrUsernameTaken = errors.New("username is already used by another account")
+	// ErrUserInactive возвращается для деактивированного пользователя
+	ErrUserInactive = errors.New("user is inactive")
+)
+
+// Service реализует вход через OpenID Connect (authorization code flow с PKCE)
+// и создает пользователей при первом входе
+type Service struct {
+	db  *sql.DB
+	cfg config.OIDCConfig
+
+	mu           sync.Mutex
+	oauth2Config *oauth2.Config
+	verifier     *gooidc.IDTokenVerifier
+}
+
+func NewService(db *sql.DB, cfg config.OIDCConfig) *Service {
+	return &Service{db: db, cfg: cfg}
+}
+
+// Enabled сообщает, настроен ли вход через OIDC
+func (s *Service) Enabled() bool {
+	return s.cfg.Issuer != "" && s.cfg.ClientID != "" && s.cfg.RedirectURL != ""
+}
+
+// client выполняет discovery провайдера при первом обращении, чтобы недоступность
+// провайдера не мешала запуску сервера; при ошибке попытка повторяется на следующем входе
+func (s *Service) client() (*oauth2.Config, *gooidc.IDTokenVerifier, error) {
+	if !s.Enabled() {
+		return nil, nil, ErrDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oauth2Config != nil {
+		return s.oauth2Config, s.verifier, nil
+	}
+
+	// Контекст провайдера используется и для последующей загрузки ключей, поэтому он не
+	// должен завершаться вместе с запросом
+	providerCtx := gooidc.ClientContext(context.Background(), &http.Client{Timeout: httpTimeout})
+	provider, err := gooidc.NewProvider(providerCtx, s.cfg.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover oidc provider: %v", err)
+	}
+
+	scopes := []string{gooidc.ScopeOpenID}
+	for _, scope := range s.cfg.Scopes {
+		if scope != gooidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	s.oauth2Config = &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	s.verifier = provider.Verifier(&gooidc.Config{ClientID: s.cfg.ClientID})
+
+	return s.oauth2Config, s.verifier, nil
+}
+
+// randomString генерирует случайную строку для state и nonce
+func randomString() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// AuthCodeURL начинает вход: сохраняет state, nonce и PKCE verifier и возвращает адрес
+// провайдера и state. Вызывающий привязывает state к браузеру, начавшему вход.
+func (s *Service) AuthCodeURL() (string, string, error) {
+	oauth2Config, _, err := s.client()
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %v", err)
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// Заодно удаляем брошенные входы
+	if _, err := s.db.Exec("DELETE FROM oidc_login_states WHERE created < $1", time.Now().Add(-StateTTL)); err != nil {
+		return "", "", fmt.Errorf("failed to clean up oidc states: %v", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO oidc_login_states (state, nonce, code_verifier) VALUES ($1, $2, $3)
+	`, state, nonce, verifier)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save oidc state: %v", err)
+	}
+
+	return oauth2Config.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), state, nil
+}
+
+// identity — данные пользователя из ID-токена
+type identity struct {
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+// Callback завершает вход: обменивает код на токены, проверяет ID-токен и возвращает
+// локального пользователя, создавая его при первом входе. created сообщает о создании.
+func (s *Service) Callback(ctx context.Context, state, code string) (user *models.User, created bool, err error) {
+	oauth2Config, verifier, err := s.client()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var nonce, codeVerifier string
+	err = s.db.QueryRow(`
+		DELETE FROM oidc_login_states WHERE state = $1 AND created >= $2
+		RETURNING nonce, code_verifier
+	`, state, time.Now().Add(-StateTTL)).Scan(&nonce, &codeVerifier)
+	if err == sql.ErrNoRows {
+		return nil, false, ErrInvalidState
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get oidc state: %v", err)
+	}
+
+	ctx = gooidc.ClientContext(ctx, &http.Client{Timeout: httpTimeout})
+	token, err := oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to exchange authorization code: %v", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, false, fmt.Errorf("provider did not return an id_token")
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to verify id_token: %v", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, false, fmt.Errorf("id_token nonce does not match")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, false, fmt.Errorf("failed to parse id_token claims: %v", err)
+	}
+	id := s.parseIdentity(idToken.Subject, claims)
+
+	role := s.mapRole(id.Groups)
+	if role == "" {
+		return nil, false, ErrNoRole
+	}
+
+	return s.provisionUser(idToken.Issuer+"|"+id.Subject, id, role)
+}
+
+// parseIdentity извлекает имя, email и группы из claims ID-токена
+func (s *Service) parseIdentity(subject string, claims map[string]interface{}) identity {
+	id := identity{Subject: subject}
+	if v, ok := claims["preferred_username"].(string); ok {
+		id.Username = v
+	}
+	if v, ok := claims["email"].(string); ok {
+		id.Email = v
+	}
+	if id.Username == "" {
+		id.Username = id.Email
+	}
+	if id.Username == "" {
+		id.Username = subject
+	}
+
+	// Провайдеры передают группы списком или одной строкой
+	switch groups := claims[s.cfg.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range groups {
+			if name, ok := g.(string); ok {
+				id.Groups = append(id.Groups, name)
+			}
+		}
+	case string:
+		id.Groups = strings.Fields(groups)
+	}
+	return id
+}
+
+// mapRole выбирает роль с наибольшими правами среди сопоставленных группам пользователя
+func (s *Service) mapRole(groups []string) string {
+	mapped := make(map[string]bool)
+	for _, group := range groups {
+		if role, ok := s.cfg.RoleMapping[group]; ok {
+			mapped[role] = true
+		}
+	}
+	// models.UserRoles упорядочены по убыванию прав
+	for _, role := range models.UserRoles {
+		if mapped[role] {
+			return role
+		}
+	}
+	for _, role := range models.UserRoles {
+		if role == s.cfg.DefaultRole {
+			return role
+		}
+	}
+	return ""
+}
+
+const userColumns = "id, username, password_hash, email, is_active, role, created, last_login, auth_provider, totp_enabled"
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(
+		&user.ID, &user.Username, &user.PasswordHash, &user.Email,
+		&user.IsActive, &user.Role, &user.Created, &user.LastLogin, &user.AuthProvider, &user.TOTPEnabled,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// provisionUser находит пользователя по внешнему идентификатору или создает его.
+// Роль и email синхронизируются с провайдером при каждом входе.
+func (s *Service) provisionUser(externalID string, id identity, role string) (*models.User, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var email interface{}
+	if id.Email != "" {
+		email = id.Email
+	}
+
+	user, err := scanUser(tx.QueryRow("SELECT "+userColumns+" FROM users WHERE external_id = $1 FOR UPDATE", externalID))
+	created := false
+	switch {
+	case err == sql.ErrNoRows:
+		// Пароль не задан: пустой хеш не совпадает ни с одним паролем, а локальный вход для OIDC-пользователей закрыт
+		user, err = scanUser(tx.QueryRow(`
+			INSERT INTO users (id, username, password_hash, email, role, is_active, auth_provider, external_id, last_login)
+			VALUES ($1, $2, '', $3, $4, true, $5, $6, NOW())
+			RETURNING `+userColumns,
+			uuid.New(), id.Username, email, role, models.AuthProviderOIDC, externalID,
+		))
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, false, fmt.Errorf("%w: %s", ErrUsernameTaken, id.Username)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create user: %v", err)
+		}
+		created = true
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to get user: %v", err)
+	case !user.IsActive:
+		return nil, false, ErrUserInactive
+	default:
+		user, err = scanUser(tx.QueryRow(`
+			UPDATE users SET email = $1, role = $2, last_login = NOW()
+			WHERE id = $3
+			RETURNING `+userColumns,
+			email, role, user.ID,
+		))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to update user: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return user, created, nil
+}
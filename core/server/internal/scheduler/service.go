@@ -0,0 +1,323 @@
+package scheduler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"monitoring-system/core/server/internal/approvals"
+	"monitoring-system/core/server/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrInvalidSchedule возвращается при некорректных параметрах расписания
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ErrAgentNotFound возвращается, если агент расписания не найден или отключен
+var ErrAgentNotFound = errors.New("agent not found")
+
+// Service управляет расписаниями действий и превращает их в записи таблицы actions
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// nextRun вычисляет время следующего запуска расписания после from.
+// Для однократного расписания возвращает run_at, если оно еще не наступило.
+func nextRun(cronExpr *string, runAt *time.Time, from time.Time) (*time.Time, error) {
+	if cronExpr != nil && *cronExpr != "" {
+		schedule, err := cron.ParseStandard(*cronExpr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cron expression: %v", ErrInvalidSchedule, err)
+		}
+		next := schedule.Next(from)
+		return &next, nil
+	}
+
+	if runAt != nil {
+		if runAt.After(from) {
+			return runAt, nil
+		}
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: either cron_expr or run_at is required", ErrInvalidSchedule)
+}
+
+const scheduleColumns = `
+	s.id, s.agent_id, s.name, s.type, s.payload, s.cron_expr, s.run_at, s.is_paused,
+	s.next_run, s.last_run, s.last_action_id, s.created_by, s.created, s.updated, a.name as agent_name`
+
+// scanSchedule сканирует строку расписания
+func scanSchedule(scanner interface{ Scan(...interface{}) error }) (*models.ActionSchedule, error) {
+	schedule := &models.ActionSchedule{}
+	var payloadJSON []byte
+	err := scanner.Scan(
+		&schedule.ID, &schedule.AgentID, &schedule.Name, &schedule.Type, &payloadJSON,
+		&schedule.CronExpr, &schedule.RunAt, &schedule.IsPaused,
+		&schedule.NextRun, &schedule.LastRun, &schedule.LastActionID, &schedule.CreatedBy,
+		&schedule.Created, &schedule.Updated, &schedule.AgentName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(payloadJSON, &schedule.Payload); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule payload: %v", err)
+	}
+
+	return schedule, nil
+}
+
+// CreateSchedule создает новое расписание. Действия расписания создаются от имени createdBy
+func (s *Service) CreateSchedule(req *models.CreateScheduleRequest, createdBy *uuid.UUID) (*models.ActionSchedule, error) {
+	if req.Name == "" || req.Type == "" {
+		return nil, fmt.Errorf("%w: name and type are required", ErrInvalidSchedule)
+	}
+	if !models.AgentActionTypes[req.Type] {
+		return nil, fmt.Errorf("%w: action type %q cannot be scheduled", ErrInvalidSchedule, req.Type)
+	}
+
+	var agentExists bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM agents WHERE id = $1 AND is_active = true)", req.AgentID).Scan(&agentExists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check agent: %v", err)
+	}
+	if !agentExists {
+		return nil, ErrAgentNotFound
+	}
+
+	if req.CronExpr != nil && *req.CronExpr == "" {
+		req.CronExpr = nil
+	}
+
+	next, err := nextRun(req.CronExpr, req.RunAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		return nil, fmt.Errorf("%w: run_at must be in the future", ErrInvalidSchedule)
+	}
+
+	if req.Payload == nil {
+		req.Payload = map[string]interface{}{}
+	}
+	payloadJSON, err := json.Marshal(req.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	var id uuid.UUID
+	err = s.db.QueryRow(`
+		INSERT INTO action_schedules (agent_id, name, type, payload, cron_expr, run_at, next_run, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, req.AgentID, req.Name, req.Type, payloadJSON, req.CronExpr, req.RunAt, next, createdBy).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %v", err)
+	}
+
+	return s.GetSchedule(id)
+}
+
+// GetSchedule получает расписание по ID
+func (s *Service) GetSchedule(id uuid.UUID) (*models.ActionSchedule, error) {
+	row := s.db.QueryRow(`
+		SELECT `+scheduleColumns+`
+		FROM action_schedules s
+		JOIN agents a ON s.agent_id = a.id
+		WHERE s.id = $1
+	`, id)
+
+	schedule, err := scanSchedule(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %v", err)
+	}
+
+	return schedule, nil
+}
+
+// GetSchedules получает список расписаний, опционально для конкретного агента
+func (s *Service) GetSchedules(agentID *uuid.UUID) ([]models.ActionSchedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM action_schedules s
+		JOIN agents a ON s.agent_id = a.id`
+	var args []interface{}
+	if agentID != nil {
+		query += " WHERE s.agent_id = $1"
+		args = append(args, *agentID)
+	}
+	query += " ORDER BY s.next_run ASC NULLS LAST, s.created DESC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %v", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.ActionSchedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %v", err)
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	return schedules, nil
+}
+
+// UpdateSchedule обновляет расписание и пересчитывает время следующего запуска
+func (s *Service) UpdateSchedule(id uuid.UUID, req *models.UpdateScheduleRequest) (*models.ActionSchedule, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		schedule.Name = *req.Name
+	}
+	if req.Payload != nil {
+		schedule.Payload = req.Payload
+	}
+	if req.CronExpr != nil {
+		if *req.CronExpr == "" {
+			schedule.CronExpr = nil
+		} else {
+			schedule.CronExpr = req.CronExpr
+			schedule.RunAt = nil
+		}
+	}
+	if req.RunAt != nil {
+		schedule.RunAt = req.RunAt
+		if req.CronExpr == nil {
+			schedule.CronExpr = nil
+		}
+	}
+	if req.IsPaused != nil {
+		schedule.IsPaused = *req.IsPaused
+	}
+
+	next, err := nextRun(schedule.CronExpr, schedule.RunAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	payloadJSON, err := json.Marshal(schedule.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE action_schedules
+		SET name = $1, payload = $2, cron_expr = $3, run_at = $4, is_paused = $5, next_run = $6, updated = now()
+		WHERE id = $7
+	`, schedule.Name, payloadJSON, schedule.CronExpr, schedule.RunAt, schedule.IsPaused, next, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schedule: %v", err)
+	}
+
+	return s.GetSchedule(id)
+}
+
+// SetPaused приостанавливает или возобновляет расписание
+func (s *Service) SetPaused(id uuid.UUID, paused bool) (*models.ActionSchedule, error) {
+	return s.UpdateSchedule(id, &models.UpdateScheduleRequest{IsPaused: &paused})
+}
+
+// DeleteSchedule удаляет расписание
+func (s *Service) DeleteSchedule(id uuid.UUID) error {
+	_, err := s.db.Exec("DELETE FROM action_schedules WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %v", err)
+	}
+	return nil
+}
+
+// RunDueSchedules создает действия для всех расписаний, время которых наступило.
+// Пропущенные за время простоя сервера запуски не догоняются: создается одно
+// действие, а следующий запуск рассчитывается от текущего момента.
+func (s *Service) RunDueSchedules() {
+	tx, err := s.db.Begin()
+	if err != nil {
+		log.Printf("Error starting scheduler transaction: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT s.id, s.agent_id, s.type, s.payload, s.cron_expr, s.created_by
+		FROM action_schedules s
+		JOIN agents a ON s.agent_id = a.id
+		WHERE s.is_paused = false AND s.next_run IS NOT NULL AND s.next_run <= now()
+			AND a.is_active = true
+		FOR UPDATE OF s SKIP LOCKED
+	`)
+	if err != nil {
+		log.Printf("Error getting due schedules: %v", err)
+		return
+	}
+
+	type dueSchedule struct {
+		id        uuid.UUID
+		agentID   uuid.UUID
+		typ       string
+		payload   []byte
+		cronExpr  *string
+		createdBy *uuid.UUID
+	}
+
+	var due []dueSchedule
+	for rows.Next() {
+		var d dueSchedule
+		if err := rows.Scan(&d.id, &d.agentID, &d.typ, &d.payload, &d.cronExpr, &d.createdBy); err != nil {
+			log.Printf("Error scanning due schedule: %v", err)
+			continue
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, d := range due {
+		actionID, _, err := approvals.InsertAction(tx, d.agentID, d.typ, d.payload, d.createdBy)
+		if err != nil {
+			log.Printf("Error creating action for schedule %s: %v", d.id, err)
+			return
+		}
+
+		// Для однократного расписания следующий запуск отсутствует
+		next, err := nextRun(d.cronExpr, nil, now)
+		if d.cronExpr == nil || err != nil {
+			next = nil
+		}
+
+		_, err = tx.Exec(`
+			UPDATE action_schedules
+			SET last_run = $1, last_action_id = $2, next_run = $3
+			WHERE id = $4
+		`, now, actionID, next, d.id)
+		if err != nil {
+			log.Printf("Error updating schedule %s: %v", d.id, err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing scheduler transaction: %v", err)
+		return
+	}
+
+	if len(due) > 0 {
+		log.Printf("Scheduler created %d actions", len(due))
+	}
+}
@@ -0,0 +1,368 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"monitoring-system/core/server/internal/auth"
+	"monitoring-system/core/server/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// minPasswordLength — минимальная длина пароля пользователя
+const minPasswordLength = 8
+
+var (
+	// ErrUserNotFound возвращается, если пользователь не найден
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidUser возвращается при некорректных данных пользователя
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrUsernameTaken возвращается, если имя пользователя уже занято
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrWrongPassword возвращается, если текущий пароль указан неверно
+	ErrWrongPassword = errors.New("current password is incorrect")
+	// ErrLastAdmin возвращается при попытке удалить, деактивировать или понизить последнего администратора
+	ErrLastAdmin = errors.New("at least one active admin is required")
+)
+
+// Service управляет пользователями системы
+type Service struct {
+	db   *sql.DB
+	auth *auth.Service
+}
+
+func NewService(db *sql.DB, authService *auth.Service) *Service {
+	return &Service{db: db, auth: authService}
+}
+
+const userColumns = "id, username, password_hash, email, is_active, role, created, last_login, auth_provider, totp_enabled"
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(
+		&user.ID, &user.Username, &user.PasswordHash, &user.Email,
+		&user.IsActive, &user.Role, &user.Created, &user.LastLogin, &user.AuthProvider, &user.TOTPEnabled,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Querier — общий интерфейс для *sql.DB и *sql.Tx
+type Querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// getAgentIDs получает агентов, которыми ограничен пользователь
+func getAgentIDs(q Querier, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.Query("SELECT agent_id FROM user_agent_scopes WHERE user_id = $1 ORDER BY agent_id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent scopes: %v", err)
+	}
+	defer rows.Close()
+
+	agentIDs := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan agent scope: %v", err)
+		}
+		agentIDs = append(agentIDs, id)
+	}
+	return agentIDs, nil
+}
+
+// setAgentIDs заменяет список агентов, которыми ограничен пользователь
+func setAgentIDs(tx *sql.Tx, userID uuid.UUID, agentIDs []uuid.UUID) error {
+	if _, err := tx.Exec("DELETE FROM user_agent_scopes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to clear agent scopes: %v", err)
+	}
+	for _, agentID := range agentIDs {
+		_, err := tx.Exec(`
+			INSERT INTO user_agent_scopes (user_id, agent_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, userID, agentID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				return fmt.Errorf("%w: unknown agent %s", ErrInvalidUser, agentID)
+			}
+			return fmt.Errorf("failed to set agent scope: %v", err)
+		}
+	}
+	return nil
+}
+
+// validRole проверяет, что роль входит в список допустимых
+func validRole(role string) bool {
+	for _, r := range models.UserRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePassword проверяет требования к паролю
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidUser, minPasswordLength)
+	}
+	return nil
+}
+
+// GetUsers получает список пользователей
+func (s *Service) GetUsers() ([]models.User, error) {
+	rows, err := s.db.Query("SELECT " + userColumns + " FROM users ORDER BY username")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %v", err)
+	}
+	defer rows.Close()
+
+	list := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %v", err)
+		}
+		list = append(list, *user)
+	}
+	rows.Close()
+
+	for i := range list {
+		if list[i].AgentIDs, err = getAgentIDs(s.db, list[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// GetUser получает пользователя по ID
+func (s *Service) GetUser(id uuid.UUID) (*models.User, error) {
+	user, err := scanUser(s.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if user.AgentIDs, err = getAgentIDs(s.db, id); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateUser создает пользователя
+func (s *Service) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		return nil, fmt.Errorf("%w: username is required", ErrInvalidUser)
+	}
+	if req.Role == "" {
+		req.Role = models.UserRoleViewer
+	}
+	if !validRole(req.Role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidUser, req.Role)
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
+	}
+
+	hash, err := s.auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	user, err := scanUser(tx.QueryRow(`
+		INSERT INTO users (username, password_hash, email, role, is_active)
+		VALUES ($1, $2, $3, $4, true)
+		RETURNING `+userColumns,
+		req.Username, hash, req.Email, req.Role,
+	))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, ErrUsernameTaken
+		}
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+
+	if err := setAgentIDs(tx, user.ID, req.AgentIDs); err != nil {
+		return nil, err
+	}
+	if user.AgentIDs, err = getAgentIDs(tx, user.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser изменяет email, роль, активность и список доступных агентов пользователя
+func (s *Service) UpdateUser(id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
+	if req.Role != nil && !validRole(*req.Role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidUser, *req.Role)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanUser(tx.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1 FOR UPDATE", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	email, role, isActive := current.Email, current.Role, current.IsActive
+	if req.Email != nil {
+		email = req.Email
+		if *req.Email == "" {
+			email = nil
+		}
+	}
+	if req.Role != nil {
+		role = *req.Role
+	}
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	// Нельзя лишить систему последнего активного администратора
+	if current.Role == models.UserRoleAdmin && current.IsActive && (role != models.UserRoleAdmin || !isActive) {
+		if err := ensureOtherAdmin(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := scanUser(tx.QueryRow(`
+		UPDATE users SET email = $1, role = $2, is_active = $3
+		WHERE id = $4
+		RETURNING `+userColumns,
+		email, role, isActive, id,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+
+	if req.AgentIDs != nil {
+		if err := setAgentIDs(tx, id, *req.AgentIDs); err != nil {
+			return nil, err
+		}
+	}
+	if user.AgentIDs, err = getAgentIDs(tx, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return user, nil
+}
+
+// DeleteUser удаляет пользователя. Авторство его действий и записей аудита сохраняется без ссылки.
+func (s *Service) DeleteUser(id uuid.UUID) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanUser(tx.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1 FOR UPDATE", id))
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %v", err)
+	}
+
+	if current.Role == models.UserRoleAdmin && current.IsActive {
+		if err := ensureOtherAdmin(tx, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM users WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// ensureOtherAdmin проверяет, что кроме указанного пользователя есть другой активный администратор
+func ensureOtherAdmin(tx *sql.Tx, id uuid.UUID) error {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM users WHERE role = $1 AND is_active = true AND id <> $2
+	`, models.UserRoleAdmin, id).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %v", err)
+	}
+	if count == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// SetPassword устанавливает новый пароль пользователя без проверки текущего
+func (s *Service) SetPassword(id uuid.UUID, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	hash, err := s.auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	result, err := s.db.Exec("UPDATE users SET password_hash = $1 WHERE id = $2", hash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего
+func (s *Service) ChangePassword(id uuid.UUID, currentPassword, newPassword string) error {
+	user, err := s.GetUser(id)
+	if err != nil {
+		return err
+	}
+
+	if !s.auth.CheckPassword(currentPassword, user.PasswordHash) {
+		return ErrWrongPassword
+	}
+
+	return s.SetPassword(id, newPassword)
+}
+
+// UpdateProfile изменяет данные собственного профиля пользователя
+func (s *Service) UpdateProfile(id uuid.UUID, req *models.UpdateProfileRequest) (*models.User, error) {
+	return s.UpdateUser(id, &models.UpdateUserRequest{Email: req.Email})
+}
@@ -0,0 +1,995 @@
+package workflows
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"time"
+
+	"monitoring-system/core/server/internal/approvals"
+	"monitoring-system/core/server/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrInvalidWorkflow возвращается при некорректном описании сценария или параметрах запуска
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// Service управляет сценариями развертывания и выполняет их запуски,
+// превращая готовые к выполнению шаги в записи таблицы actions
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// variablePattern соответствует подстановке вида {{name}}
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// validateSteps проверяет имена и типы действий шагов, зависимости и отсутствие циклов в графе
+func validateSteps(steps []models.WorkflowStepRequest) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: at least one step is required", ErrInvalidWorkflow)
+	}
+
+	names := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		if step.Name == "" || step.Type == "" {
+			return fmt.Errorf("%w: step name and type are required", ErrInvalidWorkflow)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("%w: duplicate step name %q", ErrInvalidWorkflow, step.Name)
+		}
+		if step.Compensation != nil && step.Compensation.Type == "" {
+			return fmt.Errorf("%w: compensation type is required for step %q", ErrInvalidWorkflow, step.Name)
+		}
+		if !models.AgentActionTypes[step.Type] {
+			return fmt.Errorf("%w: action type %q cannot be used in step %q", ErrInvalidWorkflow, step.Type, step.Name)
+		}
+		if step.Compensation != nil && !models.AgentActionTypes[step.Compensation.Type] {
+			return fmt.Errorf("%w: action type %q cannot be used in compensation of step %q", ErrInvalidWorkflow, step.Compensation.Type, step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	// Топологическая сортировка (алгоритм Кана) для поиска циклов
+	inDegree := make(map[string]int, len(steps))
+	dependents := make(map[string][]string, len(steps))
+	for _, step := range steps {
+		inDegree[step.Name] += 0
+		for _, dep := range step.DependsOn {
+			if !names[dep] {
+				return fmt.Errorf("%w: step %q depends on unknown step %q", ErrInvalidWorkflow, step.Name, dep)
+			}
+			if dep == step.Name {
+				return fmt.Errorf("%w: step %q depends on itself", ErrInvalidWorkflow, step.Name)
+			}
+			inDegree[step.Name]++
+			dependents[dep] = append(dependents[dep], step.Name)
+		}
+	}
+
+	var queue []string
+	for name, degree := range inDegree {
+		if degree == 0 {
+			queue = append(queue, name)
+		}
+	}
+
+	visited := 0
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, next := range dependents[name] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if visited != len(steps) {
+		return fmt.Errorf("%w: steps contain a dependency cycle", ErrInvalidWorkflow)
+	}
+
+	return nil
+}
+
+// renderValue рекурсивно подставляет значения переменных во все строки payload
+func renderValue(value interface{}, vars map[string]string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		var missing string
+		rendered := variablePattern.ReplaceAllStringFunc(v, func(match string) string {
+			name := variablePattern.FindStringSubmatch(match)[1]
+			val, ok := vars[name]
+			if !ok {
+				missing = name
+				return match
+			}
+			return val
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("%w: undefined variable %q", ErrInvalidWorkflow, missing)
+		}
+		return rendered, nil
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered, err := renderValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered, err := renderValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = rendered
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// renderPayload подставляет переменные в payload и сериализует его в JSON
+func renderPayload(payload map[string]interface{}, vars map[string]string) ([]byte, error) {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	rendered, err := renderValue(payload, vars)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rendered)
+}
+
+// insertSteps сохраняет шаги сценария в порядке их объявления
+func insertSteps(tx *sql.Tx, workflowID uuid.UUID, steps []models.WorkflowStepRequest) error {
+	for i, step := range steps {
+		if step.Payload == nil {
+			step.Payload = map[string]interface{}{}
+		}
+		payloadJSON, err := json.Marshal(step.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal step payload: %v", err)
+		}
+
+		// Пустой []byte не является NULL для драйвера, поэтому используем interface{}
+		var compensationType *string
+		var compensationPayload interface{}
+		if step.Compensation != nil {
+			compensationType = &step.Compensation.Type
+			if step.Compensation.Payload == nil {
+				step.Compensation.Payload = map[string]interface{}{}
+			}
+			data, err := json.Marshal(step.Compensation.Payload)
+			if err != nil {
+				return fmt.Errorf("failed to marshal compensation payload: %v", err)
+			}
+			compensationPayload = data
+		}
+
+		dependsOn := step.DependsOn
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO workflow_steps (workflow_id, name, type, payload, depends_on, compensation_type, compensation_payload, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, workflowID, step.Name, step.Type, payloadJSON, pq.Array(dependsOn), compensationType, compensationPayload, i)
+		if err != nil {
+			return fmt.Errorf("failed to create workflow step: %v", err)
+		}
+	}
+	return nil
+}
+
+// CreateWorkflow создает сценарий вместе с шагами
+func (s *Service) CreateWorkflow(req *models.CreateWorkflowRequest) (*models.Workflow, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidWorkflow)
+	}
+	if err := validateSteps(req.Steps); err != nil {
+		return nil, err
+	}
+
+	if req.Variables == nil {
+		req.Variables = map[string]string{}
+	}
+	variablesJSON, err := json.Marshal(req.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal variables: %v", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO workflows (name, description, variables)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, req.Name, req.Description, variablesJSON).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow: %v", err)
+	}
+
+	if err := insertSteps(tx, id, req.Steps); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return s.GetWorkflow(id)
+}
+
+// getSteps получает шаги сценария
+func (s *Service) getSteps(workflowID uuid.UUID) ([]models.WorkflowStep, error) {
+	rows, err := s.db.Query(`
+		SELECT id, workflow_id, name, type, payload, depends_on, compensation_type, compensation_payload, position
+		FROM workflow_steps
+		WHERE workflow_id = $1
+		ORDER BY position
+	`, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow steps: %v", err)
+	}
+	defer rows.Close()
+
+	steps := []models.WorkflowStep{}
+	for rows.Next() {
+		var step models.WorkflowStep
+		var payloadJSON, compensationPayload []byte
+		var dependsOn pq.StringArray
+		var compensationType *string
+		err := rows.Scan(
+			&step.ID, &step.WorkflowID, &step.Name, &step.Type, &payloadJSON, &dependsOn,
+			&compensationType, &compensationPayload, &step.Position,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow step: %v", err)
+		}
+
+		if err := json.Unmarshal(payloadJSON, &step.Payload); err != nil {
+			return nil, fmt.Errorf("failed to parse step payload: %v", err)
+		}
+		step.DependsOn = []string(dependsOn)
+
+		if compensationType != nil {
+			step.Compensation = &models.WorkflowCompensation{Type: *compensationType}
+			if compensationPayload != nil {
+				if err := json.Unmarshal(compensationPayload, &step.Compensation.Payload); err != nil {
+					return nil, fmt.Errorf("failed to parse compensation payload: %v", err)
+				}
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// GetWorkflow получает сценарий по ID вместе с шагами
+func (s *Service) GetWorkflow(id uuid.UUID) (*models.Workflow, error) {
+	workflow := &models.Workflow{}
+	var variablesJSON []byte
+	err := s.db.QueryRow(`
+		SELECT id, name, description, variables, created, updated
+		FROM workflows
+		WHERE id = $1
+	`, id).Scan(&workflow.ID, &workflow.Name, &workflow.Description, &variablesJSON, &workflow.Created, &workflow.Updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %v", err)
+	}
+
+	if err := json.Unmarshal(variablesJSON, &workflow.Variables); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow variables: %v", err)
+	}
+
+	workflow.Steps, err = s.getSteps(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return workflow, nil
+}
+
+// GetWorkflows получает список сценариев
+func (s *Service) GetWorkflows() ([]models.Workflow, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, description, variables, created, updated
+		FROM workflows
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflows: %v", err)
+	}
+	defer rows.Close()
+
+	workflows := []models.Workflow{}
+	for rows.Next() {
+		var workflow models.Workflow
+		var variablesJSON []byte
+		err := rows.Scan(&workflow.ID, &workflow.Name, &workflow.Description, &variablesJSON, &workflow.Created, &workflow.Updated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow: %v", err)
+		}
+		if err := json.Unmarshal(variablesJSON, &workflow.Variables); err != nil {
+			return nil, fmt.Errorf("failed to parse workflow variables: %v", err)
+		}
+		workflows = append(workflows, workflow)
+	}
+	rows.Close()
+
+	for i := range workflows {
+		workflows[i].Steps, err = s.getSteps(workflows[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return workflows, nil
+}
+
+// UpdateWorkflow обновляет сценарий. Если в запросе указаны шаги, они полностью заменяют
+// существующие; уже созданные запуски при этом не меняются
+func (s *Service) UpdateWorkflow(id uuid.UUID, req *models.UpdateWorkflowRequest) (*models.Workflow, error) {
+	workflow, err := s.GetWorkflow(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, fmt.Errorf("%w: name is required", ErrInvalidWorkflow)
+		}
+		workflow.Name = *req.Name
+	}
+	if req.Description != nil {
+		workflow.Description = req.Description
+	}
+	if req.Variables != nil {
+		workflow.Variables = req.Variables
+	}
+	if req.Steps != nil {
+		if err := validateSteps(req.Steps); err != nil {
+			return nil, err
+		}
+	}
+
+	variablesJSON, err := json.Marshal(workflow.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal variables: %v", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE workflows
+		SET name = $1, description = $2, variables = $3, updated = now()
+		WHERE id = $4
+	`, workflow.Name, workflow.Description, variablesJSON, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workflow: %v", err)
+	}
+
+	if req.Steps != nil {
+		if _, err := tx.Exec("DELETE FROM workflow_steps WHERE workflow_id = $1", id); err != nil {
+			return nil, fmt.Errorf("failed to delete workflow steps: %v", err)
+		}
+		if err := insertSteps(tx, id, req.Steps); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return s.GetWorkflow(id)
+}
+
+// DeleteWorkflow удаляет сценарий вместе с историей запусков
+func (s *Service) DeleteWorkflow(id uuid.UUID) error {
+	_, err := s.db.Exec("DELETE FROM workflows WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete workflow: %v", err)
+	}
+	return nil
+}
+
+// StartRun запускает сценарий на агенте от имени startedBy. Переменные запуска переопределяют
+// значения по умолчанию; payload всех шагов и компенсаций вычисляется сразу при запуске
+func (s *Service) StartRun(workflowID uuid.UUID, req *models.StartWorkflowRunRequest, startedBy *uuid.UUID) (*models.WorkflowRun, error) {
+	workflow, err := s.GetWorkflow(workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	var agentExists bool
+	err = s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM agents WHERE id = $1 AND is_active = true)", req.AgentID).Scan(&agentExists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check agent: %v", err)
+	}
+	if !agentExists {
+		return nil, fmt.Errorf("%w: agent not found", ErrInvalidWorkflow)
+	}
+
+	vars := make(map[string]string, len(workflow.Variables)+len(req.Variables))
+	for key, value := range workflow.Variables {
+		vars[key] = value
+	}
+	for key, value := range req.Variables {
+		vars[key] = value
+	}
+
+	variablesJSON, err := json.Marshal(vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal variables: %v", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var runID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO workflow_runs (workflow_id, agent_id, status, variables, started_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, workflowID, req.AgentID, models.WorkflowRunStatusRunning, variablesJSON, startedBy).Scan(&runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow run: %v", err)
+	}
+
+	for _, step := range workflow.Steps {
+		payloadJSON, err := renderPayload(step.Payload, vars)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: %w", step.Name, err)
+		}
+
+		var compensationType *string
+		var compensationPayload interface{}
+		if step.Compensation != nil {
+			compensationType = &step.Compensation.Type
+			data, err := renderPayload(step.Compensation.Payload, vars)
+			if err != nil {
+				return nil, fmt.Errorf("compensation of step %q: %w", step.Name, err)
+			}
+			compensationPayload = data
+		}
+
+		dependsOn := step.DependsOn
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO workflow_run_steps (run_id, name, type, payload, depends_on, compensation_type, compensation_payload, status, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, runID, step.Name, step.Type, payloadJSON, pq.Array(dependsOn), compensationType, compensationPayload,
+			models.WorkflowStepStatusWaiting, step.Position)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workflow run step: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	if err := s.Advance(runID); err != nil {
+		return nil, err
+	}
+
+	return s.GetRun(runID)
+}
+
+const runColumns = `
+	r.id, r.workflow_id, w.name, r.agent_id, r.status, r.variables, r.error, r.started_by, r.created, r.completed`
+
+// scanRun сканирует строку запуска сценария
+func scanRun(scanner interface{ Scan(...interface{}) error }) (*models.WorkflowRun, error) {
+	run := &models.WorkflowRun{}
+	var variablesJSON []byte
+	err := scanner.Scan(
+		&run.ID, &run.WorkflowID, &run.WorkflowName, &run.AgentID, &run.Status,
+		&variablesJSON, &run.Error, &run.StartedBy, &run.Created, &run.Completed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(variablesJSON, &run.Variables); err != nil {
+		return nil, fmt.Errorf("failed to parse run variables: %v", err)
+	}
+
+	return run, nil
+}
+
+// GetRun получает запуск сценария с результатами всех шагов
+func (s *Service) GetRun(id uuid.UUID) (*models.WorkflowRun, error) {
+	row := s.db.QueryRow(`
+		SELECT `+runColumns+`
+		FROM workflow_runs r
+		JOIN workflows w ON r.workflow_id = w.id
+		WHERE r.id = $1
+	`, id)
+
+	run, err := scanRun(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow run: %v", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT rs.id, rs.run_id, rs.name, rs.type, rs.payload, rs.depends_on, rs.is_compensation,
+			rs.status, rs.action_id, a.response, a.error, rs.started, rs.completed
+		FROM workflow_run_steps rs
+		LEFT JOIN actions a ON rs.action_id = a.id
+		WHERE rs.run_id = $1
+		ORDER BY rs.is_compensation, rs.position
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow run steps: %v", err)
+	}
+	defer rows.Close()
+
+	run.Steps = []models.WorkflowRunStep{}
+	for rows.Next() {
+		var step models.WorkflowRunStep
+		var payloadJSON []byte
+		var dependsOn pq.StringArray
+		err := rows.Scan(
+			&step.ID, &step.RunID, &step.Name, &step.Type, &payloadJSON, &dependsOn, &step.IsCompensation,
+			&step.Status, &step.ActionID, &step.Response, &step.Error, &step.Started, &step.Completed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow run step: %v", err)
+		}
+
+		if err := json.Unmarshal(payloadJSON, &step.Payload); err != nil {
+			return nil, fmt.Errorf("failed to parse step payload: %v", err)
+		}
+		step.DependsOn = []string(dependsOn)
+
+		run.Steps = append(run.Steps, step)
+	}
+
+	return run, nil
+}
+
+// GetRuns получает историю запусков сценария
+func (s *Service) GetRuns(workflowID uuid.UUID) ([]models.WorkflowRun, error) {
+	rows, err := s.db.Query(`
+		SELECT `+runColumns+`
+		FROM workflow_runs r
+		JOIN workflows w ON r.workflow_id = w.id
+		WHERE r.workflow_id = $1
+		ORDER BY r.created DESC
+	`, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow runs: %v", err)
+	}
+	defer rows.Close()
+
+	runs := []models.WorkflowRun{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow run: %v", err)
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, nil
+}
+
+// runStep — состояние шага запуска, используемое при продвижении сценария
+type runStep struct {
+	id                  uuid.UUID
+	name                string
+	typ                 string
+	payload             []byte
+	dependsOn           []string
+	compensationType    *string
+	compensationPayload []byte
+	isCompensation      bool
+	status              string
+	actionID            *uuid.UUID
+	actionStatus        *string
+	position            int
+	completed           *time.Time
+}
+
+func isTerminalStep(status string) bool {
+	return status == models.WorkflowStepStatusCompleted ||
+		status == models.WorkflowStepStatusFailed ||
+		status == models.WorkflowStepStatusSkipped
+}
+
+// Advance продвигает запуск сценария: синхронизирует статусы шагов с действиями,
+// отправляет шаги, все предшественники которых завершены, а при сбое шага
+// пропускает оставшиеся и выполняет компенсации в обратном порядке
+func (s *Service) Advance(runID uuid.UUID) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Блокируем запуск, чтобы параллельные обновления не отправили шаг дважды
+	var runStatus string
+	var agentID uuid.UUID
+	var startedBy *uuid.UUID
+	err = tx.QueryRow(`
+		SELECT status, agent_id, started_by FROM workflow_runs WHERE id = $1 FOR UPDATE
+	`, runID).Scan(&runStatus, &agentID, &startedBy)
+	if err != nil {
+		return fmt.Errorf("failed to lock workflow run: %v", err)
+	}
+	if runStatus != models.WorkflowRunStatusRunning && runStatus != models.WorkflowRunStatusCompensating {
+		return nil
+	}
+
+	rows, err := tx.Query(`
+		SELECT rs.id, rs.name, rs.type, rs.payload, rs.depends_on, rs.compensation_type, rs.compensation_payload,
+			rs.is_compensation, rs.status, rs.action_id, a.status, rs.position, rs.completed
+		FROM workflow_run_steps rs
+		LEFT JOIN actions a ON rs.action_id = a.id
+		WHERE rs.run_id = $1
+		ORDER BY rs.is_compensation, rs.position
+	`, runID)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow run steps: %v", err)
+	}
+
+	var steps []*runStep
+	for rows.Next() {
+		step := &runStep{}
+		var dependsOn pq.StringArray
+		err := rows.Scan(
+			&step.id, &step.name, &step.typ, &step.payload, &dependsOn, &step.compensationType, &step.compensationPayload,
+			&step.isCompensation, &step.status, &step.actionID, &step.actionStatus, &step.position, &step.completed,
+		)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan workflow run step: %v", err)
+		}
+		step.dependsOn = []string(dependsOn)
+		steps = append(steps, step)
+	}
+	rows.Close()
+
+	now := time.Now()
+	setStepStatus := func(step *runStep, status string) error {
+		var completed *time.Time
+		if isTerminalStep(status) {
+			completed = &now
+		}
+		_, err := tx.Exec(`
+			UPDATE workflow_run_steps SET status = $1, completed = $2 WHERE id = $3
+		`, status, completed, step.id)
+		if err != nil {
+			return fmt.Errorf("failed to update workflow run step: %v", err)
+		}
+		step.status = status
+		step.completed = completed
+		return nil
+	}
+
+	finishRun := func(status string, runErr *string) error {
+		_, err := tx.Exec(`
+			UPDATE workflow_runs SET status = $1, error = COALESCE($2, error), completed = now() WHERE id = $3
+		`, status, runErr, runID)
+		if err != nil {
+			return fmt.Errorf("failed to update workflow run: %v", err)
+		}
+		return nil
+	}
+
+	// Синхронизируем выполняющиеся шаги с результатами их действий
+	for _, step := range steps {
+		if step.status != models.WorkflowStepStatusRunning {
+			continue
+		}
+		switch {
+		case step.actionStatus == nil:
+			// Действие было удалено — считаем шаг неуспешным
+			err = setStepStatus(step, models.WorkflowStepStatusFailed)
+		case *step.actionStatus == models.ActionStatusCompleted:
+			err = setStepStatus(step, models.WorkflowStepStatusCompleted)
+		case *step.actionStatus == models.ActionStatusFailed, *step.actionStatus == models.ActionStatusRejected:
+			err = setStepStatus(step, models.WorkflowStepStatusFailed)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	byName := make(map[string]*runStep, len(steps))
+	for _, step := range steps {
+		if !step.isCompensation {
+			byName[step.name] = step
+		}
+	}
+
+	if runStatus == models.WorkflowRunStatusRunning {
+		var failed *runStep
+		running := false
+		for _, step := range steps {
+			if step.isCompensation {
+				continue
+			}
+			if step.status == models.WorkflowStepStatusFailed && failed == nil {
+				failed = step
+			}
+			if step.status == models.WorkflowStepStatusRunning {
+				running = true
+			}
+		}
+
+		if failed != nil {
+			for _, step := range steps {
+				if !step.isCompensation && step.status == models.WorkflowStepStatusWaiting {
+					if err := setStepStatus(step, models.WorkflowStepStatusSkipped); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Компенсации запускаем только после завершения уже отправленных шагов
+			if running {
+				return tx.Commit()
+			}
+
+			runErr := fmt.Sprintf("step %s failed", failed.name)
+			compensated, err := createCompensations(tx, runID, steps)
+			if err != nil {
+				return err
+			}
+			if len(compensated) == 0 {
+				if err := finishRun(models.WorkflowRunStatusFailed, &runErr); err != nil {
+					return err
+				}
+				return tx.Commit()
+			}
+
+			_, err = tx.Exec(`
+				UPDATE workflow_runs SET status = $1, error = $2 WHERE id = $3
+			`, models.WorkflowRunStatusCompensating, runErr, runID)
+			if err != nil {
+				return fmt.Errorf("failed to update workflow run: %v", err)
+			}
+			runStatus = models.WorkflowRunStatusCompensating
+			steps = append(steps, compensated...)
+		} else {
+			allCompleted := true
+			for _, step := range steps {
+				if step.isCompensation {
+					continue
+				}
+				if step.status != models.WorkflowStepStatusCompleted {
+					allCompleted = false
+				}
+				if step.status != models.WorkflowStepStatusWaiting {
+					continue
+				}
+
+				ready := true
+				for _, dep := range step.dependsOn {
+					if pred, ok := byName[dep]; !ok || pred.status != models.WorkflowStepStatusCompleted {
+						ready = false
+						break
+					}
+				}
+				if ready {
+					if err := dispatchStep(tx, agentID, startedBy, step); err != nil {
+						return err
+					}
+				}
+			}
+
+			if allCompleted {
+				if err := finishRun(models.WorkflowRunStatusCompleted, nil); err != nil {
+					return err
+				}
+			}
+			return tx.Commit()
+		}
+	}
+
+	// Компенсации выполняются по цепочке и продолжаются даже при сбое одной из них
+	compensations := make(map[string]*runStep)
+	for _, step := range steps {
+		if step.isCompensation {
+			compensations[step.name] = step
+		}
+	}
+
+	allTerminal := true
+	for _, step := range steps {
+		if !step.isCompensation {
+			continue
+		}
+		if !isTerminalStep(step.status) {
+			allTerminal = false
+		}
+		if step.status != models.WorkflowStepStatusWaiting {
+			continue
+		}
+
+		ready := true
+		for _, dep := range step.dependsOn {
+			if pred, ok := compensations[dep]; ok && !isTerminalStep(pred.status) {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			if err := dispatchStep(tx, agentID, startedBy, step); err != nil {
+				return err
+			}
+		}
+	}
+
+	if allTerminal {
+		if err := finishRun(models.WorkflowRunStatusFailed, nil); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// createCompensations создает шаги компенсации для успешно выполненных шагов
+// в порядке, обратном порядку их завершения
+func createCompensations(tx *sql.Tx, runID uuid.UUID, steps []*runStep) ([]*runStep, error) {
+	var done []*runStep
+	maxPosition := 0
+	for _, step := range steps {
+		if step.position > maxPosition {
+			maxPosition = step.position
+		}
+		if step.isCompensation || step.status != models.WorkflowStepStatusCompleted || step.compensationType == nil {
+			continue
+		}
+		done = append(done, step)
+	}
+
+	sort.SliceStable(done, func(i, j int) bool {
+		if done[i].completed == nil || done[j].completed == nil {
+			return done[i].position > done[j].position
+		}
+		if done[i].completed.Equal(*done[j].completed) {
+			return done[i].position > done[j].position
+		}
+		return done[i].completed.After(*done[j].completed)
+	})
+
+	var compensations []*runStep
+	previous := ""
+	for i, step := range done {
+		compensation := &runStep{
+			name:           "compensate_" + step.name,
+			typ:            *step.compensationType,
+			payload:        step.compensationPayload,
+			dependsOn:      []string{},
+			isCompensation: true,
+			status:         models.WorkflowStepStatusWaiting,
+			position:       maxPosition + i + 1,
+		}
+		if previous != "" {
+			compensation.dependsOn = []string{previous}
+		}
+		if compensation.payload == nil {
+			compensation.payload = []byte("{}")
+		}
+
+		err := tx.QueryRow(`
+			INSERT INTO workflow_run_steps (run_id, name, type, payload, depends_on, is_compensation, status, position)
+			VALUES ($1, $2, $3, $4, $5, true, $6, $7)
+			RETURNING id
+		`, runID, compensation.name, compensation.typ, compensation.payload, pq.Array(compensation.dependsOn),
+			compensation.status, compensation.position).Scan(&compensation.id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create compensation step: %v", err)
+		}
+
+		compensations = append(compensations, compensation)
+		previous = compensation.name
+	}
+
+	return compensations, nil
+}
+
+// dispatchStep создает действие для шага от имени запустившего сценарий пользователя
+// и переводит шаг в статус выполнения. Если действие удержано политикой
+// подтверждения, шаг ждет решения.
+func dispatchStep(tx *sql.Tx, agentID uuid.UUID, startedBy *uuid.UUID, step *runStep) error {
+	actionID, _, err := approvals.InsertAction(tx, agentID, step.typ, step.payload, startedBy)
+	if err != nil {
+		return fmt.Errorf("failed to create action for step %s: %v", step.name, err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE workflow_run_steps SET status = $1, action_id = $2, started = now() WHERE id = $3
+	`, models.WorkflowStepStatusRunning, actionID, step.id)
+	if err != nil {
+		return fmt.Errorf("failed to update workflow run step: %v", err)
+	}
+
+	step.status = models.WorkflowStepStatusRunning
+	step.actionID = &actionID
+	return nil
+}
+
+// HandleActionUpdate продвигает запуск сценария, которому принадлежит действие
+func (s *Service) HandleActionUpdate(actionID uuid.UUID) error {
+	var runID uuid.UUID
+	err := s.db.QueryRow("SELECT run_id FROM workflow_run_steps WHERE action_id = $1", actionID).Scan(&runID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find workflow run for action: %v", err)
+	}
+
+	return s.Advance(runID)
+}
+
+// AdvanceActiveRuns продвигает все незавершенные запуски. Вызывается периодически,
+// чтобы запуски не зависали, если обновление статуса действия было пропущено
+func (s *Service) AdvanceActiveRuns() {
+	rows, err := s.db.Query(`
+		SELECT id FROM workflow_runs WHERE status IN ($1, $2)
+	`, models.WorkflowRunStatusRunning, models.WorkflowRunStatusCompensating)
+	if err != nil {
+		log.Printf("Error getting active workflow runs: %v", err)
+		return
+	}
+
+	var runIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Error scanning workflow run: %v", err)
+			continue
+		}
+		runIDs = append(runIDs, id)
+	}
+	rows.Close()
+
+	for _, id := range runIDs {
+		if err := s.Advance(id); err != nil {
+			log.Printf("Error advancing workflow run %s: %v", id, err)
+		}
+	}
+}
@@ -29,16 +29,28 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	_ "monitoring-system/core/server/docs" // Swagger документация
+	"monitoring-system/core/server/internal/approvals"
+	"monitoring-system/core/server/internal/audit"
 	"monitoring-system/core/server/internal/auth"
 	"monitoring-system/core/server/internal/config"
 	"monitoring-system/core/server/internal/database"
 	"monitoring-system/core/server/internal/domains"
+	"monitoring-system/core/server/internal/enrollment"
 	"monitoring-system/core/server/internal/handlers"
+	"monitoring-system/core/server/internal/loginguard"
+	"monitoring-system/core/server/internal/mfa"
+	"monitoring-system/core/server/internal/oidc"
+	"monitoring-system/core/server/internal/scheduler"
+	"monitoring-system/core/server/internal/users"
+	"monitoring-system/core/server/internal/workflows"
 )
 
 func main() {
 	// Инициализируем конфигурацию
 	cfg := config.Load()
+	if err := audit.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Failed to parse TRUSTED_PROXIES:", err)
+	}
 
 	// Подключаемся к базе данных
 	db, err := database.Connect(cfg.DatabaseURL)
@@ -48,11 +60,20 @@ func main() {
 	defer db.Close()
 
 	// Инициализируем сервисы
-	authService := auth.NewService(cfg.JWTSecret)
+	authService := auth.NewService(cfg.JWTSecret, db)
 	domainService := domains.NewService(db)
+	schedulerService := scheduler.NewService(db)
+	workflowService := workflows.NewService(db)
+	approvalService := approvals.NewService(db)
+	auditService := audit.NewService(db)
+	userService := users.NewService(db, authService)
 
 	// Инициализируем обработчики
-	h := handlers.New(db, authService, domainService)
+	oidcService := oidc.NewService(db, cfg.OIDC)
+	mfaService := mfa.NewService(db, cfg.RequireAdmin2FA)
+	guardService := loginguard.NewService(db)
+	enrollmentService := enrollment.NewService(db, authService)
+	h := handlers.New(db, authService, domainService, schedulerService, workflowService, approvalService, auditService, userService, oidcService, mfaService, guardService, enrollmentService)
 
 	// Запускаем периодическую проверку недоступных агентов
 	go func() {
@@ -67,6 +88,47 @@ func main() {
 		}
 	}()
 
+	// Запускаем планировщик расписаний действий
+	go func() {
+		ticker := time.NewTicker(15 * time.Second) // Проверяем каждые 15 секунд
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				schedulerService.RunDueSchedules()
+			}
+		}
+	}()
+
+	// Удаляем одноразовые значения подписанных запросов агентов, вышедшие из окна проверки
+	go func() {
+		ticker := time.NewTicker(time.Minute) // Проверяем каждую минуту
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := authService.PurgeAgentNonces(); err != nil {
+					log.Printf("Error purging agent request nonces: %v", err)
+				}
+			}
+		}
+	}()
+
+	// Продвигаем запуски сценариев, если обновление статуса действия было пропущено
+	go func() {
+		ticker := time.NewTicker(30 * time.Second) // Проверяем каждые 30 секунд
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				workflowService.AdvanceActiveRuns()
+			}
+		}
+	}()
+
 	// Настраиваем роутер
 	r := chi.NewRouter()
 
@@ -91,59 +153,172 @@ func main() {
 
 		// Публичные маршруты
 		r.Post("/login", h.Login)
+		r.Post("/login/2fa", h.LoginTOTP)
+		r.Post("/login/2fa/enroll", h.LoginEnrollTOTP)
+		r.Post("/login/2fa/activate", h.LoginActivateTOTP)
+		r.Post("/refresh", h.Refresh)
+		r.Get("/oidc", h.GetOIDCStatus)
+		r.Get("/oidc/login", h.OIDCLogin)
+		r.Get("/oidc/callback", h.OIDCCallback)
 		r.Get("/domains/public", h.GetDomainsPublic) // Публичный endpoint для reverse proxy
 
 		// Маршруты для агентов (с Bearer токеном)
 		r.Post("/agent/ping", h.AgentPing)
+		r.Post("/agent/register", h.RegisterAgent) // по одноразовому токену регистрации
 		r.Put("/actions/{id}/status", h.UpdateActionStatus)
 
-		// Защищенные маршруты (требуют JWT аутентификации)
+		// Защищенные маршруты (требуют JWT аутентификации).
+		// Права ролей: viewer — view; operator — view, operate;
+		// approver — view, operate, approve; admin — все права.
 		r.Group(func(r chi.Router) {
 			r.Use(authService.JWTMiddleware)
 
-			// Управление агентами
-			r.Get("/agents", h.GetAgents)
-			r.Post("/agents", h.CreateAgent)
-			r.Put("/agents/{id}", h.UpdateAgent)
-			r.Delete("/agents/{id}", h.DeleteAgent)
-			r.Get("/agents/{id}", h.GetAgentDetail)
-			r.Get("/agents/{id}/nginx-config", h.GetAgentNginxConfig)
-
-			// Метрики и мониторинг
-			r.Get("/agents/{id}/metrics", h.GetAgentMetrics)
-			r.Get("/agents/{id}/containers", h.GetAgentContainers)
-			r.Get("/dashboard", h.GetDashboardData)
-
-			// Контейнеры
-			r.Get("/containers", h.GetContainers)
-			r.Get("/containers/{id}", h.GetContainerDetail)
-			r.Get("/containers/{id}/logs", h.GetContainerLogs)
-
-			// Образы
-			r.Get("/images", h.GetImages)
-
-			// Действия (Actions)
-			r.Get("/actions", h.GetActions)
-			r.Post("/actions", h.CreateAction)
-
-			// Домены (Domains)
-			r.Get("/domains", h.GetDomains)
-			r.Post("/domains", h.CreateDomain)
-			r.Get("/domains/{id}", h.GetDomain)
-			r.Put("/domains/{id}", h.UpdateDomain)
-			r.Delete("/domains/{id}", h.DeleteDomain)
-			r.Get("/domains/{id}/status", h.GetDomainStatus)
-
-			// Маршруты доменов (Domain Routes)
-			r.Post("/domains/routes", h.CreateDomainRoute)
-			r.Get("/domains/{domain_id}/routes", h.GetDomainRoutes)
-			r.Put("/domains/routes/{id}", h.UpdateDomainRoute)
-			r.Delete("/domains/routes/{id}", h.DeleteDomainRoute)
-
-			// Уведомления (Notifications)
-			r.Get("/notifications/settings", h.GetNotificationSettings)
-			r.Post("/notifications/settings", h.UpdateNotificationSettings)
-			r.Post("/notifications/test", h.SendTestNotification)
+			r.Post("/logout", h.Logout)
+
+			// Профиль текущего пользователя (Profile) — доступен любой роли
+			r.Get("/profile", h.GetProfile)
+			r.Put("/profile", h.UpdateProfile)
+			r.Put("/profile/password", h.ChangePassword)
+			r.Get("/profile/tokens", h.GetAPITokens)
+			r.Post("/profile/tokens", h.CreateAPIToken)
+			r.Delete("/profile/tokens/{id}", h.RevokeAPIToken)
+			r.Get("/profile/sessions", h.GetSessions)
+			r.Delete("/profile/sessions", h.RevokeAllSessions)
+			r.Delete("/profile/sessions/{id}", h.RevokeSession)
+			r.Get("/profile/2fa", h.GetMFAStatus)
+			r.Post("/profile/2fa/enroll", h.EnrollTOTP)
+			r.Post("/profile/2fa/activate", h.ActivateTOTP)
+			r.Delete("/profile/2fa", h.DisableTOTP)
+			r.Post("/profile/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+			r.Get("/profile/auth-events", h.GetMyAuthEvents)
+
+			// Просмотр (view)
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequirePermission(auth.PermissionView))
+
+				// Агенты, метрики и мониторинг
+				r.Get("/agents", h.GetAgents)
+				r.Get("/dashboard", h.GetDashboardData)
+				r.Group(func(r chi.Router) {
+					r.Use(auth.RequireAgentAccess("id"))
+					r.Get("/agents/{id}", h.GetAgentDetail)
+					r.Get("/agents/{id}/nginx-config", h.GetAgentNginxConfig)
+					r.Get("/agents/{id}/metrics", h.GetAgentMetrics)
+					r.Get("/agents/{id}/containers", h.GetAgentContainers)
+					r.Get("/agents/{id}/events", h.GetAgentEvents)
+				})
+
+				// Контейнеры
+				r.Get("/containers", h.GetContainers)
+				r.Get("/containers/{id}", h.GetContainerDetail)
+				r.Get("/containers/{id}/logs", h.GetContainerLogs)
+				r.Get("/containers/{id}/events", h.GetContainerEvents)
+
+				// Образы
+				r.Get("/images", h.GetImages)
+
+				// Действия и подтверждения
+				r.Get("/actions", h.GetActions)
+				r.Get("/approvals", h.GetApprovals)
+				r.Get("/approval-policies", h.GetApprovalPolicies)
+
+				// Расписания действий (Schedules)
+				r.Get("/schedules", h.GetSchedules)
+				r.Get("/schedules/{id}", h.GetSchedule)
+
+				// Сценарии развертывания (Workflows)
+				r.Get("/workflows", h.GetWorkflows)
+				r.Get("/workflows/runs/{id}", h.GetWorkflowRun)
+				r.Get("/workflows/{id}", h.GetWorkflow)
+				r.Get("/workflows/{id}/runs", h.GetWorkflowRuns)
+
+				// Домены (Domains)
+				r.Get("/domains", h.GetDomains)
+				r.Get("/domains/{id}", h.GetDomain)
+				r.Get("/domains/{id}/status", h.GetDomainStatus)
+				r.Get("/domains/{domain_id}/routes", h.GetDomainRoutes)
+			})
+
+			// Управление (operate)
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequirePermission(auth.PermissionOperate))
+
+				// Действия (Actions)
+				r.Post("/actions", h.CreateAction)
+
+				// Расписания действий (Schedules)
+				r.Post("/schedules", h.CreateSchedule)
+				r.Put("/schedules/{id}", h.UpdateSchedule)
+				r.Delete("/schedules/{id}", h.DeleteSchedule)
+				r.Post("/schedules/{id}/pause", h.PauseSchedule)
+				r.Post("/schedules/{id}/resume", h.ResumeSchedule)
+
+				// Сценарии развертывания (Workflows)
+				r.Post("/workflows", h.CreateWorkflow)
+				r.Put("/workflows/{id}", h.UpdateWorkflow)
+				r.Delete("/workflows/{id}", h.DeleteWorkflow)
+				r.Post("/workflows/{id}/runs", h.StartWorkflowRun)
+
+				// Домены (Domains)
+				r.Post("/domains", h.CreateDomain)
+				r.Put("/domains/{id}", h.UpdateDomain)
+				r.Delete("/domains/{id}", h.DeleteDomain)
+
+				// Маршруты доменов (Domain Routes)
+				r.Post("/domains/routes", h.CreateDomainRoute)
+				r.Put("/domains/routes/{id}", h.UpdateDomainRoute)
+				r.Delete("/domains/routes/{id}", h.DeleteDomainRoute)
+			})
+
+			// Подтверждение действий (approve)
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequirePermission(auth.PermissionApprove))
+
+				r.Post("/actions/{id}/approve", h.ApproveAction)
+				r.Post("/actions/{id}/reject", h.RejectAction)
+			})
+
+			// Администрирование (admin)
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequirePermission(auth.PermissionAdmin))
+
+				// Управление агентами
+				r.Post("/agents", h.CreateAgent)
+				r.Put("/agents/{id}", h.UpdateAgent)
+				r.Delete("/agents/{id}", h.DeleteAgent)
+				r.Post("/agents/{id}/approve", h.ApproveAgent)
+				r.Post("/agents/{id}/rotate-token", h.RotateAgentToken)
+				r.Get("/agents/enrollment-tokens", h.GetEnrollmentTokens)
+				r.Post("/agents/enrollment-tokens", h.CreateEnrollmentToken)
+				r.Delete("/agents/enrollment-tokens/{id}", h.RevokeEnrollmentToken)
+
+				// Пользователи (Users)
+				r.Get("/users", h.GetUsers)
+				r.Post("/users", h.CreateUser)
+				r.Get("/users/{id}", h.GetUser)
+				r.Put("/users/{id}", h.UpdateUser)
+				r.Delete("/users/{id}", h.DeleteUser)
+				r.Put("/users/{id}/password", h.SetUserPassword)
+				r.Get("/users/{id}/tokens", h.GetUserAPITokens)
+				r.Delete("/users/{id}/tokens/{token_id}", h.RevokeUserAPIToken)
+				r.Get("/users/{id}/sessions", h.GetUserSessions)
+				r.Delete("/users/{id}/sessions", h.RevokeUserSessions)
+				r.Delete("/users/{id}/2fa", h.ResetUserTOTP)
+				r.Post("/users/{id}/unlock", h.UnlockUser)
+
+				// Политики подтверждения (Approval policies)
+				r.Post("/approval-policies", h.CreateApprovalPolicy)
+				r.Delete("/approval-policies/{id}", h.DeleteApprovalPolicy)
+
+				// Журнал аудита (Audit)
+				r.Get("/audit", h.GetAuditLog)
+				r.Get("/auth-events", h.GetAuthEvents)
+
+				// Уведомления (Notifications)
+				r.Get("/notifications/settings", h.GetNotificationSettings)
+				r.Post("/notifications/settings", h.UpdateNotificationSettings)
+				r.Post("/notifications/test", h.SendTestNotification)
+			})
 		})
 	})
 